@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/cors"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/acmecert"
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/config"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mailer"
+	"github.com/bootdotdev/learn-cicd-starter/internal/sqlite"
+	"github.com/bootdotdev/learn-cicd-starter/internal/storage"
+	"github.com/bootdotdev/learn-cicd-starter/internal/tracing"
+)
+
+// defaultConfigFile is read when -config isn't given, if it exists.
+const defaultConfigFile = "config.yaml"
+
+// Config is every setting the server reads at startup. Each comes from the
+// environment variable of the same name or, failing that, the config file.
+type Config struct {
+	Port            string
+	PublicBaseURL   string
+	ShutdownTimeout time.Duration
+	HTTP            HTTPConfig
+	TLS             TLSConfig
+	CORS            cors.Options
+	Database        DatabaseConfig
+	Backup          BackupConfig
+	Cache           CacheConfig
+
+	JWTSecret                string
+	MetricsToken             string
+	TrustProxy               bool
+	GuestReadAccess          bool
+	LenientAuthScheme        bool
+	RequireVerifiedEmail     bool
+	APIKeySources            auth.APIKeySources
+	APIKeyRotationGrace      time.Duration
+	APIKeyUsageFlushInterval time.Duration
+	BootstrapAdminUserID     string
+
+	NotesMaxPageSize int
+	NotesMaxPinned   int
+	NotesMaxBatch    int
+	NoteMaxBytes     int
+	QuotaMaxNotes    int
+	QuotaMaxBytes    int
+
+	RateLimitRPS          float64
+	RateLimitBurst        int
+	PasswordResetsPerHour int
+	AuthFailureThreshold  int
+	AuthFailureWindow     time.Duration
+	AuthBlockDuration     time.Duration
+
+	OAuthRedirectBaseURL string
+	OAuthGitHub          OAuthClient
+	OAuthGoogle          OAuthClient
+
+	// Each of these is off while its first field is empty: S3.Bucket,
+	// SMTP.Host and Tracing.Endpoint.
+	S3      storage.S3Config
+	SMTP    mailer.SMTPConfig
+	Tracing tracing.Config
+
+	ReminderInterval time.Duration
+
+	WebhookAllowPrivateNetworks bool
+
+	JobsWorkers      int
+	JobsPollInterval time.Duration
+}
+
+// HTTPConfig bounds how long a client can hold a connection and how much
+// it can send.
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxBodyBytes      int
+}
+
+// TLSConfig serves HTTPS from CertFile and KeyFile, or from certificates
+// issued for Autocert.Domains. With neither, the server speaks plain HTTP.
+type TLSConfig struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string
+	RequireClientCert bool
+	Autocert          acmecert.Config
+}
+
+type DatabaseConfig struct {
+	URL                string
+	ReplicaURL         string
+	SkipMigrations     bool
+	PreparedStatements bool
+	QueryTimeout       time.Duration
+	SQLiteBusyTimeout  time.Duration
+	MaxOpenConns       int
+	MaxIdleConns       int
+	ConnMaxLifetime    time.Duration
+}
+
+// BackupConfig takes backups while Dir is set.
+type BackupConfig struct {
+	Dir      string
+	Keep     int
+	Upload   bool
+	Interval time.Duration
+}
+
+// CacheConfig keeps hot data in Redis while RedisURL is set, and in the
+// server's memory, up to MaxBytes, otherwise.
+type CacheConfig struct {
+	RedisURL string
+	MaxBytes int
+}
+
+type OAuthClient struct {
+	ID     string
+	Secret string
+}
+
+// loadConfig reads every setting from the environment and the YAML file at
+// path, which maps the same names to values. Unset, path is config.yaml
+// and may be missing. Settings in the environment, including .env, win
+// over the file. Every name in required must be set.
+//
+// Rather than stopping at the first problem, the error lists every
+// setting that is missing, malformed, out of range or unknown to the file,
+// one per line.
+func loadConfig(path string, required ...string) (*Config, error) {
+	l := config.NewLoader(os.Getenv)
+	optional := path == ""
+	if optional {
+		path = defaultConfigFile
+	}
+	if err := l.ReadFile(path); err != nil && !(optional && errors.Is(err, fs.ErrNotExist)) {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Port:            l.String("PORT", ""),
+		PublicBaseURL:   strings.TrimSuffix(l.String("PUBLIC_BASE_URL", ""), "/"),
+		ShutdownTimeout: l.Duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		HTTP: HTTPConfig{
+			ReadHeaderTimeout: l.Duration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+			ReadTimeout:       l.Duration("HTTP_READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:      l.Duration("HTTP_WRITE_TIMEOUT", 10*time.Second),
+			IdleTimeout:       l.Duration("HTTP_IDLE_TIMEOUT", time.Minute),
+			MaxBodyBytes:      l.Int("MAX_BODY_BYTES", 1<<20),
+		},
+		TLS: TLSConfig{
+			CertFile:          l.String("TLS_CERT_FILE", ""),
+			KeyFile:           l.String("TLS_KEY_FILE", ""),
+			ClientCAFile:      l.String("TLS_CLIENT_CA_FILE", ""),
+			RequireClientCert: l.Bool("TLS_REQUIRE_CLIENT_CERT"),
+			Autocert: acmecert.Config{
+				Domains:      l.List("TLS_AUTOCERT_DOMAINS"),
+				Email:        l.String("TLS_AUTOCERT_EMAIL", ""),
+				CacheDir:     l.String("TLS_AUTOCERT_CACHE_DIR", "certs"),
+				DirectoryURL: l.String("TLS_AUTOCERT_DIRECTORY_URL", ""),
+			},
+		},
+		CORS: corsOptions(l),
+		Database: DatabaseConfig{
+			URL:                l.String("DATABASE_URL", ""),
+			ReplicaURL:         l.String("DATABASE_REPLICA_URL", ""),
+			SkipMigrations:     l.Bool("SKIP_MIGRATIONS"),
+			PreparedStatements: l.Bool("DB_PREPARED_STATEMENTS"),
+			QueryTimeout:       l.Duration("DB_QUERY_TIMEOUT", 10*time.Second),
+			SQLiteBusyTimeout:  l.Duration("SQLITE_BUSY_TIMEOUT", 5*time.Second),
+			MaxOpenConns:       l.Int("DB_MAX_OPEN_CONNS", 0),
+			MaxIdleConns:       l.Int("DB_MAX_IDLE_CONNS", 2),
+			ConnMaxLifetime:    l.Duration("DB_CONN_MAX_LIFETIME", 0),
+		},
+		Backup: BackupConfig{
+			Dir:      l.String("BACKUP_DIR", ""),
+			Keep:     l.Int("BACKUP_KEEP", 7),
+			Upload:   l.Bool("BACKUP_UPLOAD"),
+			Interval: l.Duration("BACKUP_INTERVAL", 24*time.Hour),
+		},
+		Cache: CacheConfig{
+			RedisURL: l.String("REDIS_URL", ""),
+			MaxBytes: l.Int("CACHE_MAX_BYTES", 32<<20),
+		},
+
+		JWTSecret:            l.String("JWT_SECRET", ""),
+		MetricsToken:         l.String("METRICS_TOKEN", ""),
+		TrustProxy:           l.Bool("TRUST_PROXY_HEADERS"),
+		GuestReadAccess:      l.Bool("GUEST_READ_ACCESS"),
+		LenientAuthScheme:    l.Bool("LENIENT_AUTH_SCHEME"),
+		RequireVerifiedEmail: l.Bool("REQUIRE_VERIFIED_EMAIL"),
+		APIKeySources: auth.APIKeySources{
+			XAPIKeyHeader: l.Bool("API_KEY_HEADER_FALLBACK"),
+			QueryParam:    l.Bool("API_KEY_QUERY_FALLBACK"),
+		},
+		APIKeyRotationGrace:      l.Duration("API_KEY_ROTATION_GRACE", 24*time.Hour),
+		APIKeyUsageFlushInterval: l.Duration("API_KEY_USAGE_FLUSH_INTERVAL", 30*time.Second),
+		BootstrapAdminUserID:     l.String("BOOTSTRAP_ADMIN_USER_ID", ""),
+
+		NotesMaxPageSize: l.Int("NOTES_MAX_PAGE_SIZE", 100),
+		NotesMaxPinned:   l.Int("NOTES_MAX_PINNED", 5),
+		NotesMaxBatch:    l.Int("NOTES_MAX_BATCH", 100),
+		NoteMaxBytes:     l.Int("NOTE_MAX_BYTES", 1<<20),
+		QuotaMaxNotes:    l.Int("QUOTA_MAX_NOTES", 0),
+		QuotaMaxBytes:    l.Int("QUOTA_MAX_BYTES", 0),
+
+		RateLimitRPS:          l.Float("RATE_LIMIT_RPS", 10),
+		RateLimitBurst:        l.Int("RATE_LIMIT_BURST", 20),
+		PasswordResetsPerHour: l.Int("PASSWORD_RESETS_PER_HOUR", 5),
+		AuthFailureThreshold:  l.Int("AUTH_FAILURE_THRESHOLD", 10),
+		AuthFailureWindow:     l.Duration("AUTH_FAILURE_WINDOW", 5*time.Minute),
+		AuthBlockDuration:     l.Duration("AUTH_BLOCK_DURATION", 15*time.Minute),
+
+		OAuthRedirectBaseURL: strings.TrimSuffix(l.String("OAUTH_REDIRECT_BASE_URL", ""), "/"),
+		OAuthGitHub: OAuthClient{
+			ID:     l.String("OAUTH_GITHUB_CLIENT_ID", ""),
+			Secret: l.String("OAUTH_GITHUB_CLIENT_SECRET", ""),
+		},
+		OAuthGoogle: OAuthClient{
+			ID:     l.String("OAUTH_GOOGLE_CLIENT_ID", ""),
+			Secret: l.String("OAUTH_GOOGLE_CLIENT_SECRET", ""),
+		},
+
+		S3: storage.S3Config{
+			Bucket:          l.String("ATTACHMENTS_S3_BUCKET", ""),
+			Endpoint:        l.String("ATTACHMENTS_S3_ENDPOINT", ""),
+			Region:          l.String("ATTACHMENTS_S3_REGION", ""),
+			AccessKeyID:     l.String("ATTACHMENTS_S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: l.String("ATTACHMENTS_S3_SECRET_ACCESS_KEY", ""),
+			PathStyle:       l.Bool("ATTACHMENTS_S3_PATH_STYLE"),
+		},
+		SMTP: mailer.SMTPConfig{
+			Host:     l.String("SMTP_HOST", ""),
+			Port:     l.Int("SMTP_PORT", 587),
+			Username: l.String("SMTP_USERNAME", ""),
+			Password: l.String("SMTP_PASSWORD", ""),
+			From:     l.String("SMTP_FROM", ""),
+		},
+		Tracing: tracingConfig(l),
+
+		ReminderInterval: l.Duration("REMINDER_INTERVAL", time.Minute),
+
+		WebhookAllowPrivateNetworks: l.Bool("WEBHOOK_ALLOW_PRIVATE_NETWORKS"),
+
+		JobsWorkers:      l.Int("JOBS_WORKERS", 8),
+		JobsPollInterval: l.Duration("JOBS_POLL_INTERVAL", 5*time.Second),
+	}
+
+	for _, name := range required {
+		if !l.IsSet(name) {
+			l.Errorf("%s is not set", name)
+		}
+	}
+	cfg.validate(l)
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate records every setting that is out of range or conflicts with
+// another. Settings that only matter once a feature is on, such as SMTP
+// credentials, are checked by that feature when it starts.
+func (cfg *Config) validate(l *config.Loader) {
+	// Zero would mean no limit at all, which is what these guard against.
+	if cfg.HTTP.ReadHeaderTimeout <= 0 || cfg.HTTP.ReadTimeout <= 0 || cfg.HTTP.WriteTimeout <= 0 || cfg.HTTP.IdleTimeout <= 0 {
+		l.Errorf("HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must be positive")
+	}
+	if cfg.HTTP.MaxBodyBytes < 1 {
+		l.Errorf("MAX_BODY_BYTES must be at least 1")
+	}
+	if cfg.NotesMaxPageSize < 1 {
+		l.Errorf("NOTES_MAX_PAGE_SIZE must be at least 1")
+	}
+	if cfg.NoteMaxBytes < 1 {
+		l.Errorf("NOTE_MAX_BYTES must be at least 1")
+	}
+	if cfg.QuotaMaxNotes < 0 || cfg.QuotaMaxBytes < 0 {
+		l.Errorf("QUOTA_MAX_NOTES and QUOTA_MAX_BYTES can't be negative")
+	}
+	if cfg.RateLimitBurst < 1 {
+		l.Errorf("RATE_LIMIT_BURST must be at least 1")
+	}
+	if cfg.Cache.MaxBytes < 0 {
+		l.Errorf("CACHE_MAX_BYTES can't be negative")
+	}
+	if cfg.JobsWorkers < 1 {
+		l.Errorf("JOBS_WORKERS must be at least 1")
+	}
+	if cfg.JobsPollInterval <= 0 || cfg.ReminderInterval <= 0 {
+		l.Errorf("JOBS_POLL_INTERVAL and REMINDER_INTERVAL must be positive")
+	}
+
+	certFile, domains := cfg.TLS.CertFile != "", len(cfg.TLS.Autocert.Domains) > 0
+	switch {
+	case certFile && domains:
+		l.Errorf("set TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	case certFile != (cfg.TLS.KeyFile != ""):
+		l.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case !certFile && !domains && cfg.TLS.ClientCAFile != "":
+		l.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE, or TLS_AUTOCERT_DOMAINS")
+	}
+
+	if cfg.Backup.Dir != "" && !sqlite.IsFileURL(cfg.Database.URL) {
+		l.Errorf("BACKUP_DIR needs a local SQLite DATABASE_URL")
+	}
+	if cfg.Backup.Upload && cfg.S3.Bucket == "" {
+		l.Errorf("BACKUP_UPLOAD needs ATTACHMENTS_S3_BUCKET")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/cors"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/config"
+)
+
+// corsAllowedHeaders are the request headers the API reads that browsers
+// only send cross-origin once a preflight allows them.
+var corsAllowedHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+	"If-Match",
+	"If-Modified-Since",
+	"If-None-Match",
+	"X-API-Key",
+	"traceparent",
+	idempotencyKeyHeader,
+	requestIDHeader,
+	totpHeader,
+}
+
+// corsOptions reads which browser origins may call the API.
+// CORS_ALLOWED_ORIGINS is a comma-separated list of origins, each with at
+// most one * wildcard, such as https://*.example.com; "*" allows any.
+// Unset, only pages served from localhost and 127.0.0.1 are allowed, which
+// is enough for local development. The bundled frontend is same-origin and
+// needs no CORS at all.
+//
+// CORS_ALLOWED_METHODS and CORS_ALLOWED_HEADERS replace the default lists.
+// CORS_ALLOW_CREDENTIALS lets browsers send cookies and client
+// certificates, which can't be combined with allowing any origin.
+// CORS_MAX_AGE is how long browsers may cache a preflight, 5m by default.
+func corsOptions(l *config.Loader) cors.Options {
+	opts := cors.Options{
+		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*", "http://localhost", "http://127.0.0.1"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   corsAllowedHeaders,
+		ExposedHeaders:   []string{"Link", "ETag", "Last-Modified", "Idempotent-Replayed", "Retry-After", "Deprecation", "Sunset", apiversion.Header, requestIDHeader},
+		AllowCredentials: l.Bool("CORS_ALLOW_CREDENTIALS"),
+		MaxAge:           int(l.Duration("CORS_MAX_AGE", 5*time.Minute).Seconds()),
+	}
+	if origins := l.List("CORS_ALLOWED_ORIGINS"); origins != nil {
+		opts.AllowedOrigins = origins
+	}
+	if methods := l.List("CORS_ALLOWED_METHODS"); methods != nil {
+		opts.AllowedMethods = methods
+	}
+	if headers := l.List("CORS_ALLOWED_HEADERS"); headers != nil {
+		opts.AllowedHeaders = headers
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			l.Errorf("CORS_ALLOWED_ORIGINS entries can have at most one *: %s", origin)
+		}
+	}
+	if opts.AllowCredentials && slices.Contains(opts.AllowedOrigins, "*") {
+		l.Errorf("CORS_ALLOW_CREDENTIALS can't be used with CORS_ALLOWED_ORIGINS=*")
+	}
+	if opts.MaxAge < 0 {
+		l.Errorf("CORS_MAX_AGE can't be negative")
+	}
+	return opts
+}
+
+// corsPolicy applies CORS options that can be replaced while serving, so
+// allowed origins can change on a configuration reload.
+type corsPolicy struct {
+	current atomic.Pointer[cors.Cors]
+}
+
+func newCORSPolicy(opts cors.Options) *corsPolicy {
+	p := &corsPolicy{}
+	p.Set(opts)
+	return p
+}
+
+// Set applies opts to every request from now on.
+func (p *corsPolicy) Set(opts cors.Options) {
+	p.current.Store(cors.New(opts))
+}
+
+func (p *corsPolicy) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.current.Load().Handler(next).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// noteETag is the entity tag for a note at the given version. The version
+// changes on every update, so two tags are equal only for the same content.
+func noteETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagMatches reports whether a comma-separated If-Match or If-None-Match
+// header value lists etag. "*" matches anything. Weak tags only match when
+// weak comparison is allowed, which is the case for If-None-Match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// noteListValidators returns the entity tag and modification time of a
+// listing of the user's notes, from the state GetNoteListState reads. The
+// tag comes from the listing's version, which touchNoteList moves on with
+// every change, and from variant, the request details that shape the
+// listing, so each page, filter and format has its own. The modification
+// time is zero when nothing in the state carries one.
+func noteListValidators(state database.GetNoteListStateRow, variant string) (string, time.Time) {
+	etag := `W/"` + digest(fmt.Sprintf("%d\n%s", state.Version, variant)) + `"`
+
+	var modified time.Time
+	for _, s := range []string{state.NotesUpdatedAt, state.TagsUpdatedAt, state.SettingsUpdatedAt, state.ChangedAt} {
+		if t, err := time.Parse(time.RFC3339, s); err == nil && t.After(modified) {
+			modified = t
+		}
+	}
+	return etag, modified
+}
+
+// digest shortens s to a fixed-length string that can go in a tag or a
+// cache key.
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// notModified reports whether the request's conditional headers show that
+// the client's copy, with the given validators, is current. If-None-Match
+// takes precedence over If-Modified-Since, as RFC 9110 says.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etagMatches(match, strings.TrimPrefix(etag, "W/"), true)
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.IsZero() && !modified.Truncate(time.Second).After(since)
+}
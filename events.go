@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// Event types sent to a user's WebSocket connections and event streams.
+const (
+	eventNoteCreated = "note.created"
+	eventNoteUpdated = "note.updated"
+	eventNoteDeleted = "note.deleted"
+)
+
+// eventBuffer is how many events a connection can fall behind by before
+// it's dropped. It covers a full batch create or bulk delete.
+const eventBuffer = 256
+
+// eventHistory is how many of each user's latest events are kept for
+// event streams resuming after a disconnect. Streams that missed more
+// start over with a reset event.
+const eventHistory = 100
+
+// eventPingInterval is how often event connections are pinged, so dead
+// ones are noticed and idle ones aren't timed out by proxies.
+const eventPingInterval = 30 * time.Second
+
+// eventWriteTimeout bounds each event and ping sent to a client.
+const eventWriteTimeout = 10 * time.Second
+
+// eventEpoch tells this process's event IDs apart from those of earlier
+// ones, which start again from 1.
+var eventEpoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// event is pushed to the WebSocket connections and event streams of the
+// users it concerns.
+// Note events carry the note as GET /notes/{noteID} returns it, or only
+// its id once it's deleted.
+type event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// deletedNote is the data of a note.deleted event.
+type deletedNote struct {
+	ID string `json:"id"`
+}
+
+// webhookPayload is the body of a webhook delivery: an event, with the
+// time it happened.
+type webhookPayload struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// publishNote sends a note.created or note.updated event to the note's
+// owner and, when it's someone else, such as an editor it's shared with,
+// the user who made the change. The owner's webhooks get it too.
+func (cfg *apiConfig) publishNote(eventType string, note Note, actorID string) {
+	e := event{Type: eventType, Data: note}
+	cfg.publish(e, note.UserID, actorID)
+	cfg.queueWebhooks(e, note.UserID)
+}
+
+// publishNoteDeleted sends a note.deleted event to the note's owner and
+// their webhooks.
+func (cfg *apiConfig) publishNoteDeleted(noteID, ownerID string) {
+	e := event{Type: eventNoteDeleted, Data: deletedNote{ID: noteID}}
+	cfg.publish(e, ownerID)
+	cfg.queueWebhooks(e, ownerID)
+}
+
+func (cfg *apiConfig) publish(e event, userIDs ...string) {
+	if cfg.Events == nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			cfg.Events.Publish(id, e)
+		}
+	}
+}
+
+// queueWebhooks queues a delivery of e to each of the user's active
+// webhooks that receive its type, and a job to send each one.
+// The change e describes has already been made, so failures are logged
+// rather than returned.
+func (cfg *apiConfig) queueWebhooks(e event, userID string) {
+	if cfg.Jobs == nil {
+		return
+	}
+	ctx := context.Background()
+	hooks, err := cfg.DB.ListWebhooksForUser(ctx, userID)
+	if err != nil {
+		log.Printf("Couldn't list webhooks: %v", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	var payload []byte
+	for _, hook := range hooks {
+		if !webhookWants(hook, e.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{Type: e.Type, Timestamp: now, Data: e.Data})
+			if err != nil {
+				log.Printf("Couldn't encode webhook payload: %v", err)
+				return
+			}
+		}
+		id := uuid.New().String()
+		err = cfg.DB.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			ID:            id,
+			CreatedAt:     now.Format(time.RFC3339),
+			WebhookID:     hook.ID,
+			EventType:     e.Type,
+			Payload:       string(payload),
+			NextAttemptAt: now.Format(time.RFC3339),
+		})
+		if err != nil {
+			log.Printf("Couldn't queue webhook delivery: %v", err)
+			continue
+		}
+		if err := cfg.Jobs.Enqueue(ctx, webhooks.JobKind, webhooks.JobPayload{DeliveryID: id}); err != nil {
+			log.Printf("Couldn't queue webhook delivery %s: %v", id, err)
+		}
+	}
+}
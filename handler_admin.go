@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/go-chi/chi"
+)
+
+func (cfg *apiConfig) handlerAdminBlocksGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	type block struct {
+		IP           string    `json:"ip"`
+		BlockedUntil time.Time `json:"blocked_until"`
+	}
+
+	blocks := []block{}
+	for _, b := range cfg.AuthFailures.Blocks() {
+		blocks = append(blocks, block{
+			IP:           b.Key,
+			BlockedUntil: b.BlockedUntil.UTC(),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, blocks)
+}
+
+func (cfg *apiConfig) handlerAdminBlocksDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	ip := chi.URLParam(r, "ip")
+	if !cfg.AuthFailures.Unblock(ip) {
+		respondWithError(w, http.StatusNotFound, "IP is not blocked", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+func (cfg *apiConfig) handlerAdminAuditGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	params := database.ListAuthAuditEntriesParams{
+		UserID: sql.NullString{String: query.Get("user_id"), Valid: query.Get("user_id") != ""},
+		Ip:     sql.NullString{String: query.Get("ip"), Valid: query.Get("ip") != ""},
+		Limit:  defaultAuditLimit,
+	}
+
+	if s := query.Get("success"); s != "" {
+		success, err := strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid success filter", err)
+			return
+		}
+		params.Success = sql.NullBool{Bool: success, Valid: true}
+	}
+
+	for name, dst := range map[string]*sql.NullString{"since": &params.Since, "until": &params.Until} {
+		s := query.Get(name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+name+" filter", err)
+			return
+		}
+		*dst = sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		params.Limit = int64(limit)
+	}
+
+	entries, err := cfg.DB.ListAuthAuditEntries(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get audit log", err)
+		return
+	}
+
+	resp, err := databaseAuthAuditToAuthAudit(entries)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert audit log", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerAdminUserRevokeKeys(w http.ResponseWriter, r *http.Request, admin database.User) {
+	userID := chi.URLParam(r, "userID")
+	if _, err := cfg.DB.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	keys, err := cfg.DB.ListAPIKeysForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api keys for user", err)
+		return
+	}
+	// Deny the keys in memory first so they stop working immediately, even
+	// if the database update below fails.
+	hashes := []string{}
+	for _, key := range keys {
+		if !key.RevokedAt.Valid {
+			hashes = append(hashes, key.KeyHash)
+		}
+	}
+	cfg.APIKeyAuth.Denylist.Add(hashes...)
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Sessions and refresh tokens go too, or they would keep the account
+	// usable without any of its keys.
+	now := time.Now().UTC().Format(time.RFC3339)
+	revoked, err := tx.RevokeAllAPIKeysForUser(r.Context(), database.RevokeAllAPIKeysForUserParams{
+		RevokedAt: sql.NullString{String: now, Valid: true},
+		UpdatedAt: now,
+		UserID:    userID,
+	})
+	if err == nil {
+		err = tx.DeleteSessionsForUser(r.Context(), userID)
+	}
+	if err == nil {
+		err = tx.DeleteRefreshTokensForUser(r.Context(), userID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke api keys", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+	cfg.Users.ForgetKeys(r.Context(), hashes...)
+	cfg.Users.ForgetUser(r.Context(), userID)
+
+	type response struct {
+		Revoked int64 `json:"revoked"`
+	}
+	respondWithJSON(w, http.StatusOK, response{Revoked: revoked})
+}
+
+// handlerAdminBackupsCreate backs up the database now, outside the
+// BACKUP_INTERVAL schedule.
+func (cfg *apiConfig) handlerAdminBackupsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Name      string    `json:"name"`
+		Size      int64     `json:"size"`
+		CreatedAt time.Time `json:"created_at"`
+		Uploaded  bool      `json:"uploaded"`
+	}
+
+	if cfg.Backups == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Backups aren't configured", nil)
+		return
+	}
+
+	b, err := cfg.Backups.Run(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't back up database", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response{
+		Name:      b.Name,
+		Size:      b.Size,
+		CreatedAt: b.CreatedAt,
+		Uploaded:  b.Uploaded,
+	})
+}
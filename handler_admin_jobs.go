@@ -0,0 +1,117 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultAdminJobsLimit = 50
+	maxAdminJobsLimit     = 200
+)
+
+// handlerAdminJobsGet lists background jobs, newest first. ?status= and
+// ?kind= narrow the list, so ?status=failed shows the ones to look into.
+func (cfg *apiConfig) handlerAdminJobsGet(w http.ResponseWriter, r *http.Request, admin database.User) {
+	query := r.URL.Query()
+	params := database.ListJobsParams{
+		Status: query.Get("status"),
+		Kind:   query.Get("kind"),
+		Limit:  defaultAdminJobsLimit,
+	}
+	switch params.Status {
+	case "", jobs.StatusPending, jobs.StatusSucceeded, jobs.StatusFailed:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxAdminJobsLimit {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		params.Limit = int64(limit)
+	}
+
+	list, err := cfg.DB.ListJobs(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get jobs", err)
+		return
+	}
+
+	resp, err := databaseJobsToJobs(list)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert jobs", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerAdminJobGet(w http.ResponseWriter, r *http.Request, admin database.User) {
+	cfg.respondWithJob(w, r, chi.URLParam(r, "jobID"))
+}
+
+// handlerAdminJobRequeue gives a failed job a fresh set of attempts,
+// starting straight away.
+func (cfg *apiConfig) handlerAdminJobRequeue(w http.ResponseWriter, r *http.Request, admin database.User) {
+	id := chi.URLParam(r, "jobID")
+	job, err := cfg.DB.GetJob(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get job", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get job", err)
+		return
+	}
+	if job.Status != jobs.StatusFailed {
+		respondWithError(w, http.StatusConflict, "Only failed jobs can be requeued", nil)
+		return
+	}
+
+	n, err := cfg.DB.RequeueJob(r.Context(), database.RequeueJobParams{
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        id,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't requeue job", err)
+		return
+	}
+	if n == 0 {
+		// Requeued by someone else since it was read.
+		respondWithError(w, http.StatusConflict, "Only failed jobs can be requeued", nil)
+		return
+	}
+	cfg.Jobs.Wake()
+
+	cfg.respondWithJob(w, r, id)
+}
+
+func (cfg *apiConfig) respondWithJob(w http.ResponseWriter, r *http.Request, id string) {
+	job, err := cfg.DB.GetJob(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get job", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get job", err)
+		return
+	}
+
+	resp, err := databaseJobToJob(job)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert job", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/memstore"
+	"github.com/go-chi/chi"
+)
+
+func TestHandlerAdminUserRevokeKeys(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	now := time.Now().UTC()
+	if err := store.CreateUser(ctx, database.CreateUserParams{ID: "u1", ApiKey: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	err := store.CreateSession(ctx, database.CreateSessionParams{
+		TokenHash: auth.HashAPIKey("session"),
+		CreatedAt: now.Format(time.RFC3339),
+		UserID:    "u1",
+		ExpiresAt: now.Add(time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: auth.HashAPIKey("refresh"),
+		CreatedAt: now.Format(time.RFC3339),
+		UpdatedAt: now.Format(time.RFC3339),
+		UserID:    "u1",
+		ExpiresAt: now.Add(time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &apiConfig{
+		DB:         store,
+		APIKeyAuth: auth.APIKeyAuthenticator{Store: store, Denylist: auth.NewDenylist(time.Hour)},
+		Users:      auth.NewUserCache(store, cache.NewLRU(1<<20), time.Minute),
+		JWTSecret:  "secret",
+	}
+	sessions := auth.SessionAuthenticator{Store: store}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/admin/users/u1/revoke-keys", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("userID", "u1")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	cfg.handlerAdminUserRevokeKeys(w, r, database.User{ID: "admin"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/refresh", nil)
+	r.Header.Set("Authorization", "Bearer refresh")
+	w = httptest.NewRecorder()
+	cfg.handlerRefresh(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh: expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	r.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: "session"})
+	if _, err := sessions.Authenticate(r); err == nil {
+		t.Error("session: expected an error, got none")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pagination"
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultAdminUsersLimit = 50
+	maxAdminUsersLimit     = 200
+
+	// adminUsersCursorSort tags cursors from handlerAdminUsersGet so note
+	// list cursors can't be passed in.
+	adminUsersCursorSort = "admin users"
+)
+
+// AdminUser is the support view of an account. LastActiveAt is the latest
+// of a profile change, a note edit and an API key use.
+type AdminUser struct {
+	ID           string `json:"id"`
+	CreatedAt    string `json:"created_at"`
+	Name         string `json:"name"`
+	Email        string `json:"email,omitempty"`
+	Role         string `json:"role"`
+	Suspended    bool   `json:"suspended"`
+	NoteCount    int64  `json:"note_count"`
+	LastActiveAt string `json:"last_active_at"`
+}
+
+type adminUsersPage struct {
+	Users      []AdminUser `json:"users"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// likePattern matches values containing s, escaping LIKE wildcards with a
+// backslash.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// handlerAdminUsersGet lists accounts, newest first, a page at a time.
+// ?q= narrows the list to names or emails containing it, case-insensitively
+// for ASCII. Clients page with the opaque ?cursor= returned as next_cursor.
+func (cfg *apiConfig) handlerAdminUsersGet(w http.ResponseWriter, r *http.Request, admin database.User) {
+	query := r.URL.Query()
+	params := database.ListUsersForAdminParams{
+		Limit: defaultAdminUsersLimit,
+	}
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		params.Pattern = sql.NullString{String: likePattern(q), Valid: true}
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxAdminUsersLimit {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		params.Limit = int64(limit)
+	}
+	if s := query.Get("cursor"); s != "" {
+		cursor, err := pagination.Decode(s)
+		if err == nil && cursor.Sort != adminUsersCursorSort {
+			err = pagination.ErrInvalidCursor
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullString{String: cursor.Value, Valid: true}
+		params.BeforeID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	// One extra row tells us whether there is another page.
+	pageSize := params.Limit
+	params.Limit++
+	rows, err := cfg.DB.ListUsersForAdmin(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get users", err)
+		return
+	}
+
+	page := adminUsersPage{Users: []AdminUser{}}
+	if int64(len(rows)) > pageSize {
+		rows = rows[:pageSize]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Sort: adminUsersCursorSort, Value: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, AdminUser{
+			ID:           row.ID,
+			CreatedAt:    row.CreatedAt,
+			Name:         row.Name,
+			Email:        row.Email.String,
+			Role:         row.Role,
+			Suspended:    row.SuspendedAt.Valid,
+			NoteCount:    row.NoteCount,
+			LastActiveAt: row.LastActiveAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerAdminUserSuspend suspends an account. Its requests are refused
+// until it's reactivated, but nothing is deleted. Admins can't suspend
+// themselves, so there's always someone left to undo a suspension.
+func (cfg *apiConfig) handlerAdminUserSuspend(w http.ResponseWriter, r *http.Request, admin database.User) {
+	userID := chi.URLParam(r, "userID")
+	if userID == admin.ID {
+		respondWithError(w, http.StatusBadRequest, "Admins can't suspend themselves", nil)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	cfg.setUserSuspended(w, r, userID, sql.NullString{String: now, Valid: true})
+}
+
+// handlerAdminUserUnsuspend reactivates a suspended account.
+func (cfg *apiConfig) handlerAdminUserUnsuspend(w http.ResponseWriter, r *http.Request, admin database.User) {
+	cfg.setUserSuspended(w, r, chi.URLParam(r, "userID"), sql.NullString{})
+}
+
+func (cfg *apiConfig) setUserSuspended(w http.ResponseWriter, r *http.Request, userID string, suspendedAt sql.NullString) {
+	updated, err := cfg.DB.SetUserSuspended(r.Context(), database.SetUserSuspendedParams{
+		SuspendedAt: suspendedAt,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		ID:          userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", nil)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
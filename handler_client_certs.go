@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// clientCertRequest is the body of handlerClientCertsCreate requests.
+type clientCertRequest struct {
+	Subject string `json:"subject"`
+	UserID  string `json:"user_id"`
+}
+
+func (cfg *apiConfig) handlerClientCertsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := clientCertRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("subject", params.Subject)
+	v.Required("user_id", params.UserID)
+	v.UUID("user_id", params.UserID)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	params.Subject = strings.TrimSpace(params.Subject)
+
+	if _, err := cfg.DB.GetUserByID(r.Context(), params.UserID); errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	if _, err := cfg.DB.GetClientCertBySubject(r.Context(), params.Subject); err == nil {
+		respondWithError(w, http.StatusConflict, "Subject is already mapped", nil)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get client cert", err)
+		return
+	}
+
+	err = cfg.DB.CreateClientCert(r.Context(), database.CreateClientCertParams{
+		ID:        uuid.New().String(),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Subject:   params.Subject,
+		UserID:    params.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create client cert", err)
+		return
+	}
+
+	cert, err := cfg.DB.GetClientCertBySubject(r.Context(), params.Subject)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get client cert", err)
+		return
+	}
+
+	certResp, err := databaseClientCertToClientCert(cert)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert client cert", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, certResp)
+}
+
+func (cfg *apiConfig) handlerClientCertsGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	certs, err := cfg.DB.ListClientCerts(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get client certs", err)
+		return
+	}
+
+	certsResp, err := databaseClientCertsToClientCerts(certs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert client certs", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, certsResp)
+}
+
+func (cfg *apiConfig) handlerClientCertsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	deleted, err := cfg.DB.DeleteClientCert(r.Context(), chi.URLParam(r, "certID"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete client cert", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Client cert not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mailer"
+)
+
+// verificationTokenTTL is how long an email verification link works.
+const verificationTokenTTL = 24 * time.Hour
+
+// sendVerificationEmail mails the user a link to verify their current email,
+// replacing any link sent before.
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, user database.User) error {
+	if !user.Email.Valid {
+		return errors.New("user has no email")
+	}
+	token, err := auth.MakeVerificationToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := cfg.DB.DeleteEmailVerificationTokensForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	err = cfg.DB.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashAPIKey(token),
+		CreatedAt: now.Format(time.RFC3339),
+		UserID:    user.ID,
+		Email:     user.Email.String,
+		ExpiresAt: now.Add(verificationTokenTTL).Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := cfg.PublicBaseURL + "/v1/verify?token=" + url.QueryEscape(token)
+	return cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email.String,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link within %d hours to verify your email:\n\n%s\n\nIf you didn't sign up, you can ignore this message.\n",
+			user.Name, int(verificationTokenTTL.Hours()), link),
+	})
+}
+
+// sendVerificationEmailOrLog is for requests that shouldn't fail just
+// because the mail didn't go out; the user can ask for another link.
+func (cfg *apiConfig) sendVerificationEmailOrLog(ctx context.Context, user database.User) {
+	if err := cfg.sendVerificationEmail(ctx, user); err != nil {
+		logf(ctx, "Couldn't send verification email to user %s: %v", user.ID, err)
+	}
+}
+
+// handlerVerifyEmail handles the link from a verification email. The link
+// only verifies the address it was sent to, so it stops working if the
+// email is changed in the meantime.
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	const invalidMsg = "Invalid or expired verification token"
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, invalidMsg, nil)
+		return
+	}
+
+	stored, err := cfg.DB.GetEmailVerificationToken(r.Context(), auth.HashAPIKey(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusBadRequest, invalidMsg, err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get verification token", err)
+		return
+	}
+	now := time.Now().UTC()
+	expiresAt, err := time.Parse(time.RFC3339, stored.ExpiresAt)
+	if err != nil || !now.Before(expiresAt) {
+		respondWithError(w, http.StatusBadRequest, invalidMsg, err)
+		return
+	}
+
+	n, err := cfg.DB.SetUserEmailVerified(r.Context(), database.SetUserEmailVerifiedParams{
+		EmailVerifiedAt: sql.NullString{String: now.Format(time.RFC3339), Valid: true},
+		UpdatedAt:       now.Format(time.RFC3339),
+		ID:              stored.UserID,
+		Email:           sql.NullString{String: stored.Email, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), stored.UserID)
+	if err := cfg.DB.DeleteEmailVerificationTokensForUser(r.Context(), stored.UserID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete verification token", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusBadRequest, invalidMsg, nil)
+		return
+	}
+
+	type response struct {
+		Email    string `json:"email"`
+		Verified bool   `json:"verified"`
+	}
+	respondWithJSON(w, http.StatusOK, response{Email: stored.Email, Verified: true})
+}
+
+// handlerUsersVerificationResend sends a fresh verification link.
+func (cfg *apiConfig) handlerUsersVerificationResend(w http.ResponseWriter, r *http.Request, user database.User) {
+	if !user.Email.Valid {
+		respondWithError(w, http.StatusConflict, "User has no email", nil)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email is already verified", nil)
+		return
+	}
+	if err := cfg.sendVerificationEmail(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// middlewareVerifiedEmail guards features that reach other people, such as
+// sharing, when REQUIRE_VERIFIED_EMAIL is on. It runs inside middlewareAuth.
+func (cfg *apiConfig) middlewareVerifiedEmail(handler authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if cfg.RequireVerifiedEmail && !user.EmailVerifiedAt.Valid {
+			respondWithError(w, http.StatusForbidden, "Verify your email to use this feature", nil)
+			return
+		}
+		handler(w, r, user)
+	}
+}
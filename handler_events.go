@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+)
+
+// eventReset starts an event stream that couldn't resume where the client
+// left off: it should refetch its notes.
+const eventReset = "reset"
+
+// handlerEvents streams the user's events as server-sent events, for
+// clients behind proxies that block WebSockets. Each is named by its type,
+// such as note.updated, and carries the data a WebSocket message would.
+// EventSource sends the last id it saw in a Last-Event-ID header when it
+// reconnects, and the stream then resumes with the events missed since.
+// When it can't, because the server restarted or too many were missed,
+// and on first connecting, the stream starts with a reset event instead.
+// Imports send no events, so refetch after one too.
+func (cfg *apiConfig) handlerEvents(w http.ResponseWriter, r *http.Request, user database.User) {
+	sub, missed, resumed := cfg.resumeEvents(user.ID, r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	// The stream outlives the server's read and write timeouts, so each
+	// write gets a deadline of its own instead.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logf(r.Context(), "Couldn't clear read deadline for event stream: %v", err)
+	}
+	send := func(write func(io.Writer) error) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout)); err != nil {
+			logf(r.Context(), "Couldn't set write deadline for event stream: %v", err)
+		}
+		return write(w) == nil && rc.Flush() == nil
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stops nginx buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	version := apiversion.Find(apiVersions, w.Header().Get(apiversion.Header))
+	if !resumed {
+		missed = []pubsub.Message[event]{{ID: sub.After, Event: event{Type: eventReset, Data: struct{}{}}}}
+	}
+	for _, m := range missed {
+		if !send(serverEvent(m, version)) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(eventPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-sub.C:
+			// A client that fell behind reconnects and resumes from the
+			// history.
+			if !ok || !send(serverEvent(m, version)) {
+				return
+			}
+		case <-ping.C:
+			if !send(func(w io.Writer) error {
+				_, err := io.WriteString(w, ": ping\n\n")
+				return err
+			}) {
+				return
+			}
+		}
+	}
+}
+
+// resumeEvents subscribes to the user's events, resuming after
+// lastEventID if it's an ID this process gave out and the events since
+// are still kept.
+func (cfg *apiConfig) resumeEvents(userID, lastEventID string) (*pubsub.Subscription[event], []pubsub.Message[event], bool) {
+	epoch, id, ok := strings.Cut(lastEventID, "-")
+	if ok && epoch == eventEpoch {
+		if after, err := strconv.ParseUint(id, 10, 64); err == nil {
+			return cfg.Events.Resume(userID, after)
+		}
+	}
+	return cfg.Events.Subscribe(userID), nil, false
+}
+
+// serverEvent writes m in the text/event-stream format, its data mapped
+// for version.
+func serverEvent(m pubsub.Message[event], version *apiversion.Version) func(io.Writer) error {
+	return func(w io.Writer) error {
+		dat, err := json.Marshal(version.MapPayload(m.Event.Data))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", eventEpoch, m.ID, m.Event.Type, dat)
+		return err
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/notearchive"
+)
+
+const exportPageSize = 100
+
+// handlerExport streams all of the user's notes as a zip archive. Notes are
+// read a page at a time and written straight to the response, so memory use
+// doesn't grow with the number of notes.
+func (cfg *apiConfig) handlerExport(w http.ResponseWriter, r *http.Request, user database.User) {
+	notebooks, err := cfg.DB.ListNotebooksForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notebooks", err)
+		return
+	}
+	notebookNames := make(map[string]string, len(notebooks))
+	manifest := notearchive.Manifest{
+		ExportedAt: time.Now().UTC(),
+		Notebooks:  make([]string, len(notebooks)),
+	}
+	for i, notebook := range notebooks {
+		notebookNames[notebook.ID] = notebook.Name
+		manifest.Notebooks[i] = notebook.Name
+	}
+
+	// Large exports outlast the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logf(r.Context(), "Couldn't clear write deadline for export: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="notely-export-`+manifest.ExportedAt.Format("20060102")+`.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	// Once the body has started, errors can only be logged; the client sees
+	// a truncated archive.
+	archive := notearchive.NewWriter(w)
+	params := database.ListNotesForUserParams{
+		UserID:          user.ID,
+		IncludeArchived: true,
+		SortBy:          "created_at",
+		Limit:           exportPageSize,
+	}
+	for {
+		posts, err := cfg.DB.ListNotesForUser(r.Context(), params)
+		if err != nil {
+			logf(r.Context(), "Couldn't get notes for export: %v", err)
+			return
+		}
+		notes, err := cfg.notesResponse(r.Context(), posts)
+		if err != nil {
+			logf(r.Context(), "Couldn't convert notes for export: %v", err)
+			return
+		}
+		for _, note := range notes {
+			if note.Encrypted {
+				// There's no plaintext to write as Markdown.
+				continue
+			}
+			exported := notearchive.Note{
+				ID:        note.ID,
+				CreatedAt: note.CreatedAt,
+				UpdatedAt: note.UpdatedAt,
+				Metadata:  note.Metadata,
+				Public:    note.Public,
+				Archived:  note.Archived,
+				Pinned:    note.Pinned,
+				Tags:      note.Tags,
+				Body:      note.Note,
+			}
+			if note.Title != nil {
+				exported.Title = *note.Title
+			}
+			for _, item := range note.Items {
+				exported.Items = append(exported.Items, notearchive.Item{Text: item.Text, Done: item.Done})
+			}
+			if note.NotebookID != nil {
+				exported.Notebook = notebookNames[*note.NotebookID]
+			}
+			if err := archive.WriteNote(exported); err != nil {
+				logf(r.Context(), "Couldn't write note to export: %v", err)
+				return
+			}
+		}
+
+		if len(posts) < exportPageSize {
+			break
+		}
+		last := posts[len(posts)-1]
+		params.AfterPinned = last.Pinned
+		params.AfterValue = sql.NullString{String: last.CreatedAt, Valid: true}
+		params.AfterID = sql.NullString{String: last.ID, Valid: true}
+	}
+
+	if err := archive.Close(manifest); err != nil {
+		logf(r.Context(), "Couldn't finish export: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/contenthash"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/notearchive"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/google/uuid"
+)
+
+const importMaxBytes = 32 << 20
+
+// handlerImport creates notes from a zip archive, either one produced by
+// /export or a plain zip of Markdown files. The archive is sent as the
+// request body or as the "file" field of a multipart form. Notes whose body
+// matches an existing note (or an earlier one in the archive) are skipped.
+// Notebooks are matched by name and created if missing. Pins aren't
+// imported since they're limited per user.
+func (cfg *apiConfig) handlerImport(w http.ResponseWriter, r *http.Request, user database.User) {
+	limitBody(w, r, importMaxBytes)
+	archive, size, err := importArchive(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Archive is too large", err)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Couldn't read archive", err)
+		return
+	}
+
+	existing, err := cfg.DB.GetNotesForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notes", err)
+		return
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, note := range existing {
+		if note.ContentHash.Valid {
+			seen[note.ContentHash.String] = true
+		}
+	}
+
+	notebooks, err := cfg.DB.ListNotebooksForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notebooks", err)
+		return
+	}
+	notebookIDs := make(map[string]string, len(notebooks))
+	for _, notebook := range notebooks {
+		notebookIDs[notebook.Name] = notebook.ID
+	}
+
+	now := time.Now()
+	created, skipped := 0, 0
+	var exceeded *quotaExceededError
+	var invalid validate.Errors
+	err = database.WithTx(r.Context(), cfg.DB, func(tx database.Querier) error {
+		quota, err := cfg.startQuotaCheck(r.Context(), tx, user.ID)
+		if err != nil {
+			return err
+		}
+		err = notearchive.ReadArchive(archive, size, func(n notearchive.Note) error {
+			hash := contenthash.Sum(n.Body)
+			if seen[hash] {
+				skipped++
+				return nil
+			}
+			seen[hash] = true
+
+			items := make([]noteItemInput, len(n.Items))
+			for i, item := range n.Items {
+				items[i] = noteItemInput{Text: item.Text, Done: item.Done}
+			}
+			prepared, err := cfg.prepareNote(r.Context(), &validate.Validator{}, user, noteInput{
+				Title:    n.Title,
+				Metadata: n.Metadata,
+				Note:     n.Body,
+				Public:   n.Public,
+				Tags:     n.Tags,
+				Items:    items,
+			}, now)
+			if err != nil {
+				return err
+			}
+			if !n.CreatedAt.IsZero() {
+				prepared.params.CreatedAt = n.CreatedAt.UTC().Format(time.RFC3339)
+				prepared.params.UpdatedAt = prepared.params.CreatedAt
+			}
+			if !n.UpdatedAt.IsZero() {
+				prepared.params.UpdatedAt = n.UpdatedAt.UTC().Format(time.RFC3339)
+			}
+			prepared.params.NotebookID, err = importNotebook(r.Context(), tx, user.ID, notebookIDs, n.Notebook, now)
+			if err != nil {
+				return err
+			}
+
+			if err := insertNote(r.Context(), tx, prepared); err != nil {
+				return err
+			}
+			if n.Archived {
+				if _, err := tx.SetNoteArchived(r.Context(), database.SetNoteArchivedParams{
+					Archived: true,
+					ID:       prepared.params.ID,
+					UserID:   user.ID,
+				}); err != nil {
+					return err
+				}
+			}
+			created++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if created > 0 {
+			if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return quota.check(r.Context())
+	})
+	switch {
+	case errors.Is(err, notearchive.ErrInvalidArchive), errors.Is(err, notearchive.ErrInvalidFrontMatter):
+		respondWithError(w, http.StatusBadRequest, "Invalid archive", err)
+		return
+	case errors.Is(err, notearchive.ErrNoteTooLarge), errors.Is(err, errNoteTooLarge):
+		respondWithErrorCode(w, http.StatusRequestEntityTooLarge, errCodeNoteTooLarge, "Note in archive is too large", err)
+		return
+	case errors.As(err, &invalid):
+		respondWithError(w, http.StatusBadRequest, "Invalid note in archive: "+invalid.Error(), err)
+		return
+	case errors.As(err, &exceeded):
+		respondWithQuotaExceeded(w, exceeded)
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't import notes", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Created int `json:"created"`
+		Skipped int `json:"skipped"`
+	}{
+		Created: created,
+		Skipped: skipped,
+	})
+}
+
+// importArchive returns the uploaded zip, taken from the "file" form field
+// for multipart requests and from the raw body otherwise.
+func importArchive(r *http.Request) (io.ReaderAt, int64, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, 0, err
+		}
+		return file, header.Size, nil
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// importNotebook returns the ID of the user's notebook with the given name,
+// creating it if needed. An empty name is the default notebook.
+func importNotebook(ctx context.Context, db database.Querier, userID string, ids map[string]string, name string, now time.Time) (sql.NullString, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return sql.NullString{}, nil
+	}
+	if id, ok := ids[name]; ok {
+		return sql.NullString{String: id, Valid: true}, nil
+	}
+
+	id := uuid.New().String()
+	err := db.CreateNotebook(ctx, database.CreateNotebookParams{
+		ID:        id,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		UpdatedAt: now.UTC().Format(time.RFC3339),
+		Name:      name,
+		UserID:    userID,
+	})
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	ids[name] = id
+	return sql.NullString{String: id, Valid: true}, nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// keyCreateRequest is the body of handlerKeysCreate requests.
+type keyCreateRequest struct {
+	Label        string     `json:"label"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	AllowedCIDRs []string   `json:"allowed_cidrs"`
+}
+
+func (cfg *apiConfig) handlerKeysCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := keyCreateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	allowedCIDRs, err := auth.NormalizeCIDRs(params.AllowedCIDRs)
+	v.Check(err == nil, "allowed_cidrs", "must be IP addresses or CIDR ranges")
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't gen apikey", err)
+		return
+	}
+
+	id := uuid.New().String()
+	err = cfg.DB.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		ID:           id,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
+		UserID:       user.ID,
+		Label:        params.Label,
+		KeyHash:      auth.HashAPIKey(apiKey),
+		KeyPrefix:    auth.DisplayPrefix(apiKey),
+		ExpiresAt:    nullTime(params.ExpiresAt),
+		AllowedCidrs: allowedCIDRs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create api key", err)
+		return
+	}
+
+	key, err := cfg.DB.GetAPIKey(r.Context(), database.GetAPIKeyParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api key", err)
+		return
+	}
+
+	keyResp, err := databaseAPIKeyToAPIKey(key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert api key", err)
+		return
+	}
+	keyResp.Key = apiKey
+	respondWithJSON(w, http.StatusCreated, keyResp)
+}
+
+func (cfg *apiConfig) handlerKeysGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	keys, err := cfg.DB.ListAPIKeysForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api keys for user", err)
+		return
+	}
+
+	keysResp, err := databaseAPIKeysToAPIKeys(keys)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert api keys", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keysResp)
+}
+
+func (cfg *apiConfig) handlerKeysDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	keyID := chi.URLParam(r, "keyID")
+
+	key, err := cfg.DB.GetAPIKey(r.Context(), database.GetAPIKeyParams{
+		ID:     keyID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && key.RevokedAt.Valid) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find api key", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api key", err)
+		return
+	}
+
+	active, err := cfg.DB.CountActiveAPIKeysForUser(r.Context(), database.CountActiveAPIKeysForUserParams{
+		UserID:    user.ID,
+		ExpiresAt: sql.NullString{String: time.Now().UTC().Format(time.RFC3339), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count api keys", err)
+		return
+	}
+	if active <= 1 {
+		respondWithError(w, http.StatusConflict, "Can't revoke the last active api key", nil)
+		return
+	}
+
+	cfg.APIKeyAuth.Denylist.Add(key.KeyHash)
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = cfg.DB.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+		RevokedAt: sql.NullString{String: now, Valid: true},
+		UpdatedAt: now,
+		ID:        keyID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke api key", err)
+		return
+	}
+	cfg.Users.ForgetKeys(r.Context(), key.KeyHash)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keyRotateRequest is the body of handlerKeysRotate requests.
+type keyRotateRequest struct {
+	ID        string     `json:"id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (cfg *apiConfig) handlerKeysRotate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := keyRotateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("id", params.ID)
+	v.UUID("id", params.ID)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	now := time.Now().UTC()
+	old, err := cfg.DB.GetAPIKey(r.Context(), database.GetAPIKeyParams{
+		ID:     params.ID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (old.RevokedAt.Valid || auth.KeyExpired(old, now))) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find api key", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api key", err)
+		return
+	}
+
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't gen apikey", err)
+		return
+	}
+
+	// The old key keeps working for the grace period so clients can roll over,
+	// unless it was already due to expire sooner.
+	oldExpiresAt := now.Add(cfg.KeyRotationGrace)
+	if current, err := parseNullTime(old.ExpiresAt); err == nil && current != nil && current.Before(oldExpiresAt) {
+		oldExpiresAt = *current
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	id := uuid.New().String()
+	err = tx.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		ID:           id,
+		CreatedAt:    now.Format(time.RFC3339),
+		UpdatedAt:    now.Format(time.RFC3339),
+		UserID:       user.ID,
+		Label:        old.Label,
+		KeyHash:      auth.HashAPIKey(apiKey),
+		KeyPrefix:    auth.DisplayPrefix(apiKey),
+		ExpiresAt:    nullTime(params.ExpiresAt),
+		AllowedCidrs: old.AllowedCidrs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create api key", err)
+		return
+	}
+
+	_, err = tx.SetAPIKeyExpiry(r.Context(), database.SetAPIKeyExpiryParams{
+		ExpiresAt: nullTime(&oldExpiresAt),
+		UpdatedAt: now.Format(time.RFC3339),
+		ID:        old.ID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't expire old api key", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+	cfg.Users.ForgetKeys(r.Context(), old.KeyHash)
+
+	key, err := cfg.DB.GetAPIKey(r.Context(), database.GetAPIKeyParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api key", err)
+		return
+	}
+
+	keyResp, err := databaseAPIKeyToAPIKey(key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert api key", err)
+		return
+	}
+	keyResp.Key = apiKey
+	respondWithJSON(w, http.StatusCreated, keyResp)
+}
+
+// allowedCIDRsRequest is the body of handlerKeysAllowedCIDRsUpdate requests.
+type allowedCIDRsRequest struct {
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+}
+
+func (cfg *apiConfig) handlerKeysAllowedCIDRsUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := allowedCIDRsRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	allowedCIDRs, err := auth.NormalizeCIDRs(params.AllowedCIDRs)
+	v.Check(err == nil, "allowed_cidrs", "must be IP addresses or CIDR ranges")
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	keyID := chi.URLParam(r, "keyID")
+	updated, err := cfg.DB.SetAPIKeyAllowedCIDRs(r.Context(), database.SetAPIKeyAllowedCIDRsParams{
+		AllowedCidrs: allowedCIDRs,
+		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
+		ID:           keyID,
+		UserID:       user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update api key", err)
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find api key", nil)
+		return
+	}
+
+	key, err := cfg.DB.GetAPIKey(r.Context(), database.GetAPIKeyParams{
+		ID:     keyID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api key", err)
+		return
+	}
+	cfg.Users.ForgetKeys(r.Context(), key.KeyHash)
+
+	keyResp, err := databaseAPIKeyToAPIKey(key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert api key", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, keyResp)
+}
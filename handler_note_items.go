@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	noteItemTextMaxLength = 1000
+	noteItemsMax          = 500
+)
+
+var (
+	errTooManyItems = errors.New("too many checklist items")
+	errItemNotFound = errors.New("checklist item not found")
+)
+
+// noteItemInput is a new checklist item, on note creation or added later.
+type noteItemInput struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+func tooManyItemsMessage() string {
+	return fmt.Sprintf("must have at most %d items per note", noteItemsMax)
+}
+
+// normalizeItemText trims an item's text, which must not end up empty,
+// recording it on v as field if it's invalid.
+func normalizeItemText(v *validate.Validator, field, text string) string {
+	text = strings.TrimSpace(text)
+	v.Check(text != "" && utf8.RuneCountInString(text) <= noteItemTextMaxLength, field+".text",
+		fmt.Sprintf("must be 1 to %d characters long", noteItemTextMaxLength))
+	return text
+}
+
+// prepareNoteItems validates the items of a new note, given as field,
+// numbering them in the order given. Invalid items are recorded on v.
+func prepareNoteItems(v *validate.Validator, field, noteID string, items []noteItemInput, now time.Time) []database.CreateNoteItemParams {
+	v.Check(len(items) <= noteItemsMax, field, tooManyItemsMessage())
+	params := make([]database.CreateNoteItemParams, len(items))
+	for i, item := range items {
+		text := normalizeItemText(v, fmt.Sprintf("%s.%d", field, i), item.Text)
+		params[i] = database.CreateNoteItemParams{
+			ID:        uuid.New().String(),
+			CreatedAt: now.UTC().Format(time.RFC3339),
+			UpdatedAt: now.UTC().Format(time.RFC3339),
+			NoteID:    noteID,
+			Position:  int64(i),
+			Text:      text,
+			Done:      item.Done,
+		}
+	}
+	return params
+}
+
+// attachItems fills in the checklist items of notes in one query.
+func (cfg *apiConfig) attachItems(ctx context.Context, notes []Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	ids := make([]string, len(notes))
+	byID := make(map[string]*Note, len(notes))
+	for i := range notes {
+		ids[i] = notes[i].ID
+		byID[notes[i].ID] = &notes[i]
+	}
+
+	rows, err := cfg.DB.ListItemsForNotes(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if note, ok := byID[row.NoteID]; ok {
+			note.Items = append(note.Items, databaseNoteItemToNoteItem(row))
+		}
+	}
+	return nil
+}
+
+// noteItemUpdate changes the text or state of one checklist item.
+type noteItemUpdate struct {
+	ID   string  `json:"id"`
+	Text *string `json:"text"`
+	Done *bool   `json:"done"`
+}
+
+// noteItemsRequest is the body of handlerNoteItemsPatch requests.
+type noteItemsRequest struct {
+	Add    []noteItemInput  `json:"add"`
+	Update []noteItemUpdate `json:"update"`
+	Remove []string         `json:"remove"`
+}
+
+// handlerNoteItemsPatch edits a note's checklist. In one request, items can
+// be removed by ID, updated by ID (text and done are optional), and added
+// to the end of the list, in that order. Anyone who can write the note can
+// edit its checklist. The whole note is returned.
+//
+// Like note updates, edits must carry an If-Match header with the note's
+// current ETag.
+func (cfg *apiConfig) handlerNoteItemsPatch(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := noteItemsRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	access, err := cfg.noteAccess(r.Context(), note, &user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check note access", err)
+		return
+	}
+	switch access {
+	case noteAccessNone:
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	case noteAccessRead:
+		respondWithError(w, http.StatusForbidden, "Note is shared read-only", nil)
+		return
+	}
+	if note.Encrypted {
+		respondWithError(w, http.StatusUnprocessableEntity, "Encrypted notes can't have checklist items", nil)
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondWithError(w, http.StatusPreconditionRequired, "If-Match header is required", nil)
+		return
+	}
+	if !etagMatches(ifMatch, noteETag(note.Version), false) {
+		respondWithError(w, http.StatusPreconditionFailed, "Note has been modified", nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	updates := make([]database.UpdateNoteItemParams, len(params.Update))
+	for i, u := range params.Update {
+		field := fmt.Sprintf("update.%d", i)
+		v.Required(field+".id", u.ID)
+		updates[i] = database.UpdateNoteItemParams{
+			Done:      sql.NullBool{Bool: u.Done != nil && *u.Done, Valid: u.Done != nil},
+			UpdatedAt: now.Format(time.RFC3339),
+			ID:        u.ID,
+			NoteID:    note.ID,
+		}
+		if u.Text != nil {
+			text := normalizeItemText(v, field, *u.Text)
+			updates[i].Text = sql.NullString{String: text, Valid: true}
+		}
+	}
+	add := prepareNoteItems(v, "add", note.ID, params.Add, now)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	err = editNoteItems(r.Context(), tx, note.ID, params.Remove, updates, add)
+	switch {
+	case errors.Is(err, errItemNotFound):
+		respondWithError(w, http.StatusNotFound, "Couldn't get checklist item", err)
+		return
+	case errors.Is(err, errTooManyItems):
+		respondWithInvalidFields(w, validate.Errors{{Field: "add", Message: tooManyItemsMessage()}})
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update checklist", err)
+		return
+	}
+
+	touched, err := tx.TouchNote(r.Context(), database.TouchNoteParams{
+		UpdatedAt: now.Format(time.RFC3339),
+		ID:        note.ID,
+		Version:   note.Version,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
+	if touched == 0 {
+		// The version moved on since the If-Match check above.
+		respondWithError(w, http.StatusPreconditionFailed, "Note has been modified", nil)
+		return
+	}
+	if err := touchNoteList(r.Context(), tx, note.UserID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	note, err = cfg.DB.GetNote(r.Context(), note.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	noteResp, err := cfg.noteResponse(r.Context(), note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	cfg.publishNote(eventNoteUpdated, noteResp, user.ID)
+	w.Header().Set("ETag", noteETag(noteResp.Version))
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+// editNoteItems removes, then updates, then appends checklist items, which
+// are numbered from the end of the list. Run it in a transaction: it stops
+// at the first error, including errItemNotFound for an ID that isn't on the
+// note and errTooManyItems if the list would grow past noteItemsMax.
+func editNoteItems(
+	ctx context.Context,
+	db database.Querier,
+	noteID string,
+	remove []string,
+	updates []database.UpdateNoteItemParams,
+	add []database.CreateNoteItemParams,
+) error {
+	for _, id := range remove {
+		n, err := db.DeleteNoteItem(ctx, database.DeleteNoteItemParams{ID: id, NoteID: noteID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errItemNotFound
+		}
+	}
+
+	for _, item := range updates {
+		n, err := db.UpdateNoteItem(ctx, item)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errItemNotFound
+		}
+	}
+
+	if len(add) == 0 {
+		return nil
+	}
+	existing, err := db.ListNoteItems(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	if len(existing)+len(add) > noteItemsMax {
+		return errTooManyItems
+	}
+	next := int64(0)
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Position + 1
+	}
+	for _, item := range add {
+		item.Position += next
+		if err := db.CreateNoteItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
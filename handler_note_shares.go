@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+)
+
+const (
+	sharePermissionRead  = "read"
+	sharePermissionWrite = "write"
+)
+
+// noteAccess is what a caller may do with a note, in increasing order.
+type noteAccess int
+
+const (
+	noteAccessNone noteAccess = iota
+	noteAccessRead
+	noteAccessWrite
+	noteAccessOwner
+)
+
+// noteAccess works out the caller's access to a note from ownership, shares
+// and the note's public flag. A nil user is a guest.
+func (cfg *apiConfig) noteAccess(ctx context.Context, note database.Note, user *database.User) (noteAccess, error) {
+	if user != nil && user.ID == note.UserID {
+		return noteAccessOwner, nil
+	}
+	if user != nil {
+		share, err := cfg.DB.GetNoteShare(ctx, database.GetNoteShareParams{
+			NoteID: note.ID,
+			UserID: user.ID,
+		})
+		if err == nil && share.Permission == sharePermissionWrite {
+			return noteAccessWrite, nil
+		}
+		if err == nil {
+			return noteAccessRead, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return noteAccessNone, err
+		}
+	}
+	if note.Public {
+		return noteAccessRead, nil
+	}
+	return noteAccessNone, nil
+}
+
+// noteShareRequest is the body of handlerNoteSharesCreate requests.
+type noteShareRequest struct {
+	Email      string `json:"email"`
+	Permission string `json:"permission"`
+}
+
+// handlerNoteSharesCreate shares one of the user's notes with another user,
+// found by email. Sharing again with the same user changes the permission.
+func (cfg *apiConfig) handlerNoteSharesCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := noteShareRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Permission == "" {
+		params.Permission = sharePermissionRead
+	}
+	v.Required("email", params.Email)
+	email, err := normalizeEmail(params.Email)
+	v.Check(err == nil, "email", "must be a valid email address")
+	v.OneOf("permission", params.Permission, sharePermissionRead, sharePermissionWrite)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil || note.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	recipient, err := cfg.DB.GetUserByEmail(r.Context(), sql.NullString{String: email, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	}
+	if recipient.ID == user.ID {
+		respondWithError(w, http.StatusBadRequest, "Can't share a note with yourself", nil)
+		return
+	}
+
+	var share database.NoteShare
+	err = database.WithTx(r.Context(), cfg.DB, func(tx database.Querier) error {
+		err := tx.UpsertNoteShare(r.Context(), database.UpsertNoteShareParams{
+			NoteID:     note.ID,
+			UserID:     recipient.ID,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+			Permission: params.Permission,
+		})
+		if err != nil {
+			return err
+		}
+		share, err = tx.GetNoteShare(r.Context(), database.GetNoteShareParams{
+			NoteID: note.ID,
+			UserID: recipient.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't share note", err)
+		return
+	}
+
+	resp, err := databaseNoteShareToNoteShare(share, email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert share", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerNoteSharesGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil || note.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	shares, err := cfg.DB.ListNoteShares(r.Context(), note.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get shares", err)
+		return
+	}
+
+	resp, err := databaseNoteSharesToNoteShares(shares)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert shares", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerNoteSharesDelete revokes a share. The note's owner can revoke any
+// share; anyone else can only remove their own.
+func (cfg *apiConfig) handlerNoteSharesDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	shareUserID := chi.URLParam(r, "userID")
+	if note.UserID != user.ID && shareUserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	}
+
+	n, err := cfg.DB.DeleteNoteShare(r.Context(), database.DeleteNoteShareParams{
+		NoteID: note.ID,
+		UserID: shareUserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete share", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get share", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerNotesSharedWithMe lists notes other users have shared with the
+// caller, most recently shared first.
+func (cfg *apiConfig) handlerNotesSharedWithMe(w http.ResponseWriter, r *http.Request, user database.User) {
+	withLinks, err := linksRequested(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid links flag", err)
+		return
+	}
+	posts, err := cfg.DB.ListNotesSharedWithUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notes", err)
+		return
+	}
+
+	notes, err := cfg.notesResponse(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+
+	page := notesPage{Notes: notes}
+	if withLinks {
+		addNoteLinks(w, page.Notes, user.ID)
+		addPageLinks(&page, r)
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+var errNotebookNotFound = errors.New("notebook not found")
+
+const notebookNameMaxLength = 100
+
+// notebookRequest is the body of handlerNotebooksCreate and
+// handlerNotebooksUpdate requests.
+type notebookRequest struct {
+	Name string `json:"name"`
+}
+
+func (cfg *apiConfig) handlerNotebooksCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := notebookRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("name", params.Name)
+	v.MaxLength("name", params.Name, notebookNameMaxLength)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+
+	id := uuid.New().String()
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = cfg.DB.CreateNotebook(r.Context(), database.CreateNotebookParams{
+		ID:        id,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create notebook", err)
+		return
+	}
+
+	cfg.respondWithNotebook(w, r, http.StatusCreated, id, user)
+}
+
+func (cfg *apiConfig) handlerNotebooksGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	notebooks, err := cfg.DB.ListNotebooksForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notebooks", err)
+		return
+	}
+
+	resp, err := databaseNotebooksToNotebooks(notebooks)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notebooks", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerNotebookGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.respondWithNotebook(w, r, http.StatusOK, chi.URLParam(r, "notebookID"), user)
+}
+
+func (cfg *apiConfig) handlerNotebooksUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := notebookRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("name", params.Name)
+	v.MaxLength("name", params.Name, notebookNameMaxLength)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	name := strings.TrimSpace(params.Name)
+
+	id := chi.URLParam(r, "notebookID")
+	n, err := cfg.DB.RenameNotebook(r.Context(), database.RenameNotebookParams{
+		Name:      name,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        id,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename notebook", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get notebook", nil)
+		return
+	}
+
+	cfg.respondWithNotebook(w, r, http.StatusOK, id, user)
+}
+
+// handlerNotebooksDelete removes a notebook. Its notes move to the default
+// notebook unless ?cascade=true asks for them to be deleted too.
+func (cfg *apiConfig) handlerNotebooksDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	cascade := false
+	if s := r.URL.Query().Get("cascade"); s != "" {
+		var err error
+		cascade, err = strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cascade flag", err)
+			return
+		}
+	}
+
+	id := chi.URLParam(r, "notebookID")
+	notebookID := sql.NullString{String: id, Valid: true}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := tx.DeleteNotebook(r.Context(), database.DeleteNotebookParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete notebook", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get notebook", nil)
+		return
+	}
+
+	if cascade {
+		err = tx.DeleteNoteTagsInNotebook(r.Context(), database.DeleteNoteTagsInNotebookParams{
+			NotebookID: notebookID,
+			UserID:     user.ID,
+		})
+		if err == nil {
+			err = tx.DeleteNoteItemsInNotebook(r.Context(), database.DeleteNoteItemsInNotebookParams{
+				NotebookID: notebookID,
+				UserID:     user.ID,
+			})
+		}
+		if err == nil {
+			err = tx.DeleteNoteSharesInNotebook(r.Context(), database.DeleteNoteSharesInNotebookParams{
+				NotebookID: notebookID,
+				UserID:     user.ID,
+			})
+		}
+		if err == nil {
+			err = tx.DeleteNoteShareLinksInNotebook(r.Context(), database.DeleteNoteShareLinksInNotebookParams{
+				NotebookID: notebookID,
+				UserID:     user.ID,
+			})
+		}
+		if err == nil {
+			err = tx.DeleteNotesInNotebook(r.Context(), database.DeleteNotesInNotebookParams{
+				NotebookID: notebookID,
+				UserID:     user.ID,
+			})
+		}
+	} else {
+		err = tx.MoveNotesToDefaultNotebook(r.Context(), database.MoveNotesToDefaultNotebookParams{
+			NotebookID: notebookID,
+			UserID:     user.ID,
+		})
+	}
+	if err == nil {
+		err = touchNoteList(r.Context(), tx, user.ID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update notebook notes", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) respondWithNotebook(w http.ResponseWriter, r *http.Request, code int, id string, user database.User) {
+	notebook, err := cfg.DB.GetNotebook(r.Context(), database.GetNotebookParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get notebook", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notebook", err)
+		return
+	}
+
+	resp, err := databaseNotebookToNotebook(notebook)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notebook", err)
+		return
+	}
+
+	respondWithJSON(w, code, resp)
+}
+
+// notebookParam resolves a notebook_id from a request body. An empty ID
+// means the default notebook; any other ID must be one of the user's
+// notebooks.
+func notebookParam(ctx context.Context, db database.Querier, userID, id string) (sql.NullString, error) {
+	if id == "" {
+		return sql.NullString{}, nil
+	}
+	_, err := db.GetNotebook(ctx, database.GetNotebookParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.NullString{}, errNotebookNotFound
+	}
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: id, Valid: true}, nil
+}
@@ -1,66 +1,1215 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/contenthash"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/markdown"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pagination"
+	"github.com/bootdotdev/learn-cicd-starter/internal/settings"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
+const defaultNotesPageSize = 50
+
+// notesPageCacheTTL is how long a listing page stays cached. Its key
+// changes with every write to the listing, so this only decides how long
+// pages nobody asks for again take up room.
+const notesPageCacheTTL = 10 * time.Minute
+
+type notesPage struct {
+	Notes      []Note     `json:"notes"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Links      *pageLinks `json:"_links,omitempty"`
+}
+
+// handlerNotesGet lists the user's notes a page at a time, pinned notes
+// first and then in the order from the user's settings (oldest first by
+// default) unless ?sort=created_at|updated_at and ?order=asc|desc say
+// otherwise. ?limit= likewise overrides the page size from the settings.
+// ?created_after= (inclusive) and ?created_before= (exclusive) narrow the
+// list to an RFC3339 time range, ?tag= to notes with that tag and
+// ?notebook_id= to the notes in one notebook. Archived notes are left out
+// unless ?include_archived=true.
+// Clients page with either ?offset= or the opaque ?cursor= returned as
+// next_cursor; cursors stay stable while notes are being added.
+// Responses carry an ETag and Last-Modified, and a request with a matching
+// If-None-Match, or an If-Modified-Since no earlier than Last-Modified,
+// gets a 304 Not Modified instead of the page. Last-Modified only has
+// second precision, so pollers should prefer If-None-Match.
+// ?links=true adds _links to the page and its notes, the page's next link
+// following next_cursor.
 func (cfg *apiConfig) handlerNotesGet(w http.ResponseWriter, r *http.Request, user database.User) {
-	posts, err := cfg.DB.GetNotesForUser(r.Context(), user.ID)
+	query := r.URL.Query()
+	prefs := settings.Default()
+	if query.Get("sort") == "" || query.Get("order") == "" || query.Get("limit") == "" {
+		var err error
+		prefs, err = cfg.userSettings(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get settings", err)
+			return
+		}
+	}
+
+	sortBy := query.Get("sort")
+	switch sortBy {
+	case "":
+		sortBy = prefs.NoteSort
+	case "created_at", "updated_at":
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid sort", nil)
+		return
+	}
+	order := query.Get("order")
+	switch order {
+	case "":
+		order = prefs.NoteOrder
+	case "asc", "desc":
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid order", nil)
+		return
+	}
+	sort := sortBy + " " + order
+
+	pageSize := min(defaultNotesPageSize, cfg.NotesMaxPageSize)
+	if prefs.PageSize > 0 {
+		pageSize = min(prefs.PageSize, cfg.NotesMaxPageSize)
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > cfg.NotesMaxPageSize {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		pageSize = limit
+	}
+
+	includeArchived := false
+	if s := query.Get("include_archived"); s != "" {
+		var err error
+		includeArchived, err = strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid include_archived flag", err)
+			return
+		}
+	}
+	withLinks, err := linksRequested(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid links flag", err)
+		return
+	}
+
+	// One extra row tells us whether there is another page.
+	params := database.ListNotesForUserParams{
+		UserID:          user.ID,
+		IncludeArchived: includeArchived,
+		SortBy:          sortBy,
+		Descending:      order == "desc",
+		NotebookID:      sql.NullString{String: query.Get("notebook_id"), Valid: query.Get("notebook_id") != ""},
+		Tag:             sql.NullString{String: query.Get("tag"), Valid: query.Get("tag") != ""},
+		Limit:           int64(pageSize) + 1,
+	}
+	for name, dst := range map[string]*sql.NullString{"created_after": &params.CreatedAfter, "created_before": &params.CreatedBefore} {
+		s := query.Get(name)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+name+" filter", err)
+			return
+		}
+		*dst = sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+	}
+	if s := query.Get("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid offset", err)
+			return
+		}
+		params.Offset = int64(offset)
+	}
+	if s := query.Get("cursor"); s != "" {
+		if params.Offset != 0 {
+			respondWithError(w, http.StatusBadRequest, "Use either cursor or offset, not both", nil)
+			return
+		}
+		cursor, err := pagination.Decode(s)
+		if err == nil && cursor.Sort != sort {
+			err = pagination.ErrInvalidCursor
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.AfterPinned = cursor.Pinned
+		params.AfterValue = sql.NullString{String: cursor.Value, Valid: true}
+		params.AfterID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	// The listing's state is cheap to read, so clients polling with the
+	// validators of their last response skip the listing when nothing has
+	// changed. A write between the two reads only leaves the validators
+	// older than the listing, which costs the next poll a full response.
+	state, err := cfg.DB.GetNoteListState(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
+		return
+	}
+	variant := strings.Join([]string{r.URL.RawQuery, w.Header().Get(apiversion.Header), responseEncoderFor(w).mediaType}, "\n")
+	etag, modified := noteListValidators(state, variant)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Pages are cached under the listing's version, so a write that changes
+	// the listing moves readers on to a new key and nothing has to be
+	// dropped. A page built while a write lands may be newer than its key,
+	// which is harmless: versions only go up, so the key isn't read again.
+	cacheKey := fmt.Sprintf("notes:%s:%d:%s", user.ID, state.Version, digest(variant))
+	if b, err := cfg.Cache.Get(r.Context(), cacheKey); err == nil {
+		var page notesPage
+		if err := json.Unmarshal(b, &page); err == nil {
+			respondWithJSON(w, http.StatusOK, page)
+			return
+		}
+	} else if !errors.Is(err, cache.ErrMiss) {
+		log.Printf("Couldn't read cached notes page: %v", err)
+	}
+
+	posts, err := cfg.DB.ListNotesForUser(r.Context(), params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't get posts for user", err)
 		return
 	}
 
-	postsResp, err := databasePostsToPosts(posts)
+	page := notesPage{}
+	if len(posts) > pageSize {
+		posts = posts[:pageSize]
+		last := posts[len(posts)-1]
+		cursor := pagination.Cursor{Sort: sort, Pinned: last.Pinned, Value: last.CreatedAt, ID: last.ID}
+		if sortBy == "updated_at" {
+			cursor.Value = last.UpdatedAt
+		}
+		page.NextCursor = cursor.Encode()
+	}
+
+	page.Notes, err = cfg.notesResponse(r.Context(), posts)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert posts", err)
 		return
 	}
+	if withLinks {
+		addNoteLinks(w, page.Notes, user.ID)
+		addPageLinks(&page, r)
+	}
 
-	respondWithJSON(w, http.StatusOK, postsResp)
+	if b, err := json.Marshal(page); err == nil {
+		if err := cfg.Cache.Set(r.Context(), cacheKey, b, notesPageCacheTTL); err != nil {
+			log.Printf("Couldn't cache notes page: %v", err)
+		}
+	}
+	respondWithJSON(w, http.StatusOK, page)
 }
 
-func (cfg *apiConfig) handlerNotesCreate(w http.ResponseWriter, r *http.Request, user database.User) {
-	type parameters struct {
-		Note string `json:"note"`
+// handlerNotesSearch runs a full-text search over the user's notes, best
+// matches first. Every word in ?q= must appear in a note for it to match.
+func (cfg *apiConfig) handlerNotesSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	match := ftsQuery(query.Get("q"))
+	if match == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing search query", nil)
+		return
+	}
+	params := database.SearchNotesForUserParams{
+		UserID: user.ID,
+		Query:  match,
+		Limit:  int64(min(defaultNotesPageSize, cfg.NotesMaxPageSize)),
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > cfg.NotesMaxPageSize {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		params.Limit = int64(limit)
+	}
+	withLinks, err := linksRequested(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid links flag", err)
+		return
+	}
+
+	posts, err := cfg.DB.SearchNotesForUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search notes", err)
+		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+
+	page := notesPage{}
+	page.Notes, err = cfg.notesResponse(r.Context(), posts)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert posts", err)
 		return
 	}
+	if withLinks {
+		addNoteLinks(w, page.Notes, user.ID)
+		addPageLinks(&page, r)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerNotesUpcoming lists the user's notes with a reminder still to come,
+// soonest first.
+func (cfg *apiConfig) handlerNotesUpcoming(w http.ResponseWriter, r *http.Request, user database.User) {
+	params := database.ListUpcomingNotesForUserParams{
+		UserID:   user.ID,
+		RemindAt: sql.NullString{String: time.Now().UTC().Format(time.RFC3339), Valid: true},
+		Limit:    int64(min(defaultNotesPageSize, cfg.NotesMaxPageSize)),
+	}
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > cfg.NotesMaxPageSize {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		params.Limit = int64(limit)
+	}
+	withLinks, err := linksRequested(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid links flag", err)
+		return
+	}
+
+	posts, err := cfg.DB.ListUpcomingNotesForUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notes", err)
+		return
+	}
+
+	page := notesPage{}
+	page.Notes, err = cfg.notesResponse(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+	if withLinks {
+		addNoteLinks(w, page.Notes, user.ID)
+		addPageLinks(&page, r)
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// noteDuplicates is a set of the user's notes with the same content, oldest
+// first.
+type noteDuplicates struct {
+	ContentHash string `json:"content_hash"`
+	Notes       []Note `json:"notes"`
+}
+
+// handlerNotesDuplicates groups the user's notes whose content is the same
+// once whitespace is normalized. Notes without a duplicate are left out.
+func (cfg *apiConfig) handlerNotesDuplicates(w http.ResponseWriter, r *http.Request, user database.User) {
+	posts, err := cfg.DB.ListDuplicateNotesForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get notes", err)
+		return
+	}
+
+	notes, err := cfg.notesResponse(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+
+	// Rows come back ordered by hash, so each group is a run.
+	groups := []noteDuplicates{}
+	for i, post := range posts {
+		hash := post.ContentHash.String
+		if len(groups) == 0 || groups[len(groups)-1].ContentHash != hash {
+			groups = append(groups, noteDuplicates{ContentHash: hash})
+		}
+		group := &groups[len(groups)-1]
+		group.Notes = append(group.Notes, notes[i])
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Groups []noteDuplicates `json:"groups"`
+	}{groups})
+}
+
+// ftsQuery turns free text into an FTS5 query that matches notes containing
+// every word, quoting each word so user input can't use (or break on) FTS5
+// query syntax.
+func ftsQuery(q string) string {
+	words := strings.Fields(q)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	errNoteTooLarge    = errors.New("note too large")
+	errInvalidTitle    = errors.New("invalid title")
+	errInvalidMetadata = errors.New("invalid metadata")
+)
+
+const (
+	noteTitleMaxLength   = 200
+	noteMetadataMaxBytes = 16 << 10
+)
+
+// errCodeNoteTooLarge is the error code sent with 413 responses for notes
+// over NOTE_MAX_BYTES.
+const errCodeNoteTooLarge = "note_too_large"
+
+// noteInput is the body of a note creation request, on its own or as one
+// item of a batch. Encrypted notes are encrypted by the client: they carry a
+// base64 nonce and ciphertext instead of a note, and the server stores them
+// as given. Metadata is any JSON object the client wants kept with the
+// note. With skip_if_duplicate, a note whose content matches one the
+// user already has isn't created; the existing note is returned instead.
+type noteInput struct {
+	Title      string          `json:"title"`
+	Metadata   json.RawMessage `json:"metadata"`
+	Note       string          `json:"note"`
+	Encrypted  bool            `json:"encrypted"`
+	Nonce      string          `json:"nonce"`
+	Ciphertext string          `json:"ciphertext"`
+	Public     bool            `json:"public"`
+	Tags       []string        `json:"tags"`
+	NotebookID string          `json:"notebook_id"`
+	RemindAt   *time.Time      `json:"remind_at"`
+	Items      []noteItemInput `json:"items"`
+
+	SkipIfDuplicate bool `json:"skip_if_duplicate"`
+}
+
+// newNote is a validated noteInput ready to be inserted.
+type newNote struct {
+	params database.CreateNoteParams
+	tags   []string
+	items  []database.CreateNoteItemParams
+}
+
+// prepareNote validates a noteInput for the user, adding its invalid
+// fields to any v already holds. It returns errNoteTooLarge before checking
+// anything else, then the invalid fields as validate.Errors, then
+// errNotebookNotFound; see noteInputErrorResponse.
+func (cfg *apiConfig) prepareNote(ctx context.Context, v *validate.Validator, user database.User, in noteInput, now time.Time) (newNote, error) {
+	if len(in.Note)+len(in.Ciphertext) > cfg.NoteMaxBytes {
+		return newNote{}, errNoteTooLarge
+	}
 
+	title := checkTitle(v, in.Title)
+	metadata := checkMetadata(v, in.Metadata)
+	tags := normalizeTags(v, "tags", in.Tags)
+	v.Check(in.RemindAt == nil || in.RemindAt.After(now), "remind_at", "must be in the future")
+	if in.Encrypted {
+		v.Check(in.Note == "", "note", "must be empty on encrypted notes")
+		checkEncryptedBody(v, in.Nonce, in.Ciphertext)
+		v.Check(len(in.Items) == 0, "items", "must be empty on encrypted notes")
+	} else {
+		v.Check(in.Nonce == "", "nonce", "must only be set on encrypted notes")
+		v.Check(in.Ciphertext == "", "ciphertext", "must only be set on encrypted notes")
+	}
 	id := uuid.New().String()
-	err = cfg.DB.CreateNote(r.Context(), database.CreateNoteParams{
-		ID:        id,
-		CreatedAt: time.Now().UTC().Format(time.RFC3339),
-		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
-		Note:      params.Note,
-		UserID:    user.ID,
+	items := prepareNoteItems(v, "items", id, in.Items, now)
+	if errs := v.Errors(); errs != nil {
+		return newNote{}, errs
+	}
+
+	notebookID, err := notebookParam(ctx, cfg.DB, user.ID, in.NotebookID)
+	if err != nil {
+		return newNote{}, err
+	}
+
+	var contentHash sql.NullString
+	wordCount := sql.NullInt64{Valid: true}
+	if !in.Encrypted {
+		contentHash = sql.NullString{String: contenthash.Sum(in.Note), Valid: true}
+		wordCount = noteWordCount(in.Note)
+	}
+
+	return newNote{
+		params: database.CreateNoteParams{
+			ID:          id,
+			CreatedAt:   now.UTC().Format(time.RFC3339),
+			UpdatedAt:   now.UTC().Format(time.RFC3339),
+			Note:        in.Note,
+			UserID:      user.ID,
+			Public:      in.Public,
+			NotebookID:  notebookID,
+			RemindAt:    nullTime(in.RemindAt),
+			Encrypted:   in.Encrypted,
+			Nonce:       sql.NullString{String: in.Nonce, Valid: in.Encrypted},
+			Ciphertext:  sql.NullString{String: in.Ciphertext, Valid: in.Encrypted},
+			ContentHash: contentHash,
+			WordCount:   wordCount,
+			Title:       title,
+			Metadata:    metadata,
+		},
+		tags:  tags,
+		items: items,
+	}, nil
+}
+
+// noteInputErrorResponse maps a prepareNote error, other than
+// validate.Errors, to a status, error code and message.
+func (cfg *apiConfig) noteInputErrorResponse(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, errNoteTooLarge):
+		return http.StatusRequestEntityTooLarge, errCodeNoteTooLarge, cfg.noteTooLargeMessage()
+	case errors.Is(err, errNotebookNotFound):
+		return http.StatusNotFound, "", "Couldn't get notebook"
+	default:
+		return http.StatusInternalServerError, "", "Couldn't create note"
+	}
+}
+
+func (cfg *apiConfig) noteTooLargeMessage() string {
+	return fmt.Sprintf("Note is larger than %d bytes", cfg.NoteMaxBytes)
+}
+
+// middlewareNoteBodyLimit caps the body of a request carrying up to the
+// given number of notes, so oversized requests are turned away before
+// they're read into memory. JSON escaping can make the body bigger than the
+// notes themselves, so this leaves room for it; each note's own size is
+// checked once it's decoded.
+func (cfg *apiConfig) middlewareNoteBodyLimit(notes int, handler authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		limit := int64(notes) * (2*int64(cfg.NoteMaxBytes) + 64<<10)
+		if r.ContentLength > limit {
+			respondWithErrorCode(w, http.StatusRequestEntityTooLarge, errCodeNoteTooLarge, cfg.noteTooLargeMessage(), nil)
+			return
+		}
+		limitBody(w, r, limit)
+		handler(w, r, user)
+	}
+}
+
+// respondToDecodeError reports a note request body that couldn't be
+// decoded, either because it isn't JSON or because it ran past
+// middlewareNoteBodyLimit.
+func (cfg *apiConfig) respondToDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithErrorCode(w, http.StatusRequestEntityTooLarge, errCodeNoteTooLarge, cfg.noteTooLargeMessage(), err)
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+}
+
+// checkTitle normalizes a title, recording it on v if it's invalid.
+func checkTitle(v *validate.Validator, title string) sql.NullString {
+	normalized, err := normalizeTitle(title)
+	v.Check(err == nil, "title", fmt.Sprintf("must be a single line of at most %d characters", noteTitleMaxLength))
+	return normalized
+}
+
+// checkMetadata normalizes metadata, recording it on v if it's invalid.
+func checkMetadata(v *validate.Validator, raw json.RawMessage) sql.NullString {
+	normalized, err := normalizeMetadata(raw)
+	v.Check(err == nil, "metadata", fmt.Sprintf("must be a JSON object of at most %d bytes", noteMetadataMaxBytes))
+	return normalized
+}
+
+// normalizeTitle trims a title, treating an empty one as no title. Titles
+// are a single line so list views can show them as-is.
+func normalizeTitle(title string) (sql.NullString, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return sql.NullString{}, nil
+	}
+	if utf8.RuneCountInString(title) > noteTitleMaxLength {
+		return sql.NullString{}, errInvalidTitle
+	}
+	for _, r := range title {
+		if unicode.IsControl(r) {
+			return sql.NullString{}, errInvalidTitle
+		}
+	}
+	return sql.NullString{String: title, Valid: true}, nil
+}
+
+// normalizeMetadata checks that metadata is a JSON object and compacts it
+// for storage. Missing or null metadata is stored as none.
+func normalizeMetadata(raw json.RawMessage) (sql.NullString, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return sql.NullString{}, nil
+	}
+	if len(raw) > noteMetadataMaxBytes {
+		return sql.NullString{}, errInvalidMetadata
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil || object == nil {
+		return sql.NullString{}, errInvalidMetadata
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return sql.NullString{}, errInvalidMetadata
+	}
+	return sql.NullString{String: buf.String(), Valid: true}, nil
+}
+
+// checkEncryptedBody checks that an encrypted note's nonce and ciphertext
+// are both present and base64. The server can't check anything more about
+// them.
+func checkEncryptedBody(v *validate.Validator, nonce, ciphertext string) {
+	v.Required("nonce", nonce)
+	_, err := base64.StdEncoding.DecodeString(nonce)
+	v.Check(err == nil, "nonce", "must be base64")
+	v.Required("ciphertext", ciphertext)
+	_, err = base64.StdEncoding.DecodeString(ciphertext)
+	v.Check(err == nil, "ciphertext", "must be base64")
+}
+
+// findDuplicate returns the user's oldest note with the same content as a
+// prepared one, or nil if there isn't one. Encrypted notes never match.
+func (cfg *apiConfig) findDuplicate(ctx context.Context, note newNote) (*database.Note, error) {
+	if !note.params.ContentHash.Valid {
+		return nil, nil
+	}
+	dup, err := cfg.DB.GetNoteByContentHash(ctx, database.GetNoteByContentHashParams{
+		UserID:      note.params.UserID,
+		ContentHash: note.params.ContentHash,
 	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dup, nil
+}
+
+// insertNote writes a prepared note, its tags and its checklist items. Run
+// it in a transaction.
+func insertNote(ctx context.Context, db database.Querier, note newNote) error {
+	if err := db.CreateNote(ctx, note.params); err != nil {
+		return err
+	}
+	for _, item := range note.items {
+		if err := db.CreateNoteItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return setNoteTags(ctx, db, note.params.UserID, note.params.ID, note.tags)
+}
+
+func (cfg *apiConfig) handlerNotesCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := noteInput{}
+	err := v.Decode(r.Body, &params)
 	if err != nil {
+		cfg.respondToDecodeError(w, err)
+		return
+	}
+
+	prepared, err := cfg.prepareNote(r.Context(), v, user, params, time.Now())
+	var errs validate.Errors
+	if errors.As(err, &errs) {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	if err != nil {
+		code, errCode, msg := cfg.noteInputErrorResponse(err)
+		respondWithErrorCode(w, code, errCode, msg, err)
+		return
+	}
+
+	if params.SkipIfDuplicate {
+		dup, err := cfg.findDuplicate(r.Context(), prepared)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check for duplicates", err)
+			return
+		}
+		if dup != nil {
+			noteResp, err := cfg.noteResponse(r.Context(), *dup)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+				return
+			}
+			w.Header().Set("ETag", noteETag(noteResp.Version))
+			respondWithJSON(w, http.StatusOK, noteResp)
+			return
+		}
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	quota, err := cfg.startQuotaCheck(r.Context(), tx, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return
+	}
+	if err := insertNote(r.Context(), tx, prepared); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create note", err)
+		return
+	}
+	if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create note", err)
 		return
 	}
+	if !quota.allow(r.Context(), w) {
+		return
+	}
 
-	note, err := cfg.DB.GetNote(r.Context(), id)
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), prepared.params.ID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
 		return
 	}
 
-	noteResp, err := databaseNoteToNote(note)
+	noteResp, err := cfg.noteResponse(r.Context(), note)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
 		return
 	}
 
+	cfg.publishNote(eventNoteCreated, noteResp, user.ID)
+	w.Header().Set("ETag", noteETag(noteResp.Version))
 	respondWithJSON(w, http.StatusCreated, noteResp)
 }
+
+// handlerNoteGet serves a single note to its owner, to users it has been
+// shared with, or to anyone when the owner has made it public. A nil user is
+// a guest. Notes the caller can't read are reported as missing so their IDs
+// don't leak. ?links=true adds the note's _links.
+func (cfg *apiConfig) handlerNoteGet(w http.ResponseWriter, r *http.Request, user *database.User) {
+	withLinks, err := linksRequested(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid links flag", err)
+		return
+	}
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	access, err := cfg.noteAccess(r.Context(), note, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check note access", err)
+		return
+	}
+	if access == noteAccessNone {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	}
+
+	etag := noteETag(note.Version)
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	noteResp, err := cfg.noteResponse(r.Context(), note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+	if withLinks {
+		viewerID := ""
+		if user != nil {
+			viewerID = user.ID
+		}
+		notes := []Note{noteResp}
+		addNoteLinks(w, notes, viewerID)
+		noteResp = notes[0]
+	}
+
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+// handlerNoteHTML renders a note's Markdown body as an HTML fragment for
+// clients that can't render Markdown themselves. Access rules are the same
+// as handlerNoteGet.
+func (cfg *apiConfig) handlerNoteHTML(w http.ResponseWriter, r *http.Request, user *database.User) {
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	access, err := cfg.noteAccess(r.Context(), note, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check note access", err)
+		return
+	}
+	if access == noteAccessNone {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	}
+	if note.Encrypted {
+		respondWithError(w, http.StatusUnprocessableEntity, "Encrypted notes can't be rendered", nil)
+		return
+	}
+
+	body, err := markdown.Render(note.Note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't render note", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// The fragment is meant to be embedded; if it's opened directly, don't
+	// let it load or run anything.
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src https: data:; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", noteETag(note.Version))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.WriteString(w, body); err != nil {
+		logf(r.Context(), "Error writing response: %s", err)
+	}
+}
+
+// noteUpdateRequest is the body of handlerNotesUpdate requests.
+type noteUpdateRequest struct {
+	Title      *string         `json:"title"`
+	Metadata   json.RawMessage `json:"metadata"`
+	Note       *string         `json:"note"`
+	Nonce      *string         `json:"nonce"`
+	Ciphertext *string         `json:"ciphertext"`
+	Public     *bool           `json:"public"`
+	Tags       *[]string       `json:"tags"`
+	NotebookID *string         `json:"notebook_id"`
+	RemindAt   *string         `json:"remind_at"`
+}
+
+// handlerNotesUpdate applies a partial update to one of the user's notes:
+// fields left out of the body keep their current values, and tags, when
+// given, replace the note's tags. An empty notebook_id moves the note to the
+// default notebook, an empty title or remind_at clears it, and metadata
+// replaces the note's metadata. Encrypted notes are updated with a new nonce
+// and ciphertext, together. Users the note is shared with for writing can
+// change its title and body only. Notes the caller can't read are reported
+// as missing.
+//
+// Updates must carry an If-Match header with the note's current ETag, so
+// two clients editing the same note can't overwrite each other's changes.
+func (cfg *apiConfig) handlerNotesUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := noteUpdateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		cfg.respondToDecodeError(w, err)
+		return
+	}
+	if params.Note != nil && len(*params.Note) > cfg.NoteMaxBytes || params.Ciphertext != nil && len(*params.Ciphertext) > cfg.NoteMaxBytes {
+		respondWithErrorCode(w, http.StatusRequestEntityTooLarge, errCodeNoteTooLarge, cfg.noteTooLargeMessage(), nil)
+		return
+	}
+
+	current, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+	access, err := cfg.noteAccess(r.Context(), current, &user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check note access", err)
+		return
+	}
+	switch {
+	case access == noteAccessNone:
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	case access == noteAccessRead:
+		respondWithError(w, http.StatusForbidden, "Note is shared read-only", nil)
+		return
+	case access == noteAccessWrite && (params.Public != nil || params.Tags != nil || params.NotebookID != nil || params.RemindAt != nil || params.Metadata != nil):
+		respondWithError(w, http.StatusForbidden, "Only the note's owner can change public, tags, notebook_id, remind_at or metadata", nil)
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondWithError(w, http.StatusPreconditionRequired, "If-Match header is required", nil)
+		return
+	}
+	if !etagMatches(ifMatch, noteETag(current.Version), false) {
+		respondWithError(w, http.StatusPreconditionFailed, "Note has been modified", nil)
+		return
+	}
+
+	update := database.UpdateNoteParams{
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        current.ID,
+		UserID:    current.UserID,
+		Version:   current.Version,
+	}
+	if current.Encrypted {
+		v.Check(params.Note == nil, "note", "must be left out on encrypted notes")
+		if params.Nonce != nil || params.Ciphertext != nil {
+			update.Nonce = sql.NullString{Valid: true}
+			update.Ciphertext = sql.NullString{Valid: true}
+			if params.Nonce != nil {
+				update.Nonce.String = *params.Nonce
+			}
+			if params.Ciphertext != nil {
+				update.Ciphertext.String = *params.Ciphertext
+			}
+			checkEncryptedBody(v, update.Nonce.String, update.Ciphertext.String)
+		}
+	} else {
+		v.Check(params.Nonce == nil, "nonce", "must only be set on encrypted notes")
+		v.Check(params.Ciphertext == nil, "ciphertext", "must only be set on encrypted notes")
+	}
+	if params.Title != nil {
+		update.SetTitle = true
+		update.Title = checkTitle(v, *params.Title)
+	}
+	// Metadata is replaced as a whole; null clears it.
+	if params.Metadata != nil {
+		update.SetMetadata = true
+		update.Metadata = checkMetadata(v, params.Metadata)
+	}
+	if params.Note != nil {
+		update.Note = sql.NullString{String: *params.Note, Valid: true}
+		update.ContentHash = sql.NullString{String: contenthash.Sum(*params.Note), Valid: true}
+		update.WordCount = noteWordCount(*params.Note)
+	}
+	if params.Public != nil {
+		update.Public = sql.NullBool{Bool: *params.Public, Valid: true}
+	}
+	// Setting remind_at, even to the same time, arms the reminder again.
+	if params.RemindAt != nil {
+		update.SetRemindAt = true
+		if *params.RemindAt != "" {
+			remindAt, err := time.Parse(time.RFC3339, *params.RemindAt)
+			v.Check(err == nil, "remind_at", "must be an RFC 3339 time")
+			if err == nil {
+				v.Check(remindAt.After(time.Now()), "remind_at", "must be in the future")
+			}
+			update.RemindAt = nullTime(&remindAt)
+		}
+	}
+	var tags []string
+	if params.Tags != nil {
+		tags = normalizeTags(v, "tags", *params.Tags)
+	}
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	if params.NotebookID != nil {
+		update.SetNotebook = true
+		update.NotebookID, err = notebookParam(r.Context(), cfg.DB, user.ID, *params.NotebookID)
+		if errors.Is(err, errNotebookNotFound) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get notebook", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get notebook", err)
+			return
+		}
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Edits count against the owner's quota, whoever makes them.
+	quota, err := cfg.startQuotaCheck(r.Context(), tx, current.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return
+	}
+	n, err := tx.UpdateNote(r.Context(), update)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
+	if n == 0 {
+		// The version moved on since the If-Match check above.
+		respondWithError(w, http.StatusPreconditionFailed, "Note has been modified", nil)
+		return
+	}
+
+	if params.Tags != nil {
+		if err := setNoteTags(r.Context(), tx, user.ID, update.ID, tags); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't tag note", err)
+			return
+		}
+	}
+	if err := touchNoteList(r.Context(), tx, current.UserID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update note", err)
+		return
+	}
+	if !quota.allow(r.Context(), w) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), update.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	noteResp, err := cfg.noteResponse(r.Context(), note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	cfg.publishNote(eventNoteUpdated, noteResp, user.ID)
+	w.Header().Set("ETag", noteETag(noteResp.Version))
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+func (cfg *apiConfig) handlerNotesArchive(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.setNoteArchived(w, r, user, true)
+}
+
+func (cfg *apiConfig) handlerNotesUnarchive(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.setNoteArchived(w, r, user, false)
+}
+
+// setNoteArchived hides a note from the default listing, or brings it back,
+// without touching its updated_at.
+func (cfg *apiConfig) setNoteArchived(w http.ResponseWriter, r *http.Request, user database.User, archived bool) {
+	id := chi.URLParam(r, "noteID")
+	n, err := cfg.DB.SetNoteArchived(r.Context(), database.SetNoteArchivedParams{
+		Archived: archived,
+		ID:       id,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't archive note", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	}
+	if err := touchNoteList(r.Context(), cfg.DB, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't archive note", err)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	noteResp, err := cfg.noteResponse(r.Context(), note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	cfg.publishNote(eventNoteUpdated, noteResp, user.ID)
+	w.Header().Set("ETag", noteETag(noteResp.Version))
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+func (cfg *apiConfig) handlerNotesPin(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.setNotePinned(w, r, user, true)
+}
+
+func (cfg *apiConfig) handlerNotesUnpin(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.setNotePinned(w, r, user, false)
+}
+
+// setNotePinned pins or unpins a note. Each user can pin at most
+// NotesMaxPinned notes; pinning an already pinned note is a no-op.
+func (cfg *apiConfig) setNotePinned(w http.ResponseWriter, r *http.Request, user database.User, pinned bool) {
+	id := chi.URLParam(r, "noteID")
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	note, err := tx.GetNote(r.Context(), id)
+	if err != nil || note.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	if pinned && !note.Pinned {
+		count, err := tx.CountPinnedNotesForUser(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't count pinned notes", err)
+			return
+		}
+		if count >= int64(cfg.NotesMaxPinned) {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Can't pin more than %d notes", cfg.NotesMaxPinned), nil)
+			return
+		}
+	}
+
+	_, err = tx.SetNotePinned(r.Context(), database.SetNotePinnedParams{
+		Pinned: pinned,
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin note", err)
+		return
+	}
+	if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin note", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	note.Pinned = pinned
+	note.Version++
+	noteResp, err := cfg.noteResponse(r.Context(), note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+
+	cfg.publishNote(eventNoteUpdated, noteResp, user.ID)
+	w.Header().Set("ETag", noteETag(noteResp.Version))
+	respondWithJSON(w, http.StatusOK, noteResp)
+}
+
+// handlerNotesDelete removes one of the user's notes. Notes owned by someone
+// else get the same 404 as missing ones so their existence doesn't leak.
+func (cfg *apiConfig) handlerNotesDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	noteID := chi.URLParam(r, "noteID")
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := tx.DeleteNote(r.Context(), database.DeleteNoteParams{
+		ID:     noteID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", nil)
+		return
+	}
+
+	// Foreign keys aren't enforced on every connection, so don't rely on the
+	// cascade to clear the note's tags, checklist items, shares and share
+	// links.
+	if err := tx.DeleteNoteTags(r.Context(), noteID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note tags", err)
+		return
+	}
+	if err := tx.DeleteNoteItems(r.Context(), noteID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note items", err)
+		return
+	}
+	if err := tx.DeleteNoteShares(r.Context(), noteID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note shares", err)
+		return
+	}
+	if err := tx.DeleteNoteShareLinks(r.Context(), noteID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note share links", err)
+		return
+	}
+	if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete note", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	cfg.publishNoteDeleted(noteID, user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notesResponse converts notes for a response, tags and checklist items
+// included.
+func (cfg *apiConfig) notesResponse(ctx context.Context, notes []database.Note) ([]Note, error) {
+	resp, err := databasePostsToPosts(notes)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.attachTags(ctx, resp); err != nil {
+		return nil, err
+	}
+	if err := cfg.attachItems(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (cfg *apiConfig) noteResponse(ctx context.Context, note database.Note) (Note, error) {
+	resp, err := cfg.notesResponse(ctx, []database.Note{note})
+	if err != nil {
+		return Note{}, err
+	}
+	return resp[0], nil
+}
+
+// touchNoteList records a change to the user's note listing, moving on
+// the version its ETag is built from and its Last-Modified, which notes'
+// own updated_at can't do for deletions, archiving or pinning. Every write
+// that changes what the listing shows must call it, in the same
+// transaction if there is one.
+func touchNoteList(ctx context.Context, db database.Querier, userID string) error {
+	return db.TouchNoteList(ctx, database.TouchNoteListParams{
+		UserID:    userID,
+		ChangedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// noteBatchResult is the outcome of one item of a batch. Items with invalid
+// fields have a 422 status and list them in Errors.
+type noteBatchResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	Note   *Note           `json:"note,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Code   string          `json:"code,omitempty"`
+	Errors validate.Errors `json:"errors,omitempty"`
+}
+
+// notesBatchRequest is the body of handlerNotesBatchCreate requests.
+type notesBatchRequest struct {
+	Notes []noteInput `json:"notes"`
+}
+
+// handlerNotesBatchCreate creates up to NotesMaxBatch notes in one
+// transaction. Items that fail validation are reported in their result and
+// skipped; the rest are written together or not at all. Items skipped as
+// duplicates get a 200 with the note they duplicate, which may be an
+// earlier item in the same batch.
+func (cfg *apiConfig) handlerNotesBatchCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := notesBatchRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		cfg.respondToDecodeError(w, err)
+		return
+	}
+	v.Check(len(params.Notes) > 0 && len(params.Notes) <= cfg.NotesMaxBatch, "notes", fmt.Sprintf("must have between 1 and %d notes", cfg.NotesMaxBatch))
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	now := time.Now()
+	results := make([]noteBatchResult, len(params.Notes))
+	prepared := make([]newNote, 0, len(params.Notes))
+	created := make([]int, 0, len(params.Notes))
+	// duplicateOf maps items skipped as duplicates of an earlier item to
+	// that item.
+	duplicateOf := map[int]int{}
+	batchHashes := map[string]int{}
+	for i, in := range params.Notes {
+		results[i].Index = i
+		note, err := cfg.prepareNote(r.Context(), &validate.Validator{}, user, in, now)
+		var errs validate.Errors
+		if errors.As(err, &errs) {
+			results[i].Status = http.StatusUnprocessableEntity
+			results[i].Code = errCodeValidationFailed
+			results[i].Error = invalidFieldsMessage("Note", errs)
+			results[i].Errors = errs
+			continue
+		}
+		if err != nil {
+			code, errCode, msg := cfg.noteInputErrorResponse(err)
+			if code == http.StatusInternalServerError {
+				respondWithError(w, code, msg, err)
+				return
+			}
+			results[i].Status = code
+			results[i].Code = errCode
+			results[i].Error = msg
+			continue
+		}
+		if in.SkipIfDuplicate && note.params.ContentHash.Valid {
+			if j, ok := batchHashes[note.params.ContentHash.String]; ok {
+				duplicateOf[i] = j
+				continue
+			}
+			dup, err := cfg.findDuplicate(r.Context(), note)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't check for duplicates", err)
+				return
+			}
+			if dup != nil {
+				dupResp, err := cfg.noteResponse(r.Context(), *dup)
+				if err != nil {
+					respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+					return
+				}
+				results[i].Status = http.StatusOK
+				results[i].Note = &dupResp
+				continue
+			}
+		}
+		if note.params.ContentHash.Valid {
+			if _, ok := batchHashes[note.params.ContentHash.String]; !ok {
+				batchHashes[note.params.ContentHash.String] = i
+			}
+		}
+		prepared = append(prepared, note)
+		created = append(created, i)
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	quota, err := cfg.startQuotaCheck(r.Context(), tx, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return
+	}
+	for _, note := range prepared {
+		if err := insertNote(r.Context(), tx, note); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create notes", err)
+			return
+		}
+	}
+	if len(prepared) > 0 {
+		if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't create notes", err)
+			return
+		}
+	}
+	if !quota.allow(r.Context(), w) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	notes := make([]database.Note, len(prepared))
+	for i, note := range prepared {
+		notes[i] = database.Note{
+			ID:          note.params.ID,
+			CreatedAt:   note.params.CreatedAt,
+			UpdatedAt:   note.params.UpdatedAt,
+			Note:        note.params.Note,
+			UserID:      note.params.UserID,
+			Public:      note.params.Public,
+			NotebookID:  note.params.NotebookID,
+			Version:     1,
+			RemindAt:    note.params.RemindAt,
+			Encrypted:   note.params.Encrypted,
+			Nonce:       note.params.Nonce,
+			Ciphertext:  note.params.Ciphertext,
+			ContentHash: note.params.ContentHash,
+			Title:       note.params.Title,
+			Metadata:    note.params.Metadata,
+		}
+	}
+	notesResp, err := cfg.notesResponse(r.Context(), notes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert notes", err)
+		return
+	}
+	for i, index := range created {
+		results[index].Status = http.StatusCreated
+		results[index].Note = &notesResp[i]
+		cfg.publishNote(eventNoteCreated, notesResp[i], user.ID)
+	}
+	for i, j := range duplicateOf {
+		results[i].Status = http.StatusOK
+		results[i].Note = results[j].Note
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Results []noteBatchResult `json:"results"`
+	}{results})
+}
+
+// notesBulkDeleteRequest is the body of handlerNotesBulkDelete requests.
+type notesBulkDeleteRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// handlerNotesBulkDelete deletes up to NotesMaxBatch of the user's notes in
+// one transaction. IDs that don't name one of the user's notes are skipped
+// rather than failing the request.
+func (cfg *apiConfig) handlerNotesBulkDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := notesBulkDeleteRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	v.Check(len(params.IDs) > 0 && len(params.IDs) <= cfg.NotesMaxBatch, "ids", fmt.Sprintf("must have between 1 and %d ids", cfg.NotesMaxBatch))
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	resp := struct {
+		Deleted []string `json:"deleted"`
+		Skipped []string `json:"skipped"`
+	}{Deleted: []string{}, Skipped: []string{}}
+	seen := map[string]bool{}
+	for _, id := range params.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		n, err := tx.DeleteNote(r.Context(), database.DeleteNoteParams{
+			ID:     id,
+			UserID: user.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete notes", err)
+			return
+		}
+		if n == 0 {
+			resp.Skipped = append(resp.Skipped, id)
+			continue
+		}
+		if err := tx.DeleteNoteTags(r.Context(), id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete note tags", err)
+			return
+		}
+		if err := tx.DeleteNoteItems(r.Context(), id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete note items", err)
+			return
+		}
+		if err := tx.DeleteNoteShares(r.Context(), id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete note shares", err)
+			return
+		}
+		if err := tx.DeleteNoteShareLinks(r.Context(), id); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete note share links", err)
+			return
+		}
+		resp.Deleted = append(resp.Deleted, id)
+	}
+	if len(resp.Deleted) > 0 {
+		if err := touchNoteList(r.Context(), tx, user.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete notes", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	for _, id := range resp.Deleted {
+		cfg.publishNoteDeleted(id, user.ID)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/memstore"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHandlerNotesUpdateRemindAt(t *testing.T) {
+	tests := map[string]struct {
+		description    string
+		remindAt       string
+		expectedStatus int
+		expectedErrors validate.Errors
+	}{
+		"future": {
+			description:    "A time in the future sets the reminder",
+			remindAt:       time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			expectedStatus: http.StatusOK,
+		},
+		"empty": {
+			description:    "An empty remind_at clears the reminder",
+			remindAt:       "",
+			expectedStatus: http.StatusOK,
+		},
+		"past": {
+			description:    "A time in the past is refused",
+			remindAt:       "2020-01-01T00:00:00Z",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedErrors: validate.Errors{{Field: "remind_at", Message: "must be in the future"}},
+		},
+		"malformed": {
+			description:    "A time that doesn't parse is refused as such, not as being in the past",
+			remindAt:       "tomorrow",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedErrors: validate.Errors{{Field: "remind_at", Message: "must be an RFC 3339 time"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			ctx := context.Background()
+			store := memstore.New()
+			now := time.Now().UTC().Format(time.RFC3339)
+			if err := store.CreateUser(ctx, database.CreateUserParams{ID: "u1", ApiKey: "k1"}); err != nil {
+				t.Fatal(err)
+			}
+			err := store.CreateNote(ctx, database.CreateNoteParams{ID: "n1", CreatedAt: now, UpdatedAt: now, Note: "note", UserID: "u1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			note, err := store.GetNote(ctx, "n1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &apiConfig{DB: store, NoteMaxBytes: 1 << 20}
+
+			body, _ := json.Marshal(map[string]string{"remind_at": tc.remindAt})
+			r := httptest.NewRequest(http.MethodPatch, "/v1/notes/n1", bytes.NewReader(body))
+			r.Header.Set("If-Match", noteETag(note.Version))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("noteID", "n1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+			cfg.handlerNotesUpdate(w, r, database.User{ID: "u1"})
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, w.Code, w.Body)
+			}
+			if tc.expectedErrors == nil {
+				return
+			}
+			var resp struct {
+				Errors validate.Errors `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectedErrors, resp.Errors); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/oauth"
+	"github.com/go-chi/chi"
+)
+
+const (
+	oauthStateCookieName = "notely_oauth_state"
+	oauthStateTTL        = 10 * time.Minute
+)
+
+func (cfg *apiConfig) handlerOAuthProviders(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range cfg.OAuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	respondWithJSON(w, http.StatusOK, names)
+}
+
+func (cfg *apiConfig) handlerOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.OAuthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown OAuth provider", nil)
+		return
+	}
+
+	state, err := oauth.NewState()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create OAuth state", err)
+		return
+	}
+	verifier := oauth.NewVerifier()
+
+	// The state and PKCE verifier ride along in a short-lived cookie scoped
+	// to this provider's callback.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    state + "." + verifier,
+		Path:     "/v1/oauth/" + provider.Name,
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, provider.AuthCodeURL(state, verifier), http.StatusFound)
+}
+
+func (cfg *apiConfig) handlerOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.OAuthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown OAuth provider", nil)
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Missing OAuth state", err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    "",
+		Path:     "/v1/oauth/" + provider.Name,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if errMsg := query.Get("error"); errMsg != "" {
+		respondWithError(w, http.StatusBadRequest, "OAuth sign-in was not completed", errors.New(errMsg))
+		return
+	}
+	state, verifier, _ := strings.Cut(cookie.Value, ".")
+	if !auth.VerifyKey(query.Get("state"), state) {
+		respondWithError(w, http.StatusBadRequest, "Invalid OAuth state", nil)
+		return
+	}
+
+	identity, err := provider.Identity(r.Context(), query.Get("code"), verifier)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Couldn't get identity from provider", err)
+		return
+	}
+
+	userID, err := cfg.oauthUser(r, provider.Name, identity)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't sign in", err)
+		return
+	}
+
+	err = cfg.startSession(r.Context(), w, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create session", err)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// oauthUser resolves the Notely user for a provider identity. A known
+// identity signs in its user; otherwise it is linked to the user of the
+// current session, or to a newly created user when there is none.
+func (cfg *apiConfig) oauthUser(r *http.Request, provider string, identity oauth.Identity) (string, error) {
+	linked, err := cfg.DB.GetOAuthIdentity(r.Context(), database.GetOAuthIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		return linked.UserID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	if user, err := (auth.SessionAuthenticator{Store: cfg.DB}).Authenticate(r); err == nil {
+		return user.ID, linkOAuthIdentity(r.Context(), cfg.DB, provider, identity.Subject, user.ID)
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	user, _, err := createUser(r.Context(), tx, identity.Name, sql.NullString{})
+	if err != nil {
+		return "", err
+	}
+	if err := linkOAuthIdentity(r.Context(), tx, provider, identity.Subject, user.ID); err != nil {
+		return "", err
+	}
+	return user.ID, tx.Commit()
+}
+
+func linkOAuthIdentity(ctx context.Context, db database.Querier, provider, subject, userID string) error {
+	return db.CreateOAuthIdentity(ctx, database.CreateOAuthIdentityParams{
+		Provider:  provider,
+		Subject:   subject,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		UserID:    userID,
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mailer"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// passwordResetTokenTTL is how long a password reset token works.
+const passwordResetTokenTTL = time.Hour
+
+// passwordForgotRequest is the body of handlerPasswordForgot requests.
+type passwordForgotRequest struct {
+	Email string `json:"email"`
+}
+
+// handlerPasswordForgot mails a reset token to the account with the given
+// email. It answers the same way, and as quickly, whether or not there is
+// such an account, so it can't be used to find out who has one. Requests
+// are limited per client IP and per email.
+func (cfg *apiConfig) handlerPasswordForgot(w http.ResponseWriter, r *http.Request) {
+	v := &validate.Validator{}
+	params := passwordForgotRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	email, err := normalizeEmail(params.Email)
+	v.Required("email", params.Email)
+	v.Check(err == nil, "email", "must be a valid email address")
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+	if _, ok := cfg.allowPasswordReset(w, r, true, "email:"+email); !ok {
+		return
+	}
+
+	// The lookup and mail happen after responding so slow mail servers
+	// don't give away which emails belong to accounts.
+	go func() {
+		ctx := context.WithoutCancel(r.Context())
+		user, err := cfg.DB.GetUserByEmail(ctx, sql.NullString{String: email, Valid: true})
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err == nil {
+			err = cfg.sendPasswordResetEmail(ctx, user)
+		}
+		if err != nil {
+			logf(ctx, "Couldn't send password reset email: %v", err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// passwordResetRequest is the body of handlerPasswordReset requests.
+type passwordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// handlerPasswordReset sets a new password using a token from
+// handlerPasswordForgot. The token can only be used once, and every session
+// and refresh token of the account is revoked so a stolen one stops working.
+// Invalid tokens count as failed authentication attempts for the client IP.
+func (cfg *apiConfig) handlerPasswordReset(w http.ResponseWriter, r *http.Request) {
+	v := &validate.Validator{}
+	params := passwordResetRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	clientIP, ok := cfg.allowPasswordReset(w, r, false)
+	if !ok {
+		return
+	}
+	v.Required("token", params.Token)
+	v.Check(validPassword(params.Password), "password", invalidPasswordMessage())
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	stored, err := tx.GetPasswordResetToken(r.Context(), auth.HashAPIKey(params.Token))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get password reset token", err)
+		return
+	}
+	expiresAt, parseErr := time.Parse(time.RFC3339, stored.ExpiresAt)
+	if err != nil || parseErr != nil || !now.Before(expiresAt) {
+		if cfg.AuthFailures != nil {
+			cfg.AuthFailures.RecordFailure(clientIP)
+		}
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired password reset token", err)
+		return
+	}
+
+	err = tx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+		PasswordHash: sql.NullString{String: passwordHash, Valid: true},
+		UpdatedAt:    now.Format(time.RFC3339),
+		ID:           stored.UserID,
+	})
+	if err == nil {
+		err = tx.DeletePasswordResetTokensForUser(r.Context(), stored.UserID)
+	}
+	if err == nil {
+		err = tx.DeleteSessionsForUser(r.Context(), stored.UserID)
+	}
+	if err == nil {
+		err = tx.DeleteRefreshTokensForUser(r.Context(), stored.UserID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), stored.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowPasswordReset checks the brute-force block for the client IP and,
+// when limit is set, the password reset limit for the client IP and the
+// given keys. It returns the client IP, or writes a 429 response and returns
+// false when any of them is exceeded.
+func (cfg *apiConfig) allowPasswordReset(w http.ResponseWriter, r *http.Request, limit bool, keys ...string) (string, bool) {
+	ac, _ := auth.NewAuthContext(r, cfg.APIKeyAuth.Sources, cfg.TrustProxy)
+
+	if cfg.AuthFailures != nil {
+		if blocked, wait := cfg.AuthFailures.Blocked(ac.ClientIP); blocked {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondWithError(w, http.StatusTooManyRequests, "Too many failed authentication attempts", nil)
+			return "", false
+		}
+	}
+	if !limit || cfg.PasswordResetLimiter == nil {
+		return ac.ClientIP, true
+	}
+	for _, k := range append([]string{"ip:" + ac.ClientIP}, keys...) {
+		if ok, wait := cfg.PasswordResetLimiter.Allow(k); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondWithError(w, http.StatusTooManyRequests, "Too many password reset requests", nil)
+			return "", false
+		}
+	}
+	return ac.ClientIP, true
+}
+
+// sendPasswordResetEmail mails the user a new reset token, replacing any
+// sent before.
+func (cfg *apiConfig) sendPasswordResetEmail(ctx context.Context, user database.User) error {
+	token, err := auth.MakePasswordResetToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := cfg.DB.DeletePasswordResetTokensForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	err = cfg.DB.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashAPIKey(token),
+		CreatedAt: now.Format(time.RFC3339),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(passwordResetTokenTTL).Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email.String,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token within %d minutes to choose a new password:\n\n%s\n\nSend it with your new password to %s/v1/password/reset. If you didn't ask for this, you can ignore this message.\n",
+			user.Name, int(passwordResetTokenTTL.Minutes()), token, cfg.PublicBaseURL),
+	})
+}
@@ -1,7 +1,57 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
-func handlerReadiness(w http.ResponseWriter, r *http.Request) {
+// readinessTimeout bounds the database check, so a probe gets an answer
+// well inside its own timeout.
+const readinessTimeout = 2 * time.Second
+
+// handlerLiveness reports that the process is up and serving. It doesn't
+// touch the database, so a database outage doesn't get the server
+// restarted.
+func handlerLiveness(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// handlerReadiness reports whether the server can handle requests. It
+// answers 503 with status "degraded" when the database can't be reached,
+// so the server is taken out of rotation until it recovers.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
+	type check struct {
+		Status    string `json:"status"`
+		LatencyMs int64  `json:"latency_ms"`
+		Error     string `json:"error,omitempty"`
+	}
+	type response struct {
+		Status string           `json:"status"`
+		Checks map[string]check `json:"checks"`
+	}
+
+	resp := response{Status: "ok", Checks: map[string]check{}}
+	if cfg.DB == nil {
+		resp.Checks["database"] = check{Status: "disabled"}
+		respondWithJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	start := time.Now()
+	err := cfg.DB.Ping(ctx)
+	db := check{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		db.Status, db.Error = "error", err.Error()
+		resp.Status = "degraded"
+	}
+	resp.Checks["database"] = db
+
+	if resp.Status != "ok" {
+		respondWithJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+const sessionTTL = 30 * 24 * time.Hour
+
+func (cfg *apiConfig) handlerSessionsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := cfg.startSession(r.Context(), w, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create session", err)
+		return
+	}
+
+	userResp, err := databaseUserToUser(user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, userResp)
+}
+
+// startSession stores a new session for userID and sets its cookie on w.
+func (cfg *apiConfig) startSession(ctx context.Context, w http.ResponseWriter, userID string) error {
+	token, err := auth.MakeSessionToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(sessionTTL)
+	err = cfg.DB.CreateSession(ctx, database.CreateSessionParams{
+		TokenHash: auth.HashAPIKey(token),
+		CreatedAt: now.Format(time.RFC3339),
+		UserID:    userID,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (cfg *apiConfig) handlerSessionsDelete(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		err = cfg.DB.DeleteSession(r.Context(), auth.HashAPIKey(cookie.Value))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete session", err)
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/settings"
+)
+
+// settingsMaxBytes caps the size of a settings document.
+const settingsMaxBytes = 16 << 10
+
+// userSettings loads a user's settings, or the defaults if they haven't
+// saved any.
+func (cfg *apiConfig) userSettings(ctx context.Context, userID string) (settings.Settings, error) {
+	row, err := cfg.DB.GetUserSettings(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings.Default(), nil
+	}
+	if err != nil {
+		return settings.Settings{}, err
+	}
+	// Stored documents were valid when saved, but the page size limit can
+	// have shrunk since, so they're read without checking it.
+	s := settings.Default()
+	if err := json.Unmarshal([]byte(row.Settings), &s); err != nil {
+		return settings.Settings{}, err
+	}
+	return s, nil
+}
+
+// handlerSettingsGet returns the user's settings, with defaults filled in.
+func (cfg *apiConfig) handlerSettingsGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	s, err := cfg.userSettings(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get settings", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, s)
+}
+
+// handlerSettingsUpdate replaces the user's settings. Fields left out are
+// reset to their defaults; unknown fields and invalid values are rejected.
+func (cfg *apiConfig) handlerSettingsUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, settingsMaxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Settings are too large", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read settings", err)
+		return
+	}
+	s, err := settings.Parse(data, cfg.NotesMaxPageSize)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	stored, err := json.Marshal(s)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't encode settings", err)
+		return
+	}
+	err = cfg.DB.UpsertUserSettings(r.Context(), database.UpsertUserSettingsParams{
+		UserID:    user.ID,
+		Settings:  string(stored),
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save settings", err)
+		return
+	}
+	// The settings decide the listing's default order and page size.
+	if err := touchNoteList(r.Context(), cfg.DB, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save settings", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
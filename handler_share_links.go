@@ -0,0 +1,191 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// sharedNote is what a share link exposes: the note itself without the
+// owner's IDs, tags or notebook.
+type sharedNote struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Title      string    `json:"title,omitempty"`
+	Note       string    `json:"note"`
+	Encrypted  bool      `json:"encrypted,omitempty"`
+	Nonce      string    `json:"nonce,omitempty"`
+	Ciphertext string    `json:"ciphertext,omitempty"`
+}
+
+var sharedNoteTemplate = template.Must(template.New("shared-note").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{with .Title}}{{.}}{{else}}Shared note{{end}}</title>
+</head>
+<body>
+{{with .Title}}<h1>{{.}}</h1>
+{{end}}<pre>{{.Note}}</pre>
+<p><small>Last updated {{.UpdatedAt.Format "2006-01-02 15:04 MST"}}</small></p>
+</body>
+</html>
+`))
+
+// shareLinkRequest is the body of handlerShareLinkCreate requests.
+type shareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// handlerShareLinkCreate mints a public link to one of the user's notes,
+// replacing any link the note already has.
+func (cfg *apiConfig) handlerShareLinkCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := shareLinkRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	v.Check(params.ExpiresAt == nil || params.ExpiresAt.After(now), "expires_at", "must be in the future")
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil || note.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	token, err := auth.MakeShareToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create share token", err)
+		return
+	}
+
+	link := database.CreateNoteShareLinkParams{
+		ID:        uuid.New().String(),
+		CreatedAt: now.Format(time.RFC3339),
+		NoteID:    note.ID,
+		TokenHash: auth.HashAPIKey(token),
+		ExpiresAt: nullTime(params.ExpiresAt),
+	}
+	err = database.WithTx(r.Context(), cfg.DB, func(tx database.Querier) error {
+		_, err := tx.RevokeNoteShareLinks(r.Context(), database.RevokeNoteShareLinksParams{
+			RevokedAt: nullTime(&now),
+			NoteID:    note.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.CreateNoteShareLink(r.Context(), link)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create share link", err)
+		return
+	}
+
+	resp := ShareLink{
+		ID:        link.ID,
+		CreatedAt: now.Truncate(time.Second),
+		Token:     token,
+		URL:       "/share/" + token,
+	}
+	if params.ExpiresAt != nil {
+		expiresAt := params.ExpiresAt.UTC().Truncate(time.Second)
+		resp.ExpiresAt = &expiresAt
+	}
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerShareLinkDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	note, err := cfg.DB.GetNote(r.Context(), chi.URLParam(r, "noteID"))
+	if err != nil || note.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "Couldn't get note", err)
+		return
+	}
+
+	now := time.Now()
+	n, err := cfg.DB.RevokeNoteShareLinks(r.Context(), database.RevokeNoteShareLinksParams{
+		RevokedAt: nullTime(&now),
+		NoteID:    note.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke share link", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get share link", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerShareLinkGet serves the note behind a share link to anyone holding
+// the token, as HTML for browsers and JSON otherwise. Unknown, revoked and
+// expired links all get the same 404.
+func (cfg *apiConfig) handlerShareLinkGet(w http.ResponseWriter, r *http.Request, _ *database.User) {
+	link, err := cfg.DB.GetNoteShareLinkByTokenHash(r.Context(), auth.HashAPIKey(chi.URLParam(r, "token")))
+	if err != nil || link.RevokedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get shared note", err)
+		return
+	}
+	if link.ExpiresAt.Valid {
+		expiresAt, err := time.Parse(time.RFC3339, link.ExpiresAt.String)
+		if err != nil || !time.Now().Before(expiresAt) {
+			respondWithError(w, http.StatusNotFound, "Couldn't get shared note", err)
+			return
+		}
+	}
+
+	note, err := cfg.DB.GetNote(r.Context(), link.NoteID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get shared note", err)
+		return
+	}
+	converted, err := databaseNoteToNote(note)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert note", err)
+		return
+	}
+	resp := sharedNote{
+		ID:         converted.ID,
+		CreatedAt:  converted.CreatedAt,
+		UpdatedAt:  converted.UpdatedAt,
+		Note:       converted.Note,
+		Encrypted:  converted.Encrypted,
+		Nonce:      converted.Nonce,
+		Ciphertext: converted.Ciphertext,
+	}
+	if converted.Title != nil {
+		resp.Title = *converted.Title
+	}
+
+	// Revocation has to take effect immediately.
+	w.Header().Set("Cache-Control", "no-store")
+	// Encrypted notes can only be read by a client holding the key.
+	if resp.Encrypted || !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		respondWithJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := sharedNoteTemplate.Execute(w, resp); err != nil {
+		logf(r.Context(), "Couldn't render shared note: %v", err)
+	}
+}
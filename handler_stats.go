@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+const (
+	statsDays    = 30
+	statsTopTags = 10
+
+	wordCountBackfillBatchSize = 500
+)
+
+type dayCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type tagCount struct {
+	Name      string `json:"name"`
+	NoteCount int64  `json:"note_count"`
+}
+
+type noteStats struct {
+	NoteCount   int64      `json:"note_count"`
+	WordCount   int64      `json:"word_count"`
+	NotesPerDay []dayCount `json:"notes_per_day"`
+	TopTags     []tagCount `json:"top_tags"`
+}
+
+// handlerStats summarizes the user's notes: how many there are, how many
+// words they hold, how many were created on each of the last 30 days (UTC,
+// oldest first, including empty days) and the most used tags. Encrypted
+// notes count as notes but not towards the word count.
+func (cfg *apiConfig) handlerStats(w http.ResponseWriter, r *http.Request, user database.User) {
+	totals, err := cfg.DB.GetNoteTotalsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get note totals", err)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	first := today.AddDate(0, 0, -(statsDays - 1))
+	perDay, err := cfg.DB.CountNotesPerDayForUser(r.Context(), database.CountNotesPerDayForUserParams{
+		UserID:    user.ID,
+		CreatedAt: first.Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notes per day", err)
+		return
+	}
+	counts := make(map[string]int64, len(perDay))
+	for _, row := range perDay {
+		counts[row.Day] = row.NoteCount
+	}
+
+	tags, err := cfg.DB.ListTopTagsForUser(r.Context(), database.ListTopTagsForUserParams{
+		UserID: user.ID,
+		Limit:  statsTopTags,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get top tags", err)
+		return
+	}
+
+	resp := noteStats{
+		NoteCount:   totals.NoteCount,
+		WordCount:   totals.WordCount,
+		NotesPerDay: make([]dayCount, statsDays),
+		TopTags:     make([]tagCount, len(tags)),
+	}
+	for i := range resp.NotesPerDay {
+		date := first.AddDate(0, 0, i).Format(time.DateOnly)
+		resp.NotesPerDay[i] = dayCount{Date: date, Count: counts[date]}
+	}
+	for i, tag := range tags {
+		resp.TopTags[i] = tagCount{Name: tag.Name, NoteCount: tag.NoteCount}
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// noteWordCount is the stored word count for a note body, split on
+// whitespace.
+func noteWordCount(note string) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(len(strings.Fields(note))), Valid: true}
+}
+
+// backfillWordCounts counts the words in every unencrypted note written
+// before word counts were stored and returns how many it updated.
+func backfillWordCounts(ctx context.Context, db database.Querier) (int, error) {
+	updated := 0
+	for {
+		notes, err := db.ListNotesWithoutWordCount(ctx, wordCountBackfillBatchSize)
+		if err != nil {
+			return updated, err
+		}
+		for _, note := range notes {
+			err := db.SetNoteWordCount(ctx, database.SetNoteWordCountParams{
+				WordCount: noteWordCount(note.Note),
+				ID:        note.ID,
+			})
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(notes) < wordCountBackfillBatchSize {
+			return updated, nil
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const maxTagLength = 64
+
+var errInvalidTag = errors.New("tags must be non-empty and at most 64 characters")
+
+func (cfg *apiConfig) handlerTagsGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	tags, err := cfg.DB.ListTagsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tags", err)
+		return
+	}
+
+	resp, err := databaseTagsToTags(tags)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert tags", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// tagRenameRequest is the body of handlerTagsRename requests.
+type tagRenameRequest struct {
+	Name string `json:"name"`
+}
+
+// handlerTagsRename renames one of the user's tags everywhere it is used.
+// Renaming onto another existing tag is refused rather than merging them.
+func (cfg *apiConfig) handlerTagsRename(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := tagRenameRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	name, err := normalizeTag(params.Name)
+	v.Check(err == nil, "name", invalidTagMessage())
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	tagID := chi.URLParam(r, "tagID")
+	existing, err := cfg.DB.GetTagByName(r.Context(), database.GetTagByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err == nil && existing.ID != tagID {
+		respondWithError(w, http.StatusConflict, "Tag name is already in use", nil)
+		return
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tag", err)
+		return
+	}
+
+	n, err := cfg.DB.RenameTag(r.Context(), database.RenameTagParams{
+		Name:      name,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        tagID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename tag", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get tag", nil)
+		return
+	}
+	if err := touchNoteList(r.Context(), cfg.DB, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename tag", err)
+		return
+	}
+
+	tags, err := cfg.DB.ListTagsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get tags", err)
+		return
+	}
+	resp, err := databaseTagsToTags(tags)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert tags", err)
+		return
+	}
+	for _, tag := range resp {
+		if tag.ID == tagID {
+			respondWithJSON(w, http.StatusOK, tag)
+			return
+		}
+	}
+	respondWithError(w, http.StatusNotFound, "Couldn't get tag", nil)
+}
+
+func normalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || len(tag) > maxTagLength {
+		return "", errInvalidTag
+	}
+	return tag, nil
+}
+
+func invalidTagMessage() string {
+	return fmt.Sprintf("must be 1 to %d bytes long", maxTagLength)
+}
+
+// normalizeTags trims and de-duplicates tags from a request body, keeping
+// their order. Invalid tags are recorded on v as items of field.
+func normalizeTags(v *validate.Validator, field string, tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for i, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			v.Check(false, fmt.Sprintf("%s.%d", field, i), invalidTagMessage())
+			continue
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// setNoteTags replaces a note's tags, creating any of the user's tags that
+// don't exist yet. Run it in the same transaction as the note write.
+func setNoteTags(ctx context.Context, db database.Querier, userID, noteID string, tags []string) error {
+	if err := db.DeleteNoteTags(ctx, noteID); err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, name := range tags {
+		err := db.CreateTag(ctx, database.CreateTagParams{
+			ID:        uuid.New().String(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      name,
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		tag, err := db.GetTagByName(ctx, database.GetTagByNameParams{
+			UserID: userID,
+			Name:   name,
+		})
+		if err != nil {
+			return err
+		}
+		err = db.AddNoteTag(ctx, database.AddNoteTagParams{
+			NoteID: noteID,
+			TagID:  tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTags fills in the tags of already converted notes with one query.
+func (cfg *apiConfig) attachTags(ctx context.Context, notes []Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	ids := make([]string, len(notes))
+	byID := make(map[string]*Note, len(notes))
+	for i := range notes {
+		ids[i] = notes[i].ID
+		byID[notes[i].ID] = &notes[i]
+	}
+
+	rows, err := cfg.DB.ListTagsForNotes(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if note, ok := byID[row.NoteID]; ok {
+			note.Tags = append(note.Tags, row.Name)
+		}
+	}
+	return nil
+}
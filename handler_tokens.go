@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+const (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 60 * 24 * time.Hour
+)
+
+func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Token        string    `json:"token"`
+		ExpiresAt    time.Time `json:"expires_at"`
+		RefreshToken string    `json:"refresh_token"`
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	accessToken, err := auth.MakeJWT(user.ID, cfg.JWTSecret, accessTokenTTL)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create access token", err)
+		return
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
+		return
+	}
+
+	err = cfg.DB.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash: auth.HashAPIKey(refreshToken),
+		CreatedAt: now.Format(time.RFC3339),
+		UpdatedAt: now.Format(time.RFC3339),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(refreshTokenTTL).Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save refresh token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Token:        accessToken,
+		ExpiresAt:    now.Add(accessTokenTTL),
+		RefreshToken: refreshToken,
+	})
+}
+
+func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	refreshToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't find refresh token", err)
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	stored, err := cfg.DB.GetRefreshToken(r.Context(), auth.HashAPIKey(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get refresh token", err)
+		return
+	}
+	expiresAt, err := time.Parse(time.RFC3339, stored.ExpiresAt)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't parse refresh token expiry", err)
+		return
+	}
+	if stored.RevokedAt.Valid || !now.Before(expiresAt) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid refresh token", nil)
+		return
+	}
+
+	accessToken, err := auth.MakeJWT(stored.UserID, cfg.JWTSecret, accessTokenTTL)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create access token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Token:     accessToken,
+		ExpiresAt: now.Add(accessTokenTTL),
+	})
+}
+
+func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't find refresh token", err)
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = cfg.DB.RevokeRefreshToken(r.Context(), database.RevokeRefreshTokenParams{
+		RevokedAt: sql.NullString{String: now, Valid: true},
+		UpdatedAt: now,
+		TokenHash: auth.HashAPIKey(refreshToken),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke refresh token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/totp"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+const totpIssuer = "Notely"
+
+func (cfg *apiConfig) handlerTOTPEnroll(w http.ResponseWriter, r *http.Request, user database.User) {
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "TOTP is already enabled", nil)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate TOTP secret", err)
+		return
+	}
+
+	err = cfg.DB.SetUserTOTPSecret(r.Context(), database.SetUserTOTPSecretParams{
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+		ID:         user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save TOTP secret", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+
+	type response struct {
+		Secret     string `json:"secret"`
+		OTPAuthURL string `json:"otpauth_url"`
+	}
+	respondWithJSON(w, http.StatusCreated, response{
+		Secret:     secret,
+		OTPAuthURL: totp.URL(totpIssuer, user.Name, secret),
+	})
+}
+
+// totpVerifyRequest is the body of handlerTOTPVerify requests.
+type totpVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+func (cfg *apiConfig) handlerTOTPVerify(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := totpVerifyRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("code", params.Code)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	// The cached user passed in has no TOTP secret.
+	user, err = cfg.DB.GetUserByID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "TOTP is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "TOTP enrollment not started", nil)
+		return
+	}
+
+	step, ok := totp.Validate(user.TotpSecret.String, params.Code, time.Now())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Invalid TOTP code", nil)
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	updated, err := cfg.DB.EnableUserTOTP(r.Context(), database.EnableUserTOTPParams{
+		TotpEnabledAt: sql.NullString{String: now, Valid: true},
+		TotpLastStep:  step,
+		UpdatedAt:     now,
+		ID:            user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enable TOTP", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+	if updated == 0 {
+		respondWithError(w, http.StatusConflict, "TOTP enrollment changed, try again", nil)
+		return
+	}
+
+	user, err = cfg.DB.GetUserByID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	userResp, err := databaseUserToUser(user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, userResp)
+}
+
+func (cfg *apiConfig) handlerTOTPDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := cfg.DB.DisableUserTOTP(r.Context(), database.DisableUserTOTPParams{
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't disable TOTP", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// errCodeQuotaExceeded is the error code sent with 402 responses for writes
+// that would take a user over QUOTA_MAX_NOTES or QUOTA_MAX_BYTES.
+const errCodeQuotaExceeded = "quota_exceeded"
+
+// quotaExceededError reports which quota a write would have exceeded and
+// the usage before the write.
+type quotaExceededError struct {
+	Resource string
+	Limit    int64
+	Used     int64
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Resource, e.Limit)
+}
+
+// quotaCheck compares a user's usage before and after writes made in a
+// transaction. A nil quotaCheck, used when no quotas are set, allows
+// everything.
+type quotaCheck struct {
+	cfg    *apiConfig
+	db     database.Querier
+	userID string
+	before database.GetUsageForUserRow
+}
+
+// startQuotaCheck records the user's usage before a write. Pass the
+// transaction the write happens in, and call check on the result before
+// committing.
+func (cfg *apiConfig) startQuotaCheck(ctx context.Context, db database.Querier, userID string) (*quotaCheck, error) {
+	if cfg.QuotaMaxNotes == 0 && cfg.QuotaMaxBytes == 0 {
+		return nil, nil
+	}
+	before, err := db.GetUsageForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &quotaCheck{cfg: cfg, db: db, userID: userID, before: before}, nil
+}
+
+// check returns a *quotaExceededError if the writes since startQuotaCheck
+// took usage over a quota. Usage that didn't grow is allowed even when it's
+// over, so users above a lowered quota can still edit and delete.
+func (q *quotaCheck) check(ctx context.Context) error {
+	if q == nil {
+		return nil
+	}
+	after, err := q.db.GetUsageForUser(ctx, q.userID)
+	if err != nil {
+		return err
+	}
+	if limit := int64(q.cfg.QuotaMaxNotes); limit > 0 && after.NoteCount > q.before.NoteCount && after.NoteCount > limit {
+		return &quotaExceededError{Resource: "notes", Limit: limit, Used: q.before.NoteCount}
+	}
+	if limit := int64(q.cfg.QuotaMaxBytes); limit > 0 && after.NoteBytes > q.before.NoteBytes && after.NoteBytes > limit {
+		return &quotaExceededError{Resource: "bytes", Limit: limit, Used: q.before.NoteBytes}
+	}
+	return nil
+}
+
+// allow runs check and, if it fails, writes the response and returns false.
+func (q *quotaCheck) allow(ctx context.Context, w http.ResponseWriter) bool {
+	err := q.check(ctx)
+	var exceeded *quotaExceededError
+	if errors.As(err, &exceeded) {
+		respondWithQuotaExceeded(w, exceeded)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return false
+	}
+	return true
+}
+
+type quotaUsage struct {
+	Resource string `json:"resource"`
+	Limit    int64  `json:"limit"`
+	Used     int64  `json:"used"`
+}
+
+// quotaProblem is the problem sent for an exceeded quota, with the quota
+// that was hit.
+type quotaProblem struct {
+	problem
+	Quota quotaUsage `json:"quota"`
+}
+
+// respondWithQuotaExceeded sends a 402 with the quota that was hit, so
+// clients can show it without another request.
+func respondWithQuotaExceeded(w http.ResponseWriter, err *quotaExceededError) {
+	msg := fmt.Sprintf("This would exceed your %s quota of %d", err.Resource, err.Limit)
+	respondWithProblem(w, http.StatusPaymentRequired, quotaProblem{
+		problem: newProblem(http.StatusPaymentRequired, errCodeQuotaExceeded, msg, w.Header().Get(requestIDHeader)),
+		Quota:   quotaUsage{Resource: err.Resource, Limit: err.Limit, Used: err.Used},
+	})
+}
+
+// handlerUsage reports the user's usage against their quotas. A null limit
+// means there is none.
+func (cfg *apiConfig) handlerUsage(w http.ResponseWriter, r *http.Request, user database.User) {
+	type resourceUsage struct {
+		Used  int64  `json:"used"`
+		Limit *int64 `json:"limit"`
+	}
+	limit := func(n int) *int64 {
+		if n == 0 {
+			return nil
+		}
+		l := int64(n)
+		return &l
+	}
+
+	usage, err := cfg.DB.GetUsageForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Notes resourceUsage `json:"notes"`
+		Bytes resourceUsage `json:"bytes"`
+	}{
+		Notes: resourceUsage{Used: usage.NoteCount, Limit: limit(cfg.QuotaMaxNotes)},
+		Bytes: resourceUsage{Used: usage.NoteBytes, Limit: limit(cfg.QuotaMaxBytes)},
+	})
+}
@@ -1,74 +1,316 @@
 package main
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
 	"github.com/google/uuid"
 )
 
+// userCreateRequest is the body of handlerUsersCreate requests.
+type userCreateRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// handlerUsersCreate signs up a user and returns their first API key. With
+// an email and password they can also sign in through middlewarePasswordAuth.
 func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request) {
-	type parameters struct {
-		Name string `json:"name"`
+	v := &validate.Validator{}
+	params := userCreateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	name, err := normalizeUserName(params.Name)
+	v.Check(err == nil, "name", invalidNameMessage())
+	var email sql.NullString
+	if params.Email != "" {
+		email.String, err = normalizeEmail(params.Email)
+		v.Check(err == nil, "email", "must be a valid email address")
+		email.Valid = err == nil
+	}
+	if params.Password != "" {
+		v.Check(params.Email != "", "email", "is required to sign in with a password")
+		v.Check(validPassword(params.Password), "password", invalidPasswordMessage())
 	}
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	if email.Valid {
+		if _, err := cfg.DB.GetUserByEmail(r.Context(), email); err == nil {
+			respondWithError(w, http.StatusConflict, "Email is already in use", nil)
+			return
+		}
+	}
+	var passwordHash string
+	if params.Password != "" {
+		passwordHash, err = auth.HashPassword(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+			return
+		}
+	}
+
+	user, apiKey, err := createUser(r.Context(), cfg.DB, name, email)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
 		return
 	}
+	if passwordHash != "" {
+		user, err = cfg.setPassword(r.Context(), user, passwordHash)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save password", err)
+			return
+		}
+	}
+	if user.Email.Valid {
+		cfg.sendVerificationEmailOrLog(r.Context(), user)
+	}
 
-	apiKey, err := generateRandomSHA256Hash()
+	userResp, err := databaseUserToUser(user)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't gen apikey", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
 		return
 	}
+	// The plaintext key is only ever returned here; afterwards just its hash is stored.
+	userResp.ApiKey = apiKey
+	respondWithJSON(w, http.StatusCreated, userResp)
+}
+
+const userNameMaxLength = 100
+
+var (
+	errInvalidEmail = errors.New("invalid email address")
+	errInvalidName  = errors.New("invalid name")
+)
+
+// normalizeUserName trims a display name, which must be a non-empty single
+// line.
+func normalizeUserName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > userNameMaxLength {
+		return "", errInvalidName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return "", errInvalidName
+		}
+	}
+	return name, nil
+}
 
-	err = cfg.DB.CreateUser(r.Context(), database.CreateUserParams{
+// normalizeEmail validates a bare email address and lowercases it so lookups
+// are case-insensitive.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errInvalidEmail
+	}
+	return email, nil
+}
+
+// createUser creates a user along with its first API key and returns the
+// plaintext key, which is never stored.
+func createUser(ctx context.Context, db database.Querier, name string, email sql.NullString) (database.User, string, error) {
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		return database.User{}, "", err
+	}
+	apiKeyHash := auth.HashAPIKey(apiKey)
+
+	userID := uuid.New().String()
+	err = db.CreateUser(ctx, database.CreateUserParams{
+		ID:           userID,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
+		Name:         name,
+		ApiKey:       apiKeyHash,
+		ApiKeyPrefix: auth.DisplayPrefix(apiKey),
+		Email:        email,
+	})
+	if err != nil {
+		return database.User{}, "", err
+	}
+
+	err = db.CreateAPIKey(ctx, database.CreateAPIKeyParams{
 		ID:        uuid.New().String(),
 		CreatedAt: time.Now().UTC().Format(time.RFC3339),
 		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
-		Name:      params.Name,
-		ApiKey:    apiKey,
+		UserID:    userID,
+		Label:     auth.DefaultKeyLabel,
+		KeyHash:   apiKeyHash,
+		KeyPrefix: auth.DisplayPrefix(apiKey),
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
+		return database.User{}, "", err
+	}
+
+	user, err := db.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, "", err
+	}
+	return user, apiKey, nil
+}
+
+func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request, user database.User) {
+
+	userResp, err := databaseUserToUser(user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
 		return
 	}
 
-	user, err := cfg.DB.GetUser(r.Context(), apiKey)
+	respondWithJSON(w, http.StatusOK, userResp)
+}
+
+// userUpdateRequest is the body of handlerUsersUpdate requests.
+type userUpdateRequest struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+// handlerUsersUpdate changes the user's display name and email. Fields left
+// out are unchanged and an empty email removes it. An email can only belong
+// to one user, and a new one has to be verified again.
+func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := userUpdateRequest{}
+	err := v.Decode(r.Body, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
 		return
 	}
 
+	name := user.Name
+	if params.Name != nil {
+		name, err = normalizeUserName(*params.Name)
+		v.Check(err == nil, "name", invalidNameMessage())
+	}
+	email := user.Email
+	if params.Email != nil {
+		email = sql.NullString{}
+		if *params.Email != "" {
+			email.String, err = normalizeEmail(*params.Email)
+			v.Check(err == nil, "email", "must be a valid email address")
+			email.Valid = true
+		}
+	}
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	if email.Valid {
+		other, err := cfg.DB.GetUserByEmail(r.Context(), email)
+		if err == nil && other.ID != user.ID {
+			respondWithError(w, http.StatusConflict, "Email is already in use", nil)
+			return
+		}
+	}
+
+	emailChanged := email != user.Email
+	emailVerifiedAt := user.EmailVerifiedAt
+	if emailChanged {
+		emailVerifiedAt = sql.NullString{}
+	}
+	err = cfg.DB.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		Name:            name,
+		Email:           email,
+		EmailVerifiedAt: emailVerifiedAt,
+		UpdatedAt:       time.Now().UTC().Format(time.RFC3339),
+		ID:              user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+
+	user, err = cfg.DB.GetUserByID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if emailChanged && user.Email.Valid {
+		cfg.sendVerificationEmailOrLog(r.Context(), user)
+	}
 	userResp, err := databaseUserToUser(user)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, userResp)
+
+	respondWithJSON(w, http.StatusOK, userResp)
 }
 
-func generateRandomSHA256Hash() (string, error) {
-	randomBytes := make([]byte, 32)
-	_, err := rand.Read(randomBytes)
+// userPasswordRequest is the body of handlerUsersPassword requests.
+type userPasswordRequest struct {
+	Password        string `json:"password"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// handlerUsersPassword sets the password of a user who signed up with just
+// an API key, or changes it, in which case the current password must be
+// given too. The user needs an email to sign in with it.
+func (cfg *apiConfig) handlerUsersPassword(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := userPasswordRequest{}
+	err := v.Decode(r.Body, &params)
 	if err != nil {
-		return "", err
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
 	}
-	hash := sha256.Sum256(randomBytes)
-	hashString := hex.EncodeToString(hash[:])
-	return hashString, nil
-}
 
-func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	v.Check(validPassword(params.Password), "password", invalidPasswordMessage())
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
 
+	// The cached user passed in has no password hash.
+	user, err = cfg.DB.GetUserByID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !user.Email.Valid {
+		respondWithError(w, http.StatusConflict, "An email is required to sign in with a password", nil)
+		return
+	}
+	if user.PasswordHash.Valid && !auth.CheckPassword(params.CurrentPassword, user.PasswordHash.String) {
+		respondWithError(w, http.StatusForbidden, "Current password is incorrect", nil)
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	user, err = cfg.setPassword(r.Context(), user, passwordHash)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save password", err)
+		return
+	}
 	userResp, err := databaseUserToUser(user)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't convert user", err)
@@ -77,3 +319,32 @@ func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request, us
 
 	respondWithJSON(w, http.StatusOK, userResp)
 }
+
+func invalidPasswordMessage() string {
+	return fmt.Sprintf("must be %d to %d bytes long", auth.MinPasswordLength, auth.MaxPasswordLength)
+}
+
+// validPassword reports whether auth.HashPassword would accept password,
+// without the cost of hashing it.
+func validPassword(password string) bool {
+	return len(password) >= auth.MinPasswordLength && len(password) <= auth.MaxPasswordLength
+}
+
+func invalidNameMessage() string {
+	return fmt.Sprintf("must be a single line of 1 to %d characters", userNameMaxLength)
+}
+
+// setPassword stores a password hash for the user and returns the updated
+// user.
+func (cfg *apiConfig) setPassword(ctx context.Context, user database.User, passwordHash string) (database.User, error) {
+	err := cfg.DB.SetUserPassword(ctx, database.SetUserPasswordParams{
+		PasswordHash: sql.NullString{String: passwordHash, Valid: true},
+		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
+		ID:           user.ID,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	cfg.Users.ForgetUser(ctx, user.ID)
+	return cfg.DB.GetUserByID(ctx, user.ID)
+}
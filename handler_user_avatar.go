@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/avatar"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/storage"
+	"github.com/go-chi/chi"
+)
+
+// avatarMaxBytes caps the size of an uploaded avatar.
+const avatarMaxBytes = 5 << 20
+
+func avatarKey(userID string, size int) string {
+	return fmt.Sprintf("avatars/%s/%d.png", userID, size)
+}
+
+// handlerUsersAvatarPut sets the user's avatar from a PNG, JPEG or GIF, sent
+// as the "file" form field of a multipart request or as the raw body. It's
+// stored cropped to a square at each of avatar.Sizes.
+func (cfg *apiConfig) handlerUsersAvatarPut(w http.ResponseWriter, r *http.Request, user database.User) {
+	if cfg.Attachments == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Avatar storage isn't configured", nil)
+		return
+	}
+
+	limitBody(w, r, avatarMaxBytes)
+	data, err := avatarUpload(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Avatars can be at most %d bytes", avatarMaxBytes), err)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Couldn't read avatar", err)
+		return
+	}
+
+	images, err := avatar.Process(data)
+	if errors.Is(err, avatar.ErrInvalidImage) {
+		respondWithError(w, http.StatusBadRequest, "Avatars must be PNG, JPEG or GIF images", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resize avatar", err)
+		return
+	}
+	for size, img := range images {
+		err := cfg.Attachments.Put(r.Context(), avatarKey(user.ID, size), bytes.NewReader(img), int64(len(img)), "image/png")
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, "Couldn't store avatar", err)
+			return
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = cfg.DB.SetUserAvatar(r.Context(), database.SetUserAvatarParams{
+		AvatarUpdatedAt: sql.NullString{String: now, Valid: true},
+		UpdatedAt:       now,
+		ID:              user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUsersAvatarDelete removes the user's avatar.
+func (cfg *apiConfig) handlerUsersAvatarDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	if !user.AvatarUpdatedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "No avatar set", nil)
+		return
+	}
+
+	err := cfg.DB.SetUserAvatar(r.Context(), database.SetUserAvatarParams{
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+	cfg.deleteAvatar(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUserAvatarGet serves a user's avatar to anyone. ?size= picks one
+// of avatar.Sizes and defaults to the largest.
+func (cfg *apiConfig) handlerUserAvatarGet(w http.ResponseWriter, r *http.Request) {
+	size := avatar.Sizes[len(avatar.Sizes)-1]
+	if s := r.URL.Query().Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || !validAvatarSize(n) {
+			respondWithError(w, http.StatusBadRequest, "Invalid size", err)
+			return
+		}
+		size = n
+	}
+
+	user, err := cfg.DB.GetUserByID(r.Context(), chi.URLParam(r, "userID"))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if err != nil || !user.AvatarUpdatedAt.Valid || cfg.Attachments == nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get avatar", err)
+		return
+	}
+
+	// The upload time changes with every new avatar, so it versions the
+	// image for caches.
+	etag := strconv.Quote(user.AvatarUpdatedAt.String + "-" + strconv.Itoa(size))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if strings.Contains(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := cfg.Attachments.Get(r.Context(), avatarKey(user.ID, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get avatar", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Couldn't get avatar", err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+func validAvatarSize(size int) bool {
+	for _, s := range avatar.Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// avatarUpload returns the uploaded image, taken from the "file" form field
+// for multipart requests and from the raw body otherwise.
+func avatarUpload(r *http.Request) ([]byte, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	return io.ReadAll(r.Body)
+}
+
+// deleteAvatar removes a user's stored avatar images. Failures are only
+// logged: with avatar_updated_at cleared, leftover objects are never served.
+func (cfg *apiConfig) deleteAvatar(ctx context.Context, userID string) {
+	if cfg.Attachments == nil {
+		return
+	}
+	for _, size := range avatar.Sizes {
+		if err := cfg.Attachments.Delete(ctx, avatarKey(userID, size)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logf(ctx, "Couldn't delete avatar %s: %v", avatarKey(userID, size), err)
+		}
+	}
+}
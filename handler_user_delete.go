@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// deletionTokenTTL is how long a token from handlerUsersDeletionToken can
+// be used to delete the account.
+const deletionTokenTTL = 10 * time.Minute
+
+// handlerUsersDeletionToken issues the confirmation token that
+// handlerUsersDelete requires. Asking for a new token replaces the old one.
+func (cfg *apiConfig) handlerUsersDeletionToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, err := auth.MakeDeletionToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create deletion token", err)
+		return
+	}
+	now := time.Now().UTC()
+	expiresAt := now.Add(deletionTokenTTL).Truncate(time.Second)
+	err = cfg.DB.SetUserDeletionToken(r.Context(), database.SetUserDeletionTokenParams{
+		DeletionTokenHash:      sql.NullString{String: auth.HashAPIKey(token), Valid: true},
+		DeletionTokenExpiresAt: sql.NullString{String: expiresAt.Format(time.RFC3339), Valid: true},
+		UpdatedAt:              now.Format(time.RFC3339),
+		ID:                     user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save deletion token", err)
+		return
+	}
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+
+	type response struct {
+		ConfirmationToken string    `json:"confirmation_token"`
+		ExpiresAt         time.Time `json:"expires_at"`
+	}
+	respondWithJSON(w, http.StatusCreated, response{
+		ConfirmationToken: token,
+		ExpiresAt:         expiresAt,
+	})
+}
+
+// userDeleteRequest is the body of handlerUsersDelete requests.
+type userDeleteRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// handlerUsersDelete deletes the account along with its notes, notebooks,
+// tags, keys, sessions and shares in one transaction. The body must carry
+// an unexpired confirmation_token from handlerUsersDeletionToken. The auth
+// audit log is kept.
+func (cfg *apiConfig) handlerUsersDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := userDeleteRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	v.Required("confirmation_token", params.ConfirmationToken)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	// The cached user passed in has no deletion token hash.
+	user, err = cfg.DB.GetUserByID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !validDeletionToken(user, params.ConfirmationToken, time.Now()) {
+		respondWithError(w, http.StatusForbidden, "Invalid or expired confirmation token", nil)
+		return
+	}
+
+	keys, err := cfg.DB.ListAPIKeysForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get api keys for user", err)
+		return
+	}
+
+	err = database.WithTx(r.Context(), cfg.DB, func(tx database.Querier) error {
+		return deleteUserData(r.Context(), tx, user.ID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete user", err)
+		return
+	}
+
+	hashes := make([]string, len(keys))
+	for i, key := range keys {
+		hashes[i] = key.KeyHash
+	}
+	cfg.APIKeyAuth.Denylist.Add(hashes...)
+	cfg.Users.ForgetKeys(r.Context(), hashes...)
+	cfg.Users.ForgetUser(r.Context(), user.ID)
+	if user.AvatarUpdatedAt.Valid {
+		cfg.deleteAvatar(r.Context(), user.ID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validDeletionToken reports whether token matches the user's unexpired
+// deletion token.
+func validDeletionToken(user database.User, token string, now time.Time) bool {
+	if token == "" || !user.DeletionTokenHash.Valid {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, user.DeletionTokenExpiresAt.String)
+	if err != nil || !now.Before(expiresAt) {
+		return false
+	}
+	return auth.VerifyAPIKey(token, user.DeletionTokenHash.String)
+}
+
+// deleteUserData removes a user and everything they own. Foreign keys aren't
+// enforced on every connection, so each table is cleared explicitly, children
+// before parents. Run it in a transaction.
+func deleteUserData(ctx context.Context, db database.Querier, userID string) error {
+	steps := []func(context.Context, string) error{
+		db.DeleteNoteTagsForUser,
+		db.DeleteNoteItemsForUser,
+		db.DeleteNoteSharesForUser,
+		db.DeleteNoteSharesWithUser,
+		db.DeleteNoteShareLinksForUser,
+		db.DeleteNotesForUser,
+		db.DeleteTagsForUser,
+		db.DeleteNotebooksForUser,
+		db.DeleteAPIKeysForUser,
+		db.DeleteClientCertsForUser,
+		db.DeleteOAuthIdentitiesForUser,
+		db.DeleteSessionsForUser,
+		db.DeleteRefreshTokensForUser,
+		db.DeleteIdempotencyKeysForUser,
+		db.DeleteEmailVerificationTokensForUser,
+		db.DeletePasswordResetTokensForUser,
+		db.DeleteUserSettingsForUser,
+		db.DeleteDataExportsForUser,
+		db.DeleteNoteListChangesForUser,
+		db.DeleteWebhookDeliveriesForUser,
+		db.DeleteWebhooksForUser,
+	}
+	for _, step := range steps {
+		if err := step(ctx, userID); err != nil {
+			return err
+		}
+	}
+	_, err := db.DeleteUser(ctx, userID)
+	return err
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/bootdotdev/learn-cicd-starter/internal/settings"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	// dataExportSyncMaxNotes is the most notes an account can have for its
+	// data export to be built while the client waits.
+	dataExportSyncMaxNotes = 500
+	// dataExportTTL is how long a prepared export can be downloaded.
+	dataExportTTL = 24 * time.Hour
+	// dataExportMaxAuditEntries caps the audit log included in an export.
+	dataExportMaxAuditEntries = 10000
+)
+
+const (
+	dataExportPending = "pending"
+	dataExportReady   = "ready"
+	dataExportFailed  = "failed"
+)
+
+// dataExport is everything stored about a user, in one machine-readable
+// document. Notes shared with the user belong to someone else, so only
+// their IDs are listed.
+type dataExport struct {
+	ExportedAt      time.Time         `json:"exported_at"`
+	User            User              `json:"user"`
+	Settings        settings.Settings `json:"settings"`
+	Notebooks       []Notebook        `json:"notebooks"`
+	Notes           []Note            `json:"notes"`
+	SharesGranted   []exportShare     `json:"shares_granted"`
+	SharedWithMe    []string          `json:"shared_with_me"`
+	ShareLinks      []exportShareLink `json:"share_links"`
+	APIKeys         []APIKey          `json:"api_keys"`
+	Webhooks        []Webhook         `json:"webhooks"`
+	OAuthIdentities []exportOAuthLink `json:"oauth_identities"`
+	AuthAudit       []AuthAuditEntry  `json:"auth_audit"`
+}
+
+type exportShare struct {
+	NoteID     string    `json:"note_id"`
+	UserID     string    `json:"user_id"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportShareLink struct {
+	ID        string     `json:"id"`
+	NoteID    string     `json:"note_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type exportOAuthLink struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handlerUsersExport returns all of the user's data as JSON. Small accounts
+// get it straight away. Accounts with more than dataExportSyncMaxNotes
+// notes, or any request with ?async=true, get a 202 with a download URL
+// instead; the export is prepared in the background and can be fetched
+// from that URL, without credentials, for dataExportTTL.
+func (cfg *apiConfig) handlerUsersExport(w http.ResponseWriter, r *http.Request, user database.User) {
+	async := false
+	if s := r.URL.Query().Get("async"); s != "" {
+		var err error
+		async, err = strconv.ParseBool(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid async flag", err)
+			return
+		}
+	}
+	if !async {
+		usage, err := cfg.DB.GetUsageForUser(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get usage", err)
+			return
+		}
+		async = usage.NoteCount > dataExportSyncMaxNotes
+	}
+
+	if !async {
+		data, err := cfg.buildDataExport(r.Context(), user)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't export data", err)
+			return
+		}
+		respondWithDataExport(w, data, time.Now())
+		return
+	}
+
+	pending, err := cfg.DB.CountPendingDataExportsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get exports", err)
+		return
+	}
+	if pending > 0 {
+		respondWithError(w, http.StatusConflict, "An export is already being prepared", nil)
+		return
+	}
+
+	token, err := auth.MakeExportToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create export token", err)
+		return
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := cfg.DB.DeleteExpiredDataExports(r.Context(), now.Format(time.RFC3339)); err != nil {
+		logf(r.Context(), "Couldn't delete expired exports: %v", err)
+	}
+	id := uuid.New().String()
+	err = cfg.DB.CreateDataExport(r.Context(), database.CreateDataExportParams{
+		ID:        id,
+		CreatedAt: now.Format(time.RFC3339),
+		UserID:    user.ID,
+		TokenHash: auth.HashAPIKey(token),
+		ExpiresAt: now.Add(dataExportTTL).Format(time.RFC3339),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create export", err)
+		return
+	}
+
+	err = cfg.Jobs.Enqueue(r.Context(), jobKindDataExport, dataExportJob{ExportID: id, UserID: user.ID})
+	if err != nil {
+		if err := cfg.DB.FinishDataExport(r.Context(), database.FinishDataExportParams{Status: dataExportFailed, ID: id}); err != nil {
+			logf(r.Context(), "Couldn't save export %s: %v", id, err)
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't queue export", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, struct {
+		Status      string    `json:"status"`
+		DownloadURL string    `json:"download_url"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}{
+		Status:      dataExportPending,
+		DownloadURL: cfg.PublicBaseURL + "/v1/exports/" + token,
+		ExpiresAt:   now.Add(dataExportTTL),
+	})
+}
+
+// dataExportJob is the payload of jobKindDataExport jobs.
+type dataExportJob struct {
+	ExportID string `json:"export_id"`
+	UserID   string `json:"user_id"`
+}
+
+// runDataExportJob builds an export queued by handlerUsersExport and
+// stores the result. It's marked failed only once the job's last attempt
+// fails; until then downloads are told to keep waiting.
+func (cfg *apiConfig) runDataExportJob(ctx context.Context, job jobs.Job) error {
+	var payload dataExportJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	user, err := cfg.DB.GetUserByID(ctx, payload.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted, along with the export.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	params := database.FinishDataExportParams{Status: dataExportReady, ID: payload.ExportID}
+	data, err := cfg.buildDataExport(ctx, user)
+	if err == nil {
+		var encoded []byte
+		encoded, err = json.Marshal(data)
+		params.Data = sql.NullString{String: string(encoded), Valid: true}
+	}
+	if err != nil {
+		if job.LastAttempt() {
+			params = database.FinishDataExportParams{Status: dataExportFailed, ID: payload.ExportID}
+			if err := cfg.DB.FinishDataExport(ctx, params); err != nil {
+				log.Printf("Couldn't save export %s: %v", payload.ExportID, err)
+			}
+		}
+		return err
+	}
+	return cfg.DB.FinishDataExport(ctx, params)
+}
+
+// handlerExportDownload serves an export prepared by handlerUsersExport.
+// The token in the URL is the only credential. While the export is being
+// prepared the response is a 202 with Retry-After.
+func (cfg *apiConfig) handlerExportDownload(w http.ResponseWriter, r *http.Request) {
+	export, err := cfg.DB.GetDataExportByTokenHash(r.Context(), auth.HashAPIKey(chi.URLParam(r, "token")))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get export", err)
+		return
+	}
+	expiresAt, parseErr := time.Parse(time.RFC3339, export.ExpiresAt)
+	if err != nil || parseErr != nil || !time.Now().Before(expiresAt) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get export", err)
+		return
+	}
+
+	switch export.Status {
+	case dataExportPending:
+		w.Header().Set("Retry-After", "5")
+		respondWithJSON(w, http.StatusAccepted, struct {
+			Status string `json:"status"`
+		}{Status: dataExportPending})
+	case dataExportReady:
+		createdAt, _ := time.Parse(time.RFC3339, export.CreatedAt)
+		respondWithDataExport(w, json.RawMessage(export.Data.String), createdAt)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Export failed, please request a new one", nil)
+	}
+}
+
+func respondWithDataExport(w http.ResponseWriter, data any, exportedAt time.Time) {
+	w.Header().Set("Content-Disposition", `attachment; filename="notely-data-`+exportedAt.UTC().Format("20060102")+`.json"`)
+	respondWithJSON(w, http.StatusOK, data)
+}
+
+// buildDataExport gathers everything stored about the user.
+func (cfg *apiConfig) buildDataExport(ctx context.Context, user database.User) (dataExport, error) {
+	export := dataExport{
+		ExportedAt:      time.Now().UTC(),
+		Notes:           []Note{},
+		SharesGranted:   []exportShare{},
+		SharedWithMe:    []string{},
+		ShareLinks:      []exportShareLink{},
+		OAuthIdentities: []exportOAuthLink{},
+	}
+	var err error
+
+	if export.User, err = databaseUserToUser(user); err != nil {
+		return dataExport{}, err
+	}
+	if export.Settings, err = cfg.userSettings(ctx, user.ID); err != nil {
+		return dataExport{}, err
+	}
+
+	notebooks, err := cfg.DB.ListNotebooksForUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	if export.Notebooks, err = databaseNotebooksToNotebooks(notebooks); err != nil {
+		return dataExport{}, err
+	}
+
+	params := database.ListNotesForUserParams{
+		UserID:          user.ID,
+		IncludeArchived: true,
+		SortBy:          "created_at",
+		Limit:           exportPageSize,
+	}
+	for {
+		posts, err := cfg.DB.ListNotesForUser(ctx, params)
+		if err != nil {
+			return dataExport{}, err
+		}
+		notes, err := cfg.notesResponse(ctx, posts)
+		if err != nil {
+			return dataExport{}, err
+		}
+		export.Notes = append(export.Notes, notes...)
+		if len(posts) < exportPageSize {
+			break
+		}
+		last := posts[len(posts)-1]
+		params.AfterPinned = last.Pinned
+		params.AfterValue = sql.NullString{String: last.CreatedAt, Valid: true}
+		params.AfterID = sql.NullString{String: last.ID, Valid: true}
+	}
+
+	shares, err := cfg.DB.ListNoteSharesForOwner(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	for _, share := range shares {
+		createdAt, err := time.Parse(time.RFC3339, share.CreatedAt)
+		if err != nil {
+			return dataExport{}, err
+		}
+		export.SharesGranted = append(export.SharesGranted, exportShare{
+			NoteID:     share.NoteID,
+			UserID:     share.UserID,
+			Permission: share.Permission,
+			CreatedAt:  createdAt,
+		})
+	}
+	shared, err := cfg.DB.ListNotesSharedWithUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	for _, note := range shared {
+		export.SharedWithMe = append(export.SharedWithMe, note.ID)
+	}
+
+	links, err := cfg.DB.ListNoteShareLinksForUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	for _, link := range links {
+		exported := exportShareLink{ID: link.ID, NoteID: link.NoteID}
+		if exported.CreatedAt, err = time.Parse(time.RFC3339, link.CreatedAt); err != nil {
+			return dataExport{}, err
+		}
+		if exported.ExpiresAt, err = parseNullTime(link.ExpiresAt); err != nil {
+			return dataExport{}, err
+		}
+		if exported.RevokedAt, err = parseNullTime(link.RevokedAt); err != nil {
+			return dataExport{}, err
+		}
+		export.ShareLinks = append(export.ShareLinks, exported)
+	}
+
+	keys, err := cfg.DB.ListAPIKeysForUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	if export.APIKeys, err = databaseAPIKeysToAPIKeys(keys); err != nil {
+		return dataExport{}, err
+	}
+
+	hooks, err := cfg.DB.ListWebhooksForUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	if export.Webhooks, err = databaseWebhooksToWebhooks(hooks); err != nil {
+		return dataExport{}, err
+	}
+
+	identities, err := cfg.DB.ListOAuthIdentitiesForUser(ctx, user.ID)
+	if err != nil {
+		return dataExport{}, err
+	}
+	for _, identity := range identities {
+		createdAt, err := time.Parse(time.RFC3339, identity.CreatedAt)
+		if err != nil {
+			return dataExport{}, err
+		}
+		export.OAuthIdentities = append(export.OAuthIdentities, exportOAuthLink{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			CreatedAt: createdAt,
+		})
+	}
+
+	entries, err := cfg.DB.ListAuthAuditEntries(ctx, database.ListAuthAuditEntriesParams{
+		UserID: sql.NullString{String: user.ID, Valid: true},
+		Limit:  dataExportMaxAuditEntries,
+	})
+	if err != nil {
+		return dataExport{}, err
+	}
+	if export.AuthAudit, err = databaseAuthAuditToAuthAudit(entries); err != nil {
+		return dataExport{}, err
+	}
+
+	return export, nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/bootdotdev/learn-cicd-starter/internal/webhooks"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookURLMaxLength = 2048
+	webhooksMax         = 10
+	// defaultWebhookDeliveries and maxWebhookDeliveries bound the delivery
+	// log listed at once.
+	defaultWebhookDeliveries = 50
+	maxWebhookDeliveries     = 100
+)
+
+// webhookEventTypes are the events a webhook can receive.
+var webhookEventTypes = []string{eventNoteCreated, eventNoteUpdated, eventNoteDeleted}
+
+// webhookCreateRequest is the body of handlerWebhooksCreate requests.
+type webhookCreateRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// webhookUpdateRequest is the body of handlerWebhooksUpdate requests.
+// Fields left out keep their values.
+type webhookUpdateRequest struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// handlerWebhooksCreate registers an endpoint for the user's note events.
+// The response is the only one that includes the webhook's signing secret.
+func (cfg *apiConfig) handlerWebhooksCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := webhookCreateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	validateWebhookURL(v, params.URL)
+	validateWebhookEvents(v, params.Events)
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	count, err := cfg.DB.CountWebhooksForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count webhooks", err)
+		return
+	}
+	if count >= webhooksMax {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Can't have more than %d webhooks", webhooksMax), nil)
+		return
+	}
+
+	secret, err := auth.GenerateWebhookSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't generate webhook secret", err)
+		return
+	}
+	id := uuid.New().String()
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = cfg.DB.CreateWebhook(r.Context(), database.CreateWebhookParams{
+		ID:        id,
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Url:       params.URL,
+		Secret:    secret,
+		Events:    strings.Join(params.Events, ","),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create webhook", err)
+		return
+	}
+
+	hook, ok := cfg.getWebhook(w, r, id, user)
+	if !ok {
+		return
+	}
+	resp, err := databaseWebhookToWebhook(hook)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert webhook", err)
+		return
+	}
+	resp.Secret = hook.Secret
+
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerWebhooksGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	hooks, err := cfg.DB.ListWebhooksForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get webhooks", err)
+		return
+	}
+
+	resp, err := databaseWebhooksToWebhooks(hooks)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert webhooks", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerWebhookGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.respondWithWebhook(w, r, chi.URLParam(r, "webhookID"), user)
+}
+
+// handlerWebhooksUpdate changes a webhook's URL or events, or pauses and
+// resumes it. Deliveries due while it's paused wait until it's resumed,
+// when they're queued to be sent again.
+func (cfg *apiConfig) handlerWebhooksUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	v := &validate.Validator{}
+	params := webhookUpdateRequest{}
+	err := v.Decode(r.Body, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.URL != nil {
+		validateWebhookURL(v, *params.URL)
+	}
+	if params.Events != nil {
+		validateWebhookEvents(v, *params.Events)
+	}
+	if errs := v.Errors(); errs != nil {
+		respondWithInvalidFields(w, errs)
+		return
+	}
+
+	id := chi.URLParam(r, "webhookID")
+	hook, ok := cfg.getWebhook(w, r, id, user)
+	if !ok {
+		return
+	}
+	arg := database.UpdateWebhookParams{
+		Url:       hook.Url,
+		Events:    hook.Events,
+		Active:    hook.Active,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        id,
+		UserID:    user.ID,
+	}
+	if params.URL != nil {
+		arg.Url = *params.URL
+	}
+	if params.Events != nil {
+		arg.Events = strings.Join(*params.Events, ",")
+	}
+	if params.Active != nil {
+		arg.Active = *params.Active
+	}
+	n, err := cfg.DB.UpdateWebhook(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update webhook", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get webhook", nil)
+		return
+	}
+	if arg.Active && !hook.Active {
+		// Send whatever queued up while it was paused.
+		cfg.queuePendingWebhookDeliveries(r.Context(), id)
+	}
+
+	cfg.respondWithWebhook(w, r, id, user)
+}
+
+// handlerWebhooksDelete removes a webhook along with its delivery log,
+// including deliveries not yet sent.
+func (cfg *apiConfig) handlerWebhooksDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	id := chi.URLParam(r, "webhookID")
+
+	tx, err := cfg.DB.BeginTx(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := tx.DeleteWebhook(r.Context(), database.DeleteWebhookParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete webhook", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't get webhook", nil)
+		return
+	}
+	if err := tx.DeleteWebhookDeliveries(r.Context(), id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete webhook deliveries", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerWebhookDeliveriesGet lists a webhook's most recent deliveries,
+// newest first, with how each attempt went. Delivered and dead ones are
+// kept for webhooks.Retention.
+func (cfg *apiConfig) handlerWebhookDeliveriesGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit := defaultWebhookDeliveries
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxWebhookDeliveries {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+	}
+
+	hook, ok := cfg.getWebhook(w, r, chi.URLParam(r, "webhookID"), user)
+	if !ok {
+		return
+	}
+	deliveries, err := cfg.DB.ListWebhookDeliveries(r.Context(), database.ListWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     int64(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get webhook deliveries", err)
+		return
+	}
+
+	resp, err := databaseWebhookDeliveriesToWebhookDeliveries(deliveries)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert webhook deliveries", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// queuePendingWebhookDeliveries queues a job to send each of a webhook's
+// pending deliveries. The webhook has already been resumed, so failures
+// are logged rather than returned.
+func (cfg *apiConfig) queuePendingWebhookDeliveries(ctx context.Context, webhookID string) {
+	ids, err := cfg.DB.ListPendingWebhookDeliveryIDs(ctx, webhookID)
+	if err != nil {
+		logf(ctx, "Couldn't list pending webhook deliveries: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if err := cfg.Jobs.Enqueue(ctx, webhooks.JobKind, webhooks.JobPayload{DeliveryID: id}); err != nil {
+			logf(ctx, "Couldn't queue webhook delivery %s: %v", id, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) respondWithWebhook(w http.ResponseWriter, r *http.Request, id string, user database.User) {
+	hook, ok := cfg.getWebhook(w, r, id, user)
+	if !ok {
+		return
+	}
+
+	resp, err := databaseWebhookToWebhook(hook)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't convert webhook", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// getWebhook looks up one of the user's webhooks, responding with an error
+// and returning false if it can't.
+func (cfg *apiConfig) getWebhook(w http.ResponseWriter, r *http.Request, id string, user database.User) (database.Webhook, bool) {
+	hook, err := cfg.DB.GetWebhook(r.Context(), database.GetWebhookParams{
+		ID:     id,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get webhook", err)
+		return database.Webhook{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get webhook", err)
+		return database.Webhook{}, false
+	}
+	return hook, true
+}
+
+// validateWebhookURL checks that s is an absolute http or https URL.
+// Whether its host is on a private network is checked as each delivery
+// connects, since the name can resolve differently later.
+func validateWebhookURL(v *validate.Validator, s string) {
+	v.Required("url", s)
+	v.Check(len(s) <= webhookURLMaxLength, "url", fmt.Sprintf("must be at most %d characters", webhookURLMaxLength))
+	u, err := url.Parse(s)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil,
+		"url", "must be an http or https URL without credentials")
+}
+
+// validateWebhookEvents checks that each of events is a webhook event
+// type, listed once.
+func validateWebhookEvents(v *validate.Validator, events []string) {
+	for i, e := range events {
+		field := fmt.Sprintf("events.%d", i)
+		v.OneOf(field, e, webhookEventTypes...)
+		v.Check(!slices.Contains(events[:i], e), field, "is listed twice")
+	}
+}
+
+// webhookEvents splits a webhook's stored event types.
+func webhookEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+	return strings.Split(events, ",")
+}
+
+// webhookWants reports whether a webhook receives events of eventType.
+func webhookWants(hook database.Webhook, eventType string) bool {
+	return hook.Active && (hook.Events == "" || slices.Contains(webhookEvents(hook.Events), eventType))
+}
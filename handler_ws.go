@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// handlerWebSocket upgrades to a WebSocket on which the user receives an
+// event, such as {"type":"note.updated","data":{...}}, whenever one of
+// their notes changes, so open clients stay in sync without polling. The
+// client isn't expected to send anything. Events aren't replayed, so a
+// client should refetch its notes whenever it (re)connects; the same goes
+// after an import, which sends no events. A client that falls behind is
+// disconnected with status 1013 (try again later). Browsers can connect
+// with the session cookie, from this server's origin only. handlerEvents
+// streams the same events to clients that can't use WebSockets.
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request, user database.User) {
+	// The connection outlives the server's read and write timeouts.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logf(r.Context(), "Couldn't clear read deadline for WebSocket: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logf(r.Context(), "Couldn't clear write deadline for WebSocket: %v", err)
+	}
+
+	sub := cfg.Events.Subscribe(user.ID)
+	defer sub.Close()
+
+	// Accept responds to requests it rejects.
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		logf(r.Context(), "Couldn't accept WebSocket: %v", err)
+		return
+	}
+	defer conn.Close(websocket.StatusInternalError, "")
+
+	version := apiversion.Find(apiVersions, w.Header().Get(apiversion.Header))
+	ctx := conn.CloseRead(r.Context())
+	ping := time.NewTicker(eventPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				status, reason := websocket.StatusGoingAway, "Server shutting down"
+				if errors.Is(sub.Err(), pubsub.ErrSlow) {
+					status, reason = websocket.StatusTryAgainLater, "Fell behind on events"
+				}
+				if err := conn.Close(status, reason); err != nil {
+					logf(r.Context(), "Couldn't close WebSocket: %v", err)
+				}
+				return
+			}
+			writeCtx, cancel := context.WithTimeout(ctx, eventWriteTimeout)
+			err := wsjson.Write(writeCtx, conn, version.MapPayload(m.Event))
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, eventWriteTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
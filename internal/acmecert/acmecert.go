@@ -0,0 +1,394 @@
+// Package acmecert gets TLS certificates from an ACME certificate authority
+// such as Let's Encrypt and renews them before they expire, so the API can
+// serve HTTPS itself without a reverse proxy in front. Domains are proved
+// with tls-alpn-01 challenges, answered on the server's own TLS listener.
+// It does what the API needs from x/crypto/acme/autocert, which can't be
+// used here because it depends on x/net.
+package acmecert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	// LetsEncryptURL is the directory of Let's Encrypt's production CA.
+	LetsEncryptURL = acme.LetsEncryptURL
+
+	// ALPNProto is the protocol tls-alpn-01 challenges are sent with. The
+	// server's TLS config must offer it.
+	ALPNProto = acme.ALPNProto
+
+	// renewBefore is how long before expiry a certificate is renewed. Let's
+	// Encrypt certificates last 90 days.
+	renewBefore = 30 * 24 * time.Hour
+
+	// issueTimeout bounds getting one certificate, challenges included.
+	issueTimeout = 5 * time.Minute
+
+	accountKeyFile = "acme_account.key"
+)
+
+// Config says which certificates to get. Only names in Domains get one, so
+// a client can't make the server request certificates for arbitrary names.
+// Certificates and the ACME account key are kept in CacheDir, which should
+// survive restarts: Let's Encrypt limits how often the same certificate is
+// issued.
+type Config struct {
+	Domains      []string
+	Email        string
+	CacheDir     string
+	DirectoryURL string
+}
+
+// Manager hands out certificates through GetCertificate, getting them on
+// first use and renewing them in the background once they're close to
+// expiring.
+type Manager struct {
+	cfg     Config
+	domains map[string]bool
+	client  *acme.Client
+
+	// issueMu is held while getting a certificate, so concurrent
+	// handshakes don't place an order each.
+	issueMu    sync.Mutex
+	registered bool
+
+	mu         sync.Mutex
+	certs      map[string]*tls.Certificate
+	challenges map[string]*tls.Certificate
+	renewing   map[string]bool
+}
+
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("at least one domain is needed")
+	}
+	if cfg.DirectoryURL == "" {
+		cfg.DirectoryURL = LetsEncryptURL
+	}
+	if err := os.MkdirAll(cfg.CacheDir, 0o700); err != nil {
+		return nil, err
+	}
+	key, err := loadOrCreateKey(filepath.Join(cfg.CacheDir, accountKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load ACME account key: %w", err)
+	}
+
+	m := &Manager{
+		cfg:        cfg,
+		domains:    map[string]bool{},
+		client:     &acme.Client{Key: key, DirectoryURL: cfg.DirectoryURL, UserAgent: "notely"},
+		certs:      map[string]*tls.Certificate{},
+		challenges: map[string]*tls.Certificate{},
+		renewing:   map[string]bool{},
+	}
+	for _, domain := range cfg.Domains {
+		domain = normalize(domain)
+		m.domains[domain] = true
+		cert, err := m.load(domain)
+		if err != nil {
+			log.Printf("Couldn't load cached certificate for %s: %v", domain, err)
+			continue
+		}
+		if cert != nil {
+			m.certs[domain] = cert
+		}
+	}
+	return m, nil
+}
+
+// GetCertificate is for tls.Config. It answers tls-alpn-01 challenges and
+// otherwise returns the certificate for the requested name, getting one
+// first if there isn't a usable one yet.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := normalize(hello.ServerName)
+	if name == "" {
+		return nil, errors.New("acmecert: client didn't send a server name")
+	}
+	if !m.domains[name] {
+		return nil, fmt.Errorf("acmecert: %q isn't a configured domain", name)
+	}
+
+	m.mu.Lock()
+	if isChallenge(hello) {
+		cert := m.challenges[name]
+		m.mu.Unlock()
+		if cert == nil {
+			return nil, fmt.Errorf("acmecert: no challenge pending for %q", name)
+		}
+		return cert, nil
+	}
+	cert := m.certs[name]
+	if cert != nil && needsRenewal(cert, time.Now()) && !m.renewing[name] {
+		m.renewing[name] = true
+		go m.renew(name)
+	}
+	m.mu.Unlock()
+
+	if cert != nil && time.Now().Before(cert.Leaf.NotAfter) {
+		return cert, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), issueTimeout)
+	defer cancel()
+	return m.issue(ctx, name)
+}
+
+func (m *Manager) renew(name string) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.renewing, name)
+		m.mu.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), issueTimeout)
+	defer cancel()
+	if _, err := m.issue(ctx, name); err != nil {
+		log.Printf("Couldn't renew certificate for %s: %v", name, err)
+		return
+	}
+	log.Printf("Renewed certificate for %s", name)
+}
+
+// issue gets a new certificate for name, unless another caller got one
+// while this one waited.
+func (m *Manager) issue(ctx context.Context, name string) (*tls.Certificate, error) {
+	m.issueMu.Lock()
+	defer m.issueMu.Unlock()
+
+	m.mu.Lock()
+	cert := m.certs[name]
+	m.mu.Unlock()
+	if cert != nil && !needsRenewal(cert, time.Now()) {
+		return cert, nil
+	}
+
+	if !m.registered {
+		var contact []string
+		if m.cfg.Email != "" {
+			contact = []string{"mailto:" + m.cfg.Email}
+		}
+		_, err := m.client.Register(ctx, &acme.Account{Contact: contact}, acme.AcceptTOS)
+		if err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+			return nil, fmt.Errorf("couldn't register ACME account: %w", err)
+		}
+		m.registered = true
+	}
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range order.AuthzURLs {
+		if err := m.authorize(ctx, name, url); err != nil {
+			return nil, err
+		}
+	}
+	order, err = m.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: name},
+		DNSNames: []string{name},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, err
+	}
+	cert = &tls.Certificate{Certificate: der, PrivateKey: key, Leaf: leaf}
+
+	if err := m.save(name, cert); err != nil {
+		log.Printf("Couldn't cache certificate for %s: %v", name, err)
+	}
+	m.mu.Lock()
+	m.certs[name] = cert
+	m.mu.Unlock()
+	return cert, nil
+}
+
+// authorize proves control of name for the authorization at url, unless
+// the CA already considers it proved.
+func (m *Manager) authorize(ctx context.Context, name, url string) error {
+	authz, err := m.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "tls-alpn-01" {
+			chal = c
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("CA offered no tls-alpn-01 challenge for %s", name)
+	}
+
+	cert, err := m.client.TLSALPN01ChallengeCert(chal.Token, name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.challenges[name] = &cert
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.challenges, name)
+		m.mu.Unlock()
+	}()
+
+	if _, err := m.client.Accept(ctx, chal); err != nil {
+		return err
+	}
+	_, err = m.client.WaitAuthorization(ctx, authz.URI)
+	return err
+}
+
+func isChallenge(hello *tls.ClientHelloInfo) bool {
+	return len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == ALPNProto
+}
+
+func needsRenewal(cert *tls.Certificate, now time.Time) bool {
+	return cert.Leaf.NotAfter.Sub(now) < renewBefore
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// load reads name's certificate from the cache. It returns nil if there is
+// none or it has expired.
+func (m *Manager) load(name string) (*tls.Certificate, error) {
+	data, err := os.ReadFile(filepath.Join(m.cfg.CacheDir, name)) // #nosec G304 -- name is one of the configured domains
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The file holds the key and the chain; each side skips the other's
+	// blocks.
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	if !time.Now().Before(cert.Leaf.NotAfter) {
+		return nil, nil
+	}
+	return &cert, nil
+}
+
+// save writes name's key and certificate chain to the cache as PEM.
+func (m *Manager) save(name string, cert *tls.Certificate) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	for _, der := range cert.Certificate {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	return writeFile(filepath.Join(m.cfg.CacheDir, name), data)
+}
+
+func loadOrCreateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is in the operator's cache dir
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no PEM block in " + path)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("account key in " + path + " isn't ECDSA")
+		}
+		return ecKey, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFile writes data to path privately, through a temporary file so a
+// crash never leaves a partial key behind.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Configure sets cfg up to serve the manager's certificates. Challenge
+// handshakes get a config of their own, so client certificate settings in
+// cfg can't turn the CA away.
+func (m *Manager) Configure(cfg *tls.Config) {
+	cfg.GetCertificate = m.GetCertificate
+	cfg.NextProtos = []string{"h2", "http/1.1", ALPNProto}
+	cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if !isChallenge(hello) {
+			return nil, nil
+		}
+		return &tls.Config{
+			GetCertificate: m.GetCertificate,
+			NextProtos:     []string{ALPNProto},
+			MinVersion:     tls.VersionTLS12,
+		}, nil
+	}
+}
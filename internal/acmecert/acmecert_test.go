@@ -0,0 +1,173 @@
+package acmecert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testCert(t *testing.T, name string, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestGetCertificate(t *testing.T) {
+	m, err := NewManager(Config{Domains: []string{"Notes.Example.com."}, CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := testCert(t, "notes.example.com", time.Now().Add(60*24*time.Hour))
+	challenge := testCert(t, "notes.example.com", time.Now().Add(time.Hour))
+	m.certs["notes.example.com"] = cert
+	m.challenges["notes.example.com"] = challenge
+
+	tests := map[string]struct {
+		description string
+		hello       *tls.ClientHelloInfo
+		expected    *tls.Certificate
+		wantErr     bool
+	}{
+		"configured domain": {
+			description: "Names are matched without case or a trailing dot",
+			hello:       &tls.ClientHelloInfo{ServerName: "NOTES.example.com"},
+			expected:    cert,
+		},
+		"challenge": {
+			description: "A tls-alpn-01 handshake gets the challenge certificate",
+			hello:       &tls.ClientHelloInfo{ServerName: "notes.example.com", SupportedProtos: []string{ALPNProto}},
+			expected:    challenge,
+		},
+		"other domain": {
+			description: "Names that weren't configured are refused",
+			hello:       &tls.ClientHelloInfo{ServerName: "evil.example.com"},
+			wantErr:     true,
+		},
+		"no server name": {
+			description: "Clients connecting by IP address are refused",
+			hello:       &tls.ClientHelloInfo{},
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := m.GetCertificate(tc.hello)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected certificate for %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(Config{Domains: []string{"notes.example.com"}, CacheDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := testCert(t, "notes.example.com", time.Now().Add(60*24*time.Hour))
+	if err := m.save("notes.example.com", cert); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewManager(Config{Domains: []string{"notes.example.com"}, CacheDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reopened.certs["notes.example.com"]
+	if got == nil {
+		t.Fatal("expected the cached certificate to be loaded")
+	}
+	if diff := cmp.Diff(cert.Certificate, got.Certificate); diff != "" {
+		t.Errorf("certificate mismatch (-want +got):\n%s", diff)
+	}
+	if !reopened.client.Key.(*ecdsa.PrivateKey).Equal(m.client.Key) {
+		t.Error("expected the account key to be reused")
+	}
+}
+
+func TestNeedsRenewal(t *testing.T) {
+	now := time.Now()
+	tests := map[string]struct {
+		description string
+		notAfter    time.Time
+		expected    bool
+	}{
+		"fresh": {
+			description: "A certificate with 60 days left is kept",
+			notAfter:    now.Add(60 * 24 * time.Hour),
+		},
+		"expiring": {
+			description: "A certificate with 10 days left is renewed",
+			notAfter:    now.Add(10 * 24 * time.Hour),
+			expected:    true,
+		},
+		"expired": {
+			description: "An expired certificate is renewed",
+			notAfter:    now.Add(-time.Hour),
+			expected:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got := needsRenewal(testCert(t, "notes.example.com", tc.notAfter), now)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("needsRenewal mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	m, err := NewManager(Config{Domains: []string{"notes.example.com"}, CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12}
+	m.Configure(cfg)
+
+	got, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{"h2", "http/1.1"}})
+	if err != nil || got != nil {
+		t.Errorf("expected ordinary handshakes to use the base config, got %v, %v", got, err)
+	}
+	got, err = cfg.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{ALPNProto}})
+	if err != nil || got == nil {
+		t.Fatalf("expected a challenge config, got %v, %v", got, err)
+	}
+	if diff := cmp.Diff(tls.NoClientCert, got.ClientAuth); diff != "" {
+		t.Errorf("challenge client auth mismatch (-want +got):\n%s", diff)
+	}
+}
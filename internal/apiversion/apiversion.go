@@ -0,0 +1,172 @@
+// Package apiversion serves several versions of an API side by side from
+// one set of handlers.
+//
+// Handlers read requests and build responses in the latest version's
+// shape. Each older version translates with mappers, added with
+// MapRequest and MapResponse, so a breaking change touches the handler
+// and one mapper per older version instead of a copy of every route.
+package apiversion
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// Header names the version that served a response. Every response from a
+// mounted version carries it, which is how MapPayload's callers, holding
+// only the ResponseWriter, find the version.
+const Header = "API-Version"
+
+// Version is one version of the API, served under /{Name}.
+type Version struct {
+	Name string
+	// DeprecatedAt, once set, is sent in a Deprecation header (RFC 9745),
+	// along with a Link to the same path under Successor.
+	DeprecatedAt time.Time
+	Successor    string
+	// Sunset, once set, is sent in a Sunset header (RFC 8594) as the time
+	// the version stops being served.
+	Sunset time.Time
+
+	requests  map[string]func(body []byte) ([]byte, error)
+	responses map[reflect.Type]func(any) any
+}
+
+// MapRequest has v rewrite the body of requests to route, a method and
+// pattern such as "POST /notes", into the latest version's shape before the
+// handler reads it. Call it before Mount. The body is read whole, so routes
+// taking large uploads shouldn't be mapped.
+func MapRequest(v *Version, route string, f func(body []byte) ([]byte, error)) {
+	if v.requests == nil {
+		v.requests = map[string]func([]byte) ([]byte, error){}
+	}
+	v.requests[route] = f
+}
+
+// MapResponse has v send payloads of type T, or slices of them, as f maps
+// them. Only the payload given to MapPayload is matched, not values nested
+// inside it.
+func MapResponse[T, U any](v *Version, f func(T) U) {
+	if v.responses == nil {
+		v.responses = map[reflect.Type]func(any) any{}
+	}
+	v.responses[reflect.TypeFor[T]()] = func(payload any) any {
+		return f(payload.(T))
+	}
+	v.responses[reflect.TypeFor[[]T]()] = func(payload any) any {
+		items := payload.([]T)
+		mapped := make([]U, len(items))
+		for i, item := range items {
+			mapped[i] = f(item)
+		}
+		return mapped
+	}
+}
+
+// Find returns the version in versions called name, or nil.
+func Find(versions []*Version, name string) *Version {
+	for _, v := range versions {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// MapPayload converts a response payload into v's shape, if v has a mapper
+// for its type. A nil v returns payload as it is.
+func (v *Version) MapPayload(payload any) any {
+	if v == nil || payload == nil {
+		return payload
+	}
+	if f, ok := v.responses[reflect.TypeOf(payload)]; ok {
+		return f(payload)
+	}
+	return payload
+}
+
+// Mount serves each version under /{Name} with the routes added by routes.
+// badRequest answers requests whose body a request mapper couldn't read or
+// rewrite.
+func Mount(router chi.Router, versions []*Version, routes func(chi.Router), badRequest func(http.ResponseWriter, error)) {
+	for _, v := range versions {
+		r := chi.NewRouter()
+		r.Use(v.middleware)
+		routes(versionRouter{Router: r, version: v, badRequest: badRequest})
+		router.Mount("/"+v.Name, r)
+	}
+}
+
+// middleware labels responses with the version and, once it's deprecated,
+// tells clients so and where to move to.
+func (v *Version) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set(Header, v.Name)
+		if !v.DeprecatedAt.IsZero() {
+			h.Set("Deprecation", "@"+strconv.FormatInt(v.DeprecatedAt.Unix(), 10))
+			if v.Successor != "" {
+				successor := "/" + v.Successor + strings.TrimPrefix(r.URL.Path, "/"+v.Name)
+				h.Add("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		if !v.Sunset.IsZero() {
+			h.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// versionRouter adds routes to a version's router, putting the version's
+// request mapper, if it has one for the route, in front of the handler.
+type versionRouter struct {
+	chi.Router
+	version    *Version
+	badRequest func(http.ResponseWriter, error)
+}
+
+func (r versionRouter) Get(pattern string, h http.HandlerFunc) {
+	r.Router.Get(pattern, r.mapRequests(http.MethodGet, pattern, h))
+}
+
+func (r versionRouter) Post(pattern string, h http.HandlerFunc) {
+	r.Router.Post(pattern, r.mapRequests(http.MethodPost, pattern, h))
+}
+
+func (r versionRouter) Put(pattern string, h http.HandlerFunc) {
+	r.Router.Put(pattern, r.mapRequests(http.MethodPut, pattern, h))
+}
+
+func (r versionRouter) Patch(pattern string, h http.HandlerFunc) {
+	r.Router.Patch(pattern, r.mapRequests(http.MethodPatch, pattern, h))
+}
+
+func (r versionRouter) Delete(pattern string, h http.HandlerFunc) {
+	r.Router.Delete(pattern, r.mapRequests(http.MethodDelete, pattern, h))
+}
+
+func (r versionRouter) mapRequests(method, pattern string, next http.HandlerFunc) http.HandlerFunc {
+	f, ok := r.version.requests[method+" "+pattern]
+	if !ok {
+		return next
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err == nil {
+			body, err = f(body)
+		}
+		if err != nil {
+			r.badRequest(w, err)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, req)
+	}
+}
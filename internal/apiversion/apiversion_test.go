@@ -0,0 +1,166 @@
+package apiversion
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMount(t *testing.T) {
+	v1 := &Version{
+		Name:         "v1",
+		DeprecatedAt: time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+		Successor:    "v2",
+		Sunset:       time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC),
+	}
+	v2 := &Version{Name: "v2"}
+	MapRequest(v1, "POST /notes", func(body []byte) ([]byte, error) {
+		if string(body) == "bad" {
+			return nil, errors.New("bad body")
+		}
+		return bytes.ReplaceAll(body, []byte("text"), []byte("note")), nil
+	})
+
+	router := chi.NewRouter()
+	Mount(router, []*Version{v1, v2}, func(r chi.Router) {
+		r.Post("/notes", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		})
+	}, func(w http.ResponseWriter, err error) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	})
+
+	type response struct {
+		Status      int
+		Body        string
+		Version     string
+		Deprecation string
+		Link        string
+		Sunset      string
+	}
+	tests := map[string]struct {
+		description string
+		path        string
+		body        string
+		expected    response
+	}{
+		"deprecated": {
+			description: "A deprecated version says so, links to its successor and maps the request",
+			path:        "/v1/notes",
+			body:        `{"text":"hi"}`,
+			expected: response{
+				Status:      http.StatusOK,
+				Body:        `{"note":"hi"}`,
+				Version:     "v1",
+				Deprecation: "@1792022400",
+				Link:        `</v2/notes>; rel="successor-version"`,
+				Sunset:      "Thu, 01 Apr 2027 00:00:00 GMT",
+			},
+		},
+		"latest": {
+			description: "The latest version passes the request through untouched",
+			path:        "/v2/notes",
+			body:        `{"text":"hi"}`,
+			expected: response{
+				Status:  http.StatusOK,
+				Body:    `{"text":"hi"}`,
+				Version: "v2",
+			},
+		},
+		"unmappable": {
+			description: "A body the mapper rejects is answered by badRequest",
+			path:        "/v1/notes",
+			body:        "bad",
+			expected: response{
+				Status:      http.StatusBadRequest,
+				Body:        "bad body\n",
+				Version:     "v1",
+				Deprecation: "@1792022400",
+				Link:        `</v2/notes>; rel="successor-version"`,
+				Sunset:      "Thu, 01 Apr 2027 00:00:00 GMT",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body)))
+			got := response{
+				Status:      rec.Code,
+				Body:        rec.Body.String(),
+				Version:     rec.Header().Get(Header),
+				Deprecation: rec.Header().Get("Deprecation"),
+				Link:        rec.Header().Get("Link"),
+				Sunset:      rec.Header().Get("Sunset"),
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMapPayload(t *testing.T) {
+	type note struct{ Text string }
+	type oldNote struct{ Note string }
+	v1 := &Version{Name: "v1"}
+	MapResponse(v1, func(n note) oldNote { return oldNote{Note: n.Text} })
+	versions := []*Version{v1, {Name: "v2"}}
+
+	tests := map[string]struct {
+		description string
+		version     string
+		payload     any
+		expected    any
+	}{
+		"mapped": {
+			description: "A payload with a mapper is converted",
+			version:     "v1",
+			payload:     note{Text: "hi"},
+			expected:    oldNote{Note: "hi"},
+		},
+		"slice": {
+			description: "Slices of a mapped type are converted item by item",
+			version:     "v1",
+			payload:     []note{{Text: "a"}, {Text: "b"}},
+			expected:    []oldNote{{Note: "a"}, {Note: "b"}},
+		},
+		"other type": {
+			description: "Payloads of other types are left alone",
+			version:     "v1",
+			payload:     map[string]string{"error": "nope"},
+			expected:    map[string]string{"error": "nope"},
+		},
+		"latest": {
+			description: "A version without mappers sends the payload as it is",
+			version:     "v2",
+			payload:     note{Text: "hi"},
+			expected:    note{Text: "hi"},
+		},
+		"unversioned": {
+			description: "Responses outside any version are left alone",
+			payload:     note{Text: "hi"},
+			expected:    note{Text: "hi"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got := Find(versions, tc.version).MapPayload(tc.payload)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("payload mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/uuid"
+)
+
+// Attempt is a single authentication attempt.
+type Attempt struct {
+	At               time.Time
+	UserID           string
+	CredentialPrefix string
+	IP               string
+	UserAgent        string
+	Success          bool
+	Reason           string
+}
+
+type Store interface {
+	CreateAuthAuditEntry(ctx context.Context, arg database.CreateAuthAuditEntryParams) error
+}
+
+// Logger writes attempts to Store from a background goroutine so that
+// authentication never waits on the audit insert. Attempts are dropped, with
+// a log line, when the buffer is full.
+type Logger struct {
+	store    Store
+	attempts chan Attempt
+	done     chan struct{}
+}
+
+func NewLogger(store Store, buffer int) *Logger {
+	l := &Logger{
+		store:    store,
+		attempts: make(chan Attempt, buffer),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Record queues an attempt without blocking.
+func (l *Logger) Record(a Attempt) {
+	select {
+	case l.attempts <- a:
+	default:
+		log.Printf("audit buffer full, dropping attempt from %s", a.IP)
+	}
+}
+
+// Close stops accepting attempts and waits for queued ones to be written.
+func (l *Logger) Close() {
+	close(l.attempts)
+	<-l.done
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+	for a := range l.attempts {
+		if err := l.store.CreateAuthAuditEntry(context.Background(), entryParams(a)); err != nil {
+			log.Printf("Couldn't write auth audit entry: %v", err)
+		}
+	}
+}
+
+func entryParams(a Attempt) database.CreateAuthAuditEntryParams {
+	return database.CreateAuthAuditEntryParams{
+		ID:               uuid.New().String(),
+		CreatedAt:        a.At.UTC().Format(time.RFC3339),
+		UserID:           sql.NullString{String: a.UserID, Valid: a.UserID != ""},
+		CredentialPrefix: a.CredentialPrefix,
+		Ip:               a.IP,
+		UserAgent:        a.UserAgent,
+		Success:          a.Success,
+		Reason:           a.Reason,
+	}
+}
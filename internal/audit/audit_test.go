@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	entries []database.CreateAuthAuditEntryParams
+}
+
+func (s *fakeStore) CreateAuthAuditEntry(ctx context.Context, arg database.CreateAuthAuditEntryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, arg)
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("", 3600))
+	tests := map[string]struct {
+		description string
+		attempt     Attempt
+		expected    database.CreateAuthAuditEntryParams
+	}{
+		"success": {
+			description: "Successful attempt records the user",
+			attempt: Attempt{
+				At:               at,
+				UserID:           "user-1",
+				CredentialPrefix: "ntly_live_abcd",
+				IP:               "203.0.113.7",
+				UserAgent:        "curl/8.0",
+				Success:          true,
+			},
+			expected: database.CreateAuthAuditEntryParams{
+				CreatedAt:        "2024-03-01T11:00:00Z",
+				UserID:           sql.NullString{String: "user-1", Valid: true},
+				CredentialPrefix: "ntly_live_abcd",
+				Ip:               "203.0.113.7",
+				UserAgent:        "curl/8.0",
+				Success:          true,
+			},
+		},
+		"failure": {
+			description: "Failed attempt has no user and keeps the reason",
+			attempt: Attempt{
+				At:     at,
+				IP:     "203.0.113.7",
+				Reason: "Couldn't get user",
+			},
+			expected: database.CreateAuthAuditEntryParams{
+				CreatedAt: "2024-03-01T11:00:00Z",
+				Ip:        "203.0.113.7",
+				Reason:    "Couldn't get user",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeStore{}
+			l := NewLogger(store, 1)
+			l.Record(tc.attempt)
+			l.Close()
+
+			if len(store.entries) != 1 {
+				t.Fatalf("expected 1 entry, got %d", len(store.entries))
+			}
+			got := store.entries[0]
+			if got.ID == "" {
+				t.Errorf("expected an entry ID")
+			}
+			tc.expected.ID = got.ID
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("entry mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
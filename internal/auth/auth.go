@@ -6,24 +6,68 @@ import (
 	"strings"
 )
 
-var ErrNoAuthHeaderIncluded = errors.New("no authorization header included")
+var (
+	ErrNoAuthHeaderIncluded = errors.New("no authorization header included")
+	ErrMalformedAuthHeader  = errors.New("malformed authorization header")
+)
+
+// MalformedHeaderError carries the Authorization header value that could not
+// be parsed. It unwraps to ErrMalformedAuthHeader.
+type MalformedHeaderError struct {
+	Header string
+}
+
+func (e *MalformedHeaderError) Error() string {
+	return ErrMalformedAuthHeader.Error()
+}
+
+func (e *MalformedHeaderError) Unwrap() error {
+	return ErrMalformedAuthHeader
+}
 
 // GetAPIKey -
 func GetAPIKey(headers http.Header) (string, error) {
+	return getCredential(headers, "ApiKey")
+}
+
+// GetBearerToken -
+func GetBearerToken(headers http.Header) (string, error) {
+	return getCredential(headers, "Bearer")
+}
+
+// NormalizeAuthorization rewrites a loosely formatted Authorization header
+// into the canonical "<Scheme> <credential>" form, matching the scheme
+// case-insensitively against schemes and collapsing surrounding whitespace.
+// Headers that don't fit that shape are left alone for strict parsing to
+// reject.
+func NormalizeAuthorization(headers http.Header, schemes ...string) {
+	fields := strings.Fields(headers.Get("Authorization"))
+	if len(fields) != 2 {
+		return
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(fields[0], scheme) {
+			headers.Set("Authorization", scheme+" "+fields[1])
+			return
+		}
+	}
+}
+
+func getCredential(headers http.Header, scheme string) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
 		return "", ErrNoAuthHeaderIncluded
 	}
 	splitAuth := strings.Split(authHeader, " ")
-	if len(splitAuth) < 2 || splitAuth[0] != "ApiKey" {
-		return "", errors.New("malformed authorization header")
+	if len(splitAuth) < 2 || splitAuth[0] != scheme {
+		return "", &MalformedHeaderError{Header: authHeader}
 	}
 
-	// Check if the API key part is empty or just whitespace
-	apiKey := strings.TrimSpace(splitAuth[1])
-	if apiKey == "" {
-		return "", errors.New("malformed authorization header")
+	// Check if the credential part is empty or just whitespace
+	credential := strings.TrimSpace(splitAuth[1])
+	if credential == "" {
+		return "", &MalformedHeaderError{Header: authHeader}
 	}
 
-	return apiKey, nil
+	return credential, nil
 }
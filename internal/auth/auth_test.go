@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 
@@ -118,3 +119,192 @@ func TestGetAPIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBearerToken(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		headers     http.Header
+		wantToken   string
+		wantErr     string
+	}{
+		"success/basic_token": {
+			description: "should extract token from well-formed Authorization header",
+			headers:     http.Header{"Authorization": []string{"Bearer token123"}},
+			wantToken:   "token123",
+			wantErr:     "",
+		},
+		"success/jwt_token": {
+			description: "should handle JWT-style tokens",
+			headers:     http.Header{"Authorization": []string{"Bearer eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig"}},
+			wantToken:   "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig",
+			wantErr:     "",
+		},
+		"error/missing_header": {
+			description: "should return specific error when Authorization header is missing",
+			headers:     http.Header{},
+			wantToken:   "",
+			wantErr:     "no authorization header included",
+		},
+		"error/wrong_scheme": {
+			description: "should reject ApiKey credentials",
+			headers:     http.Header{"Authorization": []string{"ApiKey secret123"}},
+			wantToken:   "",
+			wantErr:     "malformed authorization header",
+		},
+		"error/wrong_scheme_case": {
+			description: "should reject case variations of Bearer",
+			headers:     http.Header{"Authorization": []string{"bearer token123"}},
+			wantToken:   "",
+			wantErr:     "malformed authorization header",
+		},
+		"error/only_scheme": {
+			description: "should reject header with only scheme and no token",
+			headers:     http.Header{"Authorization": []string{"Bearer"}},
+			wantToken:   "",
+			wantErr:     "malformed authorization header",
+		},
+		"error/only_scheme_with_space": {
+			description: "should reject header with scheme and space but no token",
+			headers:     http.Header{"Authorization": []string{"Bearer "}},
+			wantToken:   "",
+			wantErr:     "malformed authorization header",
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			gotToken, gotErr := GetBearerToken(tc.headers)
+
+			if tc.wantErr != "" {
+				if gotErr == nil {
+					t.Fatalf("GetBearerToken() expected error %q, got nil", tc.wantErr)
+				}
+				if diff := cmp.Diff(tc.wantErr, gotErr.Error()); diff != "" {
+					t.Fatalf("GetBearerToken() error mismatch (-want +got):\n%s", diff)
+				}
+			} else {
+				if gotErr != nil {
+					t.Fatalf("GetBearerToken() unexpected error: %v", gotErr)
+				}
+			}
+
+			if diff := cmp.Diff(tc.wantToken, gotToken); diff != "" {
+				t.Errorf("GetBearerToken() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyErrors(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		headers     http.Header
+		wantIs      error
+		wantHeader  string
+	}{
+		"missing_header": {
+			description: "should match ErrNoAuthHeaderIncluded",
+			headers:     http.Header{},
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+		"wrong_scheme": {
+			description: "should match ErrMalformedAuthHeader and carry the header value",
+			headers:     http.Header{"Authorization": []string{"Bearer token123"}},
+			wantIs:      ErrMalformedAuthHeader,
+			wantHeader:  "Bearer token123",
+		},
+		"only_scheme": {
+			description: "should match ErrMalformedAuthHeader and carry the header value",
+			headers:     http.Header{"Authorization": []string{"ApiKey "}},
+			wantIs:      ErrMalformedAuthHeader,
+			wantHeader:  "ApiKey ",
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			_, gotErr := GetAPIKey(tc.headers)
+
+			if !errors.Is(gotErr, tc.wantIs) {
+				t.Fatalf("GetAPIKey() error = %v, want errors.Is %v", gotErr, tc.wantIs)
+			}
+
+			var malformedErr *MalformedHeaderError
+			if tc.wantHeader == "" {
+				if errors.As(gotErr, &malformedErr) {
+					t.Fatalf("GetAPIKey() unexpected MalformedHeaderError: %v", gotErr)
+				}
+				return
+			}
+			if !errors.As(gotErr, &malformedErr) {
+				t.Fatalf("GetAPIKey() error = %v, want MalformedHeaderError", gotErr)
+			}
+			if diff := cmp.Diff(tc.wantHeader, malformedErr.Header); diff != "" {
+				t.Errorf("MalformedHeaderError.Header mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeAuthorization(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		header      string
+		want        string
+	}{
+		"canonical": {
+			description: "should leave a well-formed header unchanged",
+			header:      "ApiKey secret123",
+			want:        "ApiKey secret123",
+		},
+		"lowercase_scheme": {
+			description: "should match the scheme case-insensitively",
+			header:      "apikey secret123",
+			want:        "ApiKey secret123",
+		},
+		"uppercase_bearer": {
+			description: "should canonicalize Bearer too",
+			header:      "BEARER token123",
+			want:        "Bearer token123",
+		},
+		"extra_whitespace": {
+			description: "should collapse repeated and surrounding whitespace",
+			header:      "  ApiKey \t  secret123 ",
+			want:        "ApiKey secret123",
+		},
+		"unknown_scheme": {
+			description: "should leave unknown schemes alone",
+			header:      "basic dXNlcjpwYXNz",
+			want:        "basic dXNlcjpwYXNz",
+		},
+		"extra_fields": {
+			description: "should leave headers with more than one credential alone",
+			header:      "apikey secret123 other",
+			want:        "apikey secret123 other",
+		},
+		"missing": {
+			description: "should not add a header that wasn't sent",
+			header:      "",
+			want:        "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			headers := http.Header{}
+			if tc.header != "" {
+				headers.Set("Authorization", tc.header)
+			}
+			NormalizeAuthorization(headers, "ApiKey", "Bearer")
+
+			if diff := cmp.Diff(tc.want, headers.Get("Authorization")); diff != "" {
+				t.Errorf("NormalizeAuthorization() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAPIKeyExpired      = errors.New("api key expired")
+)
+
+// Authenticator resolves the user making a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (database.User, error)
+}
+
+// UserStore looks up users by API key. *database.Queries satisfies it.
+type UserStore interface {
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	GetUserByLegacyAPIKey(ctx context.Context, apiKey string) (database.User, error)
+	legacyKeyUpgrader
+}
+
+// APIKeyAuthenticator authenticates requests carrying an
+// "Authorization: ApiKey <key>" header, or a key in one of the extra Sources.
+// Keys in Denylist are rejected before the store is consulted.
+type APIKeyAuthenticator struct {
+	Store    UserStore
+	Sources  APIKeySources
+	Denylist *Denylist
+}
+
+func (a APIKeyAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	apiKey, err := a.Sources.Extract(r)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	user, err := a.lookup(r.Context(), apiKey, requestClientIP(r))
+	if errors.Is(err, ErrAPIKeyExpired) || errors.Is(err, ErrIPNotAllowed) {
+		return database.User{}, err
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	return user, nil
+}
+
+func (a APIKeyAuthenticator) lookup(ctx context.Context, apiKey, clientIP string) (database.User, error) {
+	if a.Denylist.Contains(HashAPIKey(apiKey)) {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	key, err := a.Store.GetActiveAPIKeyByHash(ctx, HashAPIKey(apiKey))
+	if err == nil {
+		if !VerifyAPIKey(apiKey, key.KeyHash) {
+			return database.User{}, sql.ErrNoRows
+		}
+		if KeyExpired(key, time.Now()) {
+			return database.User{}, ErrAPIKeyExpired
+		}
+		if !IPAllowed(key.AllowedCidrs, clientIP) {
+			return database.User{}, ErrIPNotAllowed
+		}
+		return a.Store.GetUserByID(ctx, key.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	// Keys written before hashing at rest was introduced are still stored in
+	// plaintext; accept them once and hash them in place.
+	user, err := a.Store.GetUserByLegacyAPIKey(ctx, apiKey)
+	if err != nil {
+		return database.User{}, err
+	}
+	if !VerifyKey(apiKey, user.ApiKey) {
+		return database.User{}, sql.ErrNoRows
+	}
+	// A failed upgrade is retried by HashLegacyAPIKeys on the next startup.
+	_ = upgradeLegacyKey(ctx, a.Store, user.ID, apiKey)
+	return user, nil
+}
+
+// requestClientIP prefers the AuthContext stored by the auth middleware,
+// whose client IP honours the server's proxy settings.
+func requestClientIP(r *http.Request) string {
+	if ac, ok := AuthContextFrom(r.Context()); ok {
+		return ac.ClientIP
+	}
+	return ClientIP(r, false)
+}
+
+// UserByIDStore looks up users by ID. *database.Queries satisfies it.
+type UserByIDStore interface {
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+}
+
+// JWTAuthenticator authenticates requests carrying an
+// "Authorization: Bearer <jwt>" header signed with Secret.
+type JWTAuthenticator struct {
+	Store  UserByIDStore
+	Secret string
+}
+
+func (a JWTAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	token, err := GetBearerToken(r.Header)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	userID, err := ValidateJWT(token, a.Secret)
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	user, err := a.Store.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	return user, nil
+}
+
+// SchemeAuthenticator dispatches to an Authenticator based on the scheme of
+// the Authorization header, e.g. "ApiKey" or "Bearer".
+type SchemeAuthenticator map[string]Authenticator
+
+func (s SchemeAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return database.User{}, ErrNoAuthHeaderIncluded
+	}
+	scheme, _, _ := strings.Cut(authHeader, " ")
+	authenticator, ok := s[scheme]
+	if !ok {
+		return database.User{}, &MalformedHeaderError{Header: authHeader}
+	}
+	return authenticator.Authenticate(r)
+}
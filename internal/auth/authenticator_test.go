@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeUserStore struct {
+	users []database.User
+	keys  []database.ApiKey
+}
+
+func (s *fakeUserStore) GetActiveAPIKeyByHash(_ context.Context, keyHash string) (database.ApiKey, error) {
+	for _, key := range s.keys {
+		if key.KeyHash == keyHash && !key.RevokedAt.Valid {
+			return key, nil
+		}
+	}
+	return database.ApiKey{}, sql.ErrNoRows
+}
+
+func (s *fakeUserStore) GetUserByID(_ context.Context, id string) (database.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *fakeUserStore) GetUserByLegacyAPIKey(_ context.Context, apiKey string) (database.User, error) {
+	for _, user := range s.users {
+		if user.ApiKey == apiKey && user.ApiKeyHashed == 0 {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *fakeUserStore) CreateAPIKeyIfMissing(_ context.Context, arg database.CreateAPIKeyIfMissingParams) error {
+	for _, key := range s.keys {
+		if key.KeyHash == arg.KeyHash {
+			return nil
+		}
+	}
+	s.keys = append(s.keys, database.ApiKey{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Label:     arg.Label,
+		KeyHash:   arg.KeyHash,
+		KeyPrefix: arg.KeyPrefix,
+	})
+	return nil
+}
+
+func (s *fakeUserStore) SetUserAPIKeyHash(_ context.Context, arg database.SetUserAPIKeyHashParams) error {
+	for i := range s.users {
+		if s.users[i].ID == arg.ID {
+			s.users[i].ApiKey = arg.ApiKey
+			s.users[i].ApiKeyHashed = 1
+			s.users[i].ApiKeyPrefix = arg.ApiKeyPrefix
+		}
+	}
+	return nil
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	alice := database.User{ID: "user-1", Name: "alice", ApiKey: HashAPIKey("ntly_live_secret123"), ApiKeyHashed: 1, ApiKeyPrefix: "ntly_live_secr"}
+	bob := database.User{ID: "user-2", Name: "bob", ApiKey: "legacy456"}
+	keys := []database.ApiKey{
+		{ID: "key-1", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_secret123")},
+		{ID: "key-2", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_second")},
+		{ID: "key-3", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_revoked"), RevokedAt: sql.NullString{String: "2024-01-01T00:00:00Z", Valid: true}},
+		{ID: "key-4", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_expired"), ExpiresAt: sql.NullString{String: "2024-01-01T00:00:00Z", Valid: true}},
+		{ID: "key-5", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_expiring"), ExpiresAt: sql.NullString{String: "2999-01-01T00:00:00Z", Valid: true}},
+		{ID: "key-6", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_denied")},
+		{ID: "key-7", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_office"), AllowedCidrs: "10.0.0.0/8"},
+		{ID: "key-8", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_local"), AllowedCidrs: "10.0.0.0/8,192.0.2.0/24"},
+	}
+	denylist := NewDenylist(time.Hour)
+	denylist.Add(HashAPIKey("ntly_live_denied"))
+
+	tests := map[string]struct {
+		description string
+		header      string
+		wantUser    database.User
+		wantIs      error
+	}{
+		"success/known_key": {
+			description: "should resolve a known key to its user",
+			header:      "ApiKey ntly_live_secret123",
+			wantUser:    alice,
+		},
+		"success/second_key": {
+			description: "should resolve any active key to its owner",
+			header:      "ApiKey ntly_live_second",
+			wantUser:    alice,
+		},
+		"error/revoked_key": {
+			description: "should reject a revoked key",
+			header:      "ApiKey ntly_live_revoked",
+			wantIs:      ErrInvalidCredentials,
+		},
+		"error/expired_key": {
+			description: "should reject an expired key with ErrAPIKeyExpired",
+			header:      "ApiKey ntly_live_expired",
+			wantIs:      ErrAPIKeyExpired,
+		},
+		"error/denylisted_key": {
+			description: "should reject a denylisted key even while the store still has it active",
+			header:      "ApiKey ntly_live_denied",
+			wantIs:      ErrInvalidCredentials,
+		},
+		"error/ip_not_allowed": {
+			description: "should reject a key used from outside its allowed ranges",
+			header:      "ApiKey ntly_live_office",
+			wantIs:      ErrIPNotAllowed,
+		},
+		"success/ip_allowed": {
+			description: "should accept a key used from inside one of its allowed ranges",
+			header:      "ApiKey ntly_live_local",
+			wantUser:    alice,
+		},
+		"success/key_before_expiry": {
+			description: "should accept a key whose expiry is in the future",
+			header:      "ApiKey ntly_live_expiring",
+			wantUser:    alice,
+		},
+		"success/legacy_plaintext_key": {
+			description: "should accept a key stored before hashing at rest",
+			header:      "ApiKey legacy456",
+			wantUser:    bob,
+		},
+		"error/stored_hash_as_key": {
+			description: "should not accept the stored hash itself as a key",
+			header:      "ApiKey " + HashAPIKey("ntly_live_secret123"),
+			wantIs:      ErrInvalidCredentials,
+		},
+		"error/missing_header": {
+			description: "should surface ErrNoAuthHeaderIncluded",
+			header:      "",
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+		"error/malformed_header": {
+			description: "should surface ErrMalformedAuthHeader",
+			header:      "Bearer secret123",
+			wantIs:      ErrMalformedAuthHeader,
+		},
+		"error/unknown_key": {
+			description: "should wrap lookup failures in ErrInvalidCredentials",
+			header:      "ApiKey nope",
+			wantIs:      ErrInvalidCredentials,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			store := &fakeUserStore{users: []database.User{alice, bob}, keys: keys}
+			gotUser, gotErr := APIKeyAuthenticator{Store: store, Denylist: denylist}.Authenticate(r)
+
+			if tc.wantIs != nil {
+				if !errors.Is(gotErr, tc.wantIs) {
+					t.Fatalf("Authenticate() error = %v, want errors.Is %v", gotErr, tc.wantIs)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("Authenticate() unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(tc.wantUser, gotUser); diff != "" {
+				t.Errorf("Authenticate() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemeAuthenticator(t *testing.T) {
+	alice := database.User{ID: "user-1", Name: "alice"}
+	store := &fakeUserStore{
+		users: []database.User{alice},
+		keys:  []database.ApiKey{{ID: "key-1", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_secret123")}},
+	}
+	authenticator := SchemeAuthenticator{
+		"ApiKey": APIKeyAuthenticator{Store: store},
+		"Bearer": JWTAuthenticator{Store: store, Secret: "jwt-secret"},
+	}
+
+	token, err := MakeJWT(alice.ID, "jwt-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() unexpected error: %v", err)
+	}
+	forged, err := MakeJWT(alice.ID, "other-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		description string
+		header      string
+		wantUser    database.User
+		wantIs      error
+	}{
+		"success/api_key": {
+			description: "should authenticate ApiKey headers",
+			header:      "ApiKey ntly_live_secret123",
+			wantUser:    alice,
+		},
+		"success/bearer_jwt": {
+			description: "should authenticate Bearer JWTs",
+			header:      "Bearer " + token,
+			wantUser:    alice,
+		},
+		"error/forged_jwt": {
+			description: "should reject JWTs signed with another secret",
+			header:      "Bearer " + forged,
+			wantIs:      ErrInvalidToken,
+		},
+		"error/unknown_scheme": {
+			description: "should reject unsupported schemes as malformed",
+			header:      "Basic dXNlcjpwYXNz",
+			wantIs:      ErrMalformedAuthHeader,
+		},
+		"error/missing_header": {
+			description: "should surface ErrNoAuthHeaderIncluded",
+			header:      "",
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			gotUser, gotErr := authenticator.Authenticate(r)
+
+			if tc.wantIs != nil {
+				if !errors.Is(gotErr, tc.wantIs) {
+					t.Fatalf("Authenticate() error = %v, want errors.Is %v", gotErr, tc.wantIs)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("Authenticate() unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(tc.wantUser, gotUser); diff != "" {
+				t.Errorf("Authenticate() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+var ErrNoClientCertificate = errors.New("no client certificate presented")
+
+// ClientCertStore maps certificate subjects to users. *database.Queries
+// satisfies it.
+type ClientCertStore interface {
+	GetClientCertBySubject(ctx context.Context, subject string) (database.ClientCert, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+}
+
+// CertAuthenticator authenticates requests made over mutual TLS, mapping the
+// verified client certificate to a user through the client_certs table.
+type CertAuthenticator struct {
+	Store ClientCertStore
+}
+
+func (a CertAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	cert := verifiedClientCert(r)
+	if cert == nil {
+		return database.User{}, ErrNoClientCertificate
+	}
+
+	for _, subject := range CertificateSubjects(cert) {
+		mapping, err := a.Store.GetClientCertBySubject(r.Context(), subject)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+		user, err := a.Store.GetUserByID(r.Context(), mapping.UserID)
+		if err != nil {
+			return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+		}
+		return user, nil
+	}
+	return database.User{}, fmt.Errorf("%w: no identity for certificate %q", ErrInvalidCredentials, cert.Subject.CommonName)
+}
+
+// CertificateSubjects lists the names a certificate can be mapped by, most
+// specific first: URI SANs (e.g. SPIFFE IDs), DNS SANs, then the common name.
+func CertificateSubjects(cert *x509.Certificate) []string {
+	var subjects []string
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	subjects = append(subjects, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		subjects = append(subjects, cert.Subject.CommonName)
+	}
+	return subjects
+}
+
+// verifiedClientCert returns the leaf client certificate if the TLS
+// handshake verified it against the configured client CAs.
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeClientCertStore struct {
+	fakeUserStore
+	certs []database.ClientCert
+}
+
+func (s *fakeClientCertStore) GetClientCertBySubject(_ context.Context, subject string) (database.ClientCert, error) {
+	for _, cert := range s.certs {
+		if cert.Subject == subject {
+			return cert, nil
+		}
+	}
+	return database.ClientCert{}, sql.ErrNoRows
+}
+
+func TestCertAuthenticator(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://notely/worker")
+	store := &fakeClientCertStore{
+		fakeUserStore: fakeUserStore{users: []database.User{
+			{ID: "svc-1", Name: "worker"},
+			{ID: "svc-2", Name: "billing"},
+		}},
+		certs: []database.ClientCert{
+			{Subject: "spiffe://notely/worker", UserID: "svc-1"},
+			{Subject: "billing.internal", UserID: "svc-2"},
+		},
+	}
+
+	tests := map[string]struct {
+		description string
+		cert        *x509.Certificate
+		verified    bool
+		wantUserID  string
+		wantErr     error
+	}{
+		"uri_san": {
+			description: "should map a URI SAN to its user",
+			cert:        &x509.Certificate{URIs: []*url.URL{spiffe}, Subject: pkix.Name{CommonName: "unmapped"}},
+			verified:    true,
+			wantUserID:  "svc-1",
+		},
+		"common_name": {
+			description: "should fall back to the common name",
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "billing.internal"}},
+			verified:    true,
+			wantUserID:  "svc-2",
+		},
+		"unmapped": {
+			description: "should reject a verified certificate with no mapping",
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}},
+			verified:    true,
+			wantErr:     ErrInvalidCredentials,
+		},
+		"unverified": {
+			description: "should ignore certificates the handshake didn't verify",
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "billing.internal"}},
+			wantErr:     ErrNoClientCertificate,
+		},
+		"no_tls": {
+			description: "should report no certificate on plain HTTP",
+			wantErr:     ErrNoClientCertificate,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.cert != nil {
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{tc.cert}}
+				if tc.verified {
+					r.TLS.VerifiedChains = [][]*x509.Certificate{{tc.cert}}
+				}
+			}
+
+			user, err := CertAuthenticator{Store: store}.Authenticate(r)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantUserID, user.ID); diff != "" {
+				t.Errorf("Authenticate() user mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var ErrIPNotAllowed = errors.New("client ip not allowed for this api key")
+
+// NormalizeCIDRs validates a list of CIDR ranges, accepting bare addresses
+// as single-host ranges, and returns them in the comma-separated form stored
+// in api_keys.allowed_cidrs. An empty list means any address is allowed.
+func NormalizeCIDRs(cidrs []string) (string, error) {
+	normalized := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := parseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return "", fmt.Errorf("invalid cidr %q: %w", cidr, err)
+		}
+		normalized = append(normalized, prefix.String())
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// SplitCIDRs returns the ranges in a stored allowed_cidrs value.
+func SplitCIDRs(allowed string) []string {
+	if allowed == "" {
+		return []string{}
+	}
+	return strings.Split(allowed, ",")
+}
+
+// IPAllowed reports whether ip falls in one of the ranges of a stored
+// allowed_cidrs value. An empty value allows everything; anything that
+// doesn't parse allows nothing.
+func IPAllowed(allowed, ip string) bool {
+	if allowed == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, cidr := range SplitCIDRs(allowed) {
+		prefix, err := parseCIDR(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDR(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
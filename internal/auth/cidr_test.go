@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeCIDRs(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		cidrs       []string
+		want        string
+		wantErr     bool
+	}{
+		"empty": {
+			description: "should store no ranges as an empty string",
+			cidrs:       nil,
+			want:        "",
+		},
+		"ranges": {
+			description: "should keep valid ranges",
+			cidrs:       []string{"10.0.0.0/8", "2001:db8::/32"},
+			want:        "10.0.0.0/8,2001:db8::/32",
+		},
+		"bare_address": {
+			description: "should turn bare addresses into single-host ranges",
+			cidrs:       []string{" 203.0.113.7 "},
+			want:        "203.0.113.7/32",
+		},
+		"host_bits": {
+			description: "should mask host bits",
+			cidrs:       []string{"192.168.1.77/24"},
+			want:        "192.168.1.0/24",
+		},
+		"invalid": {
+			description: "should reject garbage",
+			cidrs:       []string{"10.0.0.0/8", "not-a-cidr"},
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := NormalizeCIDRs(tc.cidrs)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NormalizeCIDRs() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("NormalizeCIDRs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		allowed     string
+		ip          string
+		want        bool
+	}{
+		"no_restriction": {
+			description: "should allow any address when no ranges are set",
+			allowed:     "",
+			ip:          "198.51.100.1",
+			want:        true,
+		},
+		"inside": {
+			description: "should allow an address inside a range",
+			allowed:     "10.0.0.0/8,203.0.113.7/32",
+			ip:          "10.1.2.3",
+			want:        true,
+		},
+		"outside": {
+			description: "should reject an address outside every range",
+			allowed:     "10.0.0.0/8,203.0.113.7/32",
+			ip:          "203.0.113.8",
+			want:        false,
+		},
+		"ipv4_mapped": {
+			description: "should match IPv4-mapped IPv6 addresses against IPv4 ranges",
+			allowed:     "10.0.0.0/8",
+			ip:          "::ffff:10.0.0.1",
+			want:        true,
+		},
+		"ipv6": {
+			description: "should match IPv6 ranges",
+			allowed:     "2001:db8::/32",
+			ip:          "2001:db8::1",
+			want:        true,
+		},
+		"unparseable_ip": {
+			description: "should fail closed on an unparseable client address",
+			allowed:     "10.0.0.0/8",
+			ip:          "unknown",
+			want:        false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if diff := cmp.Diff(tc.want, IPAllowed(tc.allowed, tc.ip)); diff != "" {
+				t.Errorf("IPAllowed() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
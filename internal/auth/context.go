@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Credential schemes reported in AuthContext.Scheme.
+const (
+	SchemeAPIKey     = "ApiKey"
+	SchemeBearer     = "Bearer"
+	SchemeSession    = "Session"
+	SchemeClientCert = "ClientCert"
+	SchemePassword   = "Password"
+)
+
+// AuthContext gathers what a request presents for authentication: the
+// credential itself, where it came from, and properties of the connection.
+// Middleware that needs more than the raw key reads it instead of picking
+// the request apart again.
+type AuthContext struct {
+	// Scheme is one of the Scheme constants, or empty when the request
+	// carries no credential.
+	Scheme     string
+	Credential string
+
+	ClientIP         string
+	UserAgent        string
+	PeerCertificates []*x509.Certificate
+	// Deadline is the request context's deadline, or the zero time if it
+	// has none.
+	Deadline time.Time
+}
+
+// NewAuthContext builds the AuthContext for r. It fails with the same errors
+// as the extractors when a credential is present but malformed or
+// conflicting.
+func NewAuthContext(r *http.Request, sources APIKeySources, trustProxy bool) (AuthContext, error) {
+	ac := AuthContext{
+		ClientIP:  ClientIP(r, trustProxy),
+		UserAgent: r.UserAgent(),
+	}
+	if r.TLS != nil {
+		ac.PeerCertificates = r.TLS.PeerCertificates
+	}
+	if deadline, ok := r.Context().Deadline(); ok {
+		ac.Deadline = deadline
+	}
+
+	// CertAuthenticator runs first in the chain, so a verified client
+	// certificate takes precedence over anything in the headers.
+	if cert := verifiedClientCert(r); cert != nil {
+		ac.Scheme, ac.Credential = SchemeClientCert, cert.Subject.CommonName
+		return ac, nil
+	}
+
+	if scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " "); scheme == SchemeBearer {
+		token, err := GetBearerToken(r.Header)
+		if err != nil {
+			return ac, err
+		}
+		ac.Scheme, ac.Credential = SchemeBearer, token
+		return ac, nil
+	}
+
+	apiKey, err := sources.Extract(r)
+	if err == nil {
+		ac.Scheme, ac.Credential = SchemeAPIKey, apiKey
+		return ac, nil
+	}
+	if !errors.Is(err, ErrNoAuthHeaderIncluded) {
+		return ac, err
+	}
+
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		ac.Scheme, ac.Credential = SchemeSession, cookie.Value
+	}
+	return ac, nil
+}
+
+type authContextKey struct{}
+
+// WithAuthContext returns a copy of ctx carrying ac.
+func WithAuthContext(ctx context.Context, ac AuthContext) context.Context {
+	return context.WithValue(ctx, authContextKey{}, ac)
+}
+
+// AuthContextFrom returns the AuthContext stored in ctx by WithAuthContext.
+func AuthContextFrom(ctx context.Context) (AuthContext, bool) {
+	ac, ok := ctx.Value(authContextKey{}).(AuthContext)
+	return ac, ok
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewAuthContext(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("cert")}
+	deadline := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		description string
+		request     func() *http.Request
+		sources     APIKeySources
+		want        AuthContext
+		wantErr     error
+	}{
+		"api_key": {
+			description: "should report an ApiKey credential with the client details",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "ApiKey secret123")
+				r.Header.Set("User-Agent", "curl/8.0")
+				return r
+			},
+			want: AuthContext{
+				Scheme:     SchemeAPIKey,
+				Credential: "secret123",
+				ClientIP:   "192.0.2.1",
+				UserAgent:  "curl/8.0",
+			},
+		},
+		"api_key_fallback": {
+			description: "should use enabled fallback sources",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/?api_key=secret123", nil)
+				return r
+			},
+			sources: APIKeySources{QueryParam: true},
+			want: AuthContext{
+				Scheme:     SchemeAPIKey,
+				Credential: "secret123",
+				ClientIP:   "192.0.2.1",
+			},
+		},
+		"bearer": {
+			description: "should report a Bearer credential",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "Bearer token123")
+				return r
+			},
+			want: AuthContext{
+				Scheme:     SchemeBearer,
+				Credential: "token123",
+				ClientIP:   "192.0.2.1",
+			},
+		},
+		"session": {
+			description: "should fall back to the session cookie",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "sess123"})
+				return r
+			},
+			want: AuthContext{
+				Scheme:     SchemeSession,
+				Credential: "sess123",
+				ClientIP:   "192.0.2.1",
+			},
+		},
+		"none": {
+			description: "should succeed with no credential",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/", nil)
+			},
+			want: AuthContext{ClientIP: "192.0.2.1"},
+		},
+		"tls_and_deadline": {
+			description: "should expose peer certificates and the context deadline",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+				ctx, cancel := context.WithDeadline(context.Background(), deadline)
+				t.Cleanup(cancel)
+				return r.WithContext(ctx)
+			},
+			want: AuthContext{
+				ClientIP:         "192.0.2.1",
+				PeerCertificates: []*x509.Certificate{cert},
+				Deadline:         deadline,
+			},
+		},
+		"malformed": {
+			description: "should report a malformed Authorization header",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "Bearer")
+				return r
+			},
+			want:    AuthContext{ClientIP: "192.0.2.1"},
+			wantErr: ErrMalformedAuthHeader,
+		},
+		"conflicting": {
+			description: "should report conflicting API keys",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "ApiKey one")
+				r.Header.Set("X-API-Key", "two")
+				return r
+			},
+			sources: APIKeySources{XAPIKeyHeader: true},
+			want:    AuthContext{ClientIP: "192.0.2.1"},
+			wantErr: ErrConflictingAPIKeys,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := NewAuthContext(tc.request(), tc.sources, false)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("NewAuthContext() error = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("NewAuthContext() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuthContextRoundTrip(t *testing.T) {
+	if _, ok := AuthContextFrom(context.Background()); ok {
+		t.Fatalf("expected no AuthContext in an empty context")
+	}
+
+	want := AuthContext{Scheme: SchemeAPIKey, Credential: "secret123"}
+	got, ok := AuthContextFrom(WithAuthContext(context.Background(), want))
+	if !ok {
+		t.Fatalf("expected an AuthContext")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AuthContextFrom() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Denylist holds the hashes of recently revoked keys so that a revocation
+// takes effect on the very next request, without depending on every lookup
+// path reading the revoked_at column. Entries are dropped after ttl, by which
+// point the database is authoritative.
+type Denylist struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func NewDenylist(ttl time.Duration) *Denylist {
+	return &Denylist{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]time.Time{},
+	}
+}
+
+// Add denies the given key hashes.
+func (d *Denylist) Add(keyHashes ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for hash, expires := range d.entries {
+		if !now.Before(expires) {
+			delete(d.entries, hash)
+		}
+	}
+	for _, hash := range keyHashes {
+		d.entries[hash] = now.Add(d.ttl)
+	}
+}
+
+// Contains reports whether keyHash is denied. A nil Denylist denies nothing.
+func (d *Denylist) Contains(keyHash string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expires, ok := d.entries[keyHash]
+	return ok && d.now().Before(expires)
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDenylist(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDenylist(time.Hour)
+	d.now = func() time.Time { return now }
+
+	d.Add("a", "b")
+	if !d.Contains("a") || !d.Contains("b") {
+		t.Fatalf("expected added hashes to be denied")
+	}
+	if d.Contains("c") {
+		t.Errorf("expected other hashes to be allowed")
+	}
+
+	now = now.Add(time.Hour)
+	if d.Contains("a") {
+		t.Errorf("expected entries to expire after the ttl")
+	}
+
+	d.Add("c")
+	if len(d.entries) != 1 {
+		t.Errorf("expected expired entries to be swept on Add, have %d", len(d.entries))
+	}
+
+	var nilList *Denylist
+	if nilList.Contains("a") {
+		t.Errorf("expected a nil Denylist to deny nothing")
+	}
+}
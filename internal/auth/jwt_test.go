@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateJWT(t *testing.T) {
+	valid, err := MakeJWT("user-1", "secret", time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() unexpected error: %v", err)
+	}
+	expired, err := MakeJWT("user-1", "secret", -time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT() unexpected error: %v", err)
+	}
+	otherIssuer, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "someone-else",
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString() unexpected error: %v", err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Issuer:    TokenIssuer,
+		Subject:   "user-1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString() unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		description string
+		token       string
+		secret      string
+		wantUserID  string
+		wantErr     bool
+	}{
+		"success/valid_token": {
+			description: "should return the subject of a valid token",
+			token:       valid,
+			secret:      "secret",
+			wantUserID:  "user-1",
+		},
+		"error/wrong_secret": {
+			description: "should reject a token signed with another secret",
+			token:       valid,
+			secret:      "other",
+			wantErr:     true,
+		},
+		"error/expired": {
+			description: "should reject an expired token",
+			token:       expired,
+			secret:      "secret",
+			wantErr:     true,
+		},
+		"error/wrong_issuer": {
+			description: "should reject a token from another issuer",
+			token:       otherIssuer,
+			secret:      "secret",
+			wantErr:     true,
+		},
+		"error/alg_none": {
+			description: "should reject unsigned tokens",
+			token:       unsigned,
+			secret:      "secret",
+			wantErr:     true,
+		},
+		"error/garbage": {
+			description: "should reject a token that isn't a JWT",
+			token:       "not-a-jwt",
+			secret:      "secret",
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			gotUserID, gotErr := ValidateJWT(tc.token, tc.secret)
+
+			if tc.wantErr {
+				if gotErr == nil {
+					t.Fatalf("ValidateJWT() expected error, got nil")
+				}
+			} else if gotErr != nil {
+				t.Fatalf("ValidateJWT() unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(tc.wantUserID, gotUserID); diff != "" {
+				t.Errorf("ValidateJWT() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
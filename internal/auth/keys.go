@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix marks keys issued by this server so they are recognisable in
+// logs and secret scanners.
+const APIKeyPrefix = "ntly_live_"
+
+// DefaultKeyLabel is the label of the key issued when a user signs up.
+const DefaultKeyLabel = "default"
+
+// displaySecretChars is how much of the secret part is kept for display.
+const displaySecretChars = 4
+
+// GenerateAPIKey returns a new random API key. Only its hash should be stored.
+func GenerateAPIKey() (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + secret, nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 digest stored in place of the key.
+func HashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// DisplayPrefix returns the non-secret leading part of a key, e.g.
+// "ntly_live_1a2b", suitable for showing to users.
+func DisplayPrefix(apiKey string) string {
+	prefix, secret := "", apiKey
+	if strings.HasPrefix(apiKey, APIKeyPrefix) {
+		prefix, secret = APIKeyPrefix, apiKey[len(APIKeyPrefix):]
+	}
+	if len(secret) > displaySecretChars {
+		secret = secret[:displaySecretChars]
+	}
+	return prefix + secret
+}
+
+// VerifyKey reports whether a provided secret equals the stored one. Secrets
+// must always be compared with it rather than ==, which returns as soon as a
+// byte differs and so leaks how much of a guess was right. Only the length
+// of the secrets can be learned from its timing.
+func VerifyKey(provided, stored string) bool {
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(stored)) == 1
+}
+
+// VerifyAPIKey reports whether apiKey hashes to storedHash, comparing in
+// constant time.
+func VerifyAPIKey(apiKey, storedHash string) bool {
+	return VerifyKey(HashAPIKey(apiKey), storedHash)
+}
+
+// KeyExpired reports whether key has an expiry at or before now. Unparseable
+// expiries are treated as expired.
+func KeyExpired(key database.ApiKey, now time.Time) bool {
+	if !key.ExpiresAt.Valid {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, key.ExpiresAt.String)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiresAt)
+}
+
+// LegacyKeyStore is the subset of queries needed to hash plaintext keys
+// written before keys were hashed at rest.
+type LegacyKeyStore interface {
+	ListLegacyAPIKeys(ctx context.Context) ([]database.ListLegacyAPIKeysRow, error)
+	legacyKeyUpgrader
+}
+
+type legacyKeyUpgrader interface {
+	CreateAPIKeyIfMissing(ctx context.Context, arg database.CreateAPIKeyIfMissingParams) error
+	SetUserAPIKeyHash(ctx context.Context, arg database.SetUserAPIKeyHashParams) error
+}
+
+// HashLegacyAPIKeys replaces every remaining plaintext key with its hash and
+// returns how many rows were updated.
+func HashLegacyAPIKeys(ctx context.Context, store LegacyKeyStore) (int, error) {
+	rows, err := store.ListLegacyAPIKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		if err := upgradeLegacyKey(ctx, store, row.ID, row.ApiKey); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+// upgradeLegacyKey registers a plaintext key in api_keys and then hashes it
+// on the users row. Both steps are idempotent so a failure can be retried.
+func upgradeLegacyKey(ctx context.Context, store legacyKeyUpgrader, userID, apiKey string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	err := store.CreateAPIKeyIfMissing(ctx, database.CreateAPIKeyIfMissingParams{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    userID,
+		Label:     DefaultKeyLabel,
+		KeyHash:   HashAPIKey(apiKey),
+		KeyPrefix: DisplayPrefix(apiKey),
+	})
+	if err != nil {
+		return err
+	}
+	return store.SetUserAPIKeyHash(ctx, database.SetUserAPIKeyHashParams{
+		ApiKey:       HashAPIKey(apiKey),
+		ApiKeyPrefix: DisplayPrefix(apiKey),
+		ID:           userID,
+	})
+}
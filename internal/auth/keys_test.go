@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		t.Errorf("GenerateAPIKey() = %q, want prefix %q", key, APIKeyPrefix)
+	}
+	if got, want := len(key), len(APIKeyPrefix)+64; got != want {
+		t.Errorf("GenerateAPIKey() length = %d, want %d", got, want)
+	}
+
+	other, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() unexpected error: %v", err)
+	}
+	if key == other {
+		t.Errorf("GenerateAPIKey() returned the same key twice: %q", key)
+	}
+}
+
+func TestDisplayPrefix(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		apiKey      string
+		want        string
+	}{
+		"prefixed_key": {
+			description: "should keep the scheme prefix and four secret characters",
+			apiKey:      "ntly_live_1a2b3c4d",
+			want:        "ntly_live_1a2b",
+		},
+		"legacy_key": {
+			description: "should keep four characters of an unprefixed key",
+			apiKey:      "deadbeefcafe",
+			want:        "dead",
+		},
+		"short_key": {
+			description: "should not panic on keys shorter than the display length",
+			apiKey:      "ab",
+			want:        "ab",
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if diff := cmp.Diff(tc.want, DisplayPrefix(tc.apiKey)); diff != "" {
+				t.Errorf("DisplayPrefix() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyKey(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		provided    string
+		stored      string
+		want        bool
+	}{
+		"match": {
+			description: "should accept identical secrets",
+			provided:    "ntly_live_secret",
+			stored:      "ntly_live_secret",
+			want:        true,
+		},
+		"different": {
+			description: "should reject a different secret of the same length",
+			provided:    "ntly_live_secreT",
+			stored:      "ntly_live_secret",
+		},
+		"prefix": {
+			description: "should reject a prefix of the stored secret",
+			provided:    "ntly_live_",
+			stored:      "ntly_live_secret",
+		},
+		"empty": {
+			description: "should reject an empty secret",
+			provided:    "",
+			stored:      "ntly_live_secret",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if diff := cmp.Diff(tc.want, VerifyKey(tc.provided, tc.stored)); diff != "" {
+				t.Errorf("VerifyKey() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	stored := HashAPIKey("ntly_live_secret")
+	if !VerifyAPIKey("ntly_live_secret", stored) {
+		t.Errorf("VerifyAPIKey() = false for matching key")
+	}
+	if VerifyAPIKey("ntly_live_other", stored) {
+		t.Errorf("VerifyAPIKey() = true for different key")
+	}
+	if VerifyAPIKey(stored, stored) {
+		t.Errorf("VerifyAPIKey() = true for the stored hash itself")
+	}
+}
+
+func TestHashLegacyAPIKeys(t *testing.T) {
+	store := &legacyKeyStore{fakeUserStore{users: []database.User{
+		{ID: "user-1", ApiKey: "legacy123"},
+		{ID: "user-2", ApiKey: HashAPIKey("ntly_live_new"), ApiKeyHashed: 1},
+	}}}
+
+	n, err := HashLegacyAPIKeys(context.Background(), store)
+	if err != nil {
+		t.Fatalf("HashLegacyAPIKeys() unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("HashLegacyAPIKeys() = %d, want 1", n)
+	}
+
+	key, err := store.GetActiveAPIKeyByHash(context.Background(), HashAPIKey("legacy123"))
+	if err != nil {
+		t.Fatalf("GetActiveAPIKeyByHash() after migration unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("user-1", key.UserID); diff != "" {
+		t.Errorf("UserID mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("lega", key.KeyPrefix); diff != "" {
+		t.Errorf("KeyPrefix mismatch (-want +got):\n%s", diff)
+	}
+
+	n, err = HashLegacyAPIKeys(context.Background(), store)
+	if err != nil {
+		t.Fatalf("HashLegacyAPIKeys() second run unexpected error: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("HashLegacyAPIKeys() second run = %d, want 0", n)
+	}
+}
+
+type legacyKeyStore struct {
+	fakeUserStore
+}
+
+func (s *legacyKeyStore) ListLegacyAPIKeys(_ context.Context) ([]database.ListLegacyAPIKeysRow, error) {
+	var rows []database.ListLegacyAPIKeysRow
+	for _, user := range s.users {
+		if user.ApiKeyHashed == 0 {
+			rows = append(rows, database.ListLegacyAPIKeysRow{ID: user.ID, ApiKey: user.ApiKey})
+		}
+	}
+	return rows, nil
+}
+
+func TestKeyExpired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		description string
+		expiresAt   sql.NullString
+		want        bool
+	}{
+		"no_expiry": {
+			description: "should never expire a key without expires_at",
+			expiresAt:   sql.NullString{},
+			want:        false,
+		},
+		"future_expiry": {
+			description: "should not expire a key before its expiry",
+			expiresAt:   sql.NullString{String: "2024-06-01T12:00:01Z", Valid: true},
+			want:        false,
+		},
+		"exact_expiry": {
+			description: "should expire a key at its expiry",
+			expiresAt:   sql.NullString{String: "2024-06-01T12:00:00Z", Valid: true},
+			want:        true,
+		},
+		"invalid_expiry": {
+			description: "should fail closed on an unparseable expiry",
+			expiresAt:   sql.NullString{String: "tomorrow", Valid: true},
+			want:        true,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got := KeyExpired(database.ApiKey{ExpiresAt: tc.expiresAt}, now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("KeyExpired() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
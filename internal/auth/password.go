@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password length limits, in bytes. bcrypt ignores anything past 72 bytes,
+// so longer passwords are rejected rather than silently truncated.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+var ErrInvalidPassword = errors.New("invalid password")
+
+// dummyPasswordHash is compared against when there's no stored hash, so a
+// login for an unknown email takes as long as one with a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("notely-dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of password, which must be between
+// MinPasswordLength and MaxPasswordLength bytes.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return "", ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches a hash from HashPassword.
+// An empty hash never matches but costs the same as one that does.
+func CheckPassword(password, hash string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
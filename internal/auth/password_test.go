@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		password    string
+		wantErr     error
+	}{
+		"valid": {
+			description: "A password within the limits is hashed",
+			password:    "correct horse",
+		},
+		"too short": {
+			description: "Passwords shorter than the minimum are rejected",
+			password:    "short",
+			wantErr:     ErrInvalidPassword,
+		},
+		"too long": {
+			description: "Passwords bcrypt would truncate are rejected",
+			password:    strings.Repeat("a", MaxPasswordLength+1),
+			wantErr:     ErrInvalidPassword,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			hash, err := HashPassword(tc.password)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if hash == tc.password {
+				t.Error("hash must not be the password itself")
+			}
+			if !CheckPassword(tc.password, hash) {
+				t.Error("expected the password to match its hash")
+			}
+		})
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		description string
+		password    string
+		hash        string
+		want        bool
+	}{
+		"match": {
+			description: "The right password matches",
+			password:    "correct horse",
+			hash:        hash,
+			want:        true,
+		},
+		"mismatch": {
+			description: "A wrong password doesn't match",
+			password:    "battery staple",
+			hash:        hash,
+		},
+		"no hash": {
+			description: "Users without a password never match",
+			password:    "correct horse",
+		},
+		"garbage hash": {
+			description: "A malformed hash never matches",
+			password:    "correct horse",
+			hash:        "not-a-hash",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := CheckPassword(tc.password, tc.hash); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
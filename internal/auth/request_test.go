@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		remoteAddr  string
+		forwarded   string
+		trustProxy  bool
+		want        string
+	}{
+		"remote_addr": {
+			description: "should strip the port from RemoteAddr",
+			remoteAddr:  "10.0.0.1:5555",
+			want:        "10.0.0.1",
+		},
+		"remote_addr_ipv6": {
+			description: "should handle bracketed IPv6 addresses",
+			remoteAddr:  "[::1]:5555",
+			want:        "::1",
+		},
+		"untrusted_forwarded_for": {
+			description: "should ignore X-Forwarded-For unless the proxy is trusted",
+			remoteAddr:  "10.0.0.1:5555",
+			forwarded:   "1.2.3.4",
+			want:        "10.0.0.1",
+		},
+		"trusted_forwarded_for": {
+			description: "should use the last X-Forwarded-For entry behind a trusted proxy",
+			remoteAddr:  "10.0.0.1:5555",
+			forwarded:   "6.6.6.6, 1.2.3.4",
+			trustProxy:  true,
+			want:        "1.2.3.4",
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if diff := cmp.Diff(tc.want, ClientIP(r, tc.trustProxy)); diff != "" {
+				t.Errorf("ClientIP() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// SessionCookieName is the cookie carrying the browser session token.
+const SessionCookieName = "notely_session"
+
+var (
+	ErrNoSessionCookie = errors.New("no session cookie included")
+	ErrSessionExpired  = errors.New("session expired")
+)
+
+// MakeSessionToken returns a random session token. Only its hash (see
+// HashAPIKey) should be stored.
+func MakeSessionToken() (string, error) {
+	return randomHex(32)
+}
+
+// MakeShareToken returns a random token for a public share link. Only its
+// hash (see HashAPIKey) should be stored.
+func MakeShareToken() (string, error) {
+	return randomHex(32)
+}
+
+// MakeVerificationToken returns a random token for an email verification
+// link. Only its hash (see HashAPIKey) should be stored.
+func MakeVerificationToken() (string, error) {
+	return randomHex(32)
+}
+
+// MakePasswordResetToken returns a random token for resetting a password.
+// Only its hash (see HashAPIKey) should be stored.
+func MakePasswordResetToken() (string, error) {
+	return randomHex(32)
+}
+
+// MakeDeletionToken returns a random token confirming an account deletion.
+// Only its hash (see HashAPIKey) should be stored.
+func MakeDeletionToken() (string, error) {
+	return randomHex(32)
+}
+
+// MakeExportToken returns a random token for downloading a data export.
+// Only its hash (see HashAPIKey) should be stored.
+func MakeExportToken() (string, error) {
+	return randomHex(32)
+}
+
+// SessionStore looks up sessions and their users. *database.Queries
+// satisfies it.
+type SessionStore interface {
+	GetSession(ctx context.Context, tokenHash string) (database.Session, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+}
+
+// SessionAuthenticator authenticates requests carrying a session cookie.
+type SessionAuthenticator struct {
+	Store SessionStore
+}
+
+func (a SessionAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return database.User{}, ErrNoSessionCookie
+	}
+
+	session, err := a.Store.GetSession(r.Context(), HashAPIKey(cookie.Value))
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, session.ExpiresAt)
+	if err != nil || !time.Now().Before(expiresAt) {
+		return database.User{}, ErrSessionExpired
+	}
+
+	user, err := a.Store.GetUserByID(r.Context(), session.UserID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	return user, nil
+}
+
+// ChainAuthenticator tries each Authenticator in order, moving on only when
+// the previous one found no credentials at all on the request.
+type ChainAuthenticator []Authenticator
+
+func (c ChainAuthenticator) Authenticate(r *http.Request) (database.User, error) {
+	for _, authenticator := range c {
+		user, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoAuthHeaderIncluded) || errors.Is(err, ErrNoSessionCookie) || errors.Is(err, ErrNoClientCertificate) {
+			continue
+		}
+		return user, err
+	}
+	return database.User{}, ErrNoAuthHeaderIncluded
+}
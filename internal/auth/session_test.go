@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeSessionStore struct {
+	fakeUserStore
+	sessions []database.Session
+}
+
+func (s *fakeSessionStore) GetSession(_ context.Context, tokenHash string) (database.Session, error) {
+	for _, session := range s.sessions {
+		if session.TokenHash == tokenHash {
+			return session, nil
+		}
+	}
+	return database.Session{}, sql.ErrNoRows
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	alice := database.User{ID: "user-1", Name: "alice"}
+	bob := database.User{ID: "user-2", Name: "bob"}
+	store := &fakeSessionStore{
+		fakeUserStore: fakeUserStore{
+			users: []database.User{alice, bob},
+			keys:  []database.ApiKey{{ID: "key-1", UserID: alice.ID, KeyHash: HashAPIKey("ntly_live_secret123")}},
+		},
+		sessions: []database.Session{
+			{TokenHash: HashAPIKey("session-bob"), UserID: bob.ID, ExpiresAt: "2999-01-01T00:00:00Z"},
+			{TokenHash: HashAPIKey("session-old"), UserID: bob.ID, ExpiresAt: "2000-01-01T00:00:00Z"},
+		},
+	}
+	authenticator := ChainAuthenticator{
+		APIKeyAuthenticator{Store: store},
+		SessionAuthenticator{Store: store},
+	}
+
+	tests := map[string]struct {
+		description string
+		header      string
+		cookie      string
+		wantUser    database.User
+		wantIs      error
+	}{
+		"success/header_only": {
+			description: "should authenticate an ApiKey header",
+			header:      "ApiKey ntly_live_secret123",
+			wantUser:    alice,
+		},
+		"success/cookie_only": {
+			description: "should fall back to the session cookie",
+			cookie:      "session-bob",
+			wantUser:    bob,
+		},
+		"success/header_wins": {
+			description: "should prefer the header when both are present",
+			header:      "ApiKey ntly_live_secret123",
+			cookie:      "session-bob",
+			wantUser:    alice,
+		},
+		"error/bad_header_does_not_fall_back": {
+			description: "should not fall back to the cookie when the header is invalid",
+			header:      "ApiKey ntly_live_wrong",
+			cookie:      "session-bob",
+			wantIs:      ErrInvalidCredentials,
+		},
+		"error/unknown_session": {
+			description: "should reject an unknown session",
+			cookie:      "session-nope",
+			wantIs:      ErrInvalidCredentials,
+		},
+		"error/expired_session": {
+			description: "should reject an expired session",
+			cookie:      "session-old",
+			wantIs:      ErrSessionExpired,
+		},
+		"error/no_credentials": {
+			description: "should report a missing header when nothing is sent",
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tc.cookie})
+			}
+
+			gotUser, gotErr := authenticator.Authenticate(r)
+
+			if tc.wantIs != nil {
+				if !errors.Is(gotErr, tc.wantIs) {
+					t.Fatalf("Authenticate() error = %v, want errors.Is %v", gotErr, tc.wantIs)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("Authenticate() unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(tc.wantUser, gotUser); diff != "" {
+				t.Errorf("Authenticate() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrConflictingAPIKeys = errors.New("conflicting api keys")
+
+// APIKeySources enables places other than the Authorization header where an
+// API key may be sent, for clients that can't set Authorization. Keys sent in
+// the query string end up in access logs, so QueryParam should only be enabled
+// when that is acceptable.
+//
+// Sources are consulted in order: Authorization, X-API-Key, ?api_key=. A
+// malformed Authorization header is never skipped over, and a request that
+// carries different keys in different sources is rejected with
+// ErrConflictingAPIKeys.
+type APIKeySources struct {
+	XAPIKeyHeader bool
+	QueryParam    bool
+}
+
+// Extract returns the API key carried by r.
+func (s APIKeySources) Extract(r *http.Request) (string, error) {
+	var candidates []string
+	if r.Header.Get("Authorization") != "" {
+		apiKey, err := GetAPIKey(r.Header)
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, apiKey)
+	}
+	if s.XAPIKeyHeader {
+		if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+			candidates = append(candidates, apiKey)
+		}
+	}
+	if s.QueryParam {
+		if apiKey := strings.TrimSpace(r.URL.Query().Get("api_key")); apiKey != "" {
+			candidates = append(candidates, apiKey)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", ErrNoAuthHeaderIncluded
+	}
+	for _, apiKey := range candidates[1:] {
+		if apiKey != candidates[0] {
+			return "", ErrConflictingAPIKeys
+		}
+	}
+	return candidates[0], nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAPIKeySourcesExtract(t *testing.T) {
+	all := APIKeySources{XAPIKeyHeader: true, QueryParam: true}
+
+	tests := map[string]struct {
+		description   string
+		sources       APIKeySources
+		url           string
+		authorization string
+		xAPIKey       string
+		wantKey       string
+		wantIs        error
+	}{
+		"success/authorization_only": {
+			description:   "should read the Authorization header",
+			sources:       all,
+			url:           "/",
+			authorization: "ApiKey key1",
+			wantKey:       "key1",
+		},
+		"success/x_api_key": {
+			description: "should read X-API-Key when enabled",
+			sources:     all,
+			url:         "/",
+			xAPIKey:     "key1",
+			wantKey:     "key1",
+		},
+		"success/query_param": {
+			description: "should read ?api_key= when enabled",
+			sources:     all,
+			url:         "/?api_key=key1",
+			wantKey:     "key1",
+		},
+		"success/matching_sources": {
+			description:   "should accept the same key sent in several places",
+			sources:       all,
+			url:           "/?api_key=key1",
+			authorization: "ApiKey key1",
+			xAPIKey:       "key1",
+			wantKey:       "key1",
+		},
+		"error/x_api_key_disabled": {
+			description: "should ignore X-API-Key by default",
+			sources:     APIKeySources{},
+			url:         "/",
+			xAPIKey:     "key1",
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+		"error/query_param_disabled": {
+			description: "should ignore ?api_key= unless enabled",
+			sources:     APIKeySources{XAPIKeyHeader: true},
+			url:         "/?api_key=key1",
+			wantIs:      ErrNoAuthHeaderIncluded,
+		},
+		"error/authorization_conflicts_with_x_api_key": {
+			description:   "should reject different keys in Authorization and X-API-Key",
+			sources:       all,
+			url:           "/",
+			authorization: "ApiKey key1",
+			xAPIKey:       "key2",
+			wantIs:        ErrConflictingAPIKeys,
+		},
+		"error/x_api_key_conflicts_with_query": {
+			description: "should reject different keys in X-API-Key and the query",
+			sources:     all,
+			url:         "/?api_key=key2",
+			xAPIKey:     "key1",
+			wantIs:      ErrConflictingAPIKeys,
+		},
+		"success/disabled_source_cannot_conflict": {
+			description:   "should not consider disabled sources for conflicts",
+			sources:       APIKeySources{},
+			url:           "/?api_key=key2",
+			authorization: "ApiKey key1",
+			xAPIKey:       "key3",
+			wantKey:       "key1",
+		},
+		"error/malformed_authorization": {
+			description:   "should not fall back past a malformed Authorization header",
+			sources:       all,
+			url:           "/?api_key=key1",
+			authorization: "ApiKey",
+			wantIs:        ErrMalformedAuthHeader,
+		},
+	}
+
+	for name, tc := range tests {
+
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.xAPIKey != "" {
+				r.Header.Set("X-API-Key", tc.xAPIKey)
+			}
+
+			gotKey, gotErr := tc.sources.Extract(r)
+
+			if tc.wantIs != nil {
+				if !errors.Is(gotErr, tc.wantIs) {
+					t.Fatalf("Extract() error = %v, want errors.Is %v", gotErr, tc.wantIs)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("Extract() unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(tc.wantKey, gotKey); diff != "" {
+				t.Errorf("Extract() result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
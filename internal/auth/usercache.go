@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// UserCache is a UserStore that keeps the API keys and users it looks up
+// in a cache, so most requests authenticate without touching the
+// database. Entries last for ttl at most; handlers that change a key or a
+// user call ForgetKeys or ForgetUser to drop them sooner. A cache that
+// fails is logged and skipped.
+type UserCache struct {
+	UserStore
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func NewUserCache(store UserStore, c cache.Cache, ttl time.Duration) *UserCache {
+	return &UserCache{UserStore: store, cache: c, ttl: ttl}
+}
+
+func apiKeyCacheKey(keyHash string) string {
+	return "apikey:" + keyHash
+}
+
+func userCacheKey(id string) string {
+	return "user:" + id
+}
+
+func (c *UserCache) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error) {
+	return cached(ctx, c, apiKeyCacheKey(keyHash), func() (database.ApiKey, error) {
+		return c.UserStore.GetActiveAPIKeyByHash(ctx, keyHash)
+	})
+}
+
+// GetUserByID returns the user without its secrets, which would otherwise
+// be copied into a shared cache. Handlers that check one load the user from
+// the database instead.
+func (c *UserCache) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return cached(ctx, c, userCacheKey(id), func() (database.User, error) {
+		user, err := c.UserStore.GetUserByID(ctx, id)
+		return withoutSecrets(user), err
+	})
+}
+
+// withoutSecrets blanks the user's API key, TOTP secret, password hash and
+// deletion token hash. Their Valid flags are kept, so whether they're set
+// can still be told.
+func withoutSecrets(user database.User) database.User {
+	user.ApiKey = ""
+	user.TotpSecret.String = ""
+	user.PasswordHash.String = ""
+	user.DeletionTokenHash.String = ""
+	return user
+}
+
+func (c *UserCache) SetUserAPIKeyHash(ctx context.Context, arg database.SetUserAPIKeyHashParams) error {
+	defer c.ForgetUser(ctx, arg.ID)
+	return c.UserStore.SetUserAPIKeyHash(ctx, arg)
+}
+
+// ForgetKeys drops the cached keys with the given hashes. A nil UserCache
+// forgets nothing.
+func (c *UserCache) ForgetKeys(ctx context.Context, keyHashes ...string) {
+	if c == nil || len(keyHashes) == 0 {
+		return
+	}
+	keys := make([]string, len(keyHashes))
+	for i, hash := range keyHashes {
+		keys[i] = apiKeyCacheKey(hash)
+	}
+	if err := c.cache.Delete(ctx, keys...); err != nil {
+		log.Printf("Couldn't drop cached api keys: %v", err)
+	}
+}
+
+// ForgetUser drops the cached user with the given ID. A nil UserCache
+// forgets nothing.
+func (c *UserCache) ForgetUser(ctx context.Context, id string) {
+	if c == nil {
+		return
+	}
+	if err := c.cache.Delete(ctx, userCacheKey(id)); err != nil {
+		log.Printf("Couldn't drop cached user: %v", err)
+	}
+}
+
+// cached returns the value cached under key, or looks it up and caches
+// it. Only values found are cached, so a key or user created later is
+// seen straight away.
+func cached[T any](ctx context.Context, c *UserCache, key string, lookup func() (T, error)) (T, error) {
+	var value T
+	b, err := c.cache.Get(ctx, key)
+	if err == nil {
+		if err := json.Unmarshal(b, &value); err == nil {
+			return value, nil
+		}
+	} else if !errors.Is(err, cache.ErrMiss) {
+		log.Printf("Couldn't read cache: %v", err)
+	}
+
+	value, err = lookup()
+	if err != nil {
+		return value, err
+	}
+	if b, err := json.Marshal(value); err == nil {
+		if err := c.cache.Set(ctx, key, b, c.ttl); err != nil {
+			log.Printf("Couldn't write cache: %v", err)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func TestUserCache(t *testing.T) {
+	keyHash := HashAPIKey("ntly_live_secret123")
+	tests := map[string]struct {
+		description string
+		// change is made to the store, and forget told to the cache,
+		// between two lookups.
+		change       func(s *fakeUserStore)
+		forget       func(ctx context.Context, c *UserCache)
+		expectedName string
+		expectedErr  error
+	}{
+		"cached": {
+			description: "Changes aren't seen until the cache is told",
+			change: func(s *fakeUserStore) {
+				s.users[0].Name = "alicia"
+				s.keys[0].RevokedAt = sql.NullString{String: "2024-03-01T12:00:00Z", Valid: true}
+			},
+			expectedName: "alice",
+		},
+		"user forgotten": {
+			description: "A forgotten user is looked up again",
+			change: func(s *fakeUserStore) {
+				s.users[0].Name = "alicia"
+			},
+			forget: func(ctx context.Context, c *UserCache) {
+				c.ForgetUser(ctx, "user-1")
+			},
+			expectedName: "alicia",
+		},
+		"key forgotten": {
+			description: "A forgotten key is looked up again",
+			change: func(s *fakeUserStore) {
+				s.keys[0].RevokedAt = sql.NullString{String: "2024-03-01T12:00:00Z", Valid: true}
+			},
+			forget: func(ctx context.Context, c *UserCache) {
+				c.ForgetKeys(ctx, keyHash)
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeUserStore{
+				users: []database.User{{ID: "user-1", Name: "alice"}},
+				keys:  []database.ApiKey{{ID: "key-1", UserID: "user-1", KeyHash: keyHash}},
+			}
+			c := NewUserCache(store, cache.NewLRU(1<<20), time.Minute)
+			ctx := context.Background()
+
+			lookup := func() (database.User, error) {
+				key, err := c.GetActiveAPIKeyByHash(ctx, keyHash)
+				if err != nil {
+					return database.User{}, err
+				}
+				return c.GetUserByID(ctx, key.UserID)
+			}
+			if _, err := lookup(); err != nil {
+				t.Fatal(err)
+			}
+			tc.change(store)
+			if tc.forget != nil {
+				tc.forget(ctx, c)
+			}
+
+			user, err := lookup()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("lookup error = %v, want %v", err, tc.expectedErr)
+			}
+			if user.Name != tc.expectedName {
+				t.Errorf("got user %q, want %q", user.Name, tc.expectedName)
+			}
+		})
+	}
+}
+
+func TestUserCacheMissesNotCached(t *testing.T) {
+	store := &fakeUserStore{}
+	c := NewUserCache(store, cache.NewLRU(1<<20), time.Minute)
+	ctx := context.Background()
+
+	if _, err := c.GetUserByID(ctx, "user-1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUserByID() error = %v, want %v", err, sql.ErrNoRows)
+	}
+	store.users = append(store.users, database.User{ID: "user-1", Name: "alice"})
+	user, err := c.GetUserByID(ctx, "user-1")
+	if err != nil || user.Name != "alice" {
+		t.Errorf("GetUserByID() = %+v, %v, want the new user", user, err)
+	}
+}
+
+func TestUserCacheLeavesOutSecrets(t *testing.T) {
+	secret := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	store := &fakeUserStore{users: []database.User{{
+		ID:                "user-1",
+		Name:              "alice",
+		ApiKey:            "api-key-hash",
+		TotpSecret:        secret("totp-secret"),
+		PasswordHash:      secret("password-hash"),
+		DeletionTokenHash: secret("deletion-token-hash"),
+	}}}
+	shared := cache.NewLRU(1 << 20)
+	c := NewUserCache(store, shared, time.Minute)
+	ctx := context.Background()
+
+	// The first lookup misses the cache and the second hits it.
+	for i := 0; i < 2; i++ {
+		user, err := c.GetUserByID(ctx, "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := database.User{
+			ID:                "user-1",
+			Name:              "alice",
+			TotpSecret:        sql.NullString{Valid: true},
+			PasswordHash:      sql.NullString{Valid: true},
+			DeletionTokenHash: sql.NullString{Valid: true},
+		}
+		if user != expected {
+			t.Errorf("lookup %d: GetUserByID() = %+v, want %+v", i+1, user, expected)
+		}
+	}
+
+	b, err := shared.Get(ctx, userCacheKey("user-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"api-key-hash", "totp-secret", "password-hash", "deletion-token-hash"} {
+		if strings.Contains(string(b), s) {
+			t.Errorf("cached user contains %q", s)
+		}
+	}
+}
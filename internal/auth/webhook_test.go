@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	secret, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatalf("GenerateWebhookSecret() unexpected error: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, WebhookSecretPrefix))
+	if !strings.HasPrefix(secret, WebhookSecretPrefix) || err != nil || len(key) != 24 {
+		t.Errorf("unexpected secret format: %q", secret)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// The example from the Standard Webhooks specification.
+	got, err := SignWebhook("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+		time.Unix(1614265330, 0), []byte(`{"test": 2432232314}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+	if got != expected {
+		t.Errorf("SignWebhook() = %q, want %q", got, expected)
+	}
+
+	if _, err := SignWebhook("whsec_!!", "msg", time.Unix(0, 0), nil); !errors.Is(err, ErrInvalidWebhookSecret) {
+		t.Errorf("SignWebhook() error = %v, want %v", err, ErrInvalidWebhookSecret)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	const secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	const id = "msg_p5jXN8AQM9LWM0D4loKWxJek"
+	payload := []byte(`{"event":"note.created"}`)
+	sentAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := "1709294400"
+	signature, err := SignWebhook(secret, id, sentAt, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		description string
+		secret      string
+		id          string
+		timestamp   string
+		signature   string
+		payload     []byte
+		now         time.Time
+		wantErr     error
+	}{
+		"valid": {
+			description: "should accept a signature it produced",
+			secret:      secret,
+			id:          id,
+			timestamp:   timestamp,
+			signature:   signature,
+			payload:     payload,
+			now:         sentAt.Add(time.Minute),
+		},
+		"rotating": {
+			description: "should accept any matching v1 signature",
+			secret:      secret,
+			id:          id,
+			timestamp:   timestamp,
+			signature:   "v1," + base64.StdEncoding.EncodeToString(make([]byte, 32)) + " " + signature,
+			payload:     payload,
+			now:         sentAt,
+		},
+		"wrong_secret": {
+			description: "should reject a signature made with another secret",
+			secret:      "whsec_b3RoZXI=",
+			id:          id,
+			timestamp:   timestamp,
+			signature:   signature,
+			payload:     payload,
+			now:         sentAt,
+			wantErr:     ErrInvalidWebhookSignature,
+		},
+		"tampered_payload": {
+			description: "should reject a modified payload",
+			secret:      secret,
+			id:          id,
+			timestamp:   timestamp,
+			signature:   signature,
+			payload:     []byte(`{"event":"note.deleted"}`),
+			now:         sentAt,
+			wantErr:     ErrInvalidWebhookSignature,
+		},
+		"tampered_id": {
+			description: "should reject a signature replayed under another delivery ID",
+			secret:      secret,
+			id:          "msg_other",
+			timestamp:   timestamp,
+			signature:   signature,
+			payload:     payload,
+			now:         sentAt,
+			wantErr:     ErrInvalidWebhookSignature,
+		},
+		"tampered_timestamp": {
+			description: "should reject a signature whose timestamp was changed",
+			secret:      secret,
+			id:          id,
+			timestamp:   "1709294460",
+			signature:   signature,
+			payload:     payload,
+			now:         sentAt,
+			wantErr:     ErrInvalidWebhookSignature,
+		},
+		"expired": {
+			description: "should reject signatures outside the tolerance",
+			secret:      secret,
+			id:          id,
+			timestamp:   timestamp,
+			signature:   signature,
+			payload:     payload,
+			now:         sentAt.Add(10 * time.Minute),
+			wantErr:     ErrWebhookSignatureExpired,
+		},
+		"malformed": {
+			description: "should reject a header without a timestamp or signature",
+			secret:      secret,
+			id:          id,
+			timestamp:   "garbage",
+			signature:   "garbage",
+			payload:     payload,
+			now:         sentAt,
+			wantErr:     ErrInvalidWebhookSignature,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			err := VerifyWebhook(tc.secret, tc.id, tc.timestamp, tc.signature, tc.payload, tc.now, 5*time.Minute)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("VerifyWebhook() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
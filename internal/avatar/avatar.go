@@ -0,0 +1,98 @@
+// Package avatar turns uploaded profile pictures into the fixed-size square
+// PNGs the API serves.
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+
+	// Formats accepted for upload.
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// Sizes are the edge lengths, in pixels, avatars are stored at.
+var Sizes = []int{64, 256}
+
+// maxPixels caps the dimensions of an upload before it's decoded, so a
+// small file can't claim a huge canvas and exhaust memory.
+const maxPixels = 4096 * 4096
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// Process decodes a PNG, JPEG or GIF, crops it to a centered square and
+// returns it as a PNG at each of Sizes, keyed by size. Images smaller than
+// a size are scaled up.
+func Process(data []byte) (map[int][]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width < 1 || config.Height < 1 || config.Width*config.Height > maxPixels {
+		return nil, ErrInvalidImage
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	square := centerSquare(src.Bounds())
+	out := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resize(src, square, size)); err != nil {
+			return nil, err
+		}
+		out[size] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// centerSquare is the largest square in the middle of b.
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// resize scales the src pixels inside r to a size×size image. Each output
+// pixel averages the source pixels it covers, which is enough for the
+// downscaling avatars mostly need; upscaling repeats pixels.
+func resize(src image.Image, r image.Rectangle, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	side := r.Dx()
+	for y := 0; y < size; y++ {
+		y0 := r.Min.Y + y*side/size
+		y1 := max(r.Min.Y+(y+1)*side/size, y0+1)
+		for x := 0; x < size; x++ {
+			x0 := r.Min.X + x*side/size
+			x1 := max(r.Min.X+(x+1)*side/size, x0+1)
+
+			var rs, gs, bs, as, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					// Weight by alpha so transparent pixels don't
+					// darken the edges they share with opaque ones.
+					rs += uint64(c.R) * uint64(c.A)
+					gs += uint64(c.G) * uint64(c.A)
+					bs += uint64(c.B) * uint64(c.A)
+					as += uint64(c.A)
+					n++
+				}
+			}
+			var c color.NRGBA
+			if as > 0 {
+				c = color.NRGBA{
+					R: uint8(rs / as >> 8),
+					G: uint8(gs / as >> 8),
+					B: uint8(bs / as >> 8),
+					A: uint8(as / n >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,121 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// halves is a w×h image whose left half is red and right half is blue.
+func halves(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestProcess(t *testing.T) {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, halves(300, 200), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		description string
+		input       []byte
+		expectedErr error
+	}{
+		"landscape png": {
+			description: "Wide images are cropped to their middle",
+			input:       encodePNG(t, halves(1000, 400)),
+		},
+		"portrait png": {
+			description: "Tall images are cropped to their middle",
+			input:       encodePNG(t, halves(300, 900)),
+		},
+		"tiny": {
+			description: "Images smaller than the sizes are scaled up",
+			input:       encodePNG(t, halves(2, 2)),
+		},
+		"jpeg": {
+			description: "JPEGs are accepted",
+			input:       jpg.Bytes(),
+		},
+		"not an image": {
+			description: "Other files are rejected",
+			input:       []byte("hello"),
+			expectedErr: ErrInvalidImage,
+		},
+		"huge canvas": {
+			description: "Images over the pixel limit are rejected before decoding",
+			input:       hugePNGHeader(t),
+			expectedErr: ErrInvalidImage,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Process(tc.input)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			for _, size := range Sizes {
+				img, err := png.Decode(bytes.NewReader(got[size]))
+				if err != nil {
+					t.Fatalf("size %d: unexpected error: %v", size, err)
+				}
+				if diff := cmp.Diff(image.Rect(0, 0, size, size), img.Bounds()); diff != "" {
+					t.Errorf("size %d: bounds mismatch (-want +got):\n%s", size, diff)
+				}
+				// The crop keeps the red/blue split in the middle.
+				left := color.NRGBAModel.Convert(img.At(0, size/2)).(color.NRGBA)
+				right := color.NRGBAModel.Convert(img.At(size-1, size/2)).(color.NRGBA)
+				if left.R < 200 || right.B < 200 {
+					t.Errorf("size %d: expected red left and blue right, got %v and %v", size, left, right)
+				}
+			}
+		})
+	}
+}
+
+// hugePNGHeader is a PNG whose header claims a canvas far over the limit.
+func hugePNGHeader(t *testing.T) []byte {
+	t.Helper()
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	// Width and height are the first fields of the IHDR chunk, after the
+	// 8-byte signature and the chunk's length and type.
+	copy(data[16:24], []byte{0, 1, 0, 0, 0, 1, 0, 0})
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,189 @@
+// Package backup snapshots a local SQLite database with VACUUM INTO, keeping
+// the newest few snapshots on disk and optionally copying each to blob
+// storage.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/storage"
+)
+
+const (
+	prefix = "notely-"
+	suffix = ".db"
+
+	// nameTime sorts in time order, so the newest backups sort last.
+	nameTime = "20060102T150405.000Z"
+
+	// UploadPrefix is where backups are put in blob storage.
+	UploadPrefix = "backups/"
+)
+
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Config says where backups go. Keep is how many are left in Dir after each
+// one; older ones are deleted. With Upload set, each backup is also put in
+// it under UploadPrefix. Retention there is up to the bucket's lifecycle
+// rules.
+type Config struct {
+	Dir    string
+	Keep   int
+	Upload storage.Storage
+}
+
+// Backup is one snapshot of the database.
+type Backup struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+	Uploaded  bool
+}
+
+// Scheduler backs up the database once per interval and on demand. Only one
+// backup runs at a time.
+type Scheduler struct {
+	db  DB
+	cfg Config
+	now func() time.Time
+
+	mu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewScheduler(db DB, cfg Config, interval time.Duration) (*Scheduler, error) {
+	if cfg.Keep < 1 {
+		return nil, fmt.Errorf("backups to keep must be at least 1, got %d", cfg.Keep)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Scheduler{
+		db:   db,
+		cfg:  cfg,
+		now:  time.Now,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go s.run(interval)
+	return s, nil
+}
+
+// Run backs up the database now. The snapshot is written under a temporary
+// name and renamed once complete, so a crash part way through never leaves
+// a truncated backup that looks finished. If the upload fails, the local
+// backup is kept and returned along with the error.
+func (s *Scheduler) Run(ctx context.Context) (Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	createdAt := s.now().UTC()
+	name := prefix + createdAt.Format(nameTime) + suffix
+	path := filepath.Join(s.cfg.Dir, name)
+	tmp := path + ".tmp"
+
+	os.Remove(tmp)
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return Backup{}, fmt.Errorf("couldn't snapshot database: %w", err)
+	}
+	// The snapshot holds every credential hash, so keep it private.
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Backup{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	backup := Backup{Name: name, Size: info.Size(), CreatedAt: createdAt}
+
+	if err := s.prune(); err != nil {
+		log.Printf("Couldn't delete old backups: %v", err)
+	}
+
+	if s.cfg.Upload != nil {
+		if err := s.upload(ctx, path, backup); err != nil {
+			return backup, fmt.Errorf("couldn't upload backup: %w", err)
+		}
+		backup.Uploaded = true
+	}
+	return backup, nil
+}
+
+func (s *Scheduler) upload(ctx context.Context, path string, backup Backup) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.cfg.Upload.Put(ctx, UploadPrefix+backup.Name, f, backup.Size, "application/vnd.sqlite3")
+}
+
+// prune deletes all but the newest Keep backups in Dir.
+func (s *Scheduler) prune() error {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= s.cfg.Keep {
+		return nil
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-s.cfg.Keep] {
+		if err := os.Remove(filepath.Join(s.cfg.Dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops the scheduler, waiting for a scheduled backup in progress to
+// finish.
+func (s *Scheduler) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			backup, err := s.Run(context.Background())
+			if err != nil {
+				log.Printf("Couldn't back up database: %v", err)
+				continue
+			}
+			log.Printf("Backed up database to %s", backup.Name)
+		case <-s.stop:
+			return
+		}
+	}
+}
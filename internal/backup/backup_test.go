@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeDB writes a small file for VACUUM INTO, or fails with err.
+type fakeDB struct {
+	err error
+}
+
+func (db fakeDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	return nil, os.WriteFile(args[0].(string), []byte("SQLite format 3\x00"), 0o600)
+}
+
+type fakeStorage struct {
+	storage.Storage
+	keys []string
+	err  error
+}
+
+func (s *fakeStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func TestSchedulerRun(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	errFailed := errors.New("failed")
+
+	tests := map[string]struct {
+		description   string
+		runs          int
+		keep          int
+		dbErr         error
+		upload        *fakeStorage
+		expectedFiles []string
+		expectedKeys  []string
+		expectedErr   error
+	}{
+		"single": {
+			description:   "A backup is written to the directory",
+			runs:          1,
+			keep:          3,
+			expectedFiles: []string{"notely-20240301T120000.000Z.db"},
+		},
+		"retention": {
+			description: "Only the newest Keep backups are kept",
+			runs:        4,
+			keep:        2,
+			expectedFiles: []string{
+				"notely-20240301T140000.000Z.db",
+				"notely-20240301T150000.000Z.db",
+			},
+		},
+		"upload": {
+			description:   "Backups are uploaded when storage is configured",
+			runs:          2,
+			keep:          1,
+			upload:        &fakeStorage{},
+			expectedFiles: []string{"notely-20240301T130000.000Z.db"},
+			expectedKeys: []string{
+				"backups/notely-20240301T120000.000Z.db",
+				"backups/notely-20240301T130000.000Z.db",
+			},
+		},
+		"upload fails": {
+			description:   "A failed upload keeps the local backup",
+			runs:          1,
+			keep:          1,
+			upload:        &fakeStorage{err: errFailed},
+			expectedFiles: []string{"notely-20240301T120000.000Z.db"},
+			expectedErr:   errFailed,
+		},
+		"snapshot fails": {
+			description: "A failed snapshot leaves nothing behind",
+			runs:        1,
+			keep:        1,
+			dbErr:       errFailed,
+			expectedErr: errFailed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			cfg := Config{Dir: t.TempDir(), Keep: tc.keep}
+			if tc.upload != nil {
+				cfg.Upload = tc.upload
+			}
+			now := start
+			s := &Scheduler{db: fakeDB{err: tc.dbErr}, cfg: cfg, now: func() time.Time { return now }}
+
+			var err error
+			for i := 0; i < tc.runs; i++ {
+				_, err = s.Run(context.Background())
+				now = now.Add(time.Hour)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Run() error = %v, want %v", err, tc.expectedErr)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(cfg.Dir, "*"))
+			for i := range files {
+				files[i] = filepath.Base(files[i])
+			}
+			sort.Strings(files)
+			if diff := cmp.Diff(tc.expectedFiles, files); diff != "" {
+				t.Errorf("files mismatch (-want +got):\n%s", diff)
+			}
+			if tc.upload != nil {
+				if diff := cmp.Diff(tc.expectedKeys, tc.upload.keys); diff != "" {
+					t.Errorf("uploads mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package cache holds copies of hot data outside the database, either in
+// the server's memory or in Redis, where every server sharing a database
+// sees the same entries.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get for keys with no entry, or an expired one.
+var ErrMiss = errors.New("cache: miss")
+
+// Cache stores values under keys for a while. Callers mustn't modify the
+// values they pass in or get back.
+type Cache interface {
+	// Get returns the value stored under key, or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key, replacing any entry, for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete drops the entries under keys, if there are any.
+	Delete(ctx context.Context, keys ...string) error
+	// Close releases the cache. Nothing may use it after.
+	Close() error
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLRU(t *testing.T) {
+	// op sets key to value for ttl, or just reads key when ttl is zero.
+	type op struct {
+		key   string
+		value string
+		ttl   time.Duration
+	}
+	tests := map[string]struct {
+		description string
+		maxBytes    int
+		ops         []op
+		deletes     []string
+		advance     time.Duration
+		expected    map[string]string
+	}{
+		"stored": {
+			description: "Values are returned until they expire",
+			maxBytes:    100,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "2", time.Minute}},
+			expected:    map[string]string{"a": "1", "b": "2"},
+		},
+		"replaced": {
+			description: "Setting a key again replaces its value",
+			maxBytes:    100,
+			ops:         []op{{"a", "1", time.Minute}, {"a", "2", time.Minute}},
+			expected:    map[string]string{"a": "2"},
+		},
+		"expired": {
+			description: "Entries past their ttl are misses",
+			maxBytes:    100,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "2", 2 * time.Minute}},
+			advance:     time.Minute,
+			expected:    map[string]string{"b": "2"},
+		},
+		"evicted": {
+			description: "The least recently used entries make room for new ones",
+			maxBytes:    6,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "2", time.Minute}, {"c", "3", time.Minute}, {"d", "4", time.Minute}},
+			expected:    map[string]string{"b": "2", "c": "3", "d": "4"},
+		},
+		"recently used": {
+			description: "Reading an entry keeps it from being evicted",
+			maxBytes:    6,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "2", time.Minute}, {"c", "3", time.Minute}, {key: "a"}, {"d", "4", time.Minute}},
+			expected:    map[string]string{"a": "1", "c": "3", "d": "4"},
+		},
+		"too big": {
+			description: "Values bigger than the whole cache aren't stored",
+			maxBytes:    6,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "too big", time.Minute}},
+			expected:    map[string]string{"a": "1"},
+		},
+		"deleted": {
+			description: "Deleted entries are misses",
+			maxBytes:    100,
+			ops:         []op{{"a", "1", time.Minute}, {"b", "2", time.Minute}},
+			deletes:     []string{"a", "missing"},
+			expected:    map[string]string{"b": "2"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			c := NewLRU(tc.maxBytes)
+			c.now = func() time.Time { return now }
+			ctx := context.Background()
+
+			for _, o := range tc.ops {
+				if o.ttl == 0 {
+					c.Get(ctx, o.key)
+					continue
+				}
+				if err := c.Set(ctx, o.key, []byte(o.value), o.ttl); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.Delete(ctx, tc.deletes...); err != nil {
+				t.Fatal(err)
+			}
+			now = now.Add(tc.advance)
+
+			got := map[string]string{}
+			for _, key := range []string{"a", "b", "c", "d"} {
+				value, err := c.Get(ctx, key)
+				if errors.Is(err, ErrMiss) {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[key] = string(value)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Tiered is a Cache that keeps recently used entries of a shared cache,
+// such as Redis, in a second cache in the server's memory, saving the
+// round trip for them. Deletes reach both, but another server's deletes
+// only reach the shared cache, so entries are held near for at most
+// nearTTL.
+type Tiered struct {
+	near    Cache
+	far     Cache
+	nearTTL time.Duration
+}
+
+func NewTiered(near, far Cache, nearTTL time.Duration) *Tiered {
+	return &Tiered{near: near, far: far, nearTTL: nearTTL}
+}
+
+func (c *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.near.Get(ctx, key); err == nil {
+		return value, nil
+	}
+	value, err := c.far.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// The far entry's remaining ttl isn't known, so it may be held near
+	// for up to nearTTL after it expires.
+	_ = c.near.Set(ctx, key, value, c.nearTTL)
+	return value, nil
+}
+
+func (c *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.Join(
+		c.near.Set(ctx, key, value, min(ttl, c.nearTTL)),
+		c.far.Set(ctx, key, value, ttl),
+	)
+}
+
+func (c *Tiered) Delete(ctx context.Context, keys ...string) error {
+	return errors.Join(c.near.Delete(ctx, keys...), c.far.Delete(ctx, keys...))
+}
+
+// Close closes both caches.
+func (c *Tiered) Close() error {
+	return errors.Join(c.near.Close(), c.far.Close())
+}
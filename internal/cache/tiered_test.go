@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTiered(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		// change is made after "a" is set and read through the tiered
+		// cache, before it's read again.
+		change   func(ctx context.Context, c *Tiered, far *LRU)
+		advance  time.Duration
+		expected string
+	}{
+		"near": {
+			description: "Entries are served from near while it holds them",
+			change: func(ctx context.Context, c *Tiered, far *LRU) {
+				far.Set(ctx, "a", []byte("2"), time.Hour)
+			},
+			expected: "1",
+		},
+		"near expired": {
+			description: "Near entries last nearTTL at most, then far is asked again",
+			change: func(ctx context.Context, c *Tiered, far *LRU) {
+				far.Set(ctx, "a", []byte("2"), time.Hour)
+			},
+			advance:  time.Minute,
+			expected: "2",
+		},
+		"deleted": {
+			description: "Deletes reach both caches",
+			change: func(ctx context.Context, c *Tiered, far *LRU) {
+				c.Delete(ctx, "a")
+			},
+		},
+		"deleted elsewhere": {
+			description: "Deletes made straight to far are seen once the near entry expires",
+			change: func(ctx context.Context, c *Tiered, far *LRU) {
+				far.Delete(ctx, "a")
+			},
+			advance: time.Minute,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			clock := func() time.Time { return now }
+			near, far := NewLRU(100), NewLRU(100)
+			near.now, far.now = clock, clock
+			c := NewTiered(near, far, time.Minute)
+			ctx := context.Background()
+
+			if err := c.Set(ctx, "a", []byte("1"), time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Get(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			tc.change(ctx, c, far)
+			now = now.Add(tc.advance)
+
+			value, err := c.Get(ctx, "a")
+			if err != nil && !errors.Is(err, ErrMiss) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, string(value)); diff != "" {
+				t.Errorf("value mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTieredFillsNear(t *testing.T) {
+	near, far := NewLRU(100), NewLRU(100)
+	c := NewTiered(near, far, time.Minute)
+	ctx := context.Background()
+
+	if err := far.Set(ctx, "a", []byte("1"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	value, err := near.Get(ctx, "a")
+	if err != nil || string(value) != "1" {
+		t.Errorf("near.Get() = %q, %v, want the far entry", value, err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package config reads settings by name from the environment and an
+// optional YAML file, collecting every problem it finds instead of stopping
+// at the first, so a misconfigured deployment can be fixed in one pass.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Loader looks each setting up in the environment first and then in the
+// file read by ReadFile. An empty environment variable counts as unset.
+// Reads that fail to parse record an error and return the fallback, so
+// loading can carry on and report the rest.
+type Loader struct {
+	getenv func(string) string
+	file   map[string]string
+	path   string
+	read   map[string]bool
+	errs   []error
+}
+
+func NewLoader(getenv func(string) string) *Loader {
+	return &Loader{
+		getenv: getenv,
+		file:   map[string]string{},
+		read:   map[string]bool{},
+	}
+}
+
+// ReadFile loads settings from a YAML file mapping the same names as the
+// environment to values, or to lists for settings that take several:
+//
+//	PORT: 8080
+//	RATE_LIMIT_RPS: 2.5
+//	CORS_ALLOWED_ORIGINS:
+//	  - https://notes.example.com
+//
+// The error wraps fs.ErrNotExist when there is no file at path.
+func (l *Loader) ReadFile(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from operator config
+	if err != nil {
+		return err
+	}
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	file := map[string]string{}
+	for name, node := range doc {
+		switch {
+		case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+		case node.Kind == yaml.ScalarNode:
+			file[name] = node.Value
+		case node.Kind == yaml.SequenceNode:
+			items := make([]string, 0, len(node.Content))
+			for _, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return fmt.Errorf("%s: %s must be a list of values", path, name)
+				}
+				items = append(items, item.Value)
+			}
+			file[name] = strings.Join(items, ",")
+		default:
+			return fmt.Errorf("%s: %s must be a value or a list", path, name)
+		}
+	}
+	l.file, l.path = file, path
+	return nil
+}
+
+func (l *Loader) lookup(name string) string {
+	l.read[name] = true
+	if value := l.getenv(name); value != "" {
+		return value
+	}
+	return l.file[name]
+}
+
+// Errorf records a problem with the settings, such as a value out of range.
+func (l *Loader) Errorf(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// Err returns every problem recorded so far, one per line, along with any
+// name in the file that was never read, which is most likely a typo. Call
+// it once all settings have been read.
+func (l *Loader) Err() error {
+	var unknown []string
+	for name := range l.file {
+		if !l.read[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	errs := l.errs
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("%s: %s is not a known setting", l.path, name))
+	}
+	return errors.Join(errs...)
+}
+
+// IsSet reports whether name has a value.
+func (l *Loader) IsSet(name string) bool {
+	return l.lookup(name) != ""
+}
+
+func (l *Loader) String(name, fallback string) string {
+	if value := l.lookup(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// List reads a comma-separated list, or nil if name isn't set.
+func (l *Loader) List(name string) []string {
+	var list []string
+	for _, item := range strings.Split(l.lookup(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (l *Loader) Bool(name string) bool {
+	value := l.lookup(name)
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		l.Errorf("%s is not a valid boolean: %q", name, value)
+	}
+	return enabled
+}
+
+func (l *Loader) Int(name string, fallback int) int {
+	value := l.lookup(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.Errorf("%s is not a valid integer: %q", name, value)
+		return fallback
+	}
+	return parsed
+}
+
+func (l *Loader) Float(name string, fallback float64) float64 {
+	value := l.lookup(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.Errorf("%s is not a valid number: %q", name, value)
+		return fallback
+	}
+	return parsed
+}
+
+func (l *Loader) Duration(name string, fallback time.Duration) time.Duration {
+	value := l.lookup(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		l.Errorf("%s is not a valid duration: %q", name, value)
+		return fallback
+	}
+	return parsed
+}
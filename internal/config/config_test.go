@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func envMap(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoader(t *testing.T) {
+	path := writeFile(t, `
+PORT: 8080
+RATE_LIMIT_RPS: 2.5
+SHUTDOWN_TIMEOUT: 30s
+GUEST_READ_ACCESS: true
+CORS_ALLOWED_ORIGINS:
+  - https://a.example.com
+  - https://b.example.com
+JWT_SECRET: from-file
+SMTP_HOST: ~
+`)
+	l := NewLoader(envMap(map[string]string{"JWT_SECRET": "from-env", "PUBLIC_BASE_URL": ""}))
+	if err := l.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	type settings struct {
+		Port          string
+		RPS           float64
+		Burst         int
+		Shutdown      time.Duration
+		Guest         bool
+		Origins       []string
+		JWTSecret     string
+		PublicBaseURL string
+		SMTPHost      string
+	}
+	got := settings{
+		Port:          l.String("PORT", ""),
+		RPS:           l.Float("RATE_LIMIT_RPS", 10),
+		Burst:         l.Int("RATE_LIMIT_BURST", 20),
+		Shutdown:      l.Duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		Guest:         l.Bool("GUEST_READ_ACCESS"),
+		Origins:       l.List("CORS_ALLOWED_ORIGINS"),
+		JWTSecret:     l.String("JWT_SECRET", ""),
+		PublicBaseURL: l.String("PUBLIC_BASE_URL", "http://localhost:8080"),
+		SMTPHost:      l.String("SMTP_HOST", ""),
+	}
+	expected := settings{
+		Port:          "8080",
+		RPS:           2.5,
+		Burst:         20,
+		Shutdown:      30 * time.Second,
+		Guest:         true,
+		Origins:       []string{"https://a.example.com", "https://b.example.com"},
+		JWTSecret:     "from-env",
+		PublicBaseURL: "http://localhost:8080",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("settings mismatch (-want +got):\n%s", diff)
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		env         map[string]string
+		file        string
+		read        func(l *Loader)
+		expected    []string
+	}{
+		"malformed values": {
+			description: "Every malformed value is reported, not just the first",
+			env:         map[string]string{"A": "yes please", "B": "ten", "C": "1.5.2", "D": "5 minutes"},
+			read: func(l *Loader) {
+				l.Bool("A")
+				l.Int("B", 0)
+				l.Float("C", 0)
+				l.Duration("D", 0)
+			},
+			expected: []string{
+				`A is not a valid boolean: "yes please"`,
+				`B is not a valid integer: "ten"`,
+				`C is not a valid number: "1.5.2"`,
+				`D is not a valid duration: "5 minutes"`,
+			},
+		},
+		"recorded": {
+			description: "Problems found by the caller are reported alongside",
+			env:         map[string]string{"A": "0"},
+			read: func(l *Loader) {
+				if l.Int("A", 1) < 1 {
+					l.Errorf("A must be at least 1")
+				}
+			},
+			expected: []string{"A must be at least 1"},
+		},
+		"unknown": {
+			description: "Names in the file that are never read are reported as unknown",
+			file:        "A: 1\nPROT: 8080\nB: 2\n",
+			read: func(l *Loader) {
+				l.Int("A", 0)
+				l.Int("B", 0)
+			},
+			expected: []string{"%s: PROT is not a known setting"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			l := NewLoader(envMap(tc.env))
+			var path string
+			if tc.file != "" {
+				path = writeFile(t, tc.file)
+				if err := l.ReadFile(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tc.read(l)
+
+			var got []string
+			err := l.Err()
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					got = append(got, e.Error())
+				}
+			}
+			var expected []string
+			for _, msg := range tc.expected {
+				if path != "" {
+					msg = fmt.Sprintf(msg, path)
+				}
+				expected = append(expected, msg)
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		contents    string
+		wantErr     bool
+	}{
+		"flat": {
+			description: "Values and lists of values are accepted",
+			contents:    "PORT: 8080\nCORS_ALLOWED_METHODS: [GET, POST]\n",
+		},
+		"nested": {
+			description: "Nested settings are refused, since every name is flat",
+			contents:    "database:\n  url: postgres://localhost/notely\n",
+			wantErr:     true,
+		},
+		"nested list": {
+			description: "Lists of lists are refused",
+			contents:    "CORS_ALLOWED_METHODS:\n  - [GET]\n",
+			wantErr:     true,
+		},
+		"malformed": {
+			description: "Files that aren't YAML are refused",
+			contents:    "PORT: [8080\n",
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			err := NewLoader(envMap(nil)).ReadFile(writeFile(t, tc.contents))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	err := NewLoader(envMap(nil)).ReadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a missing file to wrap fs.ErrNotExist, got %v", err)
+	}
+}
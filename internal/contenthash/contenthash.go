@@ -0,0 +1,61 @@
+// Package contenthash fingerprints note bodies so identical notes can be
+// found without comparing them in full.
+package contenthash
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// backfillBatchSize is how many notes Backfill hashes per query.
+const backfillBatchSize = 500
+
+// Sum returns the hex SHA-256 of a note body after normalizing it, so notes
+// that differ only in line endings, trailing whitespace on lines, or
+// surrounding blank lines hash the same.
+func Sum(note string) string {
+	note = strings.ReplaceAll(note, "\r\n", "\n")
+	lines := strings.Split(note, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	normalized := strings.Trim(strings.Join(lines, "\n"), "\n")
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+type Store interface {
+	ListNotesWithoutContentHash(ctx context.Context, limit int64) ([]database.Note, error)
+	SetNoteContentHash(ctx context.Context, arg database.SetNoteContentHashParams) error
+}
+
+// Backfill hashes every unencrypted note written before content hashes were
+// stored and returns how many it updated.
+func Backfill(ctx context.Context, store Store) (int, error) {
+	updated := 0
+	for {
+		notes, err := store.ListNotesWithoutContentHash(ctx, backfillBatchSize)
+		if err != nil {
+			return updated, err
+		}
+		for _, note := range notes {
+			err := store.SetNoteContentHash(ctx, database.SetNoteContentHashParams{
+				ContentHash: sql.NullString{String: Sum(note.Note), Valid: true},
+				ID:          note.ID,
+			})
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(notes) < backfillBatchSize {
+			return updated, nil
+		}
+	}
+}
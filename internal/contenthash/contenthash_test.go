@@ -0,0 +1,131 @@
+package contenthash
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSum(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		a           string
+		b           string
+		same        bool
+	}{
+		"identical": {
+			description: "Identical notes hash the same",
+			a:           "buy milk",
+			b:           "buy milk",
+			same:        true,
+		},
+		"line endings": {
+			description: "CRLF and LF line endings hash the same",
+			a:           "one\r\ntwo",
+			b:           "one\ntwo",
+			same:        true,
+		},
+		"trailing whitespace": {
+			description: "Trailing spaces and surrounding blank lines are ignored",
+			a:           "\n\none  \ntwo\t\n\n",
+			b:           "one\ntwo",
+			same:        true,
+		},
+		"leading whitespace": {
+			description: "Indentation is significant",
+			a:           "  one",
+			b:           "one",
+		},
+		"case": {
+			description: "Case is significant",
+			a:           "Buy milk",
+			b:           "buy milk",
+		},
+		"inner blank lines": {
+			description: "Blank lines between paragraphs are significant",
+			a:           "one\n\ntwo",
+			b:           "one\ntwo",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := Sum(tc.a) == Sum(tc.b); got != tc.same {
+				t.Errorf("expected same hash: %v, got %v", tc.same, got)
+			}
+		})
+	}
+}
+
+type fakeStore struct {
+	notes []database.Note
+}
+
+func (s *fakeStore) ListNotesWithoutContentHash(ctx context.Context, limit int64) ([]database.Note, error) {
+	var missing []database.Note
+	for _, note := range s.notes {
+		if !note.ContentHash.Valid && !note.Encrypted && int64(len(missing)) < limit {
+			missing = append(missing, note)
+		}
+	}
+	return missing, nil
+}
+
+func (s *fakeStore) SetNoteContentHash(ctx context.Context, arg database.SetNoteContentHashParams) error {
+	for i := range s.notes {
+		if s.notes[i].ID == arg.ID {
+			s.notes[i].ContentHash = arg.ContentHash
+		}
+	}
+	return nil
+}
+
+func TestBackfill(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		notes       int
+		expected    int
+	}{
+		"none": {
+			description: "Nothing to backfill",
+		},
+		"one batch": {
+			description: "Fewer notes than a batch",
+			notes:       3,
+			expected:    3,
+		},
+		"several batches": {
+			description: "Keeps going until every note is hashed",
+			notes:       2*backfillBatchSize + 1,
+			expected:    2*backfillBatchSize + 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeStore{}
+			for i := 0; i < tc.notes; i++ {
+				store.notes = append(store.notes, database.Note{ID: fmt.Sprint(i), Note: "note"})
+			}
+			store.notes = append(store.notes, database.Note{ID: "encrypted", Encrypted: true})
+
+			updated, err := Backfill(context.Background(), store)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, updated); diff != "" {
+				t.Errorf("updated mismatch (-want +got):\n%s", diff)
+			}
+			for _, note := range store.notes {
+				if note.ContentHash.Valid == note.Encrypted {
+					t.Errorf("note %s: encrypted %v but hashed %v", note.ID, note.Encrypted, note.ContentHash.Valid)
+				}
+			}
+		})
+	}
+}
@@ -4,20 +4,238 @@
 
 package database
 
-import ()
+import (
+	"database/sql"
+)
+
+type ApiKey struct {
+	ID           string
+	CreatedAt    string
+	UpdatedAt    string
+	UserID       string
+	Label        string
+	KeyHash      string
+	KeyPrefix    string
+	RevokedAt    sql.NullString
+	ExpiresAt    sql.NullString
+	LastUsedAt   sql.NullString
+	LastUsedIp   sql.NullString
+	AllowedCidrs string
+}
+
+type AuthAudit struct {
+	ID               string
+	CreatedAt        string
+	UserID           sql.NullString
+	CredentialPrefix string
+	Ip               string
+	UserAgent        string
+	Success          bool
+	Reason           string
+}
+
+type ClientCert struct {
+	ID        string
+	CreatedAt string
+	Subject   string
+	UserID    string
+}
+
+type DataExport struct {
+	ID        string
+	CreatedAt string
+	UserID    string
+	TokenHash string
+	ExpiresAt string
+	Status    string
+	Data      sql.NullString
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
+	CreatedAt string
+	UserID    string
+	Email     string
+	ExpiresAt string
+}
+
+type IdempotencyKey struct {
+	UserID       string
+	Key          string
+	CreatedAt    string
+	RequestHash  string
+	StatusCode   sql.NullInt64
+	ResponseBody sql.NullString
+}
+
+type Job struct {
+	ID          string
+	CreatedAt   string
+	UpdatedAt   string
+	Kind        string
+	Payload     string
+	UniqueKey   sql.NullString
+	Status      string
+	Attempts    int64
+	MaxAttempts int64
+	RunAt       string
+	LastError   sql.NullString
+	FinishedAt  sql.NullString
+}
 
 type Note struct {
+	ID          string
+	CreatedAt   string
+	UpdatedAt   string
+	Note        string
+	UserID      string
+	Public      bool
+	NotebookID  sql.NullString
+	Archived    bool
+	Pinned      bool
+	Version     int64
+	RemindAt    sql.NullString
+	RemindedAt  sql.NullString
+	Encrypted   bool
+	Nonce       sql.NullString
+	Ciphertext  sql.NullString
+	ContentHash sql.NullString
+	WordCount   sql.NullInt64
+	Title       sql.NullString
+	Metadata    sql.NullString
+}
+
+type NoteItem struct {
 	ID        string
 	CreatedAt string
 	UpdatedAt string
-	Note      string
+	NoteID    string
+	Position  int64
+	Text      string
+	Done      bool
+}
+
+type NoteListChange struct {
 	UserID    string
+	ChangedAt string
+	Version   int64
 }
 
-type User struct {
+type NoteShare struct {
+	NoteID     string
+	UserID     string
+	CreatedAt  string
+	Permission string
+}
+
+type NoteShareLink struct {
+	ID        string
+	CreatedAt string
+	NoteID    string
+	TokenHash string
+	ExpiresAt sql.NullString
+	RevokedAt sql.NullString
+}
+
+type Notebook struct {
 	ID        string
 	CreatedAt string
 	UpdatedAt string
 	Name      string
-	ApiKey    string
+	UserID    string
+}
+
+type NoteTag struct {
+	NoteID string
+	TagID  string
+}
+
+type OauthIdentity struct {
+	Provider  string
+	Subject   string
+	CreatedAt string
+	UserID    string
+}
+
+type PasswordResetToken struct {
+	TokenHash string
+	CreatedAt string
+	UserID    string
+	ExpiresAt string
+}
+
+type RefreshToken struct {
+	TokenHash string
+	CreatedAt string
+	UpdatedAt string
+	UserID    string
+	ExpiresAt string
+	RevokedAt sql.NullString
+}
+
+type Session struct {
+	TokenHash string
+	CreatedAt string
+	UserID    string
+	ExpiresAt string
+}
+
+type Tag struct {
+	ID        string
+	CreatedAt string
+	UpdatedAt string
+	Name      string
+	UserID    string
+}
+
+type User struct {
+	ID                     string
+	CreatedAt              string
+	UpdatedAt              string
+	Name                   string
+	ApiKey                 string
+	ApiKeyHashed           int64
+	ApiKeyPrefix           string
+	Role                   string
+	TotpSecret             sql.NullString
+	TotpEnabledAt          sql.NullString
+	TotpLastStep           int64
+	Email                  sql.NullString
+	DeletionTokenHash      sql.NullString
+	DeletionTokenExpiresAt sql.NullString
+	PasswordHash           sql.NullString
+	EmailVerifiedAt        sql.NullString
+	SuspendedAt            sql.NullString
+	AvatarUpdatedAt        sql.NullString
+}
+
+type UserSetting struct {
+	UserID    string
+	Settings  string
+	UpdatedAt string
+}
+
+type Webhook struct {
+	ID        string
+	CreatedAt string
+	UpdatedAt string
+	UserID    string
+	Url       string
+	Secret    string
+	Events    string
+	Active    bool
+}
+
+type WebhookDelivery struct {
+	ID             string
+	CreatedAt      string
+	WebhookID      string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  string
+	LastAttemptAt  sql.NullString
+	ResponseStatus sql.NullInt64
+	LastError      sql.NullString
 }
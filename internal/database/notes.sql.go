@@ -7,19 +7,42 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countPinnedNotesForUser = `-- name: CountPinnedNotesForUser :one
+
+SELECT COUNT(*) FROM notes WHERE user_id = ? AND pinned
+`
+
+func (q *Queries) CountPinnedNotesForUser(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPinnedNotesForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createNote = `-- name: CreateNote :exec
-INSERT INTO notes (id, created_at, updated_at, note, user_id)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO notes (id, created_at, updated_at, note, user_id, public, notebook_id, remind_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateNoteParams struct {
-	ID        string
-	CreatedAt string
-	UpdatedAt string
-	Note      string
-	UserID    string
+	ID          string
+	CreatedAt   string
+	UpdatedAt   string
+	Note        string
+	UserID      string
+	Public      bool
+	NotebookID  sql.NullString
+	RemindAt    sql.NullString
+	Encrypted   bool
+	Nonce       sql.NullString
+	Ciphertext  sql.NullString
+	ContentHash sql.NullString
+	WordCount   sql.NullInt64
+	Title       sql.NullString
+	Metadata    sql.NullString
 }
 
 func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) error {
@@ -29,13 +52,66 @@ func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) error {
 		arg.UpdatedAt,
 		arg.Note,
 		arg.UserID,
+		arg.Public,
+		arg.NotebookID,
+		arg.RemindAt,
+		arg.Encrypted,
+		arg.Nonce,
+		arg.Ciphertext,
+		arg.ContentHash,
+		arg.WordCount,
+		arg.Title,
+		arg.Metadata,
 	)
 	return err
 }
 
+const deleteNote = `-- name: DeleteNote :execrows
+
+DELETE FROM notes WHERE id = ? AND user_id = ?
+`
+
+type DeleteNoteParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) DeleteNote(ctx context.Context, arg DeleteNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteNote, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteNotesForUser = `-- name: DeleteNotesForUser :exec
+
+DELETE FROM notes WHERE user_id = ?
+`
+
+func (q *Queries) DeleteNotesForUser(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteNotesForUser, userID)
+	return err
+}
+
+const deleteNotesInNotebook = `-- name: DeleteNotesInNotebook :exec
+
+DELETE FROM notes WHERE notebook_id = ? AND user_id = ?
+`
+
+type DeleteNotesInNotebookParams struct {
+	NotebookID sql.NullString
+	UserID     string
+}
+
+func (q *Queries) DeleteNotesInNotebook(ctx context.Context, arg DeleteNotesInNotebookParams) error {
+	_, err := q.db.ExecContext(ctx, deleteNotesInNotebook, arg.NotebookID, arg.UserID)
+	return err
+}
+
 const getNote = `-- name: GetNote :one
 
-SELECT id, created_at, updated_at, note, user_id FROM notes WHERE id = ?
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes WHERE id = ?
 `
 
 func (q *Queries) GetNote(ctx context.Context, id string) (Note, error) {
@@ -47,13 +123,67 @@ func (q *Queries) GetNote(ctx context.Context, id string) (Note, error) {
 		&i.UpdatedAt,
 		&i.Note,
 		&i.UserID,
+		&i.Public,
+		&i.NotebookID,
+		&i.Archived,
+		&i.Pinned,
+		&i.Version,
+		&i.RemindAt,
+		&i.RemindedAt,
+		&i.Encrypted,
+		&i.Nonce,
+		&i.Ciphertext,
+		&i.ContentHash,
+		&i.WordCount,
+		&i.Title,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const getNoteByContentHash = `-- name: GetNoteByContentHash :one
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE user_id = ? AND content_hash = ?
+ORDER BY created_at, id
+LIMIT 1
+`
+
+type GetNoteByContentHashParams struct {
+	UserID      string
+	ContentHash sql.NullString
+}
+
+func (q *Queries) GetNoteByContentHash(ctx context.Context, arg GetNoteByContentHashParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, getNoteByContentHash, arg.UserID, arg.ContentHash)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Note,
+		&i.UserID,
+		&i.Public,
+		&i.NotebookID,
+		&i.Archived,
+		&i.Pinned,
+		&i.Version,
+		&i.RemindAt,
+		&i.RemindedAt,
+		&i.Encrypted,
+		&i.Nonce,
+		&i.Ciphertext,
+		&i.ContentHash,
+		&i.WordCount,
+		&i.Title,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getNotesForUser = `-- name: GetNotesForUser :many
 
-SELECT id, created_at, updated_at, note, user_id FROM notes WHERE user_id = ?
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes WHERE user_id = ?
 `
 
 func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, error) {
@@ -71,6 +201,487 @@ func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, e
 			&i.UpdatedAt,
 			&i.Note,
 			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueReminders = `-- name: ListDueReminders :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE remind_at <= ? AND reminded_at IS NULL AND NOT archived
+ORDER BY remind_at, id
+LIMIT ?
+`
+
+type ListDueRemindersParams struct {
+	RemindAt sql.NullString
+	Limit    int64
+}
+
+func (q *Queries) ListDueReminders(ctx context.Context, arg ListDueRemindersParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listDueReminders, arg.RemindAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDuplicateNotesForUser = `-- name: ListDuplicateNotesForUser :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE user_id = ?1 AND content_hash IN (
+  SELECT content_hash FROM notes
+  WHERE user_id = ?1 AND content_hash IS NOT NULL
+  GROUP BY content_hash
+  HAVING COUNT(*) > 1
+)
+ORDER BY content_hash, created_at, id
+`
+
+func (q *Queries) ListDuplicateNotesForUser(ctx context.Context, userID string) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listDuplicateNotesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotesForUser = `-- name: ListNotesForUser :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE user_id = ?1
+  AND (CAST(?2 AS BOOLEAN) OR NOT archived)
+  AND (?3 IS NULL OR created_at >= ?3)
+  AND (?4 IS NULL OR created_at < ?4)
+  AND (?5 IS NULL OR notebook_id = ?5)
+  AND (?6 IS NULL OR id IN (
+    SELECT note_tags.note_id FROM note_tags
+    JOIN tags ON tags.id = note_tags.tag_id
+    WHERE tags.user_id = ?1 AND tags.name = ?6
+  ))
+  AND (
+    ?7 IS NULL
+    OR pinned < CAST(?8 AS BOOLEAN)
+    OR (pinned = CAST(?8 AS BOOLEAN) AND NOT CAST(?9 AS BOOLEAN) AND (
+      CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END > ?7
+      OR (CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END = ?7 AND id > ?11)
+    ))
+    OR (pinned = CAST(?8 AS BOOLEAN) AND CAST(?9 AS BOOLEAN) AND (
+      CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END < ?7
+      OR (CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END = ?7 AND id < ?11)
+    ))
+  )
+ORDER BY
+  pinned DESC,
+  CASE WHEN CAST(?9 AS BOOLEAN) THEN NULL ELSE CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END END ASC,
+  CASE WHEN CAST(?9 AS BOOLEAN) THEN CASE CAST(?10 AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END END DESC,
+  CASE WHEN CAST(?9 AS BOOLEAN) THEN NULL ELSE id END ASC,
+  CASE WHEN CAST(?9 AS BOOLEAN) THEN id END DESC
+LIMIT ?12 OFFSET ?13
+`
+
+type ListNotesForUserParams struct {
+	UserID          string
+	IncludeArchived bool
+	CreatedAfter    sql.NullString
+	CreatedBefore   sql.NullString
+	NotebookID      sql.NullString
+	Tag             sql.NullString
+	AfterValue      sql.NullString
+	AfterPinned     bool
+	Descending      bool
+	SortBy          string
+	AfterID         sql.NullString
+	Limit           int64
+	Offset          int64
+}
+
+func (q *Queries) ListNotesForUser(ctx context.Context, arg ListNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesForUser,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.NotebookID,
+		arg.Tag,
+		arg.AfterValue,
+		arg.AfterPinned,
+		arg.Descending,
+		arg.SortBy,
+		arg.AfterID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotesWithoutContentHash = `-- name: ListNotesWithoutContentHash :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE content_hash IS NULL AND NOT encrypted
+LIMIT ?
+`
+
+func (q *Queries) ListNotesWithoutContentHash(ctx context.Context, limit int64) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesWithoutContentHash, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotesWithoutWordCount = `-- name: ListNotesWithoutWordCount :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE word_count IS NULL AND NOT encrypted
+LIMIT ?
+`
+
+func (q *Queries) ListNotesWithoutWordCount(ctx context.Context, limit int64) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesWithoutWordCount, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUpcomingNotesForUser = `-- name: ListUpcomingNotesForUser :many
+
+SELECT id, created_at, updated_at, note, user_id, public, notebook_id, archived, pinned, version, remind_at, reminded_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata FROM notes
+WHERE user_id = ? AND remind_at > ? AND NOT archived
+ORDER BY remind_at, id
+LIMIT ?
+`
+
+type ListUpcomingNotesForUserParams struct {
+	UserID   string
+	RemindAt sql.NullString
+	Limit    int64
+}
+
+func (q *Queries) ListUpcomingNotesForUser(ctx context.Context, arg ListUpcomingNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listUpcomingNotesForUser, arg.UserID, arg.RemindAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNoteReminded = `-- name: MarkNoteReminded :execrows
+
+UPDATE notes SET reminded_at = ? WHERE id = ? AND remind_at = ? AND reminded_at IS NULL
+`
+
+type MarkNoteRemindedParams struct {
+	RemindedAt sql.NullString
+	ID         string
+	RemindAt   sql.NullString
+}
+
+func (q *Queries) MarkNoteReminded(ctx context.Context, arg MarkNoteRemindedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNoteReminded, arg.RemindedAt, arg.ID, arg.RemindAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const moveNotesToDefaultNotebook = `-- name: MoveNotesToDefaultNotebook :exec
+
+UPDATE notes SET notebook_id = NULL WHERE notebook_id = ? AND user_id = ?
+`
+
+type MoveNotesToDefaultNotebookParams struct {
+	NotebookID sql.NullString
+	UserID     string
+}
+
+func (q *Queries) MoveNotesToDefaultNotebook(ctx context.Context, arg MoveNotesToDefaultNotebookParams) error {
+	_, err := q.db.ExecContext(ctx, moveNotesToDefaultNotebook, arg.NotebookID, arg.UserID)
+	return err
+}
+
+const searchNotesForUser = `-- name: SearchNotesForUser :many
+
+SELECT notes.id, notes.created_at, notes.updated_at, notes.note, notes.user_id, notes.public, notes.notebook_id, notes.archived, notes.pinned, notes.version, notes.remind_at, notes.reminded_at, notes.encrypted, notes.nonce, notes.ciphertext, notes.content_hash, notes.word_count, notes.title, notes.metadata FROM notes
+JOIN notes_fts ON notes_fts.rowid = notes.rowid
+WHERE notes.user_id = ?1 AND notes_fts MATCH ?2 AND NOT notes.encrypted
+ORDER BY notes_fts.rank
+LIMIT ?3
+`
+
+type SearchNotesForUserParams struct {
+	UserID string
+	Query  string
+	Limit  int64
+}
+
+func (q *Queries) SearchNotesForUser(ctx context.Context, arg SearchNotesForUserParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotesForUser, arg.UserID, arg.Query, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.UserID,
+			&i.Public,
+			&i.NotebookID,
+			&i.Archived,
+			&i.Pinned,
+			&i.Version,
+			&i.RemindAt,
+			&i.RemindedAt,
+			&i.Encrypted,
+			&i.Nonce,
+			&i.Ciphertext,
+			&i.ContentHash,
+			&i.WordCount,
+			&i.Title,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -84,3 +695,157 @@ func (q *Queries) GetNotesForUser(ctx context.Context, userID string) ([]Note, e
 	}
 	return items, nil
 }
+
+const setNoteArchived = `-- name: SetNoteArchived :execrows
+
+UPDATE notes SET archived = ?, version = version + 1 WHERE id = ? AND user_id = ?
+`
+
+type SetNoteArchivedParams struct {
+	Archived bool
+	ID       string
+	UserID   string
+}
+
+func (q *Queries) SetNoteArchived(ctx context.Context, arg SetNoteArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setNoteArchived, arg.Archived, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setNoteContentHash = `-- name: SetNoteContentHash :exec
+
+UPDATE notes SET content_hash = ? WHERE id = ?
+`
+
+type SetNoteContentHashParams struct {
+	ContentHash sql.NullString
+	ID          string
+}
+
+func (q *Queries) SetNoteContentHash(ctx context.Context, arg SetNoteContentHashParams) error {
+	_, err := q.db.ExecContext(ctx, setNoteContentHash, arg.ContentHash, arg.ID)
+	return err
+}
+
+const setNotePinned = `-- name: SetNotePinned :execrows
+
+UPDATE notes SET pinned = ?, version = version + 1 WHERE id = ? AND user_id = ?
+`
+
+type SetNotePinnedParams struct {
+	Pinned bool
+	ID     string
+	UserID string
+}
+
+func (q *Queries) SetNotePinned(ctx context.Context, arg SetNotePinnedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setNotePinned, arg.Pinned, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setNoteWordCount = `-- name: SetNoteWordCount :exec
+
+UPDATE notes SET word_count = ? WHERE id = ?
+`
+
+type SetNoteWordCountParams struct {
+	WordCount sql.NullInt64
+	ID        string
+}
+
+func (q *Queries) SetNoteWordCount(ctx context.Context, arg SetNoteWordCountParams) error {
+	_, err := q.db.ExecContext(ctx, setNoteWordCount, arg.WordCount, arg.ID)
+	return err
+}
+
+const touchNote = `-- name: TouchNote :execrows
+
+UPDATE notes SET updated_at = ?, version = version + 1 WHERE id = ? AND version = ?
+`
+
+type TouchNoteParams struct {
+	UpdatedAt string
+	ID        string
+	Version   int64
+}
+
+func (q *Queries) TouchNote(ctx context.Context, arg TouchNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, touchNote, arg.UpdatedAt, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateNote = `-- name: UpdateNote :execrows
+
+UPDATE notes
+SET note = COALESCE(?1, note),
+    content_hash = COALESCE(?2, content_hash),
+    word_count = COALESCE(?3, word_count),
+    title = CASE WHEN CAST(?4 AS BOOLEAN) THEN ?5 ELSE title END,
+    metadata = CASE WHEN CAST(?6 AS BOOLEAN) THEN ?7 ELSE metadata END,
+    public = COALESCE(?8, public),
+    nonce = COALESCE(?9, nonce),
+    ciphertext = COALESCE(?10, ciphertext),
+    notebook_id = CASE WHEN CAST(?11 AS BOOLEAN) THEN ?12 ELSE notebook_id END,
+    remind_at = CASE WHEN CAST(?13 AS BOOLEAN) THEN ?14 ELSE remind_at END,
+    reminded_at = CASE WHEN CAST(?13 AS BOOLEAN) THEN NULL ELSE reminded_at END,
+    updated_at = ?15,
+    version = version + 1
+WHERE id = ?16 AND user_id = ?17 AND version = ?18
+`
+
+type UpdateNoteParams struct {
+	Note        sql.NullString
+	ContentHash sql.NullString
+	WordCount   sql.NullInt64
+	SetTitle    bool
+	Title       sql.NullString
+	SetMetadata bool
+	Metadata    sql.NullString
+	Public      sql.NullBool
+	Nonce       sql.NullString
+	Ciphertext  sql.NullString
+	SetNotebook bool
+	NotebookID  sql.NullString
+	SetRemindAt bool
+	RemindAt    sql.NullString
+	UpdatedAt   string
+	ID          string
+	UserID      string
+	Version     int64
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateNote,
+		arg.Note,
+		arg.ContentHash,
+		arg.WordCount,
+		arg.SetTitle,
+		arg.Title,
+		arg.SetMetadata,
+		arg.Metadata,
+		arg.Public,
+		arg.Nonce,
+		arg.Ciphertext,
+		arg.SetNotebook,
+		arg.NotebookID,
+		arg.SetRemindAt,
+		arg.RemindAt,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.UserID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// QueryObserver is told how long each statement took. name is the sqlc
+// query name, or "other" for statements that aren't generated queries.
+type QueryObserver func(name string, took time.Duration, err error)
+
+// observeDBTX reports every statement run through db to observe. Queries
+// are timed until their first result is ready, not until their rows are
+// read.
+type observeDBTX struct {
+	db      DBTX
+	observe QueryObserver
+}
+
+func (o observeDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := o.db.ExecContext(ctx, query, args...)
+	o.observe(QueryName(query), time.Since(start), err)
+	return result, err
+}
+
+func (o observeDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return o.db.PrepareContext(ctx, query)
+}
+
+func (o observeDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	o.observe(QueryName(query), time.Since(start), err)
+	return rows, err
+}
+
+func (o observeDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := o.db.QueryRowContext(ctx, query, args...)
+	o.observe(QueryName(query), time.Since(start), row.Err())
+	return row
+}
+
+// QueryName returns the name sqlc gave query in its "-- name:" comment, or
+// "other".
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(query), "-- name: ")
+	if !ok {
+		return "other"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// maxPrepared caps the statements a preparedDBTX keeps. Queries with a
+// sqlc.slice argument produce a different statement for each slice length,
+// so without a cap they could grow the cache without bound. Statements past
+// the cap run unprepared.
+const maxPrepared = 512
+
+// hotQueries are prepared when the cache is created rather than on first
+// use: authentication runs on every request and note listing is the most
+// common read.
+var hotQueries = []string{
+	getActiveAPIKeyByHash,
+	getUserByID,
+	listNotesForUser,
+	getNote,
+}
+
+// preparedDBTX runs statements through prepared statements on db, prepared
+// on first use and reused after that, so hot queries aren't parsed again
+// on every request. database/sql prepares each statement again on every
+// connection it lands on, so it works across the whole pool.
+type preparedDBTX struct {
+	db *sql.DB
+
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+func newPreparedDBTX(ctx context.Context, db *sql.DB) (*preparedDBTX, error) {
+	p := &preparedDBTX{db: db, stmts: map[string]*sql.Stmt{}}
+	for _, query := range hotQueries {
+		if _, err := p.stmt(ctx, query); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *preparedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := p.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return p.db.ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (p *preparedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, query)
+}
+
+func (p *preparedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := p.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return p.db.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// QueryRowContext falls back to an unprepared query if preparing fails,
+// since a *sql.Row can't be built to carry the error.
+func (p *preparedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := p.stmt(ctx, query)
+	if err != nil || stmt == nil {
+		return p.db.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// stmt returns the prepared statement for query, preparing it if needed.
+// It returns nil once the cache is full.
+func (p *preparedDBTX) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	p.mu.RLock()
+	stmt, ok := p.stmts[query]
+	p.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if stmt, ok := p.stmts[query]; ok {
+		return stmt, nil
+	}
+	if len(p.stmts) >= maxPrepared {
+		return nil, nil
+	}
+	stmt, err := p.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	p.stmts[query] = stmt
+	return stmt, nil
+}
+
+// Close closes every prepared statement.
+func (p *preparedDBTX) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for query, stmt := range p.stmts {
+		errs = append(errs, stmt.Close())
+		delete(p.stmts, query)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// countingDriver counts the statements prepared on its connections. Every
+// statement succeeds and returns no rows.
+type countingDriver struct {
+	prepared *atomic.Int64
+}
+
+func (d countingDriver) Open(name string) (driver.Conn, error) {
+	return countingConn(d), nil
+}
+
+type countingConn struct {
+	prepared *atomic.Int64
+}
+
+func (c countingConn) Prepare(query string) (driver.Stmt, error) {
+	c.prepared.Add(1)
+	return countingStmt{}, nil
+}
+
+func (c countingConn) Close() error              { return nil }
+func (c countingConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type countingStmt struct{}
+
+func (countingStmt) Close() error  { return nil }
+func (countingStmt) NumInput() int { return -1 }
+func (countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+func (countingStmt) Query(args []driver.Value) (driver.Rows, error) { return emptyRows{}, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+var driverCount atomic.Int64
+
+func TestPreparedDBTX(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		queries     []string
+		fill        bool
+		expected    int64
+	}{
+		"reused": {
+			description: "Running a query again reuses its statement",
+			queries:     []string{"SELECT 1", "SELECT 1", "SELECT 1"},
+			expected:    1,
+		},
+		"hot": {
+			description: "Hot queries are already prepared",
+			queries:     []string{getUserByID, getNote},
+			expected:    0,
+		},
+		"distinct": {
+			description: "Each distinct query is prepared once",
+			queries:     []string{"SELECT 1", "SELECT 2", "SELECT 1"},
+			expected:    2,
+		},
+		"full": {
+			description: "Past the cap, queries run without being cached",
+			fill:        true,
+			queries:     []string{"SELECT 1", "SELECT 1"},
+			expected:    2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			var prepared atomic.Int64
+			driverName := fmt.Sprintf("counting%d", driverCount.Add(1))
+			sql.Register(driverName, countingDriver{prepared: &prepared})
+			db, err := sql.Open(driverName, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			db.SetMaxOpenConns(1)
+
+			ctx := context.Background()
+			p, err := newPreparedDBTX(ctx, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			for i := len(p.stmts); tc.fill && i < maxPrepared; i++ {
+				if _, err := p.ExecContext(ctx, fmt.Sprintf("SELECT %d -- fill", i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := prepared.Load()
+			for _, query := range tc.queries {
+				rows, err := p.QueryContext(ctx, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rows.Close()
+			}
+			if got := prepared.Load() - before; got != tc.expected {
+				t.Errorf("prepared %d statements, want %d", got, tc.expected)
+			}
+		})
+	}
+}
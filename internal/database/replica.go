@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+type wroteKey struct{}
+
+// TrackWrites returns a context that remembers whether a write has run
+// through it. Once one has, reads on the same context go to the primary
+// too, so a handler sees its own writes even while the replica lags.
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wroteKey{}, new(atomic.Bool))
+}
+
+func markWrote(ctx context.Context) {
+	if wrote, ok := ctx.Value(wroteKey{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	wrote, ok := ctx.Value(wroteKey{}).(*atomic.Bool)
+	return ok && wrote.Load()
+}
+
+// replicaDBTX sends reads to replica and everything else to primary.
+type replicaDBTX struct {
+	primary DBTX
+	replica DBTX
+}
+
+func (r replicaDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	markWrote(ctx)
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r replicaDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.route(ctx, query).PrepareContext(ctx, query)
+}
+
+func (r replicaDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (r replicaDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+func (r replicaDBTX) route(ctx context.Context, query string) DBTX {
+	if !isRead(query) {
+		markWrote(ctx)
+		return r.primary
+	}
+	if hasWritten(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
+
+// isRead reports whether query is a plain SELECT, skipping the comments
+// sqlc puts in front of each query.
+func isRead(query string) bool {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+		_, query, _ = strings.Cut(query, "\n")
+	}
+	end := strings.IndexFunc(query, unicode.IsSpace)
+	if end < 0 {
+		end = len(query)
+	}
+	return strings.EqualFold(query[:end], "SELECT")
+}
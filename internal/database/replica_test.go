@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// namedDBTX records the name of the pool each statement ran on.
+type namedDBTX struct {
+	DBTX
+	name string
+	ran  *[]string
+}
+
+func (n namedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	*n.ran = append(*n.ran, n.name)
+	return nil, nil
+}
+
+func (n namedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	*n.ran = append(*n.ran, n.name)
+	return nil, nil
+}
+
+func TestReplicaDBTX(t *testing.T) {
+	const (
+		read   = "-- name: GetNote :one\nSELECT * FROM notes WHERE id = ?"
+		upsert = "-- name: CreateNoteShareLink :one\nINSERT INTO note_share_links (id) VALUES (?) RETURNING *"
+		write  = "UPDATE notes SET pinned = 1"
+	)
+
+	tests := map[string]struct {
+		description string
+		track       bool
+		statements  []string
+		expected    []string
+	}{
+		"reads": {
+			description: "Reads go to the replica",
+			statements:  []string{read, read},
+			expected:    []string{"replica", "replica"},
+		},
+		"writes": {
+			description: "Writes go to the primary, including those returning rows",
+			statements:  []string{write, upsert},
+			expected:    []string{"primary", "primary"},
+		},
+		"untracked": {
+			description: "Without TrackWrites, reads after a write still go to the replica",
+			statements:  []string{write, read},
+			expected:    []string{"primary", "replica"},
+		},
+		"tracked": {
+			description: "With TrackWrites, reads after a write go to the primary",
+			track:       true,
+			statements:  []string{read, upsert, read},
+			expected:    []string{"replica", "primary", "primary"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			var ran []string
+			db := replicaDBTX{
+				primary: namedDBTX{name: "primary", ran: &ran},
+				replica: namedDBTX{name: "replica", ran: &ran},
+			}
+			ctx := context.Background()
+			if tc.track {
+				ctx = TrackWrites(ctx)
+			}
+			for _, statement := range tc.statements {
+				if statement == write {
+					db.ExecContext(ctx, statement)
+				} else {
+					db.QueryContext(ctx, statement)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, ran); diff != "" {
+				t.Errorf("pools mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
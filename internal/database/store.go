@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Store is the database as the API uses it: every generated query plus
+// transactions. SQLStore runs on a database/sql pool; internal/memstore keeps
+// everything in memory for tests and local runs.
+type Store interface {
+	Querier
+	BeginTx(ctx context.Context) (Tx, error)
+	// Ping checks that the database can be reached.
+	Ping(ctx context.Context) error
+	// Close releases the database. Nothing may run on the store after.
+	Close() error
+}
+
+// Tx is a transaction. Its writes take effect together on Commit. Rollback
+// after Commit has no effect beyond returning sql.ErrTxDone, so it can
+// always be deferred.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// SQLStore runs the generated queries on a SQLite, Turso, PostgreSQL or
+// MySQL pool.
+type SQLStore struct {
+	*Queries
+	db           *sql.DB
+	replica      *sql.DB
+	immediate    bool
+	queryTimeout time.Duration
+	observe      QueryObserver
+	trace        bool
+
+	// Set by PrepareStatements.
+	preparedDB      *preparedDBTX
+	preparedReplica *preparedDBTX
+}
+
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{Queries: New(db), db: db}
+}
+
+// NewSQLiteStore is NewStore for a local SQLite file. Its transactions
+// start with BEGIN IMMEDIATE, taking the write lock up front. A deferred
+// transaction that reads before writing fails with SQLITE_BUSY straight
+// away, without waiting on busy_timeout, if another write got in between.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return &SQLStore{Queries: New(db), db: db, immediate: true}
+}
+
+// DB returns the primary's pool, for work outside the generated queries.
+func (s *SQLStore) DB() *sql.DB {
+	return s.db
+}
+
+// SetQueryTimeout limits every statement run through s, including those in
+// transactions begun afterwards, to d. Statements that run out of time fail
+// with an error wrapping ErrQueryTimeout. Zero means no limit.
+func (s *SQLStore) SetQueryTimeout(d time.Duration) {
+	s.queryTimeout = d
+	s.Queries = New(s.dbtx(s.pool()))
+}
+
+// SetReplica sends reads outside transactions to replica, a read-only copy
+// of the primary. Reads on a context from TrackWrites go to the primary
+// once a write has. Transactions always run on the primary.
+func (s *SQLStore) SetReplica(replica *sql.DB) {
+	s.replica = replica
+	s.Queries = New(s.dbtx(s.pool()))
+}
+
+// PrepareStatements runs statements outside transactions as prepared
+// statements, kept for reuse so they aren't parsed again on every request.
+// The hottest queries are prepared straight away. Call it after
+// SetReplica, if at all, so the replica's statements are prepared too.
+func (s *SQLStore) PrepareStatements(ctx context.Context) error {
+	primary, err := newPreparedDBTX(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	if s.replica != nil {
+		replica, err := newPreparedDBTX(ctx, s.replica)
+		if err != nil {
+			primary.Close()
+			return fmt.Errorf("replica: %w", err)
+		}
+		s.preparedReplica = replica
+	}
+	s.preparedDB = primary
+	s.Queries = New(s.dbtx(s.pool()))
+	return nil
+}
+
+// pool is where statements outside transactions run.
+func (s *SQLStore) pool() DBTX {
+	var primary DBTX = s.db
+	if s.preparedDB != nil {
+		primary = s.preparedDB
+	}
+	if s.replica == nil {
+		return primary
+	}
+	var replica DBTX = s.replica
+	if s.preparedReplica != nil {
+		replica = s.preparedReplica
+	}
+	return replicaDBTX{primary: primary, replica: replica}
+}
+
+// SetQueryObserver reports every statement run through s, including those
+// in transactions begun afterwards, to observe.
+func (s *SQLStore) SetQueryObserver(observe QueryObserver) {
+	s.observe = observe
+	s.Queries = New(s.dbtx(s.pool()))
+}
+
+// SetTracing runs every statement through s, including those in
+// transactions begun afterwards, in a span under the one in its context.
+func (s *SQLStore) SetTracing(trace bool) {
+	s.trace = trace
+	s.Queries = New(s.dbtx(s.pool()))
+}
+
+// dbtx applies the query timeout, observer and tracing, if any, to db.
+func (s *SQLStore) dbtx(db DBTX) DBTX {
+	if s.queryTimeout > 0 {
+		db = timeoutDBTX{db: db, timeout: s.queryTimeout}
+	}
+	if s.observe != nil {
+		db = observeDBTX{db: db, observe: s.observe}
+	}
+	if s.trace {
+		db = traceDBTX{db: db}
+	}
+	return db
+}
+
+// Close closes the prepared statements and then the pools, the replica's
+// included.
+func (s *SQLStore) Close() error {
+	var errs []error
+	if s.preparedDB != nil {
+		errs = append(errs, s.preparedDB.Close())
+	}
+	if s.preparedReplica != nil {
+		errs = append(errs, s.preparedReplica.Close())
+	}
+	if s.replica != nil {
+		errs = append(errs, s.replica.Close())
+	}
+	errs = append(errs, s.db.Close())
+	return errors.Join(errs...)
+}
+
+// Ping runs a trivial query on the primary and, if there is one, the
+// replica. Some drivers connect lazily, so a plain PingContext could pass
+// without reaching the server.
+func (s *SQLStore) Ping(ctx context.Context) error {
+	if err := ping(ctx, s.db); err != nil {
+		return err
+	}
+	if s.replica != nil {
+		if err := ping(ctx, s.replica); err != nil {
+			return fmt.Errorf("replica: %w", err)
+		}
+	}
+	return nil
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	var one int
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+func (s *SQLStore) BeginTx(ctx context.Context) (Tx, error) {
+	if s.immediate {
+		return s.beginImmediate(ctx)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sqlTx{Queries: New(s.dbtx(tx)), tx: tx}, nil
+}
+
+// beginImmediate runs BEGIN IMMEDIATE on a connection of its own, since
+// database/sql can't pass the mode through BeginTx.
+func (s *SQLStore) beginImmediate(ctx context.Context) (Tx, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.dbtx(conn).ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &immediateTx{Queries: New(s.dbtx(conn)), conn: conn}, nil
+}
+
+type sqlTx struct {
+	*Queries
+	tx *sql.Tx
+}
+
+func (t sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// WithTx runs fn in a transaction on db. The transaction commits if fn
+// returns nil and rolls back otherwise, so a failure part way through a
+// multi-table change leaves nothing behind. fn's error is returned as is.
+func WithTx(ctx context.Context, db Store, fn func(tx Querier) error) error {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit transaction: %w", err)
+	}
+	return nil
+}
+
+// immediateTx is a transaction begun by beginImmediate.
+type immediateTx struct {
+	*Queries
+	conn *sql.Conn
+	done bool
+}
+
+func (t *immediateTx) Commit() error {
+	return t.finish("COMMIT")
+}
+
+func (t *immediateTx) Rollback() error {
+	return t.finish("ROLLBACK")
+}
+
+// finish ends the transaction and releases its connection. If the
+// transaction might still be open, the connection is discarded rather than
+// returned to the pool.
+func (t *immediateTx) finish(statement string) error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	defer t.conn.Close()
+
+	ctx := context.Background()
+	_, err := t.conn.ExecContext(ctx, statement)
+	if err == nil {
+		return nil
+	}
+	if statement == "COMMIT" {
+		if _, rollbackErr := t.conn.ExecContext(ctx, "ROLLBACK"); rollbackErr == nil {
+			return err
+		}
+	}
+	t.conn.Raw(func(any) error { return driver.ErrBadConn })
+	return err
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryTimeout is wrapped into the errors of statements that ran past
+// their store's query timeout.
+var ErrQueryTimeout = errors.New("database query timed out")
+
+// IsTimeout reports whether err came from a query that ran out of time,
+// either past the query timeout or past a deadline on its context.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrQueryTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// timeoutDBTX gives every statement run through db a deadline of timeout.
+type timeoutDBTX struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func (t timeoutDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := t.deadline(ctx)
+	defer cancel()
+	result, err := t.db.ExecContext(ctx, query, args...)
+	return result, wrapTimeout(ctx, err)
+}
+
+func (t timeoutDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, cancel := t.deadline(ctx)
+	defer cancel()
+	stmt, err := t.db.PrepareContext(ctx, query)
+	return stmt, wrapTimeout(ctx, err)
+}
+
+// QueryContext's rows are read after it returns, so the deadline can't be
+// cancelled early. It's released when it passes or when ctx is done,
+// whichever is first.
+func (t timeoutDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, cancel := t.deadline(ctx)
+	_ = cancel
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	return rows, wrapTimeout(ctx, err)
+}
+
+// QueryRowContext's deadline is released like QueryContext's.
+func (t timeoutDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, cancel := t.deadline(ctx)
+	_ = cancel
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+func (t timeoutDBTX) deadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, t.timeout, ErrQueryTimeout)
+}
+
+// wrapTimeout marks err as a timeout if ctx's query deadline passed.
+func wrapTimeout(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrQueryTimeout) && !errors.Is(err, ErrQueryTimeout) {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingDBTX runs statements that take delay, or until their context is
+// done.
+type blockingDBTX struct {
+	DBTX
+	delay time.Duration
+}
+
+func (b blockingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	select {
+	case <-time.After(b.delay):
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestTimeoutDBTX(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		delay       time.Duration
+		cancel      bool
+		expected    error
+		timeout     bool
+	}{
+		"in time": {
+			description: "A statement that finishes in time succeeds",
+			delay:       0,
+		},
+		"too slow": {
+			description: "A statement past the timeout fails with ErrQueryTimeout",
+			delay:       time.Second,
+			expected:    ErrQueryTimeout,
+			timeout:     true,
+		},
+		"canceled": {
+			description: "A canceled request isn't reported as a timeout",
+			delay:       time.Second,
+			cancel:      true,
+			expected:    context.Canceled,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				cancel()
+			}
+			db := timeoutDBTX{db: blockingDBTX{delay: tc.delay}, timeout: 20 * time.Millisecond}
+			_, err := db.ExecContext(ctx, "SELECT 1")
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("ExecContext() error = %v, want %v", err, tc.expected)
+			}
+			if got := IsTimeout(err); got != tc.timeout {
+				t.Errorf("IsTimeout(%v) = %v, want %v", err, got, tc.timeout)
+			}
+		})
+	}
+}
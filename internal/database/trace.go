@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/tracing"
+)
+
+// traceDBTX runs every statement in a span named after its sqlc query, as
+// a child of the span in the statement's context. Statements on a context
+// without a span, such as startup backfills, aren't traced. Like
+// observeDBTX, queries are timed until their first result is ready.
+type traceDBTX struct {
+	db DBTX
+}
+
+func (t traceDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	result, err := t.db.ExecContext(ctx, query, args...)
+	span.SetError(err)
+	return result, err
+}
+
+func (t traceDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t traceDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return rows, err
+}
+
+// QueryRowContext doesn't mark sql.ErrNoRows as a failure; handlers use it
+// to answer 404.
+func (t traceDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	row := t.db.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); !errors.Is(err, sql.ErrNoRows) {
+		span.SetError(err)
+	}
+	return row
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, QueryName(query))
+	span.SetKind(tracing.KindClient)
+	span.SetAttribute("db.query.text", query)
+	return ctx, span
+}
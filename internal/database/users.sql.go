@@ -7,25 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countUsersWithRole = `-- name: CountUsersWithRole :one
+
+SELECT COUNT(*) FROM users WHERE role = ?
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, role string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsersWithRole, role)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :exec
-INSERT INTO users (id, created_at, updated_at, name, api_key)
+INSERT INTO users (id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, email)
 VALUES (
     ?,
     ?,
     ?,
     ?,
+    ?,
+    1,
+    ?,
     ?
 )
 `
 
 type CreateUserParams struct {
-	ID        string
-	CreatedAt string
-	UpdatedAt string
-	Name      string
-	ApiKey    string
+	ID           string
+	CreatedAt    string
+	UpdatedAt    string
+	Name         string
+	ApiKey       string
+	ApiKeyPrefix string
+	Email        sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
@@ -35,13 +53,70 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 		arg.UpdatedAt,
 		arg.Name,
 		arg.ApiKey,
+		arg.ApiKeyPrefix,
+		arg.Email,
 	)
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+
+DELETE FROM users WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = ?
+WHERE id = ?
+`
+
+type DisableUserTOTPParams struct {
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, arg DisableUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, disableUserTOTP, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :execrows
+
+UPDATE users SET totp_enabled_at = ?, totp_last_step = ?, updated_at = ?
+WHERE id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+
+type EnableUserTOTPParams struct {
+	TotpEnabledAt sql.NullString
+	TotpLastStep  int64
+	UpdatedAt     string
+	ID            string
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableUserTOTP,
+		arg.TotpEnabledAt,
+		arg.TotpLastStep,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUser = `-- name: GetUser :one
 
-SELECT id, created_at, updated_at, name, api_key FROM users WHERE api_key = ?
+SELECT id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, role, totp_secret, totp_enabled_at, totp_last_step, email, deletion_token_hash, deletion_token_expires_at, password_hash, email_verified_at, suspended_at, avatar_updated_at FROM users WHERE api_key = ? AND api_key_hashed = 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, apiKey string) (User, error) {
@@ -53,6 +128,418 @@ func (q *Queries) GetUser(ctx context.Context, apiKey string) (User, error) {
 		&i.UpdatedAt,
 		&i.Name,
 		&i.ApiKey,
+		&i.ApiKeyHashed,
+		&i.ApiKeyPrefix,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Email,
+		&i.DeletionTokenHash,
+		&i.DeletionTokenExpiresAt,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.SuspendedAt,
+		&i.AvatarUpdatedAt,
 	)
 	return i, err
 }
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+
+SELECT id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, role, totp_secret, totp_enabled_at, totp_last_step, email, deletion_token_hash, deletion_token_expires_at, password_hash, email_verified_at, suspended_at, avatar_updated_at FROM users WHERE email = ?
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.ApiKeyHashed,
+		&i.ApiKeyPrefix,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Email,
+		&i.DeletionTokenHash,
+		&i.DeletionTokenExpiresAt,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.SuspendedAt,
+		&i.AvatarUpdatedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+
+SELECT id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, role, totp_secret, totp_enabled_at, totp_last_step, email, deletion_token_hash, deletion_token_expires_at, password_hash, email_verified_at, suspended_at, avatar_updated_at FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.ApiKeyHashed,
+		&i.ApiKeyPrefix,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Email,
+		&i.DeletionTokenHash,
+		&i.DeletionTokenExpiresAt,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.SuspendedAt,
+		&i.AvatarUpdatedAt,
+	)
+	return i, err
+}
+
+const getUserByLegacyAPIKey = `-- name: GetUserByLegacyAPIKey :one
+
+SELECT id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, role, totp_secret, totp_enabled_at, totp_last_step, email, deletion_token_hash, deletion_token_expires_at, password_hash, email_verified_at, suspended_at, avatar_updated_at FROM users WHERE api_key = ? AND api_key_hashed = 0
+`
+
+func (q *Queries) GetUserByLegacyAPIKey(ctx context.Context, apiKey string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByLegacyAPIKey, apiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.ApiKeyHashed,
+		&i.ApiKeyPrefix,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Email,
+		&i.DeletionTokenHash,
+		&i.DeletionTokenExpiresAt,
+		&i.PasswordHash,
+		&i.EmailVerifiedAt,
+		&i.SuspendedAt,
+		&i.AvatarUpdatedAt,
+	)
+	return i, err
+}
+
+const listLegacyAPIKeys = `-- name: ListLegacyAPIKeys :many
+
+SELECT id, api_key FROM users WHERE api_key_hashed = 0
+`
+
+type ListLegacyAPIKeysRow struct {
+	ID     string
+	ApiKey string
+}
+
+func (q *Queries) ListLegacyAPIKeys(ctx context.Context) ([]ListLegacyAPIKeysRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLegacyAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLegacyAPIKeysRow
+	for rows.Next() {
+		var i ListLegacyAPIKeysRow
+		if err := rows.Scan(&i.ID, &i.ApiKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersForAdmin = `-- name: ListUsersForAdmin :many
+
+SELECT
+    users.id,
+    users.created_at,
+    users.name,
+    users.email,
+    users.role,
+    users.suspended_at,
+    (SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id) AS note_count,
+    CAST(MAX(
+        users.updated_at,
+        COALESCE((SELECT MAX(notes.updated_at) FROM notes WHERE notes.user_id = users.id), ''),
+        COALESCE((SELECT MAX(api_keys.last_used_at) FROM api_keys WHERE api_keys.user_id = users.id), '')
+    ) AS TEXT) AS last_active_at
+FROM users
+WHERE (?1 IS NULL
+       OR users.name LIKE ?1 ESCAPE '\'
+       OR users.email LIKE ?1 ESCAPE '\')
+  AND (?2 IS NULL
+       OR users.created_at < ?2
+       OR (users.created_at = ?2 AND users.id < ?3))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT ?4
+`
+
+type ListUsersForAdminParams struct {
+	Pattern         sql.NullString
+	BeforeCreatedAt sql.NullString
+	BeforeID        sql.NullString
+	Limit           int64
+}
+
+type ListUsersForAdminRow struct {
+	ID           string
+	CreatedAt    string
+	Name         string
+	Email        sql.NullString
+	Role         string
+	SuspendedAt  sql.NullString
+	NoteCount    int64
+	LastActiveAt string
+}
+
+func (q *Queries) ListUsersForAdmin(ctx context.Context, arg ListUsersForAdminParams) ([]ListUsersForAdminRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersForAdmin,
+		arg.Pattern,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersForAdminRow
+	for rows.Next() {
+		var i ListUsersForAdminRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.SuspendedAt,
+			&i.NoteCount,
+			&i.LastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserAPIKeyHash = `-- name: SetUserAPIKeyHash :exec
+
+UPDATE users SET api_key = ?, api_key_hashed = 1, api_key_prefix = ? WHERE id = ?
+`
+
+type SetUserAPIKeyHashParams struct {
+	ApiKey       string
+	ApiKeyPrefix string
+	ID           string
+}
+
+func (q *Queries) SetUserAPIKeyHash(ctx context.Context, arg SetUserAPIKeyHashParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAPIKeyHash, arg.ApiKey, arg.ApiKeyPrefix, arg.ID)
+	return err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :exec
+
+UPDATE users SET avatar_updated_at = ?, updated_at = ? WHERE id = ?
+`
+
+type SetUserAvatarParams struct {
+	AvatarUpdatedAt sql.NullString
+	UpdatedAt       string
+	ID              string
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAvatar, arg.AvatarUpdatedAt, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserDeletionToken = `-- name: SetUserDeletionToken :exec
+
+UPDATE users SET deletion_token_hash = ?, deletion_token_expires_at = ?, updated_at = ? WHERE id = ?
+`
+
+type SetUserDeletionTokenParams struct {
+	DeletionTokenHash      sql.NullString
+	DeletionTokenExpiresAt sql.NullString
+	UpdatedAt              string
+	ID                     string
+}
+
+func (q *Queries) SetUserDeletionToken(ctx context.Context, arg SetUserDeletionTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setUserDeletionToken,
+		arg.DeletionTokenHash,
+		arg.DeletionTokenExpiresAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const setUserEmailVerified = `-- name: SetUserEmailVerified :execrows
+
+UPDATE users SET email_verified_at = ?, updated_at = ? WHERE id = ? AND email = ?
+`
+
+type SetUserEmailVerifiedParams struct {
+	EmailVerifiedAt sql.NullString
+	UpdatedAt       string
+	ID              string
+	Email           sql.NullString
+}
+
+func (q *Queries) SetUserEmailVerified(ctx context.Context, arg SetUserEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserEmailVerified,
+		arg.EmailVerifiedAt,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.Email,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+
+UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash sql.NullString
+	UpdatedAt    string
+	ID           string
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.PasswordHash, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+
+UPDATE users SET role = ?, updated_at = ? WHERE id = ?
+`
+
+type SetUserRoleParams struct {
+	Role      string
+	UpdatedAt string
+	ID        string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.UpdatedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserSuspended = `-- name: SetUserSuspended :execrows
+
+UPDATE users SET suspended_at = ?, updated_at = ? WHERE id = ?
+`
+
+type SetUserSuspendedParams struct {
+	SuspendedAt sql.NullString
+	UpdatedAt   string
+	ID          string
+}
+
+func (q *Queries) SetUserSuspended(ctx context.Context, arg SetUserSuspendedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserSuspended, arg.SuspendedAt, arg.UpdatedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :exec
+
+UPDATE users SET totp_secret = ?, totp_enabled_at = NULL, updated_at = ? WHERE id = ?
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret sql.NullString
+	UpdatedAt  string
+	ID         string
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTOTPSecret, arg.TotpSecret, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+
+UPDATE users SET name = ?, email = ?, email_verified_at = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateUserProfileParams struct {
+	Name            string
+	Email           sql.NullString
+	EmailVerifiedAt sql.NullString
+	UpdatedAt       string
+	ID              string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile,
+		arg.Name,
+		arg.Email,
+		arg.EmailVerifiedAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const useUserTOTPStep = `-- name: UseUserTOTPStep :execrows
+
+UPDATE users SET totp_last_step = ?1
+WHERE id = ?2 AND totp_last_step < ?1
+`
+
+type UseUserTOTPStepParams struct {
+	Step int64
+	ID   string
+}
+
+func (q *Queries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useUserTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,369 @@
+// Package jobs runs background work queued in the jobs table, so it
+// survives restarts and is shared by every server on the database. Each
+// kind of job has a Handler and a Policy saying how long an attempt may
+// take and how often a failed one is retried before the job is given up
+// on.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/uuid"
+)
+
+// Job statuses. A pending job is waiting for its next attempt or being
+// run; a failed one used up its attempts and waits to be requeued.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	// SucceededRetention is how long succeeded jobs are kept, and
+	// FailedRetention how long failed ones are, for looking into them.
+	SucceededRetention = 24 * time.Hour
+	FailedRetention    = 30 * 24 * time.Hour
+
+	// batchSize is how many due jobs are fetched at a time.
+	batchSize = 100
+	// leaseMargin is how much longer than its timeout a claimed job waits
+	// before another run may take it, should this one die mid-attempt.
+	leaseMargin = time.Minute
+	// maxErrorLength caps the error stored for a failed attempt.
+	maxErrorLength = 1000
+)
+
+// ErrUnknownKind is returned when queueing a kind of job with no handler.
+// Jobs of such kinds queued by other servers fail straight away.
+var ErrUnknownKind = errors.New("jobs: no handler for kind")
+
+type Store interface {
+	CreateJob(ctx context.Context, arg database.CreateJobParams) (int64, error)
+	ListDueJobs(ctx context.Context, arg database.ListDueJobsParams) ([]database.Job, error)
+	ClaimJob(ctx context.Context, arg database.ClaimJobParams) (int64, error)
+	FinishJob(ctx context.Context, arg database.FinishJobParams) error
+}
+
+// Job is one attempt at a job, as its Handler sees it. Attempt counts
+// from 1.
+type Job struct {
+	ID          string
+	Kind        string
+	Payload     json.RawMessage
+	Attempt     int64
+	MaxAttempts int64
+}
+
+// LastAttempt reports whether the job fails for good if this attempt does.
+func (j Job) LastAttempt() bool {
+	return j.Attempt >= j.MaxAttempts
+}
+
+// Handler makes one attempt at a job. An error fails the attempt.
+type Handler func(ctx context.Context, job Job) error
+
+// Policy is how a kind of job is run.
+type Policy struct {
+	// MaxAttempts is how many times a job is tried before it fails.
+	MaxAttempts int64
+	// Backoff is how long to wait after a job's attempt-th failed attempt.
+	Backoff func(attempt int64) time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+}
+
+// Exponential returns a Backoff that waits first after the first failed
+// attempt and twice as long after each one since, up to max.
+func Exponential(first, max time.Duration) func(attempt int64) time.Duration {
+	return func(attempt int64) time.Duration {
+		wait := first
+		for i := int64(1); i < attempt && wait < max; i++ {
+			wait *= 2
+		}
+		return min(wait, max)
+	}
+}
+
+type handler struct {
+	policy Policy
+	run    Handler
+}
+
+// periodic is a kind of job queued once per interval.
+type periodic struct {
+	kind     string
+	interval time.Duration
+	next     time.Time
+}
+
+// Queue runs due jobs on up to workers goroutines at once, checking for
+// them once per interval and whenever woken after new ones are queued.
+type Queue struct {
+	store    Store
+	workers  int
+	interval time.Duration
+	now      func() time.Time
+	handlers map[string]handler
+	periodic []*periodic
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+func NewQueue(store Store, workers int, interval time.Duration) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		store:    store,
+		workers:  workers,
+		interval: interval,
+		now:      time.Now,
+		handlers: map[string]handler{},
+		ctx:      ctx,
+		cancel:   cancel,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Handle sets the handler and policy for a kind of job. Call it for every
+// kind before Start.
+func (q *Queue) Handle(kind string, policy Policy, run Handler) {
+	q.handlers[kind] = handler{policy: policy, run: run}
+}
+
+// Every queues a job of kind, with a null payload, once per interval,
+// starting when the queue does. Each is queued under a unique key for its
+// slot of time, so servers sharing a database run it once per interval
+// between them. Call it before Start.
+func (q *Queue) Every(kind string, interval time.Duration) {
+	q.periodic = append(q.periodic, &periodic{kind: kind, interval: interval})
+}
+
+// Start starts running jobs, until Close.
+func (q *Queue) Start() {
+	go q.run()
+}
+
+// Enqueue queues a job of kind to run as soon as a worker is free, with
+// payload encoded as JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) error {
+	return q.enqueue(ctx, kind, "", payload)
+}
+
+// EnqueueUnique is Enqueue for jobs that must only be queued once: while a
+// job of kind queued under key is kept, queueing another does nothing.
+func (q *Queue) EnqueueUnique(ctx context.Context, kind, key string, payload any) error {
+	return q.enqueue(ctx, kind, kind+"@"+key, payload)
+}
+
+func (q *Queue) enqueue(ctx context.Context, kind, uniqueKey string, payload any) error {
+	h, ok := q.handlers[kind]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKind, kind)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := q.now().UTC().Format(time.RFC3339)
+	_, err = q.store.CreateJob(ctx, database.CreateJobParams{
+		ID:          uuid.New().String(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Kind:        kind,
+		Payload:     string(encoded),
+		UniqueKey:   sql.NullString{String: uniqueKey, Valid: uniqueKey != ""},
+		MaxAttempts: h.policy.MaxAttempts,
+		RunAt:       now,
+	})
+	if err != nil {
+		return err
+	}
+	q.Wake()
+	return nil
+}
+
+// Wake asks for due jobs to be checked straight away.
+func (q *Queue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunOnce runs every job that is due, waiting for them to finish.
+func (q *Queue) RunOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	q.dispatch(ctx, make(chan struct{}, q.workers), &wg)
+	wg.Wait()
+}
+
+// Close stops the queue, cancelling attempts in progress. They're made
+// again, counting as another attempt, once their claims run out.
+func (q *Queue) Close() {
+	q.cancel()
+	<-q.done
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, q.workers)
+
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+	for {
+		q.dispatch(q.ctx, slots, &wg)
+		select {
+		case <-ticker.C:
+		case <-q.wake:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch queues the periodic jobs that are due, then starts every due
+// job on a worker. Each job is claimed only once a worker is free for it,
+// so the claim doesn't run out while it waits, and so several servers
+// sharing a database don't run it at once. One that dies mid-attempt
+// leaves the job to be run again once the claim runs out, so handlers
+// should cope with repeats.
+func (q *Queue) dispatch(ctx context.Context, slots chan struct{}, wg *sync.WaitGroup) {
+	now := q.now().UTC()
+	for _, p := range q.periodic {
+		if now.Before(p.next) {
+			continue
+		}
+		slot := now.Truncate(p.interval)
+		if err := q.enqueue(ctx, p.kind, p.kind+"@"+slot.Format(time.RFC3339), nil); err != nil {
+			log.Printf("Couldn't queue %s job: %v", p.kind, err)
+			continue
+		}
+		p.next = slot.Add(p.interval)
+	}
+
+	for {
+		due, err := q.store.ListDueJobs(ctx, database.ListDueJobsParams{
+			RunAt: now.Format(time.RFC3339),
+			Limit: batchSize,
+		})
+		if err != nil {
+			log.Printf("Couldn't list due jobs: %v", err)
+			return
+		}
+
+		for _, job := range due {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			timeout := q.handlers[job.Kind].policy.Timeout
+			claimed, err := q.store.ClaimJob(ctx, database.ClaimJobParams{
+				LeaseUntil: q.now().UTC().Add(timeout + leaseMargin).Format(time.RFC3339),
+				UpdatedAt:  q.now().UTC().Format(time.RFC3339),
+				ID:         job.ID,
+				RunAt:      job.RunAt,
+			})
+			if err != nil || claimed == 0 {
+				<-slots
+			}
+			if err != nil {
+				log.Printf("Couldn't claim job %s: %v", job.ID, err)
+				return
+			}
+			if claimed == 0 {
+				// Another server got to it first.
+				continue
+			}
+			job.Attempts++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				q.runJob(ctx, job)
+			}()
+		}
+
+		if len(due) < batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runJob makes one attempt at a claimed job and records how it went.
+func (q *Queue) runJob(ctx context.Context, job database.Job) {
+	h, ok := q.handlers[job.Kind]
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("%w %q", ErrUnknownKind, job.Kind)
+	case job.Attempts > job.MaxAttempts:
+		// The last attempt was cut short, by its server stopping.
+		err = errors.New("jobs: last attempt didn't finish")
+	default:
+		err = q.attempt(ctx, h, job)
+		if ctx.Err() != nil {
+			// Shutting down: the claim runs out and the job is tried again.
+			return
+		}
+	}
+
+	now := q.now().UTC()
+	arg := database.FinishJobParams{
+		Status:     StatusSucceeded,
+		RunAt:      now.Format(time.RFC3339),
+		FinishedAt: sql.NullString{String: now.Format(time.RFC3339), Valid: true},
+		UpdatedAt:  now.Format(time.RFC3339),
+		ID:         job.ID,
+	}
+	if err != nil {
+		log.Printf("Job %s (%s) failed attempt %d of %d: %v", job.ID, job.Kind, job.Attempts, job.MaxAttempts, err)
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		arg.LastError = sql.NullString{String: msg, Valid: true}
+		arg.Status = StatusFailed
+		if ok && job.Attempts < job.MaxAttempts {
+			arg.Status = StatusPending
+			arg.RunAt = now.Add(h.policy.Backoff(job.Attempts)).Format(time.RFC3339)
+			arg.FinishedAt = sql.NullString{}
+		}
+	}
+	if err := q.store.FinishJob(ctx, arg); err != nil {
+		log.Printf("Couldn't record job %s: %v", job.ID, err)
+	}
+}
+
+// attempt runs a job's handler within its timeout, turning a panic into
+// an error so one bad job can't take the server down.
+func (q *Queue) attempt(ctx context.Context, h handler, job database.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, h.policy.Timeout)
+	defer cancel()
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			err = fmt.Errorf("jobs: handler panicked: %v", rvr)
+		}
+	}()
+	return h.run(ctx, Job{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Payload:     json.RawMessage(job.Payload),
+		Attempt:     job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+	})
+}
@@ -0,0 +1,313 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeStore holds jobs in memory and applies the same due, claim and
+// unique key rules as the SQL queries.
+type fakeStore struct {
+	mu   sync.Mutex
+	jobs []database.Job
+	// stolen jobs are claimed by someone else between list and claim.
+	stolen map[string]bool
+}
+
+func (s *fakeStore) CreateJob(ctx context.Context, arg database.CreateJobParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if arg.UniqueKey.Valid && j.UniqueKey == arg.UniqueKey {
+			return 0, nil
+		}
+	}
+	s.jobs = append(s.jobs, database.Job{
+		ID:          arg.ID,
+		Kind:        arg.Kind,
+		Payload:     arg.Payload,
+		UniqueKey:   arg.UniqueKey,
+		Status:      StatusPending,
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       arg.RunAt,
+	})
+	return 1, nil
+}
+
+func (s *fakeStore) ListDueJobs(ctx context.Context, arg database.ListDueJobsParams) ([]database.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []database.Job
+	for _, j := range s.jobs {
+		if j.Status == StatusPending && j.RunAt <= arg.RunAt {
+			due = append(due, j)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) ClaimJob(ctx context.Context, arg database.ClaimJobParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, j := range s.jobs {
+		if j.ID != arg.ID || j.Status != StatusPending || j.RunAt != arg.RunAt {
+			continue
+		}
+		s.jobs[i].RunAt = arg.LeaseUntil
+		s.jobs[i].Attempts++
+		if s.stolen[j.ID] {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (s *fakeStore) FinishJob(ctx context.Context, arg database.FinishJobParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, j := range s.jobs {
+		if j.ID == arg.ID {
+			s.jobs[i].Status = arg.Status
+			s.jobs[i].RunAt = arg.RunAt
+			s.jobs[i].LastError = arg.LastError
+			s.jobs[i].FinishedAt = arg.FinishedAt
+		}
+	}
+	return nil
+}
+
+func TestExponential(t *testing.T) {
+	backoff := Exponential(time.Minute, 10*time.Minute)
+	tests := map[string]struct {
+		description string
+		attempt     int64
+		expected    time.Duration
+	}{
+		"first": {
+			description: "The first retry waits the first backoff",
+			attempt:     1,
+			expected:    time.Minute,
+		},
+		"doubling": {
+			description: "Each failure doubles the wait",
+			attempt:     3,
+			expected:    4 * time.Minute,
+		},
+		"capped": {
+			description: "The wait stops growing at the max",
+			attempt:     60,
+			expected:    10 * time.Minute,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := backoff(tc.attempt); got != tc.expected {
+				t.Errorf("backoff(%d) = %s, want %s", tc.attempt, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestQueueRunOnce(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	type outcome struct {
+		Status    string
+		Attempts  int64
+		RunAt     string
+		LastError sql.NullString
+	}
+	policy := Policy{MaxAttempts: 3, Backoff: Exponential(time.Minute, time.Hour), Timeout: time.Second}
+	tests := map[string]struct {
+		description string
+		kind        string
+		attempts    int64
+		runAt       string
+		stolen      bool
+		err         error
+		panics      bool
+		expected    outcome
+		expectRun   bool
+	}{
+		"succeeded": {
+			description: "A job that returns nil succeeds",
+			kind:        "test",
+			runAt:       "2024-03-01T11:00:00Z",
+			expected:    outcome{Status: StatusSucceeded, Attempts: 1, RunAt: "2024-03-01T12:00:00Z"},
+			expectRun:   true,
+		},
+		"retried": {
+			description: "A failed attempt is retried after the backoff",
+			kind:        "test",
+			attempts:    1,
+			runAt:       "2024-03-01T12:00:00Z",
+			err:         errors.New("boom"),
+			expected:    outcome{Status: StatusPending, Attempts: 2, RunAt: "2024-03-01T12:02:00Z", LastError: sql.NullString{String: "boom", Valid: true}},
+			expectRun:   true,
+		},
+		"failed": {
+			description: "The last failed attempt fails the job",
+			kind:        "test",
+			attempts:    2,
+			runAt:       "2024-03-01T12:00:00Z",
+			err:         errors.New("boom"),
+			expected:    outcome{Status: StatusFailed, Attempts: 3, RunAt: "2024-03-01T12:00:00Z", LastError: sql.NullString{String: "boom", Valid: true}},
+			expectRun:   true,
+		},
+		"panicked": {
+			description: "A handler that panics fails the attempt",
+			kind:        "test",
+			runAt:       "2024-03-01T12:00:00Z",
+			panics:      true,
+			expected:    outcome{Status: StatusPending, Attempts: 1, RunAt: "2024-03-01T12:01:00Z", LastError: sql.NullString{String: "jobs: handler panicked: boom", Valid: true}},
+			expectRun:   true,
+		},
+		"cut short": {
+			description: "A job whose last attempt never finished fails without running",
+			kind:        "test",
+			attempts:    3,
+			runAt:       "2024-03-01T12:00:00Z",
+			expected:    outcome{Status: StatusFailed, Attempts: 4, RunAt: "2024-03-01T12:00:00Z", LastError: sql.NullString{String: "jobs: last attempt didn't finish", Valid: true}},
+		},
+		"unknown kind": {
+			description: "A job no handler is set for fails straight away",
+			kind:        "other",
+			runAt:       "2024-03-01T12:00:00Z",
+			expected:    outcome{Status: StatusFailed, Attempts: 1, RunAt: "2024-03-01T12:00:00Z", LastError: sql.NullString{String: `jobs: no handler for kind "other"`, Valid: true}},
+		},
+		"not due": {
+			description: "Jobs wait for their next attempt",
+			kind:        "test",
+			runAt:       "2024-03-01T12:00:01Z",
+			expected:    outcome{Status: StatusPending, RunAt: "2024-03-01T12:00:01Z"},
+		},
+		"stolen": {
+			description: "Jobs claimed by another server aren't run",
+			kind:        "test",
+			runAt:       "2024-03-01T12:00:00Z",
+			stolen:      true,
+			expected:    outcome{Status: StatusPending, Attempts: 1, RunAt: "2024-03-01T12:01:01Z"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeStore{
+				jobs: []database.Job{{
+					ID:          "j1",
+					Kind:        tc.kind,
+					Payload:     `{"n":1}`,
+					Status:      StatusPending,
+					Attempts:    tc.attempts,
+					MaxAttempts: policy.MaxAttempts,
+					RunAt:       tc.runAt,
+				}},
+				stolen: map[string]bool{"j1": tc.stolen},
+			}
+			q := NewQueue(store, 2, time.Minute)
+			q.now = func() time.Time { return now }
+			var ran []Job
+			q.Handle("test", policy, func(ctx context.Context, job Job) error {
+				ran = append(ran, job)
+				if tc.panics {
+					panic("boom")
+				}
+				return tc.err
+			})
+			q.RunOnce(context.Background())
+
+			row := store.jobs[0]
+			got := outcome{Status: row.Status, Attempts: row.Attempts, RunAt: row.RunAt, LastError: row.LastError}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("job mismatch (-want +got):\n%s", diff)
+			}
+			if tc.expectRun != (len(ran) == 1) {
+				t.Fatalf("ran %v, expected a run: %v", ran, tc.expectRun)
+			}
+			if tc.expectRun && (ran[0].Attempt != tc.attempts+1 || string(ran[0].Payload) != `{"n":1}`) {
+				t.Errorf("handler got %+v", ran[0])
+			}
+		})
+	}
+}
+
+func TestQueueEvery(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 30, 0, time.UTC)
+	store := &fakeStore{}
+	runs := 0
+	// Two servers sharing the database.
+	var queues []*Queue
+	for range 2 {
+		q := NewQueue(store, 1, time.Second)
+		q.now = func() time.Time { return now }
+		q.Handle("purge", Policy{MaxAttempts: 1, Backoff: Exponential(time.Minute, time.Hour), Timeout: time.Second}, func(ctx context.Context, job Job) error {
+			runs++
+			return nil
+		})
+		q.Every("purge", time.Minute)
+		queues = append(queues, q)
+	}
+
+	for _, q := range queues {
+		q.RunOnce(context.Background())
+	}
+	now = now.Add(20 * time.Second)
+	for _, q := range queues {
+		q.RunOnce(context.Background())
+	}
+	if runs != 1 {
+		t.Errorf("ran %d times in one interval, want 1", runs)
+	}
+
+	now = now.Add(20 * time.Second)
+	for _, q := range queues {
+		q.RunOnce(context.Background())
+	}
+	if runs != 2 {
+		t.Errorf("ran %d times in two intervals, want 2", runs)
+	}
+}
+
+func TestQueueEnqueue(t *testing.T) {
+	store := &fakeStore{}
+	q := NewQueue(store, 1, time.Minute)
+	q.Handle("test", Policy{MaxAttempts: 5, Backoff: Exponential(time.Minute, time.Hour), Timeout: time.Second}, func(ctx context.Context, job Job) error {
+		return nil
+	})
+
+	if err := q.Enqueue(context.Background(), "missing", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("Enqueue() of an unknown kind: got %v, want %v", err, ErrUnknownKind)
+	}
+	if err := q.Enqueue(context.Background(), "test", map[string]string{"id": "n1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(store.jobs))
+	}
+	job := store.jobs[0]
+	if job.Payload != `{"id":"n1"}` || job.MaxAttempts != 5 || job.UniqueKey.Valid {
+		t.Errorf("Enqueue() stored %+v", job)
+	}
+
+	for range 2 {
+		if err := q.EnqueueUnique(context.Background(), "test", "n2", map[string]string{"id": "n2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.jobs) != 2 {
+		t.Fatalf("got %d jobs after queueing a unique job twice, want 2", len(store.jobs))
+	}
+	if job := store.jobs[1]; job.UniqueKey.String != "test@n2" {
+		t.Errorf("EnqueueUnique() stored %+v", job)
+	}
+}
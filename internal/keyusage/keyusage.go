@@ -0,0 +1,97 @@
+package keyusage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+type Store interface {
+	TouchAPIKeyUsage(ctx context.Context, arg database.TouchAPIKeyUsageParams) error
+}
+
+// Tracker batches API key usage in memory and writes the latest use of each
+// key once per flush interval, so a busy key costs one write per interval
+// rather than one per request.
+type Tracker struct {
+	store Store
+
+	mu      sync.Mutex
+	pending map[string]use
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type use struct {
+	at time.Time
+	ip string
+}
+
+func NewTracker(store Store, interval time.Duration) *Tracker {
+	t := &Tracker{
+		store:   store,
+		pending: map[string]use{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go t.run(interval)
+	return t
+}
+
+// Touch records that the key with the given hash was used from ip at the
+// given time.
+func (t *Tracker) Touch(keyHash, ip string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.pending[keyHash]; ok && prev.at.After(at) {
+		return
+	}
+	t.pending[keyHash] = use{at: at, ip: ip}
+}
+
+// Flush writes all pending usage to the store. Keys that fail to write are
+// dropped; the next use will record them again.
+func (t *Tracker) Flush(ctx context.Context) {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[string]use{}
+	t.mu.Unlock()
+
+	for keyHash, u := range pending {
+		err := t.store.TouchAPIKeyUsage(ctx, database.TouchAPIKeyUsageParams{
+			LastUsedAt: sql.NullString{String: u.at.UTC().Format(time.RFC3339), Valid: true},
+			LastUsedIp: sql.NullString{String: u.ip, Valid: u.ip != ""},
+			KeyHash:    keyHash,
+		})
+		if err != nil {
+			log.Printf("Couldn't record api key usage: %v", err)
+		}
+	}
+}
+
+// Close stops the background flush and writes anything still pending.
+func (t *Tracker) Close() {
+	close(t.stop)
+	<-t.done
+}
+
+func (t *Tracker) run(interval time.Duration) {
+	defer close(t.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.Flush(context.Background())
+		case <-t.stop:
+			t.Flush(context.Background())
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package keyusage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeStore struct {
+	touches []database.TouchAPIKeyUsageParams
+}
+
+func (s *fakeStore) TouchAPIKeyUsage(ctx context.Context, arg database.TouchAPIKeyUsageParams) error {
+	s.touches = append(s.touches, arg)
+	return nil
+}
+
+type touch struct {
+	keyHash string
+	ip      string
+	at      time.Time
+}
+
+func TestTrackerFlush(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		description string
+		touches     []touch
+		expected    []database.TouchAPIKeyUsageParams
+	}{
+		"none": {
+			description: "Nothing pending writes nothing",
+		},
+		"latest wins": {
+			description: "Repeated uses of a key collapse into the latest one",
+			touches: []touch{
+				{keyHash: "a", ip: "198.51.100.1", at: t0},
+				{keyHash: "a", ip: "198.51.100.2", at: t0.Add(time.Minute)},
+				{keyHash: "a", ip: "198.51.100.3", at: t0.Add(-time.Minute)},
+			},
+			expected: []database.TouchAPIKeyUsageParams{
+				{
+					LastUsedAt: sql.NullString{String: "2024-03-01T12:01:00Z", Valid: true},
+					LastUsedIp: sql.NullString{String: "198.51.100.2", Valid: true},
+					KeyHash:    "a",
+				},
+			},
+		},
+		"per key": {
+			description: "Each key gets its own write",
+			touches: []touch{
+				{keyHash: "a", ip: "198.51.100.1", at: t0},
+				{keyHash: "b", at: t0},
+			},
+			expected: []database.TouchAPIKeyUsageParams{
+				{
+					LastUsedAt: sql.NullString{String: "2024-03-01T12:00:00Z", Valid: true},
+					LastUsedIp: sql.NullString{String: "198.51.100.1", Valid: true},
+					KeyHash:    "a",
+				},
+				{
+					LastUsedAt: sql.NullString{String: "2024-03-01T12:00:00Z", Valid: true},
+					KeyHash:    "b",
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeStore{}
+			tracker := &Tracker{store: store, pending: map[string]use{}}
+			for _, tt := range tc.touches {
+				tracker.Touch(tt.keyHash, tt.ip, tt.at)
+			}
+			tracker.Flush(context.Background())
+
+			sort.Slice(store.touches, func(i, j int) bool {
+				return store.touches[i].KeyHash < store.touches[j].KeyHash
+			})
+			if diff := cmp.Diff(tc.expected, store.touches); diff != "" {
+				t.Errorf("touches mismatch (-want +got):\n%s", diff)
+			}
+
+			store.touches = nil
+			tracker.Flush(context.Background())
+			if len(store.touches) != 0 {
+				t.Errorf("expected second flush to be empty, got %d writes", len(store.touches))
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Package mailer sends transactional email such as verification links.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Message is a plain-text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes messages to the log instead of sending them, for
+// development and servers without SMTP configured.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,138 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewSMTP(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		cfg         SMTPConfig
+		expectErr   bool
+	}{
+		"valid": {
+			description: "Host, port and from address are enough",
+			cfg:         SMTPConfig{Host: "smtp.example.com", Port: 587, From: "Notely <no-reply@example.com>"},
+		},
+		"no host": {
+			description: "A host is required",
+			cfg:         SMTPConfig{Port: 587, From: "no-reply@example.com"},
+			expectErr:   true,
+		},
+		"bad from": {
+			description: "The from address must parse",
+			cfg:         SMTPConfig{Host: "smtp.example.com", Port: 587, From: "not an address"},
+			expectErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			_, err := NewSMTP(tc.cfg)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	type sent struct {
+		Addr string
+		Auth bool
+		From string
+		To   []string
+		Data string
+	}
+	tests := map[string]struct {
+		description string
+		username    string
+		msg         Message
+		expected    *sent
+		expectedErr error
+	}{
+		"plain": {
+			description: "Renders headers and CRLF line endings",
+			msg:         Message{To: "ann@example.com", Subject: "Hello", Body: "line one\nline two"},
+			expected: &sent{
+				Addr: "smtp.example.com:587",
+				From: "no-reply@example.com",
+				To:   []string{"ann@example.com"},
+				Data: "From: Notely <no-reply@example.com>\r\n" +
+					"To: ann@example.com\r\n" +
+					"Subject: Hello\r\n" +
+					"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n" +
+					"MIME-Version: 1.0\r\n" +
+					"Content-Type: text/plain; charset=utf-8\r\n" +
+					"\r\n" +
+					"line one\r\nline two",
+			},
+		},
+		"auth": {
+			description: "Authenticates when a username is configured",
+			username:    "mailer",
+			msg:         Message{To: "ann@example.com", Subject: "Hi", Body: "x"},
+			expected: &sent{
+				Addr: "smtp.example.com:587",
+				Auth: true,
+				From: "no-reply@example.com",
+				To:   []string{"ann@example.com"},
+				Data: "From: Notely <no-reply@example.com>\r\n" +
+					"To: ann@example.com\r\n" +
+					"Subject: Hi\r\n" +
+					"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n" +
+					"MIME-Version: 1.0\r\n" +
+					"Content-Type: text/plain; charset=utf-8\r\n" +
+					"\r\n" +
+					"x",
+			},
+		},
+		"header injection": {
+			description: "Line breaks in the subject are rejected",
+			msg:         Message{To: "ann@example.com", Subject: "Hi\r\nBcc: eve@example.com"},
+			expectedErr: ErrInvalidMessage,
+		},
+		"bad recipient": {
+			description: "The recipient must be an address",
+			msg:         Message{To: "ann", Subject: "Hi"},
+			expectedErr: ErrInvalidMessage,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			s, err := NewSMTP(SMTPConfig{
+				Host:     "smtp.example.com",
+				Port:     587,
+				Username: tc.username,
+				Password: "secret",
+				From:     "Notely <no-reply@example.com>",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+			var got *sent
+			s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				got = &sent{Addr: addr, Auth: a != nil, From: from, To: to, Data: string(msg)}
+				return nil
+			}
+
+			err = s.Send(context.Background(), tc.msg)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("sent mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
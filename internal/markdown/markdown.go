@@ -0,0 +1,26 @@
+// Package markdown renders note bodies to HTML that is safe to embed in a
+// page: raw HTML in the source is dropped and links with dangerous schemes
+// such as javascript: are neutralised.
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// The renderer is left in its default safe mode; never enable
+// html.WithUnsafe here, since note bodies are user input.
+var renderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// Render converts GitHub-flavoured Markdown to sanitized HTML.
+func Render(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,59 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		expected    string
+	}{
+		"basic": {
+			description: "Headings, emphasis and lists are rendered",
+			input:       "# Groceries\n\n- **milk**\n- eggs\n",
+			expected:    "<h1>Groceries</h1>\n<ul>\n<li><strong>milk</strong></li>\n<li>eggs</li>\n</ul>\n",
+		},
+		"gfm": {
+			description: "Task lists and strikethrough from GFM are supported",
+			input:       "- [x] ~~done~~\n",
+			expected:    "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> <del>done</del></li>\n</ul>\n",
+		},
+		"raw html block": {
+			description: "Raw HTML blocks are dropped",
+			input:       "<script>alert(1)</script>\n",
+			expected:    "<!-- raw HTML omitted -->\n",
+		},
+		"inline html": {
+			description: "Inline HTML is dropped",
+			input:       "hi <img src=x onerror=alert(1)>",
+			expected:    "<p>hi <!-- raw HTML omitted --></p>\n",
+		},
+		"javascript link": {
+			description: "Links with a javascript: scheme lose their target",
+			input:       "[click](javascript:alert(1))",
+			expected:    "<p><a href=\"\">click</a></p>\n",
+		},
+		"escaped text": {
+			description: "Special characters in text are escaped",
+			input:       "a < b & c",
+			expected:    "<p>a &lt; b &amp; c</p>\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Render(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("html mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
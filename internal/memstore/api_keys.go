@@ -0,0 +1,157 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) error {
+	t, done := q.write()
+	defer done()
+	if err := t.checkAPIKey(arg.ID, arg.KeyHash); err != nil {
+		return err
+	}
+	t.apiKeys = append(t.apiKeys, database.ApiKey{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		UserID:       arg.UserID,
+		Label:        arg.Label,
+		KeyHash:      arg.KeyHash,
+		KeyPrefix:    arg.KeyPrefix,
+		ExpiresAt:    arg.ExpiresAt,
+		AllowedCidrs: arg.AllowedCidrs,
+	})
+	return nil
+}
+
+func (q queries) CreateAPIKeyIfMissing(ctx context.Context, arg database.CreateAPIKeyIfMissingParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.apiKeys, func(k database.ApiKey) bool { return k.KeyHash == arg.KeyHash }) {
+		return nil
+	}
+	if err := t.checkAPIKey(arg.ID, arg.KeyHash); err != nil {
+		return err
+	}
+	t.apiKeys = append(t.apiKeys, database.ApiKey{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		Label:     arg.Label,
+		KeyHash:   arg.KeyHash,
+		KeyPrefix: arg.KeyPrefix,
+	})
+	return nil
+}
+
+func (t *tables) checkAPIKey(id, keyHash string) error {
+	if exists(t.apiKeys, func(k database.ApiKey) bool { return k.ID == id }) {
+		return errUnique("api_keys.id")
+	}
+	if exists(t.apiKeys, func(k database.ApiKey) bool { return k.KeyHash == keyHash }) {
+		return errUnique("api_keys.key_hash")
+	}
+	return nil
+}
+
+func (q queries) GetAPIKey(ctx context.Context, arg database.GetAPIKeyParams) (database.ApiKey, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.apiKeys, func(k database.ApiKey) bool {
+		return k.ID == arg.ID && k.UserID == arg.UserID
+	})
+}
+
+func (q queries) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.apiKeys, func(k database.ApiKey) bool {
+		return k.KeyHash == keyHash && !k.RevokedAt.Valid
+	})
+}
+
+func (q queries) ListAPIKeysForUser(ctx context.Context, userID string) ([]database.ApiKey, error) {
+	t, done := q.read()
+	defer done()
+	keys := where(t.apiKeys, func(k database.ApiKey) bool { return k.UserID == userID })
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+	return keys, nil
+}
+
+func (q queries) CountActiveAPIKeysForUser(ctx context.Context, arg database.CountActiveAPIKeysForUserParams) (int64, error) {
+	t, done := q.read()
+	defer done()
+	return count(t.apiKeys, func(k database.ApiKey) bool {
+		if k.UserID != arg.UserID || k.RevokedAt.Valid {
+			return false
+		}
+		return !k.ExpiresAt.Valid || arg.ExpiresAt.Valid && k.ExpiresAt.String > arg.ExpiresAt.String
+	}), nil
+}
+
+func (q queries) RevokeAPIKey(ctx context.Context, arg database.RevokeAPIKeyParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.apiKeys, func(k database.ApiKey) bool {
+		return k.ID == arg.ID && k.UserID == arg.UserID && !k.RevokedAt.Valid
+	}, func(k *database.ApiKey) {
+		k.RevokedAt = arg.RevokedAt
+		k.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) SetAPIKeyExpiry(ctx context.Context, arg database.SetAPIKeyExpiryParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.apiKeys, func(k database.ApiKey) bool {
+		return k.ID == arg.ID && k.UserID == arg.UserID && !k.RevokedAt.Valid
+	}, func(k *database.ApiKey) {
+		k.ExpiresAt = arg.ExpiresAt
+		k.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) TouchAPIKeyUsage(ctx context.Context, arg database.TouchAPIKeyUsageParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.apiKeys, func(k database.ApiKey) bool {
+		return k.KeyHash == arg.KeyHash
+	}, func(k *database.ApiKey) {
+		k.LastUsedAt = arg.LastUsedAt
+		k.LastUsedIp = arg.LastUsedIp
+	})
+	return nil
+}
+
+func (q queries) RevokeAllAPIKeysForUser(ctx context.Context, arg database.RevokeAllAPIKeysForUserParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.apiKeys, func(k database.ApiKey) bool {
+		return k.UserID == arg.UserID && !k.RevokedAt.Valid
+	}, func(k *database.ApiKey) {
+		k.RevokedAt = arg.RevokedAt
+		k.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) SetAPIKeyAllowedCIDRs(ctx context.Context, arg database.SetAPIKeyAllowedCIDRsParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.apiKeys, func(k database.ApiKey) bool {
+		return k.ID == arg.ID && k.UserID == arg.UserID && !k.RevokedAt.Valid
+	}, func(k *database.ApiKey) {
+		k.AllowedCidrs = arg.AllowedCidrs
+		k.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteAPIKeysForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.apiKeys, func(k database.ApiKey) bool { return k.UserID == userID })
+	return nil
+}
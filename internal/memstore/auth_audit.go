@@ -0,0 +1,41 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateAuthAuditEntry(ctx context.Context, arg database.CreateAuthAuditEntryParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.authAudit, func(e database.AuthAudit) bool { return e.ID == arg.ID }) {
+		return errUnique("auth_audit.id")
+	}
+	t.authAudit = append(t.authAudit, database.AuthAudit{
+		ID:               arg.ID,
+		CreatedAt:        arg.CreatedAt,
+		UserID:           arg.UserID,
+		CredentialPrefix: arg.CredentialPrefix,
+		Ip:               arg.Ip,
+		UserAgent:        arg.UserAgent,
+		Success:          arg.Success,
+		Reason:           arg.Reason,
+	})
+	return nil
+}
+
+func (q queries) ListAuthAuditEntries(ctx context.Context, arg database.ListAuthAuditEntriesParams) ([]database.AuthAudit, error) {
+	t, done := q.read()
+	defer done()
+	entries := where(t.authAudit, func(e database.AuthAudit) bool {
+		return (!arg.UserID.Valid || nullEqual(e.UserID, arg.UserID)) &&
+			(!arg.Ip.Valid || e.Ip == arg.Ip.String) &&
+			(!arg.Success.Valid || e.Success == arg.Success.Bool) &&
+			(!arg.Since.Valid || e.CreatedAt >= arg.Since.String) &&
+			(!arg.Until.Valid || e.CreatedAt < arg.Until.String)
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt > entries[j].CreatedAt })
+	return limit(entries, arg.Limit), nil
+}
@@ -0,0 +1,53 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateClientCert(ctx context.Context, arg database.CreateClientCertParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.clientCerts, func(c database.ClientCert) bool { return c.ID == arg.ID }) {
+		return errUnique("client_certs.id")
+	}
+	if exists(t.clientCerts, func(c database.ClientCert) bool { return c.Subject == arg.Subject }) {
+		return errUnique("client_certs.subject")
+	}
+	t.clientCerts = append(t.clientCerts, database.ClientCert{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		Subject:   arg.Subject,
+		UserID:    arg.UserID,
+	})
+	return nil
+}
+
+func (q queries) GetClientCertBySubject(ctx context.Context, subject string) (database.ClientCert, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.clientCerts, func(c database.ClientCert) bool { return c.Subject == subject })
+}
+
+func (q queries) ListClientCerts(ctx context.Context) ([]database.ClientCert, error) {
+	t, done := q.read()
+	defer done()
+	certs := where(t.clientCerts, func(database.ClientCert) bool { return true })
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].CreatedAt < certs[j].CreatedAt })
+	return certs, nil
+}
+
+func (q queries) DeleteClientCert(ctx context.Context, id string) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return remove(&t.clientCerts, func(c database.ClientCert) bool { return c.ID == id }), nil
+}
+
+func (q queries) DeleteClientCertsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.clientCerts, func(c database.ClientCert) bool { return c.UserID == userID })
+	return nil
+}
@@ -0,0 +1,67 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateDataExport(ctx context.Context, arg database.CreateDataExportParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.dataExports, func(e database.DataExport) bool { return e.ID == arg.ID }) {
+		return errUnique("data_exports.id")
+	}
+	if exists(t.dataExports, func(e database.DataExport) bool { return e.TokenHash == arg.TokenHash }) {
+		return errUnique("data_exports.token_hash")
+	}
+	t.dataExports = append(t.dataExports, database.DataExport{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UserID:    arg.UserID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+		Status:    "pending",
+	})
+	return nil
+}
+
+func (q queries) GetDataExportByTokenHash(ctx context.Context, tokenHash string) (database.DataExport, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.dataExports, func(e database.DataExport) bool { return e.TokenHash == tokenHash })
+}
+
+func (q queries) CountPendingDataExportsForUser(ctx context.Context, userID string) (int64, error) {
+	t, done := q.read()
+	defer done()
+	return count(t.dataExports, func(e database.DataExport) bool {
+		return e.UserID == userID && e.Status == "pending"
+	}), nil
+}
+
+func (q queries) FinishDataExport(ctx context.Context, arg database.FinishDataExportParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.dataExports, func(e database.DataExport) bool {
+		return e.ID == arg.ID
+	}, func(e *database.DataExport) {
+		e.Status = arg.Status
+		e.Data = arg.Data
+	})
+	return nil
+}
+
+func (q queries) DeleteExpiredDataExports(ctx context.Context, expiresAt string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.dataExports, func(e database.DataExport) bool { return e.ExpiresAt <= expiresAt })
+	return nil
+}
+
+func (q queries) DeleteDataExportsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.dataExports, func(e database.DataExport) bool { return e.UserID == userID })
+	return nil
+}
@@ -0,0 +1,67 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateIdempotencyKey(ctx context.Context, arg database.CreateIdempotencyKeyParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	if exists(t.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.UserID == arg.UserID && k.Key == arg.Key
+	}) {
+		return 0, nil
+	}
+	t.idempotencyKeys = append(t.idempotencyKeys, database.IdempotencyKey{
+		UserID:      arg.UserID,
+		Key:         arg.Key,
+		CreatedAt:   arg.CreatedAt,
+		RequestHash: arg.RequestHash,
+	})
+	return 1, nil
+}
+
+func (q queries) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.UserID == arg.UserID && k.Key == arg.Key
+	})
+}
+
+func (q queries) SaveIdempotencyKeyResponse(ctx context.Context, arg database.SaveIdempotencyKeyResponseParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.UserID == arg.UserID && k.Key == arg.Key
+	}, func(k *database.IdempotencyKey) {
+		k.StatusCode = arg.StatusCode
+		k.ResponseBody = arg.ResponseBody
+	})
+	return nil
+}
+
+func (q queries) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.idempotencyKeys, func(k database.IdempotencyKey) bool {
+		return k.UserID == arg.UserID && k.Key == arg.Key
+	})
+	return nil
+}
+
+func (q queries) DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.idempotencyKeys, func(k database.IdempotencyKey) bool { return k.CreatedAt < createdAt })
+	return nil
+}
+
+func (q queries) DeleteIdempotencyKeysForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.idempotencyKeys, func(k database.IdempotencyKey) bool { return k.UserID == userID })
+	return nil
+}
@@ -0,0 +1,115 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateJob(ctx context.Context, arg database.CreateJobParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	if exists(t.jobs, func(j database.Job) bool {
+		return j.ID == arg.ID || arg.UniqueKey.Valid && nullEqual(j.UniqueKey, arg.UniqueKey)
+	}) {
+		return 0, nil
+	}
+	t.jobs = append(t.jobs, database.Job{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Kind:        arg.Kind,
+		Payload:     arg.Payload,
+		UniqueKey:   arg.UniqueKey,
+		Status:      "pending",
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       arg.RunAt,
+	})
+	return 1, nil
+}
+
+func (q queries) GetJob(ctx context.Context, id string) (database.Job, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.jobs, func(j database.Job) bool { return j.ID == id })
+}
+
+func (q queries) ListJobs(ctx context.Context, arg database.ListJobsParams) ([]database.Job, error) {
+	t, done := q.read()
+	defer done()
+	jobs := where(t.jobs, func(j database.Job) bool {
+		return (arg.Status == "" || j.Status == arg.Status) && (arg.Kind == "" || j.Kind == arg.Kind)
+	})
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt > b.CreatedAt
+		}
+		return a.ID > b.ID
+	})
+	return limit(jobs, arg.Limit), nil
+}
+
+func (q queries) ListDueJobs(ctx context.Context, arg database.ListDueJobsParams) ([]database.Job, error) {
+	t, done := q.read()
+	defer done()
+	jobs := where(t.jobs, func(j database.Job) bool { return j.Status == "pending" && j.RunAt <= arg.RunAt })
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if a.RunAt != b.RunAt {
+			return a.RunAt < b.RunAt
+		}
+		return a.ID < b.ID
+	})
+	return limit(jobs, arg.Limit), nil
+}
+
+func (q queries) ClaimJob(ctx context.Context, arg database.ClaimJobParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.jobs, func(j database.Job) bool {
+		return j.ID == arg.ID && j.Status == "pending" && j.RunAt == arg.RunAt
+	}, func(j *database.Job) {
+		j.RunAt = arg.LeaseUntil
+		j.Attempts++
+		j.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) FinishJob(ctx context.Context, arg database.FinishJobParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.jobs, func(j database.Job) bool { return j.ID == arg.ID }, func(j *database.Job) {
+		j.Status = arg.Status
+		j.RunAt = arg.RunAt
+		j.LastError = arg.LastError
+		j.FinishedAt = arg.FinishedAt
+		j.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) RequeueJob(ctx context.Context, arg database.RequeueJobParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.jobs, func(j database.Job) bool {
+		return j.ID == arg.ID && j.Status == "failed"
+	}, func(j *database.Job) {
+		j.Status = "pending"
+		j.Attempts = 0
+		j.RunAt = arg.UpdatedAt
+		j.FinishedAt = sql.NullString{}
+		j.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteFinishedJobs(ctx context.Context, arg database.DeleteFinishedJobsParams) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.jobs, func(j database.Job) bool {
+		return j.Status == arg.Status && j.FinishedAt.Valid && arg.FinishedAt.Valid && j.FinishedAt.String < arg.FinishedAt.String
+	})
+	return nil
+}
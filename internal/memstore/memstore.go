@@ -0,0 +1,367 @@
+// Package memstore is an in-memory database.Store for tests and quick local
+// runs. It follows the SQL in sql/queries, including its constraints and
+// cascading deletes, closely enough that the API behaves the same as on
+// SQLite. Nothing survives a restart.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+var _ database.Store = (*Store)(nil)
+
+// Store holds every table in memory. Reads always see committed data.
+// Writes are serialized like SQLite's: a transaction holds the write lock
+// from BeginTx until Commit or Rollback, and writes outside one wait for it.
+type Store struct {
+	queries
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	data    *tables
+}
+
+// New returns an empty Store.
+func New() *Store {
+	s := &Store{data: &tables{}}
+	s.queries = queries{store: s}
+	return s
+}
+
+// Ping always succeeds; there is nothing to reach.
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing; the data goes when the Store does.
+func (s *Store) Close() error {
+	return nil
+}
+
+// BeginTx starts a transaction on a copy of the tables. It blocks while
+// another transaction is open.
+func (s *Store) BeginTx(ctx context.Context) (database.Tx, error) {
+	s.writeMu.Lock()
+	s.mu.Lock()
+	data := s.data.clone()
+	s.mu.Unlock()
+
+	tx := &Tx{data: data}
+	tx.queries = queries{store: s, tx: tx}
+	return tx, nil
+}
+
+// Tx is a transaction on a Store.
+type Tx struct {
+	queries
+
+	mu   sync.Mutex
+	data *tables
+	done bool
+}
+
+// Commit makes the transaction's writes visible to everyone.
+func (tx *Tx) Commit() error {
+	return tx.finish(true)
+}
+
+// Rollback discards the transaction's writes.
+func (tx *Tx) Rollback() error {
+	return tx.finish(false)
+}
+
+func (tx *Tx) finish(commit bool) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+
+	s := tx.store
+	if commit {
+		s.mu.Lock()
+		s.data = tx.data
+		s.mu.Unlock()
+	}
+	s.writeMu.Unlock()
+	return nil
+}
+
+// queries implements database.Querier on a Store's committed tables or,
+// inside a transaction, on the transaction's copy.
+type queries struct {
+	store *Store
+	tx    *Tx
+}
+
+// read returns the tables to query and the func to call when done.
+func (q queries) read() (*tables, func()) {
+	if q.tx != nil {
+		q.tx.mu.Lock()
+		if q.tx.done {
+			q.tx.mu.Unlock()
+			panic("memstore: query on a finished transaction")
+		}
+		return q.tx.data, q.tx.mu.Unlock
+	}
+	q.store.mu.Lock()
+	return q.store.data, q.store.mu.Unlock
+}
+
+// write is read for statements that change data.
+func (q queries) write() (*tables, func()) {
+	if q.tx != nil {
+		return q.read()
+	}
+	q.store.writeMu.Lock()
+	q.store.mu.Lock()
+	return q.store.data, func() {
+		q.store.mu.Unlock()
+		q.store.writeMu.Unlock()
+	}
+}
+
+type tables struct {
+	apiKeys                 []database.ApiKey
+	authAudit               []database.AuthAudit
+	clientCerts             []database.ClientCert
+	dataExports             []database.DataExport
+	emailVerificationTokens []database.EmailVerificationToken
+	idempotencyKeys         []database.IdempotencyKey
+	jobs                    []database.Job
+	noteItems               []database.NoteItem
+	noteListChanges         []database.NoteListChange
+	noteShareLinks          []database.NoteShareLink
+	noteShares              []database.NoteShare
+	noteTags                []database.NoteTag
+	notebooks               []database.Notebook
+	notes                   []database.Note
+	oauthIdentities         []database.OauthIdentity
+	passwordResetTokens     []database.PasswordResetToken
+	refreshTokens           []database.RefreshToken
+	sessions                []database.Session
+	tags                    []database.Tag
+	userSettings            []database.UserSetting
+	users                   []database.User
+	webhookDeliveries       []database.WebhookDelivery
+	webhooks                []database.Webhook
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		apiKeys:                 slices.Clone(t.apiKeys),
+		authAudit:               slices.Clone(t.authAudit),
+		clientCerts:             slices.Clone(t.clientCerts),
+		dataExports:             slices.Clone(t.dataExports),
+		emailVerificationTokens: slices.Clone(t.emailVerificationTokens),
+		idempotencyKeys:         slices.Clone(t.idempotencyKeys),
+		jobs:                    slices.Clone(t.jobs),
+		noteItems:               slices.Clone(t.noteItems),
+		noteListChanges:         slices.Clone(t.noteListChanges),
+		noteShareLinks:          slices.Clone(t.noteShareLinks),
+		noteShares:              slices.Clone(t.noteShares),
+		noteTags:                slices.Clone(t.noteTags),
+		notebooks:               slices.Clone(t.notebooks),
+		notes:                   slices.Clone(t.notes),
+		oauthIdentities:         slices.Clone(t.oauthIdentities),
+		passwordResetTokens:     slices.Clone(t.passwordResetTokens),
+		refreshTokens:           slices.Clone(t.refreshTokens),
+		sessions:                slices.Clone(t.sessions),
+		tags:                    slices.Clone(t.tags),
+		userSettings:            slices.Clone(t.userSettings),
+		users:                   slices.Clone(t.users),
+		webhookDeliveries:       slices.Clone(t.webhookDeliveries),
+		webhooks:                slices.Clone(t.webhooks),
+	}
+}
+
+// deleteNotes deletes the matching notes and everything that references
+// them with ON DELETE CASCADE.
+func (t *tables) deleteNotes(match func(database.Note) bool) int64 {
+	ids := map[string]bool{}
+	for _, note := range t.notes {
+		if match(note) {
+			ids[note.ID] = true
+		}
+	}
+	if len(ids) == 0 {
+		return 0
+	}
+	remove(&t.notes, func(n database.Note) bool { return ids[n.ID] })
+	remove(&t.noteTags, func(nt database.NoteTag) bool { return ids[nt.NoteID] })
+	remove(&t.noteItems, func(i database.NoteItem) bool { return ids[i.NoteID] })
+	remove(&t.noteShares, func(s database.NoteShare) bool { return ids[s.NoteID] })
+	remove(&t.noteShareLinks, func(l database.NoteShareLink) bool { return ids[l.NoteID] })
+	return int64(len(ids))
+}
+
+// deleteNotebooks deletes the matching notebooks, moving their notes to the
+// default notebook as ON DELETE SET NULL does.
+func (t *tables) deleteNotebooks(match func(database.Notebook) bool) int64 {
+	ids := map[string]bool{}
+	for _, notebook := range t.notebooks {
+		if match(notebook) {
+			ids[notebook.ID] = true
+		}
+	}
+	update(t.notes, func(n database.Note) bool {
+		return n.NotebookID.Valid && ids[n.NotebookID.String]
+	}, func(n *database.Note) {
+		n.NotebookID = sql.NullString{}
+	})
+	return remove(&t.notebooks, func(n database.Notebook) bool { return ids[n.ID] })
+}
+
+// deleteTags deletes the matching tags and their links to notes.
+func (t *tables) deleteTags(match func(database.Tag) bool) int64 {
+	ids := map[string]bool{}
+	for _, tag := range t.tags {
+		if match(tag) {
+			ids[tag.ID] = true
+		}
+	}
+	remove(&t.noteTags, func(nt database.NoteTag) bool { return ids[nt.TagID] })
+	return remove(&t.tags, func(tag database.Tag) bool { return ids[tag.ID] })
+}
+
+// deleteWebhooks deletes the matching webhooks and their deliveries.
+func (t *tables) deleteWebhooks(match func(database.Webhook) bool) int64 {
+	ids := map[string]bool{}
+	for _, h := range t.webhooks {
+		if match(h) {
+			ids[h.ID] = true
+		}
+	}
+	remove(&t.webhookDeliveries, func(d database.WebhookDelivery) bool { return ids[d.WebhookID] })
+	return remove(&t.webhooks, func(h database.Webhook) bool { return ids[h.ID] })
+}
+
+// deleteUser deletes a user and everything that references them with ON
+// DELETE CASCADE. The auth audit log keeps their entries.
+func (t *tables) deleteUser(id string) int64 {
+	owned := func(userID string) bool { return userID == id }
+	t.deleteNotes(func(n database.Note) bool { return owned(n.UserID) })
+	t.deleteNotebooks(func(n database.Notebook) bool { return owned(n.UserID) })
+	t.deleteTags(func(tag database.Tag) bool { return owned(tag.UserID) })
+	remove(&t.apiKeys, func(k database.ApiKey) bool { return owned(k.UserID) })
+	remove(&t.clientCerts, func(c database.ClientCert) bool { return owned(c.UserID) })
+	remove(&t.dataExports, func(e database.DataExport) bool { return owned(e.UserID) })
+	remove(&t.emailVerificationTokens, func(v database.EmailVerificationToken) bool { return owned(v.UserID) })
+	remove(&t.idempotencyKeys, func(k database.IdempotencyKey) bool { return owned(k.UserID) })
+	remove(&t.noteListChanges, func(c database.NoteListChange) bool { return owned(c.UserID) })
+	remove(&t.noteShares, func(s database.NoteShare) bool { return owned(s.UserID) })
+	remove(&t.oauthIdentities, func(o database.OauthIdentity) bool { return owned(o.UserID) })
+	remove(&t.passwordResetTokens, func(p database.PasswordResetToken) bool { return owned(p.UserID) })
+	remove(&t.refreshTokens, func(r database.RefreshToken) bool { return owned(r.UserID) })
+	remove(&t.sessions, func(s database.Session) bool { return owned(s.UserID) })
+	remove(&t.userSettings, func(s database.UserSetting) bool { return owned(s.UserID) })
+	t.deleteWebhooks(func(h database.Webhook) bool { return owned(h.UserID) })
+	return remove(&t.users, func(u database.User) bool { return owned(u.ID) })
+}
+
+// notesOwnedBy returns the IDs of a user's notes, optionally only those in
+// one notebook. A NULL notebook matches nothing, as in SQL.
+func (t *tables) notesOwnedBy(userID string, notebookID *sql.NullString) map[string]bool {
+	ids := map[string]bool{}
+	for _, note := range t.notes {
+		if note.UserID != userID {
+			continue
+		}
+		if notebookID != nil && !nullEqual(note.NotebookID, *notebookID) {
+			continue
+		}
+		ids[note.ID] = true
+	}
+	return ids
+}
+
+// errUnique mirrors SQLite's message for a UNIQUE or PRIMARY KEY violation.
+func errUnique(columns string) error {
+	return fmt.Errorf("memstore: UNIQUE constraint failed: %s", columns)
+}
+
+// errCheck mirrors SQLite's message for a CHECK constraint violation.
+func errCheck(constraint string) error {
+	return fmt.Errorf("memstore: CHECK constraint failed: %s", constraint)
+}
+
+// nullEqual is SQL's = on nullable strings: NULL equals nothing.
+func nullEqual(a, b sql.NullString) bool {
+	return a.Valid && b.Valid && a.String == b.String
+}
+
+// first returns the first matching row, or sql.ErrNoRows.
+func first[T any](rows []T, match func(T) bool) (T, error) {
+	for _, row := range rows {
+		if match(row) {
+			return row, nil
+		}
+	}
+	var zero T
+	return zero, sql.ErrNoRows
+}
+
+// exists reports whether any row matches.
+func exists[T any](rows []T, match func(T) bool) bool {
+	return slices.ContainsFunc(rows, match)
+}
+
+// where returns a copy of the matching rows, or nil if there are none, as
+// sqlc's :many queries do.
+func where[T any](rows []T, match func(T) bool) []T {
+	var matched []T
+	for _, row := range rows {
+		if match(row) {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
+
+// count returns how many rows match.
+func count[T any](rows []T, match func(T) bool) int64 {
+	var n int64
+	for _, row := range rows {
+		if match(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// update applies fn to the matching rows and returns how many there were.
+func update[T any](rows []T, match func(T) bool, fn func(*T)) int64 {
+	var n int64
+	for i := range rows {
+		if match(rows[i]) {
+			fn(&rows[i])
+			n++
+		}
+	}
+	return n
+}
+
+// remove deletes the matching rows and returns how many there were.
+func remove[T any](rows *[]T, match func(T) bool) int64 {
+	before := len(*rows)
+	*rows = slices.DeleteFunc(*rows, match)
+	return int64(before - len(*rows))
+}
+
+// limit returns at most n rows. A negative n means no limit, as in SQLite.
+func limit[T any](rows []T, n int64) []T {
+	if n >= 0 && int64(len(rows)) > n {
+		rows = rows[:n]
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows
+}
@@ -0,0 +1,485 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+// seedNotes creates user u1 with notes n1..n5, created a minute apart, and
+// pins n2.
+func seedNotes(t *testing.T, s *Store) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		err := s.CreateNote(ctx, database.CreateNoteParams{
+			ID:        fmt.Sprintf("n%d", i),
+			CreatedAt: fmt.Sprintf("2024-01-01T00:0%d:00Z", i),
+			UpdatedAt: fmt.Sprintf("2024-01-01T00:0%d:00Z", i),
+			Note:      fmt.Sprintf("note %d", i),
+			UserID:    "u1",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.SetNotePinned(ctx, database.SetNotePinnedParams{Pinned: true, ID: "n2", UserID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListNotesForUser(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		params      database.ListNotesForUserParams
+		expected    []string
+	}{
+		"ascending": {
+			description: "Pinned notes come first, then oldest first",
+			params:      database.ListNotesForUserParams{Limit: 10},
+			expected:    []string{"n2", "n1", "n3", "n4", "n5"},
+		},
+		"descending": {
+			description: "Pinned notes still come first when newest first",
+			params:      database.ListNotesForUserParams{Descending: true, Limit: 10},
+			expected:    []string{"n2", "n5", "n4", "n3", "n1"},
+		},
+		"cursor in pinned": {
+			description: "A cursor on a pinned note continues with the unpinned ones",
+			params: database.ListNotesForUserParams{
+				AfterValue:  nullString("2024-01-01T00:02:00Z"),
+				AfterPinned: true,
+				AfterID:     nullString("n2"),
+				Limit:       10,
+			},
+			expected: []string{"n1", "n3", "n4", "n5"},
+		},
+		"cursor descending": {
+			description: "A cursor continues after the note it names",
+			params: database.ListNotesForUserParams{
+				AfterValue: nullString("2024-01-01T00:04:00Z"),
+				AfterID:    nullString("n4"),
+				Descending: true,
+				Limit:      10,
+			},
+			expected: []string{"n3", "n1"},
+		},
+		"offset and limit": {
+			description: "Offset skips rows before the limit applies",
+			params:      database.ListNotesForUserParams{Offset: 1, Limit: 2},
+			expected:    []string{"n1", "n3"},
+		},
+		"created range": {
+			description: "created_after is inclusive and created_before exclusive",
+			params: database.ListNotesForUserParams{
+				CreatedAfter:  nullString("2024-01-01T00:03:00Z"),
+				CreatedBefore: nullString("2024-01-01T00:05:00Z"),
+				Limit:         10,
+			},
+			expected: []string{"n3", "n4"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			s := New()
+			seedNotes(t, s)
+			tc.params.UserID = "u1"
+			notes, err := s.ListNotesForUser(context.Background(), tc.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, note := range notes {
+				ids = append(ids, note.ID)
+			}
+			if diff := cmp.Diff(tc.expected, ids); diff != "" {
+				t.Errorf("ListNotesForUser() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSearchNotesForUser(t *testing.T) {
+	notes := map[string]string{
+		"n1": "Buy milk and eggs",
+		"n2": "Milk, milk, milk!",
+		"n3": "Call the plumber about the sink",
+	}
+
+	tests := map[string]struct {
+		description string
+		query       string
+		expected    []string
+	}{
+		"one word": {
+			description: "Matches are case-insensitive and ranked by how often they occur",
+			query:       `"MILK"`,
+			expected:    []string{"n2", "n1"},
+		},
+		"every word": {
+			description: "Each phrase must match",
+			query:       `"milk" "eggs"`,
+			expected:    []string{"n1"},
+		},
+		"phrase": {
+			description: "A quoted phrase matches consecutive words",
+			query:       `"the plumber"`,
+			expected:    []string{"n3"},
+		},
+		"punctuation": {
+			description: "Punctuation in a phrase separates words",
+			query:       `"about-the"`,
+			expected:    []string{"n3"},
+		},
+		"no match": {
+			description: "Words out of order don't match a phrase",
+			query:       `"eggs milk"`,
+			expected:    nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			ctx := context.Background()
+			s := New()
+			for _, id := range []string{"n1", "n2", "n3"} {
+				if err := s.CreateNote(ctx, database.CreateNoteParams{ID: id, Note: notes[id], UserID: "u1"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			found, err := s.SearchNotesForUser(ctx, database.SearchNotesForUserParams{UserID: "u1", Query: tc.query, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, note := range found {
+				ids = append(ids, note.ID)
+			}
+			if diff := cmp.Diff(tc.expected, ids); diff != "" {
+				t.Errorf("SearchNotesForUser(%q) mismatch (-want +got):\n%s", tc.query, diff)
+			}
+		})
+	}
+}
+
+func TestGetNoteListState(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		description string
+		setup       func(s *Store) error
+		userID      string
+		expected    database.GetNoteListStateRow
+	}{
+		"notes": {
+			description: "Notes carry the time of the latest update",
+			setup:       func(s *Store) error { return nil },
+			userID:      "u1",
+			expected:    database.GetNoteListStateRow{NotesUpdatedAt: "2024-01-01T00:05:00Z"},
+		},
+		"related": {
+			description: "Tags, settings and recorded changes carry their own times, and changes are counted",
+			setup: func(s *Store) error {
+				return errors.Join(
+					s.CreateTag(ctx, database.CreateTagParams{ID: "t1", Name: "work", UserID: "u1", UpdatedAt: "2024-02-01T00:00:00Z"}),
+					s.UpsertUserSettings(ctx, database.UpsertUserSettingsParams{UserID: "u1", Settings: "{}", UpdatedAt: "2024-03-01T00:00:00Z"}),
+					s.TouchNoteList(ctx, database.TouchNoteListParams{UserID: "u1", ChangedAt: "2024-04-01T00:00:00Z"}),
+					s.TouchNoteList(ctx, database.TouchNoteListParams{UserID: "u1", ChangedAt: "2024-05-01T00:00:00Z"}),
+				)
+			},
+			userID: "u1",
+			expected: database.GetNoteListStateRow{
+				Version:           2,
+				NotesUpdatedAt:    "2024-01-01T00:05:00Z",
+				TagsUpdatedAt:     "2024-02-01T00:00:00Z",
+				SettingsUpdatedAt: "2024-03-01T00:00:00Z",
+				ChangedAt:         "2024-05-01T00:00:00Z",
+			},
+		},
+		"other user": {
+			description: "Another user's notes and changes don't count",
+			setup: func(s *Store) error {
+				return s.TouchNoteList(ctx, database.TouchNoteListParams{UserID: "u1", ChangedAt: "2024-04-01T00:00:00Z"})
+			},
+			userID:   "u2",
+			expected: database.GetNoteListStateRow{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			s := New()
+			seedNotes(t, s)
+			if err := tc.setup(s); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.GetNoteListState(ctx, tc.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("GetNoteListState() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLike(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		s           string
+		pattern     string
+		expected    bool
+	}{
+		"contains": {
+			description: "% matches any run of characters",
+			s:           "Alice Smith",
+			pattern:     "%smi%",
+			expected:    true,
+		},
+		"underscore": {
+			description: "_ matches exactly one character",
+			s:           "bob",
+			pattern:     "b_b",
+			expected:    true,
+		},
+		"escaped percent": {
+			description: `\% matches a literal percent sign`,
+			s:           "100 percent",
+			pattern:     `100\%`,
+			expected:    false,
+		},
+		"escaped underscore": {
+			description: `\_ matches a literal underscore`,
+			s:           "a_b",
+			pattern:     `a\_b`,
+			expected:    true,
+		},
+		"no match": {
+			description: "The whole string must match",
+			s:           "carol",
+			pattern:     "car",
+			expected:    false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := like(tc.s, tc.pattern); got != tc.expected {
+				t.Errorf("like(%q, %q) = %v, want %v", tc.s, tc.pattern, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTx(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		commit      bool
+		expected    error
+	}{
+		"commit": {
+			description: "Committed writes are visible outside the transaction",
+			commit:      true,
+		},
+		"rollback": {
+			description: "Rolled back writes are discarded",
+			expected:    sql.ErrNoRows,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			ctx := context.Background()
+			s := New()
+			tx, err := s.BeginTx(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.CreateNotebook(ctx, database.CreateNotebookParams{ID: "b1", UserID: "u1"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.GetNotebook(ctx, database.GetNotebookParams{ID: "b1", UserID: "u1"}); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("GetNotebook() before commit: got %v, want %v", err, sql.ErrNoRows)
+			}
+			if tc.commit {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+				t.Errorf("Rollback() after finishing: got %v, want %v", err, sql.ErrTxDone)
+			}
+			if _, err := s.GetNotebook(ctx, database.GetNotebookParams{ID: "b1", UserID: "u1"}); !errors.Is(err, tc.expected) {
+				t.Errorf("GetNotebook() after finishing: got %v, want %v", err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	for _, id := range []string{"u1", "u2"} {
+		if err := s.CreateUser(ctx, database.CreateUserParams{ID: id, ApiKey: "key-" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seedNotes(t, s)
+	steps := []error{
+		s.CreateNotebook(ctx, database.CreateNotebookParams{ID: "b1", UserID: "u1"}),
+		s.CreateTag(ctx, database.CreateTagParams{ID: "t1", Name: "work", UserID: "u1"}),
+		s.AddNoteTag(ctx, database.AddNoteTagParams{NoteID: "n1", TagID: "t1"}),
+		s.CreateNoteItem(ctx, database.CreateNoteItemParams{ID: "i1", NoteID: "n1"}),
+		s.UpsertNoteShare(ctx, database.UpsertNoteShareParams{NoteID: "n1", UserID: "u2", Permission: "read"}),
+		s.CreateAuthAuditEntry(ctx, database.CreateAuthAuditEntryParams{ID: "a1", UserID: nullString("u1")}),
+		s.CreateWebhook(ctx, database.CreateWebhookParams{ID: "w1", UserID: "u1"}),
+		s.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{ID: "d1", WebhookID: "w1"}),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := s.DeleteUser(ctx, "u1"); err != nil || n != 1 {
+		t.Fatalf("DeleteUser() = %d, %v, want 1, nil", n, err)
+	}
+
+	got := map[string]int{
+		"notes":      len(s.data.notes),
+		"notebooks":  len(s.data.notebooks),
+		"tags":       len(s.data.tags),
+		"note_tags":  len(s.data.noteTags),
+		"note_items": len(s.data.noteItems),
+		"shares":     len(s.data.noteShares),
+		"users":      len(s.data.users),
+		"auth_audit": len(s.data.authAudit),
+		"webhooks":   len(s.data.webhooks),
+		"deliveries": len(s.data.webhookDeliveries),
+	}
+	expected := map[string]int{
+		"notes":      0,
+		"notebooks":  0,
+		"tags":       0,
+		"note_tags":  0,
+		"note_items": 0,
+		"shares":     0,
+		"users":      1,
+		"auth_audit": 1,
+		"webhooks":   0,
+		"deliveries": 0,
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("rows left after DeleteUser() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUniqueConstraints(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	if err := s.CreateUser(ctx, database.CreateUserParams{ID: "u1", ApiKey: "k1", Email: nullString("a@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		description string
+		err         error
+		expectErr   bool
+	}{
+		"duplicate email": {
+			description: "Two users can't share an email",
+			err:         s.CreateUser(ctx, database.CreateUserParams{ID: "u2", ApiKey: "k2", Email: nullString("a@example.com")}),
+			expectErr:   true,
+		},
+		"null emails": {
+			description: "Any number of users can have no email",
+			err:         s.CreateUser(ctx, database.CreateUserParams{ID: "u3", ApiKey: "k3"}),
+		},
+		"duplicate key": {
+			description: "Two users can't share an API key",
+			err:         s.CreateUser(ctx, database.CreateUserParams{ID: "u4", ApiKey: "k1"}),
+			expectErr:   true,
+		},
+		"own email": {
+			description: "Saving a profile with its own email is fine",
+			err:         s.UpdateUserProfile(ctx, database.UpdateUserProfileParams{ID: "u1", Email: nullString("a@example.com")}),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if (tc.err != nil) != tc.expectErr {
+				t.Errorf("got error %v, want error: %v", tc.err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := map[string]struct {
+		description string
+		fnErr       error
+		expected    []string
+	}{
+		"success": {
+			description: "Every write commits when fn succeeds",
+			expected:    []string{"b1", "b2"},
+		},
+		"failure": {
+			description: "A failure after the first write leaves nothing behind",
+			fnErr:       errFailed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			ctx := context.Background()
+			s := New()
+			err := database.WithTx(ctx, s, func(tx database.Querier) error {
+				if err := tx.CreateNotebook(ctx, database.CreateNotebookParams{ID: "b1", Name: "a", UserID: "u1"}); err != nil {
+					return err
+				}
+				if tc.fnErr != nil {
+					return tc.fnErr
+				}
+				return tx.CreateNotebook(ctx, database.CreateNotebookParams{ID: "b2", Name: "b", UserID: "u1"})
+			})
+			if !errors.Is(err, tc.fnErr) {
+				t.Errorf("WithTx() error = %v, want %v", err, tc.fnErr)
+			}
+			notebooks, err := s.ListNotebooksForUser(ctx, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, notebook := range notebooks {
+				ids = append(ids, notebook.ID)
+			}
+			if diff := cmp.Diff(tc.expected, ids); diff != "" {
+				t.Errorf("notebooks after WithTx() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
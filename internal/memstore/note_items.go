@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateNoteItem(ctx context.Context, arg database.CreateNoteItemParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.noteItems, func(i database.NoteItem) bool { return i.ID == arg.ID }) {
+		return errUnique("note_items.id")
+	}
+	t.noteItems = append(t.noteItems, database.NoteItem(arg))
+	return nil
+}
+
+func (q queries) ListNoteItems(ctx context.Context, noteID string) ([]database.NoteItem, error) {
+	t, done := q.read()
+	defer done()
+	items := where(t.noteItems, func(i database.NoteItem) bool { return i.NoteID == noteID })
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+	return items, nil
+}
+
+func (q queries) UpdateNoteItem(ctx context.Context, arg database.UpdateNoteItemParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.noteItems, func(i database.NoteItem) bool {
+		return i.ID == arg.ID && i.NoteID == arg.NoteID
+	}, func(i *database.NoteItem) {
+		if arg.Text.Valid {
+			i.Text = arg.Text.String
+		}
+		if arg.Done.Valid {
+			i.Done = arg.Done.Bool
+		}
+		i.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteNoteItem(ctx context.Context, arg database.DeleteNoteItemParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return remove(&t.noteItems, func(i database.NoteItem) bool {
+		return i.ID == arg.ID && i.NoteID == arg.NoteID
+	}), nil
+}
+
+func (q queries) DeleteNoteItems(ctx context.Context, noteID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteItems, func(i database.NoteItem) bool { return i.NoteID == noteID })
+	return nil
+}
+
+func (q queries) DeleteNoteItemsInNotebook(ctx context.Context, arg database.DeleteNoteItemsInNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(arg.UserID, &arg.NotebookID)
+	remove(&t.noteItems, func(i database.NoteItem) bool { return ids[i.NoteID] })
+	return nil
+}
+
+func (q queries) ListItemsForNotes(ctx context.Context, noteIds []string) ([]database.NoteItem, error) {
+	t, done := q.read()
+	defer done()
+	ids := make(map[string]bool, len(noteIds))
+	for _, id := range noteIds {
+		ids[id] = true
+	}
+	items := where(t.noteItems, func(i database.NoteItem) bool { return ids[i.NoteID] })
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].NoteID != items[j].NoteID {
+			return items[i].NoteID < items[j].NoteID
+		}
+		return items[i].Position < items[j].Position
+	})
+	return items, nil
+}
+
+func (q queries) DeleteNoteItemsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	remove(&t.noteItems, func(i database.NoteItem) bool { return ids[i.NoteID] })
+	return nil
+}
@@ -0,0 +1,54 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) TouchNoteList(ctx context.Context, arg database.TouchNoteListParams) error {
+	t, done := q.write()
+	defer done()
+	n := update(t.noteListChanges, func(c database.NoteListChange) bool {
+		return c.UserID == arg.UserID
+	}, func(c *database.NoteListChange) {
+		c.ChangedAt = arg.ChangedAt
+		c.Version++
+	})
+	if n == 0 {
+		t.noteListChanges = append(t.noteListChanges, database.NoteListChange{UserID: arg.UserID, ChangedAt: arg.ChangedAt, Version: 1})
+	}
+	return nil
+}
+
+func (q queries) GetNoteListState(ctx context.Context, userID string) (database.GetNoteListStateRow, error) {
+	t, done := q.read()
+	defer done()
+	var state database.GetNoteListStateRow
+	for _, note := range t.notes {
+		if note.UserID != userID {
+			continue
+		}
+		state.NotesUpdatedAt = max(state.NotesUpdatedAt, note.UpdatedAt)
+	}
+	for _, tag := range t.tags {
+		if tag.UserID == userID {
+			state.TagsUpdatedAt = max(state.TagsUpdatedAt, tag.UpdatedAt)
+		}
+	}
+	if s, err := first(t.userSettings, func(s database.UserSetting) bool { return s.UserID == userID }); err == nil {
+		state.SettingsUpdatedAt = s.UpdatedAt
+	}
+	if c, err := first(t.noteListChanges, func(c database.NoteListChange) bool { return c.UserID == userID }); err == nil {
+		state.ChangedAt = c.ChangedAt
+		state.Version = c.Version
+	}
+	return state, nil
+}
+
+func (q queries) DeleteNoteListChangesForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteListChanges, func(c database.NoteListChange) bool { return c.UserID == userID })
+	return nil
+}
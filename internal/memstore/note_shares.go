@@ -0,0 +1,208 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) UpsertNoteShare(ctx context.Context, arg database.UpsertNoteShareParams) error {
+	t, done := q.write()
+	defer done()
+	if arg.Permission != "read" && arg.Permission != "write" {
+		return errCheck("note_shares.permission")
+	}
+	n := update(t.noteShares, func(s database.NoteShare) bool {
+		return s.NoteID == arg.NoteID && s.UserID == arg.UserID
+	}, func(s *database.NoteShare) {
+		s.Permission = arg.Permission
+	})
+	if n == 0 {
+		t.noteShares = append(t.noteShares, database.NoteShare(arg))
+	}
+	return nil
+}
+
+func (q queries) GetNoteShare(ctx context.Context, arg database.GetNoteShareParams) (database.NoteShare, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.noteShares, func(s database.NoteShare) bool {
+		return s.NoteID == arg.NoteID && s.UserID == arg.UserID
+	})
+}
+
+func (q queries) ListNoteShares(ctx context.Context, noteID string) ([]database.ListNoteSharesRow, error) {
+	t, done := q.read()
+	defer done()
+	shares := where(t.noteShares, func(s database.NoteShare) bool { return s.NoteID == noteID })
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].CreatedAt < shares[j].CreatedAt })
+
+	var rows []database.ListNoteSharesRow
+	for _, share := range shares {
+		user, err := first(t.users, func(u database.User) bool { return u.ID == share.UserID })
+		if err != nil {
+			continue
+		}
+		rows = append(rows, database.ListNoteSharesRow{
+			NoteID:     share.NoteID,
+			UserID:     share.UserID,
+			CreatedAt:  share.CreatedAt,
+			Permission: share.Permission,
+			Email:      user.Email,
+		})
+	}
+	return rows, nil
+}
+
+func (q queries) ListNotesSharedWithUser(ctx context.Context, userID string) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	shares := where(t.noteShares, func(s database.NoteShare) bool { return s.UserID == userID })
+	var notes []database.Note
+	sharedAt := map[string]string{}
+	for _, share := range shares {
+		note, err := first(t.notes, func(n database.Note) bool { return n.ID == share.NoteID })
+		if err != nil {
+			continue
+		}
+		notes = append(notes, note)
+		sharedAt[note.ID] = share.CreatedAt
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := sharedAt[notes[i].ID], sharedAt[notes[j].ID]
+		if a != b {
+			return a > b
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return notes, nil
+}
+
+func (q queries) DeleteNoteShare(ctx context.Context, arg database.DeleteNoteShareParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return remove(&t.noteShares, func(s database.NoteShare) bool {
+		return s.NoteID == arg.NoteID && s.UserID == arg.UserID
+	}), nil
+}
+
+func (q queries) DeleteNoteShares(ctx context.Context, noteID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteShares, func(s database.NoteShare) bool { return s.NoteID == noteID })
+	return nil
+}
+
+func (q queries) DeleteNoteSharesInNotebook(ctx context.Context, arg database.DeleteNoteSharesInNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(arg.UserID, &arg.NotebookID)
+	remove(&t.noteShares, func(s database.NoteShare) bool { return ids[s.NoteID] })
+	return nil
+}
+
+func (q queries) DeleteNoteSharesForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	remove(&t.noteShares, func(s database.NoteShare) bool { return ids[s.NoteID] })
+	return nil
+}
+
+func (q queries) DeleteNoteSharesWithUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteShares, func(s database.NoteShare) bool { return s.UserID == userID })
+	return nil
+}
+
+func (q queries) ListNoteSharesForOwner(ctx context.Context, userID string) ([]database.NoteShare, error) {
+	t, done := q.read()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	shares := where(t.noteShares, func(s database.NoteShare) bool { return ids[s.NoteID] })
+	sort.SliceStable(shares, func(i, j int) bool {
+		a, b := shares[i], shares[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		if a.NoteID != b.NoteID {
+			return a.NoteID < b.NoteID
+		}
+		return a.UserID < b.UserID
+	})
+	return shares, nil
+}
+
+func (q queries) CreateNoteShareLink(ctx context.Context, arg database.CreateNoteShareLinkParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.noteShareLinks, func(l database.NoteShareLink) bool { return l.ID == arg.ID }) {
+		return errUnique("note_share_links.id")
+	}
+	if exists(t.noteShareLinks, func(l database.NoteShareLink) bool { return l.TokenHash == arg.TokenHash }) {
+		return errUnique("note_share_links.token_hash")
+	}
+	t.noteShareLinks = append(t.noteShareLinks, database.NoteShareLink{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		NoteID:    arg.NoteID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (q queries) GetNoteShareLinkByTokenHash(ctx context.Context, tokenHash string) (database.NoteShareLink, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.noteShareLinks, func(l database.NoteShareLink) bool { return l.TokenHash == tokenHash })
+}
+
+func (q queries) RevokeNoteShareLinks(ctx context.Context, arg database.RevokeNoteShareLinksParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.noteShareLinks, func(l database.NoteShareLink) bool {
+		return l.NoteID == arg.NoteID && !l.RevokedAt.Valid
+	}, func(l *database.NoteShareLink) {
+		l.RevokedAt = arg.RevokedAt
+	}), nil
+}
+
+func (q queries) DeleteNoteShareLinks(ctx context.Context, noteID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteShareLinks, func(l database.NoteShareLink) bool { return l.NoteID == noteID })
+	return nil
+}
+
+func (q queries) DeleteNoteShareLinksInNotebook(ctx context.Context, arg database.DeleteNoteShareLinksInNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(arg.UserID, &arg.NotebookID)
+	remove(&t.noteShareLinks, func(l database.NoteShareLink) bool { return ids[l.NoteID] })
+	return nil
+}
+
+func (q queries) DeleteNoteShareLinksForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	remove(&t.noteShareLinks, func(l database.NoteShareLink) bool { return ids[l.NoteID] })
+	return nil
+}
+
+func (q queries) ListNoteShareLinksForUser(ctx context.Context, userID string) ([]database.NoteShareLink, error) {
+	t, done := q.read()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	links := where(t.noteShareLinks, func(l database.NoteShareLink) bool { return ids[l.NoteID] })
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].CreatedAt != links[j].CreatedAt {
+			return links[i].CreatedAt < links[j].CreatedAt
+		}
+		return links[i].ID < links[j].ID
+	})
+	return links, nil
+}
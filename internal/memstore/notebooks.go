@@ -0,0 +1,60 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateNotebook(ctx context.Context, arg database.CreateNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.notebooks, func(n database.Notebook) bool { return n.ID == arg.ID }) {
+		return errUnique("notebooks.id")
+	}
+	t.notebooks = append(t.notebooks, database.Notebook(arg))
+	return nil
+}
+
+func (q queries) GetNotebook(ctx context.Context, arg database.GetNotebookParams) (database.Notebook, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.notebooks, func(n database.Notebook) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	})
+}
+
+func (q queries) ListNotebooksForUser(ctx context.Context, userID string) ([]database.Notebook, error) {
+	t, done := q.read()
+	defer done()
+	notebooks := where(t.notebooks, func(n database.Notebook) bool { return n.UserID == userID })
+	sort.SliceStable(notebooks, func(i, j int) bool { return notebooks[i].Name < notebooks[j].Name })
+	return notebooks, nil
+}
+
+func (q queries) RenameNotebook(ctx context.Context, arg database.RenameNotebookParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notebooks, func(n database.Notebook) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	}, func(n *database.Notebook) {
+		n.Name = arg.Name
+		n.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteNotebook(ctx context.Context, arg database.DeleteNotebookParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.deleteNotebooks(func(n database.Notebook) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	}), nil
+}
+
+func (q queries) DeleteNotebooksForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	t.deleteNotebooks(func(n database.Notebook) bool { return n.UserID == userID })
+	return nil
+}
@@ -0,0 +1,443 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateNote(ctx context.Context, arg database.CreateNoteParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.notes, func(n database.Note) bool { return n.ID == arg.ID }) {
+		return errUnique("notes.id")
+	}
+	t.notes = append(t.notes, database.Note{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Note:        arg.Note,
+		UserID:      arg.UserID,
+		Public:      arg.Public,
+		NotebookID:  arg.NotebookID,
+		Version:     1,
+		RemindAt:    arg.RemindAt,
+		Encrypted:   arg.Encrypted,
+		Nonce:       arg.Nonce,
+		Ciphertext:  arg.Ciphertext,
+		ContentHash: arg.ContentHash,
+		WordCount:   arg.WordCount,
+		Title:       arg.Title,
+		Metadata:    arg.Metadata,
+	})
+	return nil
+}
+
+func (q queries) GetNote(ctx context.Context, id string) (database.Note, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.notes, func(n database.Note) bool { return n.ID == id })
+}
+
+func (q queries) GetNotesForUser(ctx context.Context, userID string) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	return where(t.notes, func(n database.Note) bool { return n.UserID == userID }), nil
+}
+
+func (q queries) UpdateNote(ctx context.Context, arg database.UpdateNoteParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID && n.Version == arg.Version
+	}, func(n *database.Note) {
+		if arg.Note.Valid {
+			n.Note = arg.Note.String
+		}
+		if arg.ContentHash.Valid {
+			n.ContentHash = arg.ContentHash
+		}
+		if arg.WordCount.Valid {
+			n.WordCount = arg.WordCount
+		}
+		if arg.SetTitle {
+			n.Title = arg.Title
+		}
+		if arg.SetMetadata {
+			n.Metadata = arg.Metadata
+		}
+		if arg.Public.Valid {
+			n.Public = arg.Public.Bool
+		}
+		if arg.Nonce.Valid {
+			n.Nonce = arg.Nonce
+		}
+		if arg.Ciphertext.Valid {
+			n.Ciphertext = arg.Ciphertext
+		}
+		if arg.SetNotebook {
+			n.NotebookID = arg.NotebookID
+		}
+		if arg.SetRemindAt {
+			n.RemindAt = arg.RemindAt
+			n.RemindedAt = sql.NullString{}
+		}
+		n.UpdatedAt = arg.UpdatedAt
+		n.Version++
+	}), nil
+}
+
+func (q queries) ListNotesForUser(ctx context.Context, arg database.ListNotesForUserParams) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+
+	key := func(n database.Note) string {
+		if arg.SortBy == "updated_at" {
+			return n.UpdatedAt
+		}
+		return n.CreatedAt
+	}
+	tagged := map[string]bool{}
+	if arg.Tag.Valid {
+		for _, tag := range t.tags {
+			if tag.UserID != arg.UserID || tag.Name != arg.Tag.String {
+				continue
+			}
+			for _, nt := range t.noteTags {
+				if nt.TagID == tag.ID {
+					tagged[nt.NoteID] = true
+				}
+			}
+		}
+	}
+	// after reports whether n comes after the cursor in the listing order.
+	after := func(n database.Note) bool {
+		if n.Pinned != arg.AfterPinned {
+			return !n.Pinned
+		}
+		k, v := key(n), arg.AfterValue.String
+		if arg.Descending {
+			return k < v || k == v && arg.AfterID.Valid && n.ID < arg.AfterID.String
+		}
+		return k > v || k == v && arg.AfterID.Valid && n.ID > arg.AfterID.String
+	}
+
+	notes := where(t.notes, func(n database.Note) bool {
+		return n.UserID == arg.UserID &&
+			(arg.IncludeArchived || !n.Archived) &&
+			(!arg.CreatedAfter.Valid || n.CreatedAt >= arg.CreatedAfter.String) &&
+			(!arg.CreatedBefore.Valid || n.CreatedAt < arg.CreatedBefore.String) &&
+			(!arg.NotebookID.Valid || nullEqual(n.NotebookID, arg.NotebookID)) &&
+			(!arg.Tag.Valid || tagged[n.ID]) &&
+			(!arg.AfterValue.Valid || after(n))
+	})
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		ka, kb := key(a), key(b)
+		if ka == kb {
+			ka, kb = a.ID, b.ID
+		}
+		if arg.Descending {
+			return ka > kb
+		}
+		return ka < kb
+	})
+	if arg.Offset >= int64(len(notes)) {
+		return nil, nil
+	}
+	return limit(notes[arg.Offset:], arg.Limit), nil
+}
+
+// SearchNotesForUser approximates the FTS5 MATCH in notes.sql: the query is a
+// list of quoted phrases, each of which must appear in the note, and notes
+// with more matches rank first.
+func (q queries) SearchNotesForUser(ctx context.Context, arg database.SearchNotesForUserParams) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+
+	var phrases [][]string
+	for _, phrase := range ftsPhrases(arg.Query) {
+		if tokens := ftsTokens(phrase); len(tokens) > 0 {
+			phrases = append(phrases, tokens)
+		}
+	}
+	if len(phrases) == 0 {
+		return nil, nil
+	}
+
+	hits := map[string]int{}
+	notes := where(t.notes, func(n database.Note) bool {
+		if n.UserID != arg.UserID || n.Encrypted {
+			return false
+		}
+		tokens := ftsTokens(n.Note)
+		total := 0
+		for _, phrase := range phrases {
+			found := phraseCount(tokens, phrase)
+			if found == 0 {
+				return false
+			}
+			total += found
+		}
+		hits[n.ID] = total
+		return true
+	})
+	sort.SliceStable(notes, func(i, j int) bool { return hits[notes[i].ID] > hits[notes[j].ID] })
+	return limit(notes, arg.Limit), nil
+}
+
+// ftsPhrases splits an FTS5 query into its phrases. Quoted strings are one
+// phrase, with "" standing for a quote; anything else is split on spaces.
+func ftsPhrases(query string) []string {
+	var phrases []string
+	for query = strings.TrimSpace(query); query != ""; query = strings.TrimSpace(query) {
+		if query[0] != '"' {
+			end := strings.IndexAny(query, " \t\n\"")
+			if end < 0 {
+				end = len(query)
+			}
+			phrases = append(phrases, query[:end])
+			query = query[end:]
+			continue
+		}
+		var phrase strings.Builder
+		i := 1
+		for i < len(query) {
+			if query[i] == '"' {
+				if i+1 < len(query) && query[i+1] == '"' {
+					phrase.WriteByte('"')
+					i += 2
+					continue
+				}
+				i++
+				break
+			}
+			phrase.WriteByte(query[i])
+			i++
+		}
+		phrases = append(phrases, phrase.String())
+		query = query[i:]
+	}
+	return phrases
+}
+
+// ftsTokens splits text the way FTS5's default unicode61 tokenizer does:
+// runs of letters and digits, case folded.
+func ftsTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// phraseCount returns how many times phrase appears in tokens.
+func phraseCount(tokens, phrase []string) int {
+	n := 0
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(phrase)], phrase) {
+			n++
+		}
+	}
+	return n
+}
+
+func (q queries) DeleteNote(ctx context.Context, arg database.DeleteNoteParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.deleteNotes(func(n database.Note) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	}), nil
+}
+
+func (q queries) DeleteNotesForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	t.deleteNotes(func(n database.Note) bool { return n.UserID == userID })
+	return nil
+}
+
+func (q queries) DeleteNotesInNotebook(ctx context.Context, arg database.DeleteNotesInNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	t.deleteNotes(func(n database.Note) bool {
+		return nullEqual(n.NotebookID, arg.NotebookID) && n.UserID == arg.UserID
+	})
+	return nil
+}
+
+func (q queries) MoveNotesToDefaultNotebook(ctx context.Context, arg database.MoveNotesToDefaultNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.notes, func(n database.Note) bool {
+		return nullEqual(n.NotebookID, arg.NotebookID) && n.UserID == arg.UserID
+	}, func(n *database.Note) {
+		n.NotebookID = sql.NullString{}
+	})
+	return nil
+}
+
+func (q queries) SetNoteArchived(ctx context.Context, arg database.SetNoteArchivedParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	}, func(n *database.Note) {
+		n.Archived = arg.Archived
+		n.Version++
+	}), nil
+}
+
+func (q queries) SetNotePinned(ctx context.Context, arg database.SetNotePinnedParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID && n.UserID == arg.UserID
+	}, func(n *database.Note) {
+		n.Pinned = arg.Pinned
+		n.Version++
+	}), nil
+}
+
+func (q queries) CountPinnedNotesForUser(ctx context.Context, userID string) (int64, error) {
+	t, done := q.read()
+	defer done()
+	return count(t.notes, func(n database.Note) bool { return n.UserID == userID && n.Pinned }), nil
+}
+
+func (q queries) TouchNote(ctx context.Context, arg database.TouchNoteParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID && n.Version == arg.Version
+	}, func(n *database.Note) {
+		n.UpdatedAt = arg.UpdatedAt
+		n.Version++
+	}), nil
+}
+
+func (q queries) ListUpcomingNotesForUser(ctx context.Context, arg database.ListUpcomingNotesForUserParams) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	notes := where(t.notes, func(n database.Note) bool {
+		return n.UserID == arg.UserID && n.RemindAt.Valid && arg.RemindAt.Valid &&
+			n.RemindAt.String > arg.RemindAt.String && !n.Archived
+	})
+	sortByRemindAt(notes)
+	return limit(notes, arg.Limit), nil
+}
+
+func (q queries) ListDueReminders(ctx context.Context, arg database.ListDueRemindersParams) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	notes := where(t.notes, func(n database.Note) bool {
+		return n.RemindAt.Valid && arg.RemindAt.Valid && n.RemindAt.String <= arg.RemindAt.String &&
+			!n.RemindedAt.Valid && !n.Archived
+	})
+	sortByRemindAt(notes)
+	return limit(notes, arg.Limit), nil
+}
+
+func sortByRemindAt(notes []database.Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.RemindAt.String != b.RemindAt.String {
+			return a.RemindAt.String < b.RemindAt.String
+		}
+		return a.ID < b.ID
+	})
+}
+
+func (q queries) MarkNoteReminded(ctx context.Context, arg database.MarkNoteRemindedParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID && nullEqual(n.RemindAt, arg.RemindAt) && !n.RemindedAt.Valid
+	}, func(n *database.Note) {
+		n.RemindedAt = arg.RemindedAt
+	}), nil
+}
+
+func (q queries) ListDuplicateNotesForUser(ctx context.Context, userID string) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	hashes := map[string]int{}
+	for _, note := range t.notes {
+		if note.UserID == userID && note.ContentHash.Valid {
+			hashes[note.ContentHash.String]++
+		}
+	}
+	notes := where(t.notes, func(n database.Note) bool {
+		return n.UserID == userID && n.ContentHash.Valid && hashes[n.ContentHash.String] > 1
+	})
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.ContentHash.String != b.ContentHash.String {
+			return a.ContentHash.String < b.ContentHash.String
+		}
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.ID < b.ID
+	})
+	return notes, nil
+}
+
+func (q queries) GetNoteByContentHash(ctx context.Context, arg database.GetNoteByContentHashParams) (database.Note, error) {
+	t, done := q.read()
+	defer done()
+	notes := where(t.notes, func(n database.Note) bool {
+		return n.UserID == arg.UserID && nullEqual(n.ContentHash, arg.ContentHash)
+	})
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].CreatedAt != notes[j].CreatedAt {
+			return notes[i].CreatedAt < notes[j].CreatedAt
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return first(notes, func(database.Note) bool { return true })
+}
+
+func (q queries) ListNotesWithoutContentHash(ctx context.Context, n int64) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	return limit(where(t.notes, func(n database.Note) bool {
+		return !n.ContentHash.Valid && !n.Encrypted
+	}), n), nil
+}
+
+func (q queries) ListNotesWithoutWordCount(ctx context.Context, n int64) ([]database.Note, error) {
+	t, done := q.read()
+	defer done()
+	return limit(where(t.notes, func(n database.Note) bool {
+		return !n.WordCount.Valid && !n.Encrypted
+	}), n), nil
+}
+
+func (q queries) SetNoteContentHash(ctx context.Context, arg database.SetNoteContentHashParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID
+	}, func(n *database.Note) {
+		n.ContentHash = arg.ContentHash
+	})
+	return nil
+}
+
+func (q queries) SetNoteWordCount(ctx context.Context, arg database.SetNoteWordCountParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.notes, func(n database.Note) bool {
+		return n.ID == arg.ID
+	}, func(n *database.Note) {
+		n.WordCount = arg.WordCount
+	})
+	return nil
+}
@@ -0,0 +1,43 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateOAuthIdentity(ctx context.Context, arg database.CreateOAuthIdentityParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.oauthIdentities, func(o database.OauthIdentity) bool {
+		return o.Provider == arg.Provider && o.Subject == arg.Subject
+	}) {
+		return errUnique("oauth_identities.provider, oauth_identities.subject")
+	}
+	t.oauthIdentities = append(t.oauthIdentities, database.OauthIdentity(arg))
+	return nil
+}
+
+func (q queries) GetOAuthIdentity(ctx context.Context, arg database.GetOAuthIdentityParams) (database.OauthIdentity, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.oauthIdentities, func(o database.OauthIdentity) bool {
+		return o.Provider == arg.Provider && o.Subject == arg.Subject
+	})
+}
+
+func (q queries) ListOAuthIdentitiesForUser(ctx context.Context, userID string) ([]database.OauthIdentity, error) {
+	t, done := q.read()
+	defer done()
+	identities := where(t.oauthIdentities, func(o database.OauthIdentity) bool { return o.UserID == userID })
+	sort.SliceStable(identities, func(i, j int) bool { return identities[i].CreatedAt < identities[j].CreatedAt })
+	return identities, nil
+}
+
+func (q queries) DeleteOAuthIdentitiesForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.oauthIdentities, func(o database.OauthIdentity) bool { return o.UserID == userID })
+	return nil
+}
@@ -0,0 +1,83 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) GetNoteTotalsForUser(ctx context.Context, userID string) (database.GetNoteTotalsForUserRow, error) {
+	t, done := q.read()
+	defer done()
+	var totals database.GetNoteTotalsForUserRow
+	for _, note := range t.notes {
+		if note.UserID != userID {
+			continue
+		}
+		totals.NoteCount++
+		totals.WordCount += note.WordCount.Int64
+	}
+	return totals, nil
+}
+
+func (q queries) CountNotesPerDayForUser(ctx context.Context, arg database.CountNotesPerDayForUserParams) ([]database.CountNotesPerDayForUserRow, error) {
+	t, done := q.read()
+	defer done()
+	perDay := map[string]int64{}
+	for _, note := range t.notes {
+		if note.UserID == arg.UserID && note.CreatedAt >= arg.CreatedAt {
+			perDay[prefix(note.CreatedAt, 10)]++
+		}
+	}
+	var rows []database.CountNotesPerDayForUserRow
+	for day, n := range perDay {
+		rows = append(rows, database.CountNotesPerDayForUserRow{Day: day, NoteCount: n})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Day < rows[j].Day })
+	return rows, nil
+}
+
+func (q queries) ListTopTagsForUser(ctx context.Context, arg database.ListTopTagsForUserParams) ([]database.ListTopTagsForUserRow, error) {
+	t, done := q.read()
+	defer done()
+	var rows []database.ListTopTagsForUserRow
+	for _, tag := range t.tags {
+		if tag.UserID != arg.UserID {
+			continue
+		}
+		n := count(t.noteTags, func(nt database.NoteTag) bool { return nt.TagID == tag.ID })
+		if n > 0 {
+			rows = append(rows, database.ListTopTagsForUserRow{Name: tag.Name, NoteCount: n})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].NoteCount != rows[j].NoteCount {
+			return rows[i].NoteCount > rows[j].NoteCount
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return limit(rows, arg.Limit), nil
+}
+
+func (q queries) GetUsageForUser(ctx context.Context, userID string) (database.GetUsageForUserRow, error) {
+	t, done := q.read()
+	defer done()
+	var usage database.GetUsageForUserRow
+	for _, note := range t.notes {
+		if note.UserID != userID {
+			continue
+		}
+		usage.NoteCount++
+		usage.NoteBytes += int64(len(note.Note) + len(note.Ciphertext.String))
+	}
+	return usage, nil
+}
+
+// prefix is SQL's substr(s, 1, n).
+func prefix(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
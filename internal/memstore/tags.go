@@ -0,0 +1,130 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateTag(ctx context.Context, arg database.CreateTagParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.tags, func(tag database.Tag) bool {
+		return tag.UserID == arg.UserID && tag.Name == arg.Name
+	}) {
+		return nil
+	}
+	if exists(t.tags, func(tag database.Tag) bool { return tag.ID == arg.ID }) {
+		return errUnique("tags.id")
+	}
+	t.tags = append(t.tags, database.Tag(arg))
+	return nil
+}
+
+func (q queries) GetTagByName(ctx context.Context, arg database.GetTagByNameParams) (database.Tag, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.tags, func(tag database.Tag) bool {
+		return tag.UserID == arg.UserID && tag.Name == arg.Name
+	})
+}
+
+func (q queries) ListTagsForUser(ctx context.Context, userID string) ([]database.ListTagsForUserRow, error) {
+	t, done := q.read()
+	defer done()
+	tags := where(t.tags, func(tag database.Tag) bool { return tag.UserID == userID })
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	var rows []database.ListTagsForUserRow
+	for _, tag := range tags {
+		rows = append(rows, database.ListTagsForUserRow{
+			ID:        tag.ID,
+			CreatedAt: tag.CreatedAt,
+			UpdatedAt: tag.UpdatedAt,
+			Name:      tag.Name,
+			UserID:    tag.UserID,
+			NoteCount: count(t.noteTags, func(nt database.NoteTag) bool { return nt.TagID == tag.ID }),
+		})
+	}
+	return rows, nil
+}
+
+func (q queries) RenameTag(ctx context.Context, arg database.RenameTagParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	if exists(t.tags, func(tag database.Tag) bool {
+		return tag.ID != arg.ID && tag.UserID == arg.UserID && tag.Name == arg.Name
+	}) {
+		return 0, errUnique("tags.user_id, tags.name")
+	}
+	return update(t.tags, func(tag database.Tag) bool {
+		return tag.ID == arg.ID && tag.UserID == arg.UserID
+	}, func(tag *database.Tag) {
+		tag.Name = arg.Name
+		tag.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteTagsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	t.deleteTags(func(tag database.Tag) bool { return tag.UserID == userID })
+	return nil
+}
+
+func (q queries) AddNoteTag(ctx context.Context, arg database.AddNoteTagParams) error {
+	t, done := q.write()
+	defer done()
+	if !exists(t.noteTags, func(nt database.NoteTag) bool {
+		return nt.NoteID == arg.NoteID && nt.TagID == arg.TagID
+	}) {
+		t.noteTags = append(t.noteTags, database.NoteTag(arg))
+	}
+	return nil
+}
+
+func (q queries) ListTagsForNotes(ctx context.Context, noteIds []string) ([]database.ListTagsForNotesRow, error) {
+	t, done := q.read()
+	defer done()
+	ids := make(map[string]bool, len(noteIds))
+	for _, id := range noteIds {
+		ids[id] = true
+	}
+	var rows []database.ListTagsForNotesRow
+	for _, nt := range t.noteTags {
+		if !ids[nt.NoteID] {
+			continue
+		}
+		tag, err := first(t.tags, func(tag database.Tag) bool { return tag.ID == nt.TagID })
+		if err != nil {
+			continue
+		}
+		rows = append(rows, database.ListTagsForNotesRow{NoteID: nt.NoteID, Name: tag.Name})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows, nil
+}
+
+func (q queries) DeleteNoteTags(ctx context.Context, noteID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.noteTags, func(nt database.NoteTag) bool { return nt.NoteID == noteID })
+	return nil
+}
+
+func (q queries) DeleteNoteTagsInNotebook(ctx context.Context, arg database.DeleteNoteTagsInNotebookParams) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(arg.UserID, &arg.NotebookID)
+	remove(&t.noteTags, func(nt database.NoteTag) bool { return ids[nt.NoteID] })
+	return nil
+}
+
+func (q queries) DeleteNoteTagsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	ids := t.notesOwnedBy(userID, nil)
+	remove(&t.noteTags, func(nt database.NoteTag) bool { return ids[nt.NoteID] })
+	return nil
+}
@@ -0,0 +1,126 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// The single-use tokens: email verification, password reset, refresh
+// tokens and sessions.
+
+func (q queries) CreateEmailVerificationToken(ctx context.Context, arg database.CreateEmailVerificationTokenParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.emailVerificationTokens, func(v database.EmailVerificationToken) bool { return v.TokenHash == arg.TokenHash }) {
+		return errUnique("email_verification_tokens.token_hash")
+	}
+	t.emailVerificationTokens = append(t.emailVerificationTokens, database.EmailVerificationToken(arg))
+	return nil
+}
+
+func (q queries) GetEmailVerificationToken(ctx context.Context, tokenHash string) (database.EmailVerificationToken, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.emailVerificationTokens, func(v database.EmailVerificationToken) bool { return v.TokenHash == tokenHash })
+}
+
+func (q queries) DeleteEmailVerificationTokensForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.emailVerificationTokens, func(v database.EmailVerificationToken) bool { return v.UserID == userID })
+	return nil
+}
+
+func (q queries) CreatePasswordResetToken(ctx context.Context, arg database.CreatePasswordResetTokenParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.passwordResetTokens, func(p database.PasswordResetToken) bool { return p.TokenHash == arg.TokenHash }) {
+		return errUnique("password_reset_tokens.token_hash")
+	}
+	t.passwordResetTokens = append(t.passwordResetTokens, database.PasswordResetToken(arg))
+	return nil
+}
+
+func (q queries) GetPasswordResetToken(ctx context.Context, tokenHash string) (database.PasswordResetToken, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.passwordResetTokens, func(p database.PasswordResetToken) bool { return p.TokenHash == tokenHash })
+}
+
+func (q queries) DeletePasswordResetTokensForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.passwordResetTokens, func(p database.PasswordResetToken) bool { return p.UserID == userID })
+	return nil
+}
+
+func (q queries) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.refreshTokens, func(r database.RefreshToken) bool { return r.TokenHash == arg.TokenHash }) {
+		return errUnique("refresh_tokens.token_hash")
+	}
+	t.refreshTokens = append(t.refreshTokens, database.RefreshToken{
+		TokenHash: arg.TokenHash,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (q queries) GetRefreshToken(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.refreshTokens, func(r database.RefreshToken) bool { return r.TokenHash == tokenHash })
+}
+
+func (q queries) RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.refreshTokens, func(r database.RefreshToken) bool {
+		return r.TokenHash == arg.TokenHash && !r.RevokedAt.Valid
+	}, func(r *database.RefreshToken) {
+		r.RevokedAt = arg.RevokedAt
+		r.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteRefreshTokensForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.refreshTokens, func(r database.RefreshToken) bool { return r.UserID == userID })
+	return nil
+}
+
+func (q queries) CreateSession(ctx context.Context, arg database.CreateSessionParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.sessions, func(s database.Session) bool { return s.TokenHash == arg.TokenHash }) {
+		return errUnique("sessions.token_hash")
+	}
+	t.sessions = append(t.sessions, database.Session(arg))
+	return nil
+}
+
+func (q queries) GetSession(ctx context.Context, tokenHash string) (database.Session, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.sessions, func(s database.Session) bool { return s.TokenHash == tokenHash })
+}
+
+func (q queries) DeleteSession(ctx context.Context, tokenHash string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.sessions, func(s database.Session) bool { return s.TokenHash == tokenHash })
+	return nil
+}
+
+func (q queries) DeleteSessionsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.sessions, func(s database.Session) bool { return s.UserID == userID })
+	return nil
+}
@@ -0,0 +1,35 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) GetUserSettings(ctx context.Context, userID string) (database.UserSetting, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.userSettings, func(s database.UserSetting) bool { return s.UserID == userID })
+}
+
+func (q queries) UpsertUserSettings(ctx context.Context, arg database.UpsertUserSettingsParams) error {
+	t, done := q.write()
+	defer done()
+	n := update(t.userSettings, func(s database.UserSetting) bool {
+		return s.UserID == arg.UserID
+	}, func(s *database.UserSetting) {
+		s.Settings = arg.Settings
+		s.UpdatedAt = arg.UpdatedAt
+	})
+	if n == 0 {
+		t.userSettings = append(t.userSettings, database.UserSetting(arg))
+	}
+	return nil
+}
+
+func (q queries) DeleteUserSettingsForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.userSettings, func(s database.UserSetting) bool { return s.UserID == userID })
+	return nil
+}
@@ -0,0 +1,323 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateUser(ctx context.Context, arg database.CreateUserParams) error {
+	t, done := q.write()
+	defer done()
+	if err := t.checkUser(database.User{ID: arg.ID, ApiKey: arg.ApiKey, Email: arg.Email}, ""); err != nil {
+		return err
+	}
+	t.users = append(t.users, database.User{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		Name:         arg.Name,
+		ApiKey:       arg.ApiKey,
+		ApiKeyHashed: 1,
+		ApiKeyPrefix: arg.ApiKeyPrefix,
+		Role:         "member",
+		Email:        arg.Email,
+	})
+	return nil
+}
+
+// checkUser enforces the users table's unique columns for u, ignoring the
+// row with ID self.
+func (t *tables) checkUser(u database.User, self string) error {
+	others := where(t.users, func(o database.User) bool { return o.ID != self })
+	switch {
+	case u.ID != self && exists(others, func(o database.User) bool { return o.ID == u.ID }):
+		return errUnique("users.id")
+	case u.ApiKey != "" && exists(others, func(o database.User) bool { return o.ApiKey == u.ApiKey }):
+		return errUnique("users.api_key")
+	case exists(others, func(o database.User) bool { return nullEqual(o.Email, u.Email) }):
+		return errUnique("users.email")
+	}
+	return nil
+}
+
+func (q queries) GetUser(ctx context.Context, apiKey string) (database.User, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.users, func(u database.User) bool { return u.ApiKey == apiKey && u.ApiKeyHashed == 1 })
+}
+
+func (q queries) GetUserByLegacyAPIKey(ctx context.Context, apiKey string) (database.User, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.users, func(u database.User) bool { return u.ApiKey == apiKey && u.ApiKeyHashed == 0 })
+}
+
+func (q queries) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.users, func(u database.User) bool { return u.ID == id })
+}
+
+func (q queries) GetUserByEmail(ctx context.Context, email sql.NullString) (database.User, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.users, func(u database.User) bool { return nullEqual(u.Email, email) })
+}
+
+func (q queries) ListLegacyAPIKeys(ctx context.Context) ([]database.ListLegacyAPIKeysRow, error) {
+	t, done := q.read()
+	defer done()
+	var rows []database.ListLegacyAPIKeysRow
+	for _, u := range t.users {
+		if u.ApiKeyHashed == 0 {
+			rows = append(rows, database.ListLegacyAPIKeysRow{ID: u.ID, ApiKey: u.ApiKey})
+		}
+	}
+	return rows, nil
+}
+
+func (q queries) SetUserAPIKeyHash(ctx context.Context, arg database.SetUserAPIKeyHashParams) error {
+	t, done := q.write()
+	defer done()
+	if err := t.checkUser(database.User{ID: arg.ID, ApiKey: arg.ApiKey}, arg.ID); err != nil {
+		return err
+	}
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.ApiKey = arg.ApiKey
+		u.ApiKeyHashed = 1
+		u.ApiKeyPrefix = arg.ApiKeyPrefix
+	})
+	return nil
+}
+
+func (q queries) CountUsersWithRole(ctx context.Context, role string) (int64, error) {
+	t, done := q.read()
+	defer done()
+	return count(t.users, func(u database.User) bool { return u.Role == role }), nil
+}
+
+func (q queries) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.updateUser(arg.ID, func(u *database.User) {
+		u.Role = arg.Role
+		u.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) SetUserTOTPSecret(ctx context.Context, arg database.SetUserTOTPSecretParams) error {
+	t, done := q.write()
+	defer done()
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.TotpSecret = arg.TotpSecret
+		u.TotpEnabledAt = sql.NullString{}
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) EnableUserTOTP(ctx context.Context, arg database.EnableUserTOTPParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.users, func(u database.User) bool {
+		return u.ID == arg.ID && u.TotpSecret.Valid && !u.TotpEnabledAt.Valid
+	}, func(u *database.User) {
+		u.TotpEnabledAt = arg.TotpEnabledAt
+		u.TotpLastStep = arg.TotpLastStep
+		u.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DisableUserTOTP(ctx context.Context, arg database.DisableUserTOTPParams) error {
+	t, done := q.write()
+	defer done()
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.TotpSecret = sql.NullString{}
+		u.TotpEnabledAt = sql.NullString{}
+		u.TotpLastStep = 0
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) UseUserTOTPStep(ctx context.Context, arg database.UseUserTOTPStepParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.users, func(u database.User) bool {
+		return u.ID == arg.ID && u.TotpLastStep < arg.Step
+	}, func(u *database.User) {
+		u.TotpLastStep = arg.Step
+	}), nil
+}
+
+func (q queries) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	t, done := q.write()
+	defer done()
+	if err := t.checkUser(database.User{ID: arg.ID, Email: arg.Email}, arg.ID); err != nil {
+		return err
+	}
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.Name = arg.Name
+		u.Email = arg.Email
+		u.EmailVerifiedAt = arg.EmailVerifiedAt
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) SetUserDeletionToken(ctx context.Context, arg database.SetUserDeletionTokenParams) error {
+	t, done := q.write()
+	defer done()
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.DeletionTokenHash = arg.DeletionTokenHash
+		u.DeletionTokenExpiresAt = arg.DeletionTokenExpiresAt
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	t, done := q.write()
+	defer done()
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.PasswordHash = arg.PasswordHash
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) SetUserEmailVerified(ctx context.Context, arg database.SetUserEmailVerifiedParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.users, func(u database.User) bool {
+		return u.ID == arg.ID && nullEqual(u.Email, arg.Email)
+	}, func(u *database.User) {
+		u.EmailVerifiedAt = arg.EmailVerifiedAt
+		u.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) SetUserSuspended(ctx context.Context, arg database.SetUserSuspendedParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.updateUser(arg.ID, func(u *database.User) {
+		u.SuspendedAt = arg.SuspendedAt
+		u.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) SetUserAvatar(ctx context.Context, arg database.SetUserAvatarParams) error {
+	t, done := q.write()
+	defer done()
+	t.updateUser(arg.ID, func(u *database.User) {
+		u.AvatarUpdatedAt = arg.AvatarUpdatedAt
+		u.UpdatedAt = arg.UpdatedAt
+	})
+	return nil
+}
+
+func (q queries) DeleteUser(ctx context.Context, id string) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.deleteUser(id), nil
+}
+
+func (q queries) ListUsersForAdmin(ctx context.Context, arg database.ListUsersForAdminParams) ([]database.ListUsersForAdminRow, error) {
+	t, done := q.read()
+	defer done()
+	users := where(t.users, func(u database.User) bool {
+		if arg.Pattern.Valid && !like(u.Name, arg.Pattern.String) &&
+			!(u.Email.Valid && like(u.Email.String, arg.Pattern.String)) {
+			return false
+		}
+		if arg.BeforeCreatedAt.Valid {
+			before := arg.BeforeCreatedAt.String
+			return u.CreatedAt < before ||
+				u.CreatedAt == before && arg.BeforeID.Valid && u.ID < arg.BeforeID.String
+		}
+		return true
+	})
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].CreatedAt != users[j].CreatedAt {
+			return users[i].CreatedAt > users[j].CreatedAt
+		}
+		return users[i].ID > users[j].ID
+	})
+
+	var rows []database.ListUsersForAdminRow
+	for _, u := range limit(users, arg.Limit) {
+		row := database.ListUsersForAdminRow{
+			ID:           u.ID,
+			CreatedAt:    u.CreatedAt,
+			Name:         u.Name,
+			Email:        u.Email,
+			Role:         u.Role,
+			SuspendedAt:  u.SuspendedAt,
+			LastActiveAt: u.UpdatedAt,
+		}
+		for _, note := range t.notes {
+			if note.UserID == u.ID {
+				row.NoteCount++
+				row.LastActiveAt = max(row.LastActiveAt, note.UpdatedAt)
+			}
+		}
+		for _, key := range t.apiKeys {
+			if key.UserID == u.ID && key.LastUsedAt.Valid {
+				row.LastActiveAt = max(row.LastActiveAt, key.LastUsedAt.String)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// updateUser applies fn to the user with the given ID.
+func (t *tables) updateUser(id string, fn func(*database.User)) int64 {
+	return update(t.users, func(u database.User) bool { return u.ID == id }, fn)
+}
+
+// like is SQLite's LIKE with ESCAPE '\': % matches any run of characters, _
+// any one character, and ASCII letters match regardless of case.
+func like(s, pattern string) bool {
+	str, pat := []rune(s), []rune(pattern)
+	var match func(i, j int) bool
+	match = func(i, j int) bool {
+		for j < len(pat) {
+			switch c := pat[j]; {
+			case c == '%':
+				for k := i; k <= len(str); k++ {
+					if match(k, j+1) {
+						return true
+					}
+				}
+				return false
+			case c == '_':
+				if i == len(str) {
+					return false
+				}
+			default:
+				if c == '\\' && j+1 < len(pat) {
+					j++
+					c = pat[j]
+				}
+				if i == len(str) || asciiLower(str[i]) != asciiLower(c) {
+					return false
+				}
+			}
+			i++
+			j++
+		}
+		return i == len(str)
+	}
+	return match(0, 0)
+}
+
+func asciiLower(r rune) rune {
+	if 'A' <= r && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
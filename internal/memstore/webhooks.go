@@ -0,0 +1,205 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (q queries) CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.webhooks, func(h database.Webhook) bool { return h.ID == arg.ID }) {
+		return errUnique("webhooks.id")
+	}
+	t.webhooks = append(t.webhooks, database.Webhook{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		Events:    arg.Events,
+		Active:    true,
+	})
+	return nil
+}
+
+func (q queries) GetWebhook(ctx context.Context, arg database.GetWebhookParams) (database.Webhook, error) {
+	t, done := q.read()
+	defer done()
+	return first(t.webhooks, func(h database.Webhook) bool { return h.ID == arg.ID && h.UserID == arg.UserID })
+}
+
+func (q queries) ListWebhooksForUser(ctx context.Context, userID string) ([]database.Webhook, error) {
+	t, done := q.read()
+	defer done()
+	webhooks := where(t.webhooks, func(h database.Webhook) bool { return h.UserID == userID })
+	sort.SliceStable(webhooks, func(i, j int) bool {
+		a, b := webhooks[i], webhooks[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.ID < b.ID
+	})
+	return webhooks, nil
+}
+
+func (q queries) CountWebhooksForUser(ctx context.Context, userID string) (int64, error) {
+	t, done := q.read()
+	defer done()
+	return count(t.webhooks, func(h database.Webhook) bool { return h.UserID == userID }), nil
+}
+
+func (q queries) UpdateWebhook(ctx context.Context, arg database.UpdateWebhookParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return update(t.webhooks, func(h database.Webhook) bool {
+		return h.ID == arg.ID && h.UserID == arg.UserID
+	}, func(h *database.Webhook) {
+		h.Url = arg.Url
+		h.Events = arg.Events
+		h.Active = arg.Active
+		h.UpdatedAt = arg.UpdatedAt
+	}), nil
+}
+
+func (q queries) DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) (int64, error) {
+	t, done := q.write()
+	defer done()
+	return t.deleteWebhooks(func(h database.Webhook) bool { return h.ID == arg.ID && h.UserID == arg.UserID }), nil
+}
+
+func (q queries) DeleteWebhooksForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	t.deleteWebhooks(func(h database.Webhook) bool { return h.UserID == userID })
+	return nil
+}
+
+func (q queries) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	t, done := q.write()
+	defer done()
+	if exists(t.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.ID == arg.ID }) {
+		return errUnique("webhook_deliveries.id")
+	}
+	t.webhookDeliveries = append(t.webhookDeliveries, database.WebhookDelivery{
+		ID:            arg.ID,
+		CreatedAt:     arg.CreatedAt,
+		WebhookID:     arg.WebhookID,
+		EventType:     arg.EventType,
+		Payload:       arg.Payload,
+		Status:        "pending",
+		NextAttemptAt: arg.NextAttemptAt,
+	})
+	return nil
+}
+
+func (q queries) GetWebhookDeliveryForSending(ctx context.Context, id string) (database.GetWebhookDeliveryForSendingRow, error) {
+	t, done := q.read()
+	defer done()
+	d, err := first(t.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.ID == id })
+	if err != nil {
+		return database.GetWebhookDeliveryForSendingRow{}, err
+	}
+	h, err := first(t.webhooks, func(h database.Webhook) bool { return h.ID == d.WebhookID })
+	if err != nil {
+		return database.GetWebhookDeliveryForSendingRow{}, err
+	}
+	return database.GetWebhookDeliveryForSendingRow{
+		ID:             d.ID,
+		CreatedAt:      d.CreatedAt,
+		WebhookID:      d.WebhookID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		Url:            h.Url,
+		Secret:         h.Secret,
+		Active:         h.Active,
+	}, nil
+}
+
+func (q queries) RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error {
+	t, done := q.write()
+	defer done()
+	update(t.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return d.ID == arg.ID
+	}, func(d *database.WebhookDelivery) {
+		d.Status = arg.Status
+		d.Attempts++
+		d.NextAttemptAt = arg.NextAttemptAt
+		d.LastAttemptAt = arg.LastAttemptAt
+		d.ResponseStatus = arg.ResponseStatus
+		d.LastError = arg.LastError
+	})
+	return nil
+}
+
+func (q queries) ListWebhookDeliveries(ctx context.Context, arg database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	t, done := q.read()
+	defer done()
+	deliveries := where(t.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.WebhookID == arg.WebhookID })
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt > b.CreatedAt
+		}
+		return a.ID > b.ID
+	})
+	return limit(deliveries, arg.Limit), nil
+}
+
+func (q queries) ListPendingWebhookDeliveryIDs(ctx context.Context, webhookID string) ([]string, error) {
+	t, done := q.read()
+	defer done()
+	deliveries := where(t.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return d.WebhookID == webhookID && d.Status == "pending"
+	})
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.ID < b.ID
+	})
+	var ids []string
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return ids, nil
+}
+
+func (q queries) DeleteWebhookDeliveries(ctx context.Context, webhookID string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.WebhookID == webhookID })
+	return nil
+}
+
+func (q queries) DeleteWebhookDeliveriesForUser(ctx context.Context, userID string) error {
+	t, done := q.write()
+	defer done()
+	ids := map[string]bool{}
+	for _, h := range t.webhooks {
+		if h.UserID == userID {
+			ids[h.ID] = true
+		}
+	}
+	remove(&t.webhookDeliveries, func(d database.WebhookDelivery) bool { return ids[d.WebhookID] })
+	return nil
+}
+
+func (q queries) DeleteFinishedWebhookDeliveries(ctx context.Context, createdAt string) error {
+	t, done := q.write()
+	defer done()
+	remove(&t.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return d.Status != "pending" && d.CreatedAt < createdAt
+	})
+	return nil
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		record      func(r *Registry)
+		expected    string
+	}{
+		"counter": {
+			description: "Counters are written per label values, sorted",
+			record: func(r *Registry) {
+				c := r.NewCounterVec("requests_total", "Requests served.", "route", "status")
+				c.Inc("/v1/notes", "200")
+				c.Inc("/v1/notes", "200")
+				c.Inc("/v1/keys", "404")
+			},
+			expected: `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/v1/keys",status="404"} 1
+requests_total{route="/v1/notes",status="200"} 2
+`,
+		},
+		"histogram": {
+			description: "Histogram buckets are cumulative and end with +Inf",
+			record: func(r *Registry) {
+				h := r.NewHistogramVec("duration_seconds", "Time taken.", []float64{0.1, 1}, "route")
+				h.Observe(0.05, "/a")
+				h.Observe(0.5, "/a")
+				h.Observe(2, "/a")
+			},
+			expected: `# HELP duration_seconds Time taken.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/a",le="0.1"} 1
+duration_seconds_bucket{route="/a",le="1"} 2
+duration_seconds_bucket{route="/a",le="+Inf"} 3
+duration_seconds_sum{route="/a"} 2.55
+duration_seconds_count{route="/a"} 3
+`,
+		},
+		"gauge": {
+			description: "Gauge functions are read at write time",
+			record: func(r *Registry) {
+				r.NewGaugeFunc("open_connections", "Open connections.", func() float64 { return 3 })
+			},
+			expected: `# HELP open_connections Open connections.
+# TYPE open_connections gauge
+open_connections 3
+`,
+		},
+		"escaping": {
+			description: "Label values are escaped",
+			record: func(r *Registry) {
+				r.NewCounterVec("failures_total", "Failures.", "reason").Inc("bad \"key\"\n")
+			},
+			expected: `# HELP failures_total Failures.
+# TYPE failures_total counter
+failures_total{reason="bad \"key\"\n"} 1
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			r := NewRegistry()
+			tc.record(r)
+			var b strings.Builder
+			if _, err := r.WriteTo(&b); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
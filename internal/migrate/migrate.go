@@ -0,0 +1,342 @@
+// Package migrate applies the goose-style SQL migrations embedded in the
+// binary. Applied versions are recorded in goose's own goose_db_version
+// table, so databases migrated with the goose CLI carry on where they left
+// off.
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavor of the database being migrated. Only the version
+// table differs between them; the migrations themselves are per dialect.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+	MySQL
+)
+
+// ErrNoMigrations is returned by Down when nothing has been applied.
+var ErrNoMigrations = errors.New("no migrations to roll back")
+
+// ErrSchemaMismatch is wrapped by Check's errors when the database's schema
+// isn't the one the binary was built for.
+var ErrSchemaMismatch = errors.New("database schema doesn't match this binary")
+
+// Migration is one numbered schema file.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// NoTx runs the statements outside a transaction, as goose does for
+	// files marked "-- +goose NO TRANSACTION".
+	NoTx bool
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies and rolls back one set of migrations on a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New loads the *.sql migrations at the root of fsys, which must be named
+// after their version, as in 001_users.sql.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := Parse(name, data)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("%s and %s have the same version", other, name)
+		}
+		seen[m.Version] = name
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Status lists every migration, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration, Applied: applied[migration.Version]}
+	}
+	return statuses, nil
+}
+
+// Check confirms the database is at exactly the schema version these
+// migrations produce. It fails if any are pending, or if the database has
+// versions applied that aren't among them, as happens after rolling back
+// to an older binary without rolling back its migrations first.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	return check(m.migrations, applied)
+}
+
+func check(migrations []Migration, applied map[int64]bool) error {
+	if todo := pending(migrations, applied); len(todo) > 0 {
+		return fmt.Errorf("%w: %s isn't applied yet (%d pending in all); run -migrate up", ErrSchemaMismatch, todo[0].Name, len(todo))
+	}
+	known := make(map[int64]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+	var unknown []int64
+	for version, isApplied := range applied {
+		// Version 0 is goose's marker for an initialized table.
+		if isApplied && version != 0 && !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+		return fmt.Errorf("%w: version %d is applied but unknown, so the database is newer than this binary; deploy a newer binary or roll the migrations back with it", ErrSchemaMismatch, unknown[len(unknown)-1])
+	}
+	return nil
+}
+
+// Up applies every pending migration, oldest first, and returns the ones it
+// applied. It stops at the first failure; migrations before it stay applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range pending(m.migrations, applied) {
+		err := m.run(ctx, migration, migration.Up, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?)", migration.Version, true)
+		if err != nil {
+			return done, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		err := m.run(ctx, migration, migration.Down, "DELETE FROM goose_db_version WHERE version_id = ?", migration.Version)
+		if err != nil {
+			return Migration{}, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		return migration, nil
+	}
+	return Migration{}, ErrNoMigrations
+}
+
+// run executes a migration's statements and then record, in one transaction
+// unless the migration opts out. MySQL commits each schema change as it
+// runs, so there a migration that fails partway stays partly applied.
+func (m *Migrator) run(ctx context.Context, migration Migration, statements []string, record string, args ...any) error {
+	if migration.NoTx {
+		for _, stmt := range statements {
+			if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		_, err := m.db.ExecContext(ctx, record, args...)
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied returns the versions recorded in goose_db_version, creating the
+// table the way goose does if it's missing. Older goose versions marked
+// rollbacks with is_applied = false instead of deleting the row, so the
+// latest row for a version wins.
+func (m *Migrator) applied(ctx context.Context) (map[int64]bool, error) {
+	if _, err := m.db.ExecContext(ctx, m.createVersionTable()); err != nil {
+		return nil, fmt.Errorf("couldn't create goose_db_version: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version_id, is_applied FROM goose_db_version ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, err
+		}
+		applied[version] = isApplied
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(applied) == 0 {
+		_, err := m.db.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?)", 0, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) createVersionTable() string {
+	// goose creates the same table on MySQL, where serial is an
+	// auto-incrementing BIGINT.
+	if m.dialect == Postgres || m.dialect == MySQL {
+		return `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id serial NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp NULL DEFAULT now(),
+    PRIMARY KEY (id)
+)`
+	}
+	return `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`
+}
+
+// pending returns the migrations not yet applied, oldest first. A gap left
+// by a migration added out of order is filled in too.
+func pending(migrations []Migration, applied map[int64]bool) []Migration {
+	var todo []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			todo = append(todo, migration)
+		}
+	}
+	return todo
+}
+
+// Parse splits a goose SQL file into its Up and Down statements. Statements
+// end at a line ending in a semicolon, except between "-- +goose
+// StatementBegin" and "-- +goose StatementEnd". Comment lines outside those
+// blocks are dropped.
+func Parse(name string, data []byte) (Migration, error) {
+	prefix, _, _ := strings.Cut(path.Base(name), "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("%s: name must start with a version number", name)
+	}
+	m := Migration{Version: version, Name: path.Base(name)}
+
+	var section *[]string
+	var buf strings.Builder
+	inBlock, sawUp := false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				section, sawUp = &m.Up, true
+			case "Down":
+				section = &m.Down
+			case "StatementBegin":
+				inBlock = true
+			case "StatementEnd":
+				if !inBlock {
+					return Migration{}, fmt.Errorf("%s: StatementEnd without StatementBegin", name)
+				}
+				inBlock = false
+				*section = append(*section, strings.TrimSpace(buf.String()))
+				buf.Reset()
+			case "NO TRANSACTION":
+				m.NoTx = true
+			default:
+				return Migration{}, fmt.Errorf("%s: unsupported annotation %q", name, trimmed)
+			}
+			continue
+		}
+
+		if !inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if section == nil {
+			return Migration{}, fmt.Errorf("%s: statement before -- +goose Up", name)
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			*section = append(*section, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if inBlock || strings.TrimSpace(buf.String()) != "" {
+		return Migration{}, fmt.Errorf("%s: unterminated statement", name)
+	}
+	if !sawUp {
+		return Migration{}, fmt.Errorf("%s: missing -- +goose Up", name)
+	}
+	return m, nil
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		name        string
+		data        string
+		expected    Migration
+		expectErr   bool
+	}{
+		"simple": {
+			description: "One statement each way",
+			name:        "001_users.sql",
+			data:        "-- +goose Up\nCREATE TABLE users (\n    id TEXT PRIMARY KEY\n);\n\n-- +goose Down\nDROP TABLE users;\n",
+			expected: Migration{
+				Version: 1,
+				Name:    "001_users.sql",
+				Up:      []string{"CREATE TABLE users (\n    id TEXT PRIMARY KEY\n);"},
+				Down:    []string{"DROP TABLE users;"},
+			},
+		},
+		"several statements": {
+			description: "Statements end at a line ending in a semicolon; comments are dropped",
+			name:        "012_tags.sql",
+			data:        "-- +goose Up\n-- tags belong to users\nCREATE TABLE tags (id TEXT);\nCREATE INDEX tags_id_idx ON tags(id);\n-- +goose Down\nDROP INDEX tags_id_idx;\nDROP TABLE tags;\n",
+			expected: Migration{
+				Version: 12,
+				Name:    "012_tags.sql",
+				Up:      []string{"CREATE TABLE tags (id TEXT);", "CREATE INDEX tags_id_idx ON tags(id);"},
+				Down:    []string{"DROP INDEX tags_id_idx;", "DROP TABLE tags;"},
+			},
+		},
+		"statement block": {
+			description: "Semicolons inside a StatementBegin block don't end the statement",
+			name:        "017_notes_fts.sql",
+			data:        "-- +goose Up\n-- +goose StatementBegin\nCREATE TRIGGER t AFTER INSERT ON notes BEGIN\n    INSERT INTO log VALUES (new.id);\nEND;\n-- +goose StatementEnd\n\n-- +goose Down\nDROP TRIGGER t;\n",
+			expected: Migration{
+				Version: 17,
+				Name:    "017_notes_fts.sql",
+				Up:      []string{"CREATE TRIGGER t AFTER INSERT ON notes BEGIN\n    INSERT INTO log VALUES (new.id);\nEND;"},
+				Down:    []string{"DROP TRIGGER t;"},
+			},
+		},
+		"no transaction": {
+			description: "NO TRANSACTION is honored",
+			name:        "002_index.sql",
+			data:        "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY i ON t(c);\n-- +goose Down\nDROP INDEX i;\n",
+			expected: Migration{
+				Version: 2,
+				Name:    "002_index.sql",
+				Up:      []string{"CREATE INDEX CONCURRENTLY i ON t(c);"},
+				Down:    []string{"DROP INDEX i;"},
+				NoTx:    true,
+			},
+		},
+		"no version": {
+			description: "File names must start with a version",
+			name:        "users.sql",
+			data:        "-- +goose Up\nCREATE TABLE users (id TEXT);\n",
+			expectErr:   true,
+		},
+		"no up": {
+			description: "Files need an Up section",
+			name:        "003_x.sql",
+			data:        "CREATE TABLE x (id TEXT);\n",
+			expectErr:   true,
+		},
+		"unterminated": {
+			description: "A statement without a semicolon is an error",
+			name:        "004_x.sql",
+			data:        "-- +goose Up\nCREATE TABLE x (id TEXT)\n",
+			expectErr:   true,
+		},
+		"unclosed block": {
+			description: "A StatementBegin without StatementEnd is an error",
+			name:        "005_x.sql",
+			data:        "-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE x (id TEXT);\n",
+			expectErr:   true,
+		},
+		"unknown annotation": {
+			description: "Annotations this runner doesn't support are rejected",
+			name:        "006_x.sql",
+			data:        "-- +goose Up\n-- +goose ENVSUB ON\nCREATE TABLE x (id TEXT);\n",
+			expectErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Parse(tc.name, []byte(tc.data))
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("migration mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	tests := map[string]struct {
+		description string
+		applied     map[int64]bool
+		expected    []int64
+	}{
+		"fresh": {
+			description: "Everything is pending on a new database",
+			applied:     map[int64]bool{},
+			expected:    []int64{1, 2, 3},
+		},
+		"partly applied": {
+			description: "Only newer migrations are pending",
+			applied:     map[int64]bool{0: true, 1: true},
+			expected:    []int64{2, 3},
+		},
+		"gap": {
+			description: "A migration skipped earlier is still pending",
+			applied:     map[int64]bool{1: true, 3: true},
+			expected:    []int64{2},
+		},
+		"rolled back": {
+			description: "Versions marked not applied by older goose are pending",
+			applied:     map[int64]bool{1: true, 2: true, 3: false},
+			expected:    []int64{3},
+		},
+		"up to date": {
+			description: "Nothing is pending once everything is applied",
+			applied:     map[int64]bool{1: true, 2: true, 3: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			var got []int64
+			for _, m := range pending(migrations, tc.applied) {
+				got = append(got, m.Version)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("pending mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "001_users.sql"}, {Version: 2, Name: "002_notes.sql"}}
+	tests := map[string]struct {
+		description string
+		applied     map[int64]bool
+		expectErr   bool
+	}{
+		"up to date": {
+			description: "Every migration applied and nothing else",
+			applied:     map[int64]bool{0: true, 1: true, 2: true},
+		},
+		"behind": {
+			description: "A pending migration is a mismatch",
+			applied:     map[int64]bool{0: true, 1: true},
+			expectErr:   true,
+		},
+		"ahead": {
+			description: "A version the binary doesn't know is a mismatch",
+			applied:     map[int64]bool{0: true, 1: true, 2: true, 3: true},
+			expectErr:   true,
+		},
+		"rolled back": {
+			description: "An unknown version marked not applied is ignored",
+			applied:     map[int64]bool{1: true, 2: true, 3: false},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			err := check(migrations, tc.applied)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("check() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if err != nil && !errors.Is(err, ErrSchemaMismatch) {
+				t.Errorf("check() error = %v, want ErrSchemaMismatch", err)
+			}
+		})
+	}
+}
+
+func TestShippedMigrations(t *testing.T) {
+	for _, dir := range []string{"../../sql/schema", "../../sql/postgres/schema", "../../sql/mysql/schema"} {
+		t.Run(dir, func(t *testing.T) {
+			m, err := New(nil, SQLite, os.DirFS(dir))
+			if err != nil {
+				t.Fatalf("couldn't load migrations: %v", err)
+			}
+			if len(m.migrations) == 0 {
+				t.Fatal("no migrations found")
+			}
+			for _, migration := range m.migrations {
+				if len(migration.Up) == 0 || len(migration.Down) == 0 {
+					t.Errorf("%s: expected Up and Down statements", migration.Name)
+				}
+			}
+		})
+	}
+}
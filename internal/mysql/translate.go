@@ -0,0 +1,221 @@
+package mysql
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// overrides replaces sqlc queries, by name, that use SQLite-only features:
+// FTS5, the multi-argument MAX, ON CONFLICT and backslashes that aren't
+// escapes, along with the queries on idempotency_keys, whose key column is a
+// reserved word in MySQL. They must
+// return the same columns in the same order as the generated query and take
+// the same parameters, numbered as SQLite numbers them.
+//
+// ON CONFLICT DO NOTHING becomes an update that sets a column to itself.
+// MySQL counts no affected rows for it, as SQLite does for the skipped
+// insert, and unlike INSERT IGNORE it doesn't hide other errors.
+var overrides = map[string]string{
+	"SearchNotesForUser": `
+SELECT notes.id, notes.created_at, notes.updated_at, notes.note, notes.user_id, notes.public, notes.notebook_id, notes.archived, notes.pinned, notes.version, notes.remind_at, notes.reminded_at, notes.encrypted, notes.nonce, notes.ciphertext, notes.content_hash, notes.word_count, notes.title, notes.metadata FROM notes
+WHERE notes.user_id = ?1
+  AND MATCH (notes.note) AGAINST (?2 IN NATURAL LANGUAGE MODE)
+  AND NOT notes.encrypted
+ORDER BY MATCH (notes.note) AGAINST (?2 IN NATURAL LANGUAGE MODE) DESC, notes.id
+LIMIT ?3
+`,
+	"ListUsersForAdmin": `
+SELECT
+    users.id,
+    users.created_at,
+    users.name,
+    users.email,
+    users.role,
+    users.suspended_at,
+    (SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id) AS note_count,
+    GREATEST(
+        users.updated_at,
+        COALESCE((SELECT MAX(notes.updated_at) FROM notes WHERE notes.user_id = users.id), ''),
+        COALESCE((SELECT MAX(api_keys.last_used_at) FROM api_keys WHERE api_keys.user_id = users.id), '')
+    ) AS last_active_at
+FROM users
+WHERE (?1 IS NULL
+       OR users.name LIKE ?1 ESCAPE '\\'
+       OR users.email LIKE ?1 ESCAPE '\\')
+  AND (?2 IS NULL
+       OR users.created_at < ?2
+       OR (users.created_at = ?2 AND users.id < ?3))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT ?4
+`,
+	"CreateAPIKeyIfMissing": `
+INSERT INTO api_keys (id, created_at, updated_at, user_id, label, key_hash, key_prefix)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE key_hash = key_hash
+`,
+	"CreateJob": `
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, unique_key, max_attempts, run_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE id = id
+`,
+	"CreateTag": `
+INSERT INTO tags (id, created_at, updated_at, name, user_id)
+VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE id = id
+`,
+	"AddNoteTag": `
+INSERT INTO note_tags (note_id, tag_id)
+VALUES (?, ?)
+ON DUPLICATE KEY UPDATE note_id = note_id
+`,
+	"UpsertNoteShare": `
+INSERT INTO note_shares (note_id, user_id, created_at, permission)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE permission = VALUES(permission)
+`,
+	"TouchNoteList": `
+INSERT INTO note_list_changes (user_id, changed_at, version)
+VALUES (?, ?, 1)
+ON DUPLICATE KEY UPDATE changed_at = VALUES(changed_at), version = version + 1
+`,
+	"UpsertUserSettings": `
+INSERT INTO user_settings (user_id, settings, updated_at)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE settings = VALUES(settings), updated_at = VALUES(updated_at)
+`,
+	"CreateIdempotencyKey": "\nINSERT INTO idempotency_keys (user_id, `key`, created_at, request_hash)\n" +
+		"VALUES (?, ?, ?, ?)\n" +
+		"ON DUPLICATE KEY UPDATE user_id = user_id\n",
+	"GetIdempotencyKey": "\nSELECT user_id, `key`, created_at, request_hash, status_code, response_body FROM idempotency_keys WHERE user_id = ? AND `key` = ?\n",
+	"SaveIdempotencyKeyResponse": "\nUPDATE idempotency_keys SET status_code = ?, response_body = ?\n" +
+		"WHERE user_id = ? AND `key` = ?\n",
+	"DeleteIdempotencyKey": "\nDELETE FROM idempotency_keys WHERE user_id = ? AND `key` = ?\n",
+}
+
+// castTypes renames the SQLite types the queries CAST to. MySQL only casts
+// to a handful of types, and has no BOOLEAN, TEXT, INTEGER or BIGINT among
+// them.
+var castTypes = strings.NewReplacer(
+	" AS BOOLEAN)", " AS SIGNED)",
+	" AS INTEGER)", " AS SIGNED)",
+	" AS BIGINT)", " AS SIGNED)",
+	" AS TEXT)", " AS CHAR)",
+	" AS BLOB)", " AS BINARY)",
+)
+
+// translation is a cached result of Translate.
+type translation struct {
+	query     string
+	positions []int
+}
+
+// translated caches Translate, since sqlc sends the same few hundred query
+// strings over and over.
+var translated sync.Map
+
+// Translate turns a query written for SQLite into one MySQL accepts, along
+// with the argument positions Rebind returns for it. Queries named in
+// overrides are replaced before they're rebound.
+func Translate(query string) (string, []int) {
+	if t, ok := translated.Load(query); ok {
+		t := t.(translation)
+		return t.query, t.positions
+	}
+	q, ok := overrides[queryName(query)]
+	if !ok {
+		q = query
+	}
+	q, positions := Rebind(q)
+	translated.Store(query, translation{query: q, positions: positions})
+	return q, positions
+}
+
+// queryName returns the name sqlc puts in the first line of every generated
+// query, as in "-- name: GetNote :one".
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// Rebind rewrites SQLite placeholders as MySQL ones and renames the types in
+// CASTs. MySQL only has bare "?" placeholders, each taking the next
+// argument, so "?N" becomes "?" and positions lists, for each placeholder in
+// the result, which of the original arguments it takes. A bare "?" takes the
+// number after the largest one used so far, as SQLite numbers it. positions
+// is nil when the arguments don't need reordering. Placeholders and types
+// inside string literals, quoted identifiers and comments are left alone.
+func Rebind(query string) (string, []int) {
+	var b strings.Builder
+	b.Grow(len(query))
+	var positions []int
+	reordered := false
+	last, code := 0, 0
+	flush := func(i int) {
+		b.WriteString(castTypes.Replace(query[code:i]))
+	}
+	for i := 0; i < len(query); {
+		end := i + 1
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end = closingQuote(query, i)
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				end = i + n + 1
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end = len(query)
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			}
+		case c == '?':
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n := last + 1
+			if end > i+1 {
+				n, _ = strconv.Atoi(query[i+1 : end])
+			}
+			last = max(last, n)
+			positions = append(positions, n)
+			reordered = reordered || n != len(positions)
+			flush(i)
+			b.WriteByte('?')
+			i, code = end, end
+			continue
+		default:
+			i = end
+			continue
+		}
+		flush(i)
+		b.WriteString(query[i:end])
+		i, code = end, end
+	}
+	flush(len(query))
+	if !reordered {
+		positions = nil
+	}
+	return b.String(), positions
+}
+
+// closingQuote returns the index just past the quoted string or identifier
+// starting at start. A doubled quote is an escaped quote, not the end.
+func closingQuote(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
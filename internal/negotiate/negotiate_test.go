@@ -0,0 +1,66 @@
+package negotiate
+
+import "testing"
+
+func TestMediaType(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "application/msgpack"}
+
+	tests := map[string]struct {
+		description string
+		accept      string
+		expected    string
+	}{
+		"empty": {
+			description: "No Accept header gets the first offer",
+			expected:    "application/json",
+		},
+		"exact": {
+			description: "An exact media type is picked",
+			accept:      "application/msgpack",
+			expected:    "application/msgpack",
+		},
+		"case": {
+			description: "Media types are matched case-insensitively",
+			accept:      "Application/XML",
+			expected:    "application/xml",
+		},
+		"wildcard": {
+			description: "Wildcards match the first offer",
+			accept:      "*/*",
+			expected:    "application/json",
+		},
+		"quality": {
+			description: "The highest q wins",
+			accept:      "application/json;q=0.5, application/xml",
+			expected:    "application/xml",
+		},
+		"specific": {
+			description: "A specific range outweighs a wildcard matching the same offer",
+			accept:      "application/*, application/json;q=0",
+			expected:    "application/xml",
+		},
+		"browser": {
+			description: "A browser's Accept header prefers XML to anything else",
+			accept:      "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			expected:    "application/xml",
+		},
+		"none": {
+			description: "Nothing is picked when no offer is acceptable",
+			accept:      "text/csv",
+		},
+		"invalid q": {
+			description: "Ranges with an invalid q aren't acceptable",
+			accept:      "application/xml;q=2, application/msgpack;q=0.1",
+			expected:    "application/msgpack",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := MediaType(tc.accept, offers); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
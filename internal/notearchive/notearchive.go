@@ -0,0 +1,274 @@
+// Package notearchive reads and writes the zip format used to export and
+// import notes: one Markdown file per note with its metadata as front
+// matter, plus a manifest.json describing the export.
+package notearchive
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// Version is the format version written to the manifest.
+	Version = 1
+
+	ManifestName = "manifest.json"
+	notesDir     = "notes/"
+	frontMatter  = "---"
+
+	// MaxNoteSize caps the uncompressed size of a single archive entry.
+	MaxNoteSize = 1 << 20
+)
+
+var (
+	ErrInvalidFrontMatter = errors.New("invalid front matter")
+	ErrInvalidArchive     = errors.New("invalid archive")
+	ErrNoteTooLarge       = errors.New("note too large")
+)
+
+// Note is one exported note. Notebook is the notebook's name, empty for the
+// default notebook. Metadata is a JSON object, or nil for none. Items is
+// the note's checklist, in order.
+type Note struct {
+	ID        string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Title     string
+	Metadata  json.RawMessage
+	Public    bool
+	Archived  bool
+	Pinned    bool
+	Notebook  string
+	Tags      []string
+	Items     []Item
+	Body      string
+}
+
+// Item is one checklist item of a note.
+type Item struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	NoteCount  int       `json:"note_count"`
+	Notebooks  []string  `json:"notebooks"`
+}
+
+// Writer streams notes into a zip archive. Only the current entry is held
+// in memory.
+type Writer struct {
+	zw    *zip.Writer
+	count int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+func (w *Writer) WriteNote(n Note) error {
+	f, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     notesDir + n.ID + ".md",
+		Method:   zip.Deflate,
+		Modified: n.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(MarshalNote(n)); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Close writes the manifest, filling in the note count, and finishes the
+// archive.
+func (w *Writer) Close(m Manifest) error {
+	m.Version = Version
+	m.NoteCount = w.count
+	if m.Notebooks == nil {
+		m.Notebooks = []string{}
+	}
+	f, err := w.zw.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// ReadArchive calls fn for every Markdown file in a zip archive, in archive
+// order. It reads archives written by Writer as well as plain zips of
+// Markdown files; everything else, including the manifest, is skipped.
+// Errors from fn are returned unchanged.
+func ReadArchive(r io.ReaderAt, size int64, fn func(Note) error) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	for _, f := range zr.File {
+		if !isNoteFile(f) {
+			continue
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		n, err := ParseNote(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNoteFile(f *zip.File) bool {
+	if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+		return false
+	}
+	if strings.HasPrefix(path.Base(f.Name), ".") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(f.Name)) {
+	case ".md", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	// The header's size can't be trusted, so cap what is actually read.
+	data, err := io.ReadAll(io.LimitReader(rc, MaxNoteSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if len(data) > MaxNoteSize {
+		return nil, ErrNoteTooLarge
+	}
+	return data, nil
+}
+
+// MarshalNote renders a note as Markdown with front matter. Front matter
+// values are JSON, which keeps them valid YAML for other tools.
+func MarshalNote(n Note) []byte {
+	var buf bytes.Buffer
+	field := func(key string, value any) {
+		data, _ := json.Marshal(value)
+		fmt.Fprintf(&buf, "%s: %s\n", key, data)
+	}
+	tags := n.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	buf.WriteString(frontMatter + "\n")
+	field("id", n.ID)
+	field("created_at", n.CreatedAt.UTC().Format(time.RFC3339))
+	field("updated_at", n.UpdatedAt.UTC().Format(time.RFC3339))
+	field("title", n.Title)
+	field("metadata", n.Metadata)
+	field("public", n.Public)
+	field("archived", n.Archived)
+	field("pinned", n.Pinned)
+	field("notebook", n.Notebook)
+	field("tags", tags)
+	if len(n.Items) > 0 {
+		field("items", n.Items)
+	}
+	buf.WriteString(frontMatter + "\n\n")
+	buf.WriteString(n.Body)
+	return buf.Bytes()
+}
+
+// ParseNote reads a note written by MarshalNote. Markdown without front
+// matter is taken as the body of a note with no metadata. Unknown front
+// matter keys are ignored.
+func ParseNote(data []byte) (Note, error) {
+	text := string(data)
+	if !strings.HasPrefix(text, frontMatter+"\n") {
+		return Note{Body: text}, nil
+	}
+
+	var n Note
+	sc := bufio.NewScanner(strings.NewReader(text[len(frontMatter)+1:]))
+	consumed := len(frontMatter) + 1
+	closed := false
+	for sc.Scan() {
+		line := sc.Text()
+		consumed += len(line) + 1
+		if line == frontMatter {
+			closed = true
+			break
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return Note{}, ErrInvalidFrontMatter
+		}
+		if err := n.setField(key, []byte(value)); err != nil {
+			return Note{}, fmt.Errorf("%w: %s: %v", ErrInvalidFrontMatter, key, err)
+		}
+	}
+	if !closed {
+		return Note{}, ErrInvalidFrontMatter
+	}
+
+	n.Body = strings.TrimPrefix(text[min(consumed, len(text)):], "\n")
+	return n, nil
+}
+
+func (n *Note) setField(key string, value []byte) error {
+	switch key {
+	case "id":
+		return json.Unmarshal(value, &n.ID)
+	case "created_at":
+		return json.Unmarshal(value, &n.CreatedAt)
+	case "updated_at":
+		return json.Unmarshal(value, &n.UpdatedAt)
+	case "title":
+		return json.Unmarshal(value, &n.Title)
+	case "metadata":
+		if string(value) == "null" {
+			return nil
+		}
+		return json.Unmarshal(value, &n.Metadata)
+	case "public":
+		return json.Unmarshal(value, &n.Public)
+	case "archived":
+		return json.Unmarshal(value, &n.Archived)
+	case "pinned":
+		return json.Unmarshal(value, &n.Pinned)
+	case "notebook":
+		return json.Unmarshal(value, &n.Notebook)
+	case "tags":
+		return json.Unmarshal(value, &n.Tags)
+	case "items":
+		return json.Unmarshal(value, &n.Items)
+	default:
+		return nil
+	}
+}
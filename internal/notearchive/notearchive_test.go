@@ -0,0 +1,239 @@
+package notearchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarshalNoteRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		description string
+		note        Note
+	}{
+		"full": {
+			description: "Every field survives a round trip",
+			note: Note{
+				ID:        "8c1d6b0e",
+				CreatedAt: created,
+				UpdatedAt: created.Add(time.Hour),
+				Title:     "Plans",
+				Metadata:  json.RawMessage(`{"color":"red"}`),
+				Public:    true,
+				Archived:  true,
+				Pinned:    true,
+				Notebook:  `Work: "Q2"`,
+				Tags:      []string{"a", "b c"},
+				Items:     []Item{{Text: "milk", Done: true}, {Text: "eggs"}},
+				Body:      "# Title\n\n---\nbody with a rule\n",
+			},
+		},
+		"empty body": {
+			description: "A note with no body and no tags",
+			note: Note{
+				ID:        "8c1d6b0e",
+				CreatedAt: created,
+				UpdatedAt: created,
+				Tags:      []string{},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := ParseNote(MarshalNote(tc.note))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.note, got); diff != "" {
+				t.Errorf("note mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseNote(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		expected    Note
+		expectedErr error
+	}{
+		"plain markdown": {
+			description: "Markdown without front matter becomes the body",
+			input:       "# Groceries\n\n- milk\n",
+			expected:    Note{Body: "# Groceries\n\n- milk\n"},
+		},
+		"unknown keys": {
+			description: "Front matter keys from other tools are ignored",
+			input:       "---\nlayout: \"x\"\ntags: [\"a\"]\n---\n\nhello",
+			expected:    Note{Tags: []string{"a"}, Body: "hello"},
+		},
+		"title": {
+			description: "Titles from other tools are kept",
+			input:       "---\ntitle: \"x\"\n---\n\nhello",
+			expected:    Note{Title: "x", Body: "hello"},
+		},
+		"items": {
+			description: "Checklist items are kept in order",
+			input:       "---\nitems: [{\"text\":\"b\",\"done\":true},{\"text\":\"a\"}]\n---\n\nhello",
+			expected:    Note{Items: []Item{{Text: "b", Done: true}, {Text: "a"}}, Body: "hello"},
+		},
+		"unterminated": {
+			description: "Front matter must be closed",
+			input:       "---\nid: \"x\"\n",
+			expectedErr: ErrInvalidFrontMatter,
+		},
+		"bad value": {
+			description: "Values must be JSON",
+			input:       "---\npinned: yes please\n---\n",
+			expectedErr: ErrInvalidFrontMatter,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := ParseNote([]byte(tc.input))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("note mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, id := range []string{"a", "b"} {
+		if err := w.WriteNote(Note{ID: id, Body: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(Manifest{Notebooks: []string{"Work"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	var manifest Manifest
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name != ManifestName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"notes/a.md", "notes/b.md", ManifestName}, names); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(Manifest{Version: Version, NoteCount: 2, Notebooks: []string{"Work"}}, manifest); diff != "" {
+		t.Errorf("manifest mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	type entry struct {
+		name string
+		body string
+	}
+	tests := map[string]struct {
+		description string
+		entries     []entry
+		raw         string
+		expected    []Note
+		expectedErr error
+	}{
+		"export": {
+			description: "Notes are read back and the manifest is skipped",
+			entries: []entry{
+				{name: "notes/a.md", body: "---\nid: \"a\"\ntags: [\"x\"]\n---\n\nfirst"},
+				{name: ManifestName, body: "{}"},
+			},
+			expected: []Note{{ID: "a", Tags: []string{"x"}, Body: "first"}},
+		},
+		"plain markdown": {
+			description: "Markdown files anywhere in the zip are imported and other files skipped",
+			entries: []entry{
+				{name: "Journal/2024.MD", body: "# 2024"},
+				{name: "Journal/", body: ""},
+				{name: "Journal/.hidden.md", body: "x"},
+				{name: "__MACOSX/Journal/._2024.MD", body: "x"},
+				{name: "photo.png", body: "x"},
+				{name: "todo.markdown", body: "- milk"},
+			},
+			expected: []Note{{Body: "# 2024"}, {Body: "- milk"}},
+		},
+		"bad front matter": {
+			description: "Invalid front matter fails the whole archive",
+			entries:     []entry{{name: "a.md", body: "---\nid: \"a\"\n"}},
+			expectedErr: ErrInvalidFrontMatter,
+		},
+		"too large": {
+			description: "Oversized entries are rejected",
+			entries:     []entry{{name: "a.md", body: strings.Repeat("a", MaxNoteSize+1)}},
+			expectedErr: ErrNoteTooLarge,
+		},
+		"not a zip": {
+			description: "Garbage input is an invalid archive",
+			raw:         "not a zip",
+			expectedErr: ErrInvalidArchive,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			for _, e := range tc.entries {
+				f, err := zw.Create(e.name)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				f.Write([]byte(e.body))
+			}
+			zw.Close()
+			if tc.raw != "" {
+				buf.Reset()
+				buf.WriteString(tc.raw)
+			}
+
+			var got []Note
+			err := ReadArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()), func(n Note) error {
+				got = append(got, n)
+				return nil
+			})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("notes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
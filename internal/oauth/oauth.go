@@ -0,0 +1,141 @@
+// Package oauth signs users in through third-party OAuth2 providers.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+var ErrNoSubject = errors.New("provider returned no account id")
+
+// Identity is the account a user signed in with at a provider.
+type Identity struct {
+	// Subject is the provider's stable ID for the account.
+	Subject string
+	Name    string
+}
+
+// Provider is an OAuth2 identity provider such as GitHub or Google.
+type Provider struct {
+	Name        string
+	config      *oauth2.Config
+	userInfoURL string
+	parse       func(body []byte) (Identity, error)
+}
+
+func GitHub(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name: "github",
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     endpoints.GitHub,
+			Scopes:       []string{"read:user"},
+		},
+		userInfoURL: "https://api.github.com/user",
+		parse: func(body []byte) (Identity, error) {
+			var user struct {
+				ID    json.Number `json:"id"`
+				Login string      `json:"login"`
+				Name  string      `json:"name"`
+			}
+			if err := json.Unmarshal(body, &user); err != nil {
+				return Identity{}, err
+			}
+			name := user.Name
+			if name == "" {
+				name = user.Login
+			}
+			return Identity{Subject: user.ID.String(), Name: name}, nil
+		},
+	}
+}
+
+func Google(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name: "google",
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     endpoints.Google,
+			Scopes:       []string{"openid", "profile"},
+		},
+		userInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		parse: func(body []byte) (Identity, error) {
+			var user struct {
+				Sub  string `json:"sub"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(body, &user); err != nil {
+				return Identity{}, err
+			}
+			return Identity{Subject: user.Sub, Name: user.Name}, nil
+		},
+	}
+}
+
+// NewState returns a random value for the OAuth2 state parameter.
+func NewState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewVerifier returns a PKCE code verifier.
+func NewVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// AuthCodeURL returns the provider URL to send the browser to.
+func (p *Provider) AuthCodeURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+// Identity exchanges an authorization code for a token and fetches the
+// account it belongs to.
+func (p *Provider) Identity(ctx context.Context, code, verifier string) (Identity, error) {
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.config.Client(ctx, token).Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, fmt.Errorf("fetching user info: unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Identity{}, err
+	}
+
+	identity, err := p.parse(body)
+	if err != nil {
+		return Identity{}, fmt.Errorf("parsing user info: %w", err)
+	}
+	if identity.Subject == "" {
+		return Identity{}, ErrNoSubject
+	}
+	return identity, nil
+}
@@ -0,0 +1,83 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/oauth2"
+)
+
+func TestProviderIdentity(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		provider    func(redirectURL string) *Provider
+		userInfo    string
+		want        Identity
+		wantErr     error
+	}{
+		"github": {
+			description: "should read the numeric GitHub id and display name",
+			provider:    func(u string) *Provider { return GitHub("id", "secret", u) },
+			userInfo:    `{"id": 1234, "login": "octocat", "name": "The Octocat"}`,
+			want:        Identity{Subject: "1234", Name: "The Octocat"},
+		},
+		"github_no_name": {
+			description: "should fall back to the GitHub login",
+			provider:    func(u string) *Provider { return GitHub("id", "secret", u) },
+			userInfo:    `{"id": 1234, "login": "octocat"}`,
+			want:        Identity{Subject: "1234", Name: "octocat"},
+		},
+		"google": {
+			description: "should read the OpenID subject",
+			provider:    func(u string) *Provider { return Google("id", "secret", u) },
+			userInfo:    `{"sub": "10769150350006150715113082367", "name": "Jane Doe"}`,
+			want:        Identity{Subject: "10769150350006150715113082367", Name: "Jane Doe"},
+		},
+		"no_subject": {
+			description: "should reject a response without an account id",
+			provider:    func(u string) *Provider { return Google("id", "secret", u) },
+			userInfo:    `{"name": "Jane Doe"}`,
+			wantErr:     ErrNoSubject,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				if r.FormValue("code") != "the-code" || r.FormValue("code_verifier") != "the-verifier" {
+					http.Error(w, "bad request", http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token": "the-token", "token_type": "bearer"}`))
+			})
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer the-token" {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(tc.userInfo))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			p := tc.provider(srv.URL + "/callback")
+			p.config.Endpoint = oauth2.Endpoint{AuthURL: srv.URL + "/authorize", TokenURL: srv.URL + "/token"}
+			p.userInfoURL = srv.URL + "/user"
+
+			got, err := p.Identity(context.Background(), "the-code", "the-verifier")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Identity() error = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Identity() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
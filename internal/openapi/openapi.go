@@ -0,0 +1,361 @@
+// Package openapi describes an HTTP API as an OpenAPI 3 document.
+//
+// Request and response schemas aren't written by hand: they are generated
+// from the Go types the handlers decode and encode, following the same
+// json tags, so the document can't drift from what the API sends.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Version is the OpenAPI version of the documents built.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document, with the fields Builder fills in.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a URL the API is served from, which paths are relative to.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds a path's operations by lowercase method.
+type PathItem map[string]*Operation
+
+// Operation is one method on a path.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter is a parameter of an operation, such as a path parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body an operation reads, by content type.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one of an operation's responses.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body of one content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas and security schemes operations refer to.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of authenticating, such as a bearer token.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is the subset of JSON Schema that Go types map to. The empty
+// Schema accepts any value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Body is a request or response body sent as something other than
+// application/json. A nil Value describes a binary body, such as an
+// uploaded file.
+type Body struct {
+	ContentType string
+	Value       any
+}
+
+// Route describes one operation. Bodies are given as values, usually the
+// zero value of the type a handler decodes or encodes, such as User{}; a
+// Body sets their content type. A nil response describes one without a
+// body.
+type Route struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Security names the security schemes, any one of which the route
+	// accepts. Routes without any are public.
+	Security   []string
+	Request    any
+	Responses  map[int]any
+	Deprecated bool
+}
+
+// Builder builds a Document one route at a time.
+type Builder struct {
+	doc   *Document
+	names map[reflect.Type]string
+	// errorBody is sent with every operation as its default response.
+	errorBody any
+}
+
+// New starts a document for the API served under serverURL.
+func New(info Info, serverURL string) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Servers: []Server{{URL: serverURL}},
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:         map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{},
+			},
+		},
+		names: map[reflect.Type]string{},
+	}
+}
+
+// SecurityScheme adds a security scheme routes can name.
+func (b *Builder) SecurityScheme(name string, s SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = s
+}
+
+// ErrorBody sets the body of error responses, documented on every route
+// as its default response.
+func (b *Builder) ErrorBody(body any) {
+	b.errorBody = body
+}
+
+// Add documents the route for method and pattern, a path such as
+// /notes/{noteID}. Every path parameter is documented as a string.
+func (b *Builder) Add(method, pattern string, route Route) {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Responses:   map[string]*Response{},
+		Deprecated:  route.Deprecated,
+	}
+	path, params := pathParams(pattern)
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, name := range route.Security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: b.content(route.Request)}
+	}
+	for code, body := range route.Responses {
+		resp := &Response{Description: http.StatusText(code)}
+		if body != nil {
+			resp.Content = b.content(body)
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	if b.errorBody != nil {
+		op.Responses["default"] = &Response{Description: "Error", Content: b.content(b.errorBody)}
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &Response{Description: "Response"}
+	}
+
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = PathItem{}
+	}
+	b.doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// Document returns the document built so far.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) content(body any) map[string]MediaType {
+	bd, ok := body.(Body)
+	if !ok {
+		bd = Body{ContentType: "application/json", Value: body}
+	}
+	schema := &Schema{Type: "string", Format: "binary"}
+	if bd.Value != nil {
+		schema = b.Schema(reflect.TypeOf(bd.Value))
+	}
+	return map[string]MediaType{bd.ContentType: {Schema: schema}}
+}
+
+// pathParams converts a chi pattern into an OpenAPI path, dropping any
+// regular expressions, and returns the names of its parameters.
+func pathParams(pattern string) (string, []string) {
+	var params []string
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name, _, _ := strings.Cut(seg[1:len(seg)-1], ":")
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Schema returns the schema of values of type t as encoding/json encodes
+// them. Named struct types are added to the document's components and
+// referred to, so each is described once.
+func (b *Builder) Schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := b.Schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component adds the named struct type t to the components, if it isn't
+// there yet, and returns its name there. Types are named as in Go with a
+// leading capital; types of the same name from different scopes are
+// numbered.
+func (b *Builder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	base := []rune(t.Name())
+	base[0] = unicode.ToUpper(base[0])
+	name := string(base)
+	for n := 2; b.doc.Components.Schemas[name] != nil; n++ {
+		name = string(base) + strconv.Itoa(n)
+	}
+	b.names[t] = name
+	// Reserve the name before describing the fields, which may refer back
+	// to t.
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.structSchema(t)
+	return name
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || len(f.Index) > 1 && !embeddedJSON(t, f) {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			// Its fields are promoted, and VisibleFields lists them.
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := b.Schema(f.Type)
+		if strings.Contains(","+opts+",", ",string,") {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// embeddedJSON reports whether f, a field promoted from an embedded struct
+// of t, is encoded as part of t, as it is when every struct it's promoted
+// through is embedded without a json name.
+func embeddedJSON(t reflect.Type, f reflect.StructField) bool {
+	for i := range f.Index[:len(f.Index)-1] {
+		outer := t.FieldByIndex(f.Index[:i+1])
+		name, _, _ := strings.Cut(outer.Tag.Get("json"), ",")
+		if name != "" || indirect(outer.Type).Kind() != reflect.Struct {
+			return false
+		}
+	}
+	return true
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type base struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type item struct {
+	Text string `json:"text"`
+}
+
+type note struct {
+	base
+	Title    string          `json:"title,omitempty"`
+	Metadata json.RawMessage `json:"metadata"`
+	RemindAt *time.Time      `json:"remind_at"`
+	Items    []item          `json:"items"`
+	Counts   map[string]int  `json:"counts"`
+	Version  int64           `json:"version,string"`
+	Secret   string          `json:"-"`
+}
+
+func TestSchema(t *testing.T) {
+	b := New(Info{Title: "Test", Version: "1"}, "/v1")
+
+	tests := map[string]struct {
+		description string
+		value       any
+		expected    *Schema
+	}{
+		"scalar": {
+			description: "Scalars map to their JSON types",
+			value:       int32(0),
+			expected:    &Schema{Type: "integer", Format: "int32"},
+		},
+		"time": {
+			description: "Times are RFC 3339 strings",
+			value:       time.Time{},
+			expected:    &Schema{Type: "string", Format: "date-time"},
+		},
+		"named struct": {
+			description: "Named structs are referred to by their component",
+			value:       note{},
+			expected:    &Schema{Ref: "#/components/schemas/Note"},
+		},
+		"slice": {
+			description: "Slices are arrays of their element's schema",
+			value:       []item{},
+			expected:    &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Item"}},
+		},
+		"anonymous struct": {
+			description: "Anonymous structs are described inline",
+			value:       struct{ OK bool }{},
+			expected:    &Schema{Type: "object", Properties: map[string]*Schema{"OK": {Type: "boolean"}}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got := b.Schema(reflect.TypeOf(tc.value))
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("schema mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	expected := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":         {Type: "string"},
+			"created_at": {Type: "string", Format: "date-time"},
+			"title":      {Type: "string"},
+			"metadata":   {},
+			"remind_at":  {Type: "string", Format: "date-time", Nullable: true},
+			"items":      {Type: "array", Items: &Schema{Ref: "#/components/schemas/Item"}},
+			"counts":     {Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}},
+			"version":    {Type: "string"},
+		},
+	}
+	if diff := cmp.Diff(expected, b.Document().Components.Schemas["Note"]); diff != "" {
+		t.Errorf("component mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	type problem struct {
+		Title string `json:"title"`
+	}
+	b := New(Info{Title: "Test", Version: "1"}, "/v1")
+	b.ErrorBody(Body{ContentType: "application/problem+json", Value: problem{}})
+	b.Add(http.MethodPatch, "/notes/{noteID}/items/{itemID:[0-9]+}", Route{
+		Summary:   "Update an item",
+		Security:  []string{"apiKey"},
+		Request:   item{},
+		Responses: map[int]any{http.StatusOK: item{}, http.StatusNoContent: nil},
+	})
+
+	jsonContent := func(ref string) map[string]MediaType {
+		return map[string]MediaType{"application/json": {Schema: &Schema{Ref: ref}}}
+	}
+	expected := PathItem{
+		"patch": {
+			Summary: "Update an item",
+			Parameters: []Parameter{
+				{Name: "noteID", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+				{Name: "itemID", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+			},
+			RequestBody: &RequestBody{Required: true, Content: jsonContent("#/components/schemas/Item")},
+			Responses: map[string]*Response{
+				"200": {Description: "OK", Content: jsonContent("#/components/schemas/Item")},
+				"204": {Description: "No Content"},
+				"default": {
+					Description: "Error",
+					Content:     map[string]MediaType{"application/problem+json": {Schema: &Schema{Ref: "#/components/schemas/Problem"}}},
+				},
+			},
+			Security: []map[string][]string{{"apiKey": {}}},
+		},
+	}
+	if diff := cmp.Diff(expected, b.Document().Paths["/notes/{noteID}/items/{itemID}"]); diff != "" {
+		t.Errorf("path mismatch (-want +got):\n%s", diff)
+	}
+}
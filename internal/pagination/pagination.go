@@ -0,0 +1,43 @@
+// Package pagination holds the opaque cursors used for keyset pagination of
+// list endpoints.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last row of a page by its sort value, with the row ID
+// breaking ties between rows that share a value. Pinned rows sort ahead of
+// all others, so the cursor also records whether the row was pinned. Sort
+// records the ordering the cursor was issued for, so it can't be replayed
+// against another one.
+type Cursor struct {
+	Sort   string `json:"s,omitempty"`
+	Pinned bool   `json:"p,omitempty"`
+	Value  string `json:"v"`
+	ID     string `json:"id"`
+}
+
+// Encode renders the cursor as a URL-safe token. Clients should treat it as
+// opaque.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a token produced by Encode.
+func Decode(token string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
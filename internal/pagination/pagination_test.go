@@ -0,0 +1,54 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{Sort: "updated_at desc", Pinned: true, Value: "2024-03-01T12:00:00Z", ID: "8c1d6b0e-1f7a-4f43-9d7b-3f1f6c1e2a10"}
+
+	got, err := Decode(want.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("cursor mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		token       string
+	}{
+		"not base64": {
+			description: "Tokens that aren't base64 are rejected",
+			token:       "not a cursor!",
+		},
+		"not json": {
+			description: "Tokens that don't hold JSON are rejected",
+			token:       "bm90IGpzb24",
+		},
+		"missing id": {
+			description: "Cursors without a row ID are rejected",
+			token:       Cursor{Value: "2024-03-01T12:00:00Z"}.Encode(),
+		},
+		"empty": {
+			description: "An empty token is rejected",
+			token:       "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			_, err := Decode(tc.token)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package postgres runs the sqlc queries in internal/database against
+// PostgreSQL. The queries are written for SQLite, so the connections it opens
+// rewrite each statement on its way to the server: placeholders become $N and
+// the few queries that rely on SQLite-only features are swapped for
+// PostgreSQL versions. Everything else, including transactions started with
+// Queries.WithTx, goes through unchanged.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// IsURL reports whether a DATABASE_URL points at PostgreSQL rather than
+// SQLite or Turso.
+func IsURL(dbURL string) bool {
+	return strings.HasPrefix(dbURL, "postgres://") || strings.HasPrefix(dbURL, "postgresql://")
+}
+
+// Open returns a pool of PostgreSQL connections that accept the SQLite
+// queries generated into internal/database.
+func Open(dbURL string) (*sql.DB, error) {
+	base, err := pq.NewConnector(dbURL)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector{base: base}), nil
+}
+
+type connector struct {
+	base *pq.Connector
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc, ok := cn.(pqConn)
+	if !ok {
+		cn.Close()
+		return nil, fmt.Errorf("postgres: unexpected connection type %T", cn)
+	}
+	return conn{pc}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// pqConn is everything database/sql uses on a lib/pq connection. conn has to
+// expose all of it, or database/sql falls back to slower paths.
+type pqConn interface {
+	driver.Conn
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+	driver.NamedValueChecker
+}
+
+// conn translates every statement before handing it to lib/pq.
+type conn struct {
+	pqConn
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	return c.pqConn.Prepare(Translate(query))
+}
+
+func (c conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.pqConn.PrepareContext(ctx, Translate(query))
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.pqConn.ExecContext(ctx, Translate(query), args)
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.pqConn.QueryContext(ctx, Translate(query), args)
+}
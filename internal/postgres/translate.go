@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// overrides replaces sqlc queries, by name, that use SQLite-only features:
+// FTS5, the multi-argument MAX and BLOB casts. They must return the same
+// columns in the same order as the generated query and take the same
+// parameters.
+var overrides = map[string]string{
+	"SearchNotesForUser": `
+SELECT notes.id, notes.created_at, notes.updated_at, notes.note, notes.user_id, notes.public, notes.notebook_id, notes.archived, notes.pinned, notes.version, notes.remind_at, notes.reminded_at, notes.encrypted, notes.nonce, notes.ciphertext, notes.content_hash, notes.word_count, notes.title, notes.metadata FROM notes
+WHERE notes.user_id = $1
+  AND to_tsvector('simple', notes.note) @@ plainto_tsquery('simple', $2)
+  AND NOT notes.encrypted
+ORDER BY ts_rank(to_tsvector('simple', notes.note), plainto_tsquery('simple', $2)) DESC, notes.id
+LIMIT $3
+`,
+	"ListUsersForAdmin": `
+SELECT
+    users.id,
+    users.created_at,
+    users.name,
+    users.email,
+    users.role,
+    users.suspended_at,
+    (SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id) AS note_count,
+    GREATEST(
+        users.updated_at,
+        COALESCE((SELECT MAX(notes.updated_at) FROM notes WHERE notes.user_id = users.id), ''),
+        COALESCE((SELECT MAX(api_keys.last_used_at) FROM api_keys WHERE api_keys.user_id = users.id), '')
+    ) AS last_active_at
+FROM users
+WHERE (CAST($1 AS TEXT) IS NULL
+       OR users.name ILIKE $1 ESCAPE '\'
+       OR users.email ILIKE $1 ESCAPE '\')
+  AND (CAST($2 AS TEXT) IS NULL
+       OR users.created_at < $2
+       OR (users.created_at = $2 AND users.id < $3))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT $4
+`,
+	"GetUsageForUser": `
+SELECT
+    COUNT(*) AS note_count,
+    CAST(COALESCE(SUM(octet_length(note) + COALESCE(octet_length(ciphertext), 0)), 0) AS BIGINT) AS note_bytes
+FROM notes
+WHERE user_id = $1
+`,
+}
+
+// translated caches Translate, since sqlc sends the same few hundred query
+// strings over and over.
+var translated sync.Map
+
+// Translate turns a query written for SQLite into one PostgreSQL accepts.
+// Queries named in overrides are replaced outright; the rest only have their
+// placeholders rewritten.
+func Translate(query string) string {
+	if q, ok := translated.Load(query); ok {
+		return q.(string)
+	}
+	q, ok := overrides[queryName(query)]
+	if !ok {
+		q = Rebind(query)
+	}
+	translated.Store(query, q)
+	return q
+}
+
+// queryName returns the name sqlc puts in the first line of every generated
+// query, as in "-- name: GetNote :one".
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// Rebind rewrites SQLite placeholders as PostgreSQL ones. "?N" becomes "$N",
+// and a bare "?" takes the number after the largest one used so far, as
+// SQLite numbers it. Placeholders inside string literals, quoted identifiers
+// and comments are left alone.
+func Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	last := 0
+	for i := 0; i < len(query); {
+		end := i + 1
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			end = closingQuote(query, i)
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				end = i + n + 1
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end = len(query)
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			}
+		case c == '?':
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n := last + 1
+			if end > i+1 {
+				n, _ = strconv.Atoi(query[i+1 : end])
+			}
+			last = max(last, n)
+			b.WriteString("$" + strconv.Itoa(n))
+			i = end
+			continue
+		}
+		b.WriteString(query[i:end])
+		i = end
+	}
+	return b.String()
+}
+
+// closingQuote returns the index just past the quoted string or identifier
+// starting at start. A doubled quote is an escaped quote, not the end.
+func closingQuote(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
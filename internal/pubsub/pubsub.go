@@ -0,0 +1,196 @@
+// Package pubsub fans events out to subscribers within one process.
+package pubsub
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrSlow ends a subscription whose buffer filled up. Events published
+	// since then were missed, so the subscriber should catch up some other
+	// way before subscribing again.
+	ErrSlow = errors.New("subscriber fell behind")
+	// ErrClosed ends every subscription when the hub is closed.
+	ErrClosed = errors.New("hub closed")
+)
+
+// Hub delivers events published to a topic, such as a user ID, to every
+// current subscriber of that topic. Publishing never blocks: a subscriber
+// that doesn't keep up is dropped rather than holding up the publisher.
+// The hub also keeps each topic's latest events, so a subscriber that
+// was dropped or disconnected can resume where it left off.
+type Hub[T any] struct {
+	buffer  int
+	history int
+
+	mu     sync.Mutex
+	topics map[string]map[*Subscription[T]]struct{}
+	logs   map[string]*topicLog[T]
+	lastID uint64
+	closed bool
+}
+
+// Message is an event as published, numbered in publishing order. IDs are
+// shared by all of a hub's topics and start at 1.
+type Message[T any] struct {
+	ID    uint64
+	Event T
+}
+
+// topicLog is the latest messages of a topic, oldest first, and the ID of
+// the last one that no longer fits.
+type topicLog[T any] struct {
+	messages []Message[T]
+	evicted  uint64
+}
+
+// NewHub returns a hub buffering up to buffer events per subscriber and
+// keeping the last history events of each topic.
+func NewHub[T any](buffer, history int) *Hub[T] {
+	return &Hub[T]{
+		buffer:  buffer,
+		history: history,
+		topics:  map[string]map[*Subscription[T]]struct{}{},
+		logs:    map[string]*topicLog[T]{},
+	}
+}
+
+// Subscription receives a topic's messages on C until it is closed, by
+// Close, by falling behind or by the hub closing; Err then says why.
+type Subscription[T any] struct {
+	C <-chan Message[T]
+	// After is the ID of the last message published before the
+	// subscription started, which it doesn't receive.
+	After uint64
+
+	hub   *Hub[T]
+	topic string
+	ch    chan Message[T]
+	err   error
+}
+
+// Subscribe starts a subscription to topic. A subscription to a closed
+// hub is already closed.
+func (h *Hub[T]) Subscribe(topic string) *Subscription[T] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.subscribe(topic)
+}
+
+// Resume starts a subscription to topic and returns the messages
+// published to it after the one with ID after, which the subscription
+// doesn't repeat. It returns false, and no messages, if some of those are
+// no longer kept or after is an ID the hub hasn't given out.
+func (h *Hub[T]) Resume(topic string, after uint64) (*Subscription[T], []Message[T], bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.subscribe(topic)
+	log := h.logs[topic]
+	switch {
+	case after > h.lastID:
+		return s, nil, false
+	case log == nil:
+		// The topic has had no messages, unless the hub keeps no history,
+		// when only a subscriber that has seen every message missed none.
+		return s, nil, h.history > 0 || after == h.lastID
+	case after < log.evicted:
+		return s, nil, false
+	}
+	var missed []Message[T]
+	for _, m := range log.messages {
+		if m.ID > after {
+			missed = append(missed, m)
+		}
+	}
+	return s, missed, true
+}
+
+// subscribe starts a subscription to topic. h.mu must be held.
+func (h *Hub[T]) subscribe(topic string) *Subscription[T] {
+	ch := make(chan Message[T], h.buffer)
+	s := &Subscription[T]{C: ch, After: h.lastID, hub: h, topic: topic, ch: ch}
+	if h.closed {
+		s.err = ErrClosed
+		close(ch)
+		return s
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*Subscription[T]]struct{}{}
+	}
+	h.topics[topic][s] = struct{}{}
+	return s
+}
+
+// Publish sends event to every subscriber of topic.
+func (h *Hub[T]) Publish(topic string, event T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.lastID++
+	m := Message[T]{ID: h.lastID, Event: event}
+	if h.history > 0 {
+		log := h.logs[topic]
+		if log == nil {
+			log = &topicLog[T]{}
+			h.logs[topic] = log
+		}
+		if len(log.messages) == h.history {
+			log.evicted = log.messages[0].ID
+			log.messages = append(log.messages[:0], log.messages[1:]...)
+		}
+		log.messages = append(log.messages, m)
+	}
+	for s := range h.topics[topic] {
+		select {
+		case s.ch <- m:
+		default:
+			h.remove(s, ErrSlow)
+		}
+	}
+}
+
+// Close ends every subscription. Later subscriptions end at once and
+// events published are dropped.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.topics {
+		for s := range subs {
+			h.remove(s, ErrClosed)
+		}
+	}
+}
+
+// remove ends s with err. h.mu must be held.
+func (h *Hub[T]) remove(s *Subscription[T], err error) {
+	subs := h.topics[s.topic]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.topics, s.topic)
+	}
+	s.err = err
+	close(s.ch)
+}
+
+// Close ends the subscription. Events already buffered can still be
+// received from C.
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s, nil)
+}
+
+// Err returns why the subscription ended: ErrSlow, ErrClosed, or nil if
+// it is still open or was ended by Close.
+func (s *Subscription[T]) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
@@ -0,0 +1,149 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// drain receives what is left on s.C, which must be closed.
+func drain(s *Subscription[string]) []string {
+	var got []string
+	for m := range s.C {
+		got = append(got, m.Event)
+	}
+	return got
+}
+
+func TestHub(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		run         func(h *Hub[string]) *Subscription[string]
+		expected    []string
+		expectedErr error
+	}{
+		"topic": {
+			description: "Subscribers get their topic's events and no others",
+			run: func(h *Hub[string]) *Subscription[string] {
+				s := h.Subscribe("alice")
+				h.Publish("alice", "a1")
+				h.Publish("bob", "b1")
+				h.Publish("alice", "a2")
+				s.Close()
+				return s
+			},
+			expected: []string{"a1", "a2"},
+		},
+		"slow": {
+			description: "A subscriber whose buffer is full is dropped instead of blocking",
+			run: func(h *Hub[string]) *Subscription[string] {
+				s := h.Subscribe("alice")
+				h.Publish("alice", "a1")
+				h.Publish("alice", "a2")
+				h.Publish("alice", "a3")
+				h.Publish("alice", "a4")
+				return s
+			},
+			expected:    []string{"a1", "a2"},
+			expectedErr: ErrSlow,
+		},
+		"closed hub": {
+			description: "Closing the hub ends its subscriptions",
+			run: func(h *Hub[string]) *Subscription[string] {
+				s := h.Subscribe("alice")
+				h.Publish("alice", "a1")
+				h.Close()
+				h.Publish("alice", "a2")
+				return s
+			},
+			expected:    []string{"a1"},
+			expectedErr: ErrClosed,
+		},
+		"subscribe after close": {
+			description: "Subscriptions to a closed hub end at once",
+			run: func(h *Hub[string]) *Subscription[string] {
+				h.Close()
+				return h.Subscribe("alice")
+			},
+			expectedErr: ErrClosed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			s := tc.run(NewHub[string](2, 2))
+			if diff := cmp.Diff(tc.expected, drain(s)); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+			if err := s.Err(); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		history     int
+		after       uint64
+		expected    []Message[string]
+		expectedOK  bool
+	}{
+		"kept": {
+			description: "Messages after the given ID are returned while they're kept",
+			history:     2,
+			after:       3,
+			expected:    []Message[string]{{ID: 4, Event: "a3"}},
+			expectedOK:  true,
+		},
+		"up to date": {
+			description: "A subscriber that saw the latest message missed nothing",
+			history:     2,
+			after:       4,
+			expectedOK:  true,
+		},
+		"evicted": {
+			description: "Resuming fails once missed messages have been dropped",
+			history:     2,
+		},
+		"unknown ID": {
+			description: "IDs the hub hasn't given out can't be resumed from",
+			history:     2,
+			after:       5,
+		},
+		"no history": {
+			description: "Without history, only an up-to-date subscriber can resume",
+			after:       3,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			h := NewHub[string](2, tc.history)
+			h.Publish("alice", "a1")
+			h.Publish("bob", "b1")
+			h.Publish("alice", "a2")
+			h.Publish("alice", "a3")
+
+			s, missed, ok := h.Resume("alice", tc.after)
+			if ok != tc.expectedOK {
+				t.Errorf("expected ok %v, got %v", tc.expectedOK, ok)
+			}
+			if diff := cmp.Diff(tc.expected, missed); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+			if s.After != 4 {
+				t.Errorf("expected subscription after 4, got %d", s.After)
+			}
+			h.Publish("alice", "a4")
+			s.Close()
+			if diff := cmp.Diff([]string{"a4"}, drain(s)); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FailureTracker counts failures per key, such as failed logins per IP, and
+// blocks a key for BlockFor once it reaches Threshold failures within Window.
+type FailureTracker struct {
+	threshold int
+	window    time.Duration
+	blockFor  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*failureEntry
+	lastSweep time.Time
+}
+
+type failureEntry struct {
+	count        int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+// Block is a currently blocked key.
+type Block struct {
+	Key          string
+	BlockedUntil time.Time
+}
+
+func NewFailureTracker(threshold int, window, blockFor time.Duration) *FailureTracker {
+	return &FailureTracker{
+		threshold: threshold,
+		window:    window,
+		blockFor:  blockFor,
+		now:       time.Now,
+		entries:   map[string]*failureEntry{},
+	}
+}
+
+// Blocked reports whether key is blocked and for how much longer.
+func (t *FailureTracker) Blocked(key string) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return false, 0
+	}
+	remaining := e.blockedUntil.Sub(t.now())
+	if remaining <= 0 {
+		return false, 0
+	}
+	return true, remaining
+}
+
+// RecordFailure counts a failure for key, blocking it when the threshold is
+// reached.
+func (t *FailureTracker) RecordFailure(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	e, ok := t.entries[key]
+	if !ok {
+		e = &failureEntry{windowStart: now}
+		t.entries[key] = e
+	} else if now.Sub(e.windowStart) >= t.window {
+		e.count = 0
+		e.windowStart = now
+	}
+	e.count++
+	if e.count >= t.threshold {
+		e.blockedUntil = now.Add(t.blockFor)
+		e.count = 0
+		e.windowStart = now
+	}
+}
+
+// Blocks lists the currently blocked keys, soonest to expire first.
+func (t *FailureTracker) Blocks() []Block {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	blocks := []Block{}
+	for key, e := range t.entries {
+		if e.blockedUntil.After(now) {
+			blocks = append(blocks, Block{Key: key, BlockedUntil: e.blockedUntil})
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockedUntil.Before(blocks[j].BlockedUntil)
+	})
+	return blocks
+}
+
+// Unblock clears key's block and failure count. It reports whether key was
+// blocked.
+func (t *FailureTracker) Unblock(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return false
+	}
+	delete(t.entries, key)
+	return e.blockedUntil.After(t.now())
+}
+
+// sweep drops entries whose window and block have both lapsed.
+func (t *FailureTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+	for key, e := range t.entries {
+		if now.Sub(e.windowStart) >= t.window && !e.blockedUntil.After(now) {
+			delete(t.entries, key)
+		}
+	}
+}
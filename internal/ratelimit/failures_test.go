@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFailureTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewFailureTracker(3, time.Minute, 10*time.Minute)
+	tracker.now = clock.now
+
+	tracker.RecordFailure("1.2.3.4")
+	tracker.RecordFailure("1.2.3.4")
+	if blocked, _ := tracker.Blocked("1.2.3.4"); blocked {
+		t.Fatalf("Blocked() = true below threshold")
+	}
+
+	// Failures outside the window start a fresh count.
+	clock.advance(time.Minute)
+	tracker.RecordFailure("1.2.3.4")
+	tracker.RecordFailure("1.2.3.4")
+	if blocked, _ := tracker.Blocked("1.2.3.4"); blocked {
+		t.Fatalf("Blocked() = true after window reset")
+	}
+
+	tracker.RecordFailure("1.2.3.4")
+	blocked, remaining := tracker.Blocked("1.2.3.4")
+	if !blocked {
+		t.Fatalf("Blocked() = false at threshold")
+	}
+	if diff := cmp.Diff(10*time.Minute, remaining); diff != "" {
+		t.Errorf("Blocked() remaining mismatch (-want +got):\n%s", diff)
+	}
+	if blocked, _ := tracker.Blocked("5.6.7.8"); blocked {
+		t.Errorf("Blocked() = true for an unrelated key")
+	}
+
+	wantBlocks := []Block{{Key: "1.2.3.4", BlockedUntil: clock.t.Add(10 * time.Minute)}}
+	if diff := cmp.Diff(wantBlocks, tracker.Blocks()); diff != "" {
+		t.Errorf("Blocks() mismatch (-want +got):\n%s", diff)
+	}
+
+	clock.advance(10 * time.Minute)
+	if blocked, _ := tracker.Blocked("1.2.3.4"); blocked {
+		t.Errorf("Blocked() = true after block expired")
+	}
+}
+
+func TestFailureTrackerUnblock(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewFailureTracker(1, time.Minute, time.Hour)
+	tracker.now = clock.now
+
+	tracker.RecordFailure("1.2.3.4")
+	if !tracker.Unblock("1.2.3.4") {
+		t.Fatalf("Unblock() = false for a blocked key")
+	}
+	if blocked, _ := tracker.Blocked("1.2.3.4"); blocked {
+		t.Errorf("Blocked() = true after Unblock()")
+	}
+	if tracker.Unblock("1.2.3.4") {
+		t.Errorf("Unblock() = true for a key that is no longer blocked")
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLimiterAllow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := New(2, 3)
+	limiter.now = clock.now
+
+	type step struct {
+		advance   time.Duration
+		key       string
+		wantOK    bool
+		wantRetry time.Duration
+	}
+
+	steps := []step{
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: false, wantRetry: 500 * time.Millisecond},
+		{key: "b", wantOK: true},
+		{advance: 250 * time.Millisecond, key: "a", wantOK: false, wantRetry: 250 * time.Millisecond},
+		{advance: 250 * time.Millisecond, key: "a", wantOK: true},
+		{key: "a", wantOK: false, wantRetry: 500 * time.Millisecond},
+		{advance: 10 * time.Second, key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: false, wantRetry: 500 * time.Millisecond},
+	}
+
+	for i, s := range steps {
+		clock.advance(s.advance)
+		gotOK, gotRetry := limiter.Allow(s.key)
+		if diff := cmp.Diff(s.wantOK, gotOK); diff != "" {
+			t.Fatalf("step %d: Allow(%q) ok mismatch (-want +got):\n%s", i, s.key, diff)
+		}
+		if diff := cmp.Diff(s.wantRetry, gotRetry); diff != "" {
+			t.Fatalf("step %d: Allow(%q) retry mismatch (-want +got):\n%s", i, s.key, diff)
+		}
+	}
+}
+
+func TestLimiterSetRate(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := New(1, 5)
+	limiter.now = clock.now
+
+	limiter.Allow("a")
+	limiter.SetRate(2, 2)
+
+	type step struct {
+		advance   time.Duration
+		key       string
+		wantOK    bool
+		wantRetry time.Duration
+	}
+
+	steps := []step{
+		// a had 4 tokens left, cut down to the new burst of 2.
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: false, wantRetry: 500 * time.Millisecond},
+		{key: "b", wantOK: true},
+		{key: "b", wantOK: true},
+		{key: "b", wantOK: false, wantRetry: 500 * time.Millisecond},
+		{advance: 500 * time.Millisecond, key: "a", wantOK: true},
+	}
+
+	for i, s := range steps {
+		clock.advance(s.advance)
+		gotOK, gotRetry := limiter.Allow(s.key)
+		if diff := cmp.Diff(s.wantOK, gotOK); diff != "" {
+			t.Fatalf("step %d: Allow(%q) ok mismatch (-want +got):\n%s", i, s.key, diff)
+		}
+		if diff := cmp.Diff(s.wantRetry, gotRetry); diff != "" {
+			t.Fatalf("step %d: Allow(%q) retry mismatch (-want +got):\n%s", i, s.key, diff)
+		}
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := New(1, 1)
+	limiter.now = clock.now
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	clock.advance(2 * sweepInterval)
+	limiter.Allow("c")
+
+	if got := len(limiter.buckets); got != 1 {
+		t.Errorf("len(buckets) after sweep = %d, want 1", got)
+	}
+}
@@ -0,0 +1,95 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// batchSize is how many due reminders are fetched at a time.
+const batchSize = 100
+
+type Store interface {
+	ListDueReminders(ctx context.Context, arg database.ListDueRemindersParams) ([]database.Note, error)
+	MarkNoteReminded(ctx context.Context, arg database.MarkNoteRemindedParams) (int64, error)
+}
+
+// Notifier delivers a due reminder to the note's owner. The same reminder
+// can be handed over more than once, so a Notifier that must not send it
+// twice has to ignore repeats.
+type Notifier interface {
+	NotifyReminder(ctx context.Context, note database.Note) error
+}
+
+// LogNotifier writes reminders to the server log. It's the fallback when no
+// other delivery channel is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyReminder(ctx context.Context, note database.Note) error {
+	log.Printf("Reminder due for note %s (user %s) at %s", note.ID, note.UserID, note.RemindAt.String)
+	return nil
+}
+
+// Scheduler hands due reminders to a Notifier. It's run periodically, as
+// a job.
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	now      func() time.Time
+}
+
+func NewScheduler(store Store, notifier Notifier) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		notifier: notifier,
+		now:      time.Now,
+	}
+}
+
+// RunOnce hands every due reminder to the Notifier and then marks it sent.
+// A reminder the Notifier fails to take is logged and left unmarked, to be
+// tried again on the next run. Servers sharing a database may both hand
+// over a reminder that comes due while they're scanning, as may a run
+// that can't mark one sent, which is why Notifiers see repeats.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	now := s.now().UTC().Format(time.RFC3339)
+	for {
+		due, err := s.store.ListDueReminders(ctx, database.ListDueRemindersParams{
+			RemindAt: sql.NullString{String: now, Valid: true},
+			Limit:    batchSize,
+		})
+		if err != nil {
+			log.Printf("Couldn't list due reminders: %v", err)
+			return
+		}
+
+		failed := 0
+		for _, note := range due {
+			if err := s.notifier.NotifyReminder(ctx, note); err != nil {
+				log.Printf("Couldn't deliver reminder for note %s: %v", note.ID, err)
+				failed++
+				continue
+			}
+			// Marking does nothing if the note was rescheduled meanwhile,
+			// so its new reminder still fires.
+			_, err := s.store.MarkNoteReminded(ctx, database.MarkNoteRemindedParams{
+				RemindedAt: sql.NullString{String: now, Valid: true},
+				ID:         note.ID,
+				RemindAt:   note.RemindAt,
+			})
+			if err != nil {
+				log.Printf("Couldn't mark note %s reminded: %v", note.ID, err)
+				return
+			}
+		}
+
+		// Unmarked reminders would be listed again, so a full batch of
+		// them waits for the next run.
+		if len(due) < batchSize || failed == len(due) {
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package reminders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeStore holds notes in memory and applies the same due and claim rules
+// as the SQL queries.
+type fakeStore struct {
+	notes []database.Note
+	// stolen notes are marked by someone else between list and mark.
+	stolen map[string]bool
+}
+
+func (s *fakeStore) ListDueReminders(ctx context.Context, arg database.ListDueRemindersParams) ([]database.Note, error) {
+	var due []database.Note
+	for _, note := range s.notes {
+		if note.RemindAt.Valid && note.RemindAt.String <= arg.RemindAt.String && !note.RemindedAt.Valid && !note.Archived {
+			due = append(due, note)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) MarkNoteReminded(ctx context.Context, arg database.MarkNoteRemindedParams) (int64, error) {
+	for i, note := range s.notes {
+		if note.ID != arg.ID || note.RemindAt != arg.RemindAt || note.RemindedAt.Valid {
+			continue
+		}
+		s.notes[i].RemindedAt = arg.RemindedAt
+		if s.stolen[note.ID] {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	return 0, nil
+}
+
+type fakeNotifier struct {
+	sent []string
+	err  error
+}
+
+func (n *fakeNotifier) NotifyReminder(ctx context.Context, note database.Note) error {
+	n.sent = append(n.sent, note.ID)
+	return n.err
+}
+
+func reminderAt(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func TestSchedulerRunOnce(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		description    string
+		notes          []database.Note
+		stolen         map[string]bool
+		notifyErr      error
+		expectedSent   []string
+		expectedMarked []string
+	}{
+		"none": {
+			description: "Notes without reminders are left alone",
+			notes: []database.Note{
+				{ID: "a"},
+			},
+		},
+		"due": {
+			description: "Reminders at or before now fire; later ones wait",
+			notes: []database.Note{
+				{ID: "a", RemindAt: reminderAt("2024-03-01T11:00:00Z")},
+				{ID: "b", RemindAt: reminderAt("2024-03-01T12:00:00Z")},
+				{ID: "c", RemindAt: reminderAt("2024-03-01T12:00:01Z")},
+			},
+			expectedSent:   []string{"a", "b"},
+			expectedMarked: []string{"a", "b"},
+		},
+		"already sent": {
+			description: "Reminders that already fired don't fire again",
+			notes: []database.Note{
+				{ID: "a", RemindAt: reminderAt("2024-03-01T11:00:00Z"), RemindedAt: reminderAt("2024-03-01T11:00:00Z")},
+			},
+			expectedMarked: []string{"a"},
+		},
+		"archived": {
+			description: "Archived notes don't send reminders",
+			notes: []database.Note{
+				{ID: "a", RemindAt: reminderAt("2024-03-01T11:00:00Z"), Archived: true},
+			},
+		},
+		"marked elsewhere": {
+			description: "A reminder another server marks first is still handed over, for the notifier to drop",
+			notes: []database.Note{
+				{ID: "a", RemindAt: reminderAt("2024-03-01T11:00:00Z")},
+				{ID: "b", RemindAt: reminderAt("2024-03-01T11:00:00Z")},
+			},
+			stolen:         map[string]bool{"a": true},
+			expectedSent:   []string{"a", "b"},
+			expectedMarked: []string{"a", "b"},
+		},
+		"delivery fails": {
+			description: "A reminder the notifier fails to take is left unmarked for the next run",
+			notes: []database.Note{
+				{ID: "a", RemindAt: reminderAt("2024-03-01T11:00:00Z")},
+			},
+			notifyErr:    errors.New("queue down"),
+			expectedSent: []string{"a"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			store := &fakeStore{notes: tc.notes, stolen: tc.stolen}
+			notifier := &fakeNotifier{err: tc.notifyErr}
+			s := &Scheduler{store: store, notifier: notifier, now: func() time.Time { return now }}
+
+			s.RunOnce(context.Background())
+
+			if diff := cmp.Diff(tc.expectedSent, notifier.sent); diff != "" {
+				t.Errorf("sent mismatch (-want +got):\n%s", diff)
+			}
+			var marked []string
+			for _, note := range store.notes {
+				if note.RemindedAt.Valid {
+					marked = append(marked, note.ID)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedMarked, marked); diff != "" {
+				t.Errorf("marked mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Package settings holds the per-user preferences clients share, stored as
+// one JSON document per user.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	// Embedded so timezones validate the same on hosts without tzdata.
+	_ "time/tzdata"
+)
+
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// Settings are a user's preferences. Fields left out of a stored or
+// submitted document take their default values.
+type Settings struct {
+	// NoteSort and NoteOrder are the note list ordering used when a
+	// request doesn't ask for one.
+	NoteSort  string `json:"note_sort"`
+	NoteOrder string `json:"note_order"`
+	// PageSize is the number of notes per page when a request doesn't set
+	// a limit. Zero means the server default.
+	PageSize int `json:"page_size"`
+	// Timezone is an IANA name used for times shown to the user, such as
+	// in reminder emails.
+	Timezone      string        `json:"timezone"`
+	Notifications Notifications `json:"notifications"`
+}
+
+// Notifications are the messages a user has opted into.
+type Notifications struct {
+	ReminderEmails bool `json:"reminder_emails"`
+}
+
+// Default returns the settings of a user who hasn't saved any.
+func Default() Settings {
+	return Settings{
+		NoteSort:  "created_at",
+		NoteOrder: "asc",
+		Timezone:  "UTC",
+	}
+}
+
+// Parse reads a settings document over the defaults and validates it,
+// allowing page sizes up to maxPageSize. Unknown fields are rejected so
+// typos don't silently do nothing.
+func Parse(data []byte, maxPageSize int) (Settings, error) {
+	s := Default()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return Settings{}, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if err := s.Validate(maxPageSize); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+// Validate checks every field, allowing page sizes up to maxPageSize.
+func (s Settings) Validate(maxPageSize int) error {
+	switch s.NoteSort {
+	case "created_at", "updated_at":
+	default:
+		return fmt.Errorf("%w: note_sort must be created_at or updated_at", ErrInvalidSettings)
+	}
+	switch s.NoteOrder {
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("%w: note_order must be asc or desc", ErrInvalidSettings)
+	}
+	if s.PageSize < 0 || s.PageSize > maxPageSize {
+		return fmt.Errorf("%w: page_size must be between 0 and %d", ErrInvalidSettings, maxPageSize)
+	}
+	if _, err := s.Location(); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSettings, s.Timezone)
+	}
+	return nil
+}
+
+// Location loads the settings' timezone.
+func (s Settings) Location() (*time.Location, error) {
+	// LoadLocation treats "" as UTC and "Local" as the server's zone;
+	// neither is a name a client should store.
+	if s.Timezone == "" || s.Timezone == "Local" {
+		return nil, ErrInvalidSettings
+	}
+	return time.LoadLocation(s.Timezone)
+}
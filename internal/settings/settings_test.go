@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		expected    Settings
+		expectedErr error
+	}{
+		"empty": {
+			description: "An empty document gives the defaults",
+			input:       `{}`,
+			expected:    Default(),
+		},
+		"full": {
+			description: "Every field is read",
+			input:       `{"note_sort":"updated_at","note_order":"desc","page_size":25,"timezone":"Europe/Berlin","notifications":{"reminder_emails":true}}`,
+			expected: Settings{
+				NoteSort:      "updated_at",
+				NoteOrder:     "desc",
+				PageSize:      25,
+				Timezone:      "Europe/Berlin",
+				Notifications: Notifications{ReminderEmails: true},
+			},
+		},
+		"partial": {
+			description: "Fields left out keep their defaults",
+			input:       `{"timezone":"America/New_York"}`,
+			expected: Settings{
+				NoteSort:  "created_at",
+				NoteOrder: "asc",
+				Timezone:  "America/New_York",
+			},
+		},
+		"unknown field": {
+			description: "Unknown fields are rejected",
+			input:       `{"page_sise":25}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"bad sort": {
+			description: "Only known sort fields are allowed",
+			input:       `{"note_sort":"title"}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"bad order": {
+			description: "Only asc and desc are allowed",
+			input:       `{"note_order":"up"}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"page size too big": {
+			description: "Page sizes above the server maximum are rejected",
+			input:       `{"page_size":101}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"negative page size": {
+			description: "Page sizes can't be negative",
+			input:       `{"page_size":-1}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"unknown timezone": {
+			description: "Timezones must be IANA names",
+			input:       `{"timezone":"Mars/Olympus_Mons"}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"local timezone": {
+			description: "The server's local zone isn't a valid setting",
+			input:       `{"timezone":"Local"}`,
+			expectedErr: ErrInvalidSettings,
+		},
+		"wrong type": {
+			description: "Fields must have the right JSON type",
+			input:       `{"page_size":"25"}`,
+			expectedErr: ErrInvalidSettings,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Parse([]byte(tc.input), 100)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("settings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
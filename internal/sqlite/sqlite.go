@@ -0,0 +1,96 @@
+// Package sqlite opens local SQLite databases through libsql with the
+// pragmas the API needs under concurrent requests: WAL, so reads don't wait
+// for writes, and a busy timeout, so a write waits for the lock instead of
+// failing with "database is locked". Both are set on every new connection.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IsFileURL reports whether a DATABASE_URL points at a local SQLite file
+// rather than a Turso or sqld server.
+func IsFileURL(dbURL string) bool {
+	return strings.HasPrefix(dbURL, "file:")
+}
+
+// pragmas returns the statements run on each new connection.
+func pragmas(busyTimeout time.Duration) []string {
+	return []string{
+		"PRAGMA journal_mode = WAL",
+		fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout.Milliseconds()),
+	}
+}
+
+// Open returns a pool of connections to the SQLite file at dbURL, opened by
+// the driver registered as driverName.
+func Open(driverName, dbURL string, busyTimeout time.Duration) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dbURL)
+	if err != nil {
+		return nil, err
+	}
+	base := db.Driver()
+	db.Close()
+
+	var c driver.Connector = dsnConnector{dsn: dbURL, driver: base}
+	if dc, ok := base.(driver.DriverContext); ok {
+		c, err = dc.OpenConnector(dbURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(connector{base: c, pragmas: pragmas(busyTimeout)}), nil
+}
+
+type connector struct {
+	base    driver.Connector
+	pragmas []string
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pragma := range c.pragmas {
+		if err := exec(ctx, cn, pragma); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("sqlite: %s: %w", pragma, err)
+		}
+	}
+	return cn, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// dsnConnector is the driver.Connector for drivers that only implement
+// driver.Driver.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// exec runs a statement on a raw driver connection.
+func exec(ctx context.Context, cn driver.Conn, query string) error {
+	execer, ok := cn.(driver.ExecerContext)
+	if !ok {
+		return fmt.Errorf("connection type %T can't execute statements", cn)
+	}
+	_, err := execer.ExecContext(ctx, query, nil)
+	return err
+}
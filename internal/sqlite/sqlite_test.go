@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsFileURL(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		dbURL       string
+		expected    bool
+	}{
+		"file": {
+			description: "A file: URL is a local database",
+			dbURL:       "file:notely.db",
+			expected:    true,
+		},
+		"absolute file": {
+			description: "An absolute file: URL is a local database",
+			dbURL:       "file:///var/lib/notely.db",
+			expected:    true,
+		},
+		"turso": {
+			description: "A libsql:// URL is a server",
+			dbURL:       "libsql://notely.turso.io?authToken=x",
+		},
+		"http": {
+			description: "An http:// URL is a server",
+			dbURL:       "http://127.0.0.1:8080",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := IsFileURL(tc.dbURL); got != tc.expected {
+				t.Errorf("IsFileURL(%q) = %v, want %v", tc.dbURL, got, tc.expected)
+			}
+		})
+	}
+}
+
+// recordingDriver is a driver whose connections record the statements they
+// execute, failing any that match fail.
+type recordingDriver struct {
+	mu       sync.Mutex
+	executed []string
+	fail     string
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return recordingConn{d}, nil
+}
+
+type recordingConn struct {
+	d *recordingDriver
+}
+
+func (c recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if query == c.d.fail {
+		return nil, errors.New("failed")
+	}
+	c.d.executed = append(c.d.executed, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c recordingConn) Close() error {
+	return nil
+}
+
+func (c recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+var (
+	registerOnce sync.Once
+	testDriver   = &recordingDriver{}
+)
+
+func TestOpen(t *testing.T) {
+	registerOnce.Do(func() { sql.Register("sqlitetest", testDriver) })
+
+	tests := map[string]struct {
+		description string
+		fail        string
+		expected    []string
+		expectErr   bool
+	}{
+		"pragmas": {
+			description: "New connections get WAL and the busy timeout",
+			expected:    []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 2500"},
+		},
+		"failed pragma": {
+			description: "A pragma that fails fails the connection",
+			fail:        "PRAGMA journal_mode = WAL",
+			expectErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			testDriver.mu.Lock()
+			testDriver.executed, testDriver.fail = nil, tc.fail
+			testDriver.mu.Unlock()
+
+			db, err := Open("sqlitetest", "file:test.db", 2500*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			err = db.PingContext(context.Background())
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("Ping() error = %v, want error: %v", err, tc.expectErr)
+			}
+			testDriver.mu.Lock()
+			defer testDriver.mu.Unlock()
+			if diff := cmp.Diff(tc.expected, testDriver.executed); diff != "" {
+				t.Errorf("executed statements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+// Package storage holds blobs such as note attachments outside the
+// database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage is a flat key/value blob store.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns the object's contents; callers must close it. Missing
+	// objects return ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL that downloads the object directly from the
+	// backend, without credentials, until it expires.
+	PresignGet(key string, expires time.Duration) (string, error)
+}
@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// RFC 6238 appendix B test secret, "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		unix        int64
+		want        string
+	}{
+		"t59": {
+			description: "should match the RFC 6238 vector at T=59",
+			unix:        59,
+			want:        "287082",
+		},
+		"t1111111109": {
+			description: "should match the RFC 6238 vector at T=1111111109",
+			unix:        1111111109,
+			want:        "081804",
+		},
+		"t1234567890": {
+			description: "should match the RFC 6238 vector at T=1234567890",
+			unix:        1234567890,
+			want:        "005924",
+		},
+		"t2000000000": {
+			description: "should match the RFC 6238 vector at T=2000000000",
+			unix:        2000000000,
+			want:        "279037",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Code(rfcSecret, time.Unix(tc.unix, 0))
+			if err != nil {
+				t.Fatalf("Code() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Code() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	tests := map[string]struct {
+		description string
+		code        string
+		at          time.Time
+		wantOK      bool
+		wantStep    int64
+	}{
+		"current": {
+			description: "should accept the current code",
+			code:        "005924",
+			at:          now,
+			wantOK:      true,
+			wantStep:    1234567890 / 30,
+		},
+		"previous_step": {
+			description: "should accept a code from the previous step",
+			code:        "005924",
+			at:          now.Add(30 * time.Second),
+			wantOK:      true,
+			wantStep:    1234567890 / 30,
+		},
+		"too_old": {
+			description: "should reject a code from two steps ago",
+			code:        "005924",
+			at:          now.Add(60 * time.Second),
+		},
+		"wrong": {
+			description: "should reject a wrong code",
+			code:        "123456",
+			at:          now,
+		},
+		"wrong_length": {
+			description: "should reject codes of the wrong length",
+			code:        "5924",
+			at:          now,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			step, ok := Validate(rfcSecret, tc.code, tc.at)
+			if ok != tc.wantOK {
+				t.Fatalf("Validate() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.wantStep, step); diff != "" {
+				t.Errorf("Validate() step mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() unexpected error: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("expected a 32 character secret, got %q", secret)
+	}
+	if _, err := Code(secret, time.Now()); err != nil {
+		t.Errorf("generated secret doesn't decode: %v", err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	got := URL("Notely", "alice", "ABC")
+	want := "otpauth://totp/Notely:alice?issuer=Notely&secret=ABC"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("URL() mismatch (-want +got):\n%s", diff)
+	}
+}
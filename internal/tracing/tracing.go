@@ -0,0 +1,493 @@
+// Package tracing records request spans and exports them to an
+// OpenTelemetry collector with OTLP over HTTP, in its JSON encoding. It
+// covers what the API traces and nothing more: spans with attributes and
+// an error status, W3C traceparent propagation and trace ID ratio sampling.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// batchSize is the most spans sent in one export.
+	batchSize = 512
+
+	exportTimeout = 10 * time.Second
+)
+
+// Kind says what a span stands for, as OTLP numbers it.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// Config says where spans go. Endpoint is the full URL of the collector's
+// traces endpoint, usually ending in /v1/traces. Headers are sent with
+// every export, for collectors that need an API key. SampleRatio is the
+// share of new traces recorded, from 0 to 1; traces started upstream follow
+// the caller's sampling decision instead.
+type Config struct {
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	SampleRatio float64
+}
+
+// Tracer starts root spans and exports finished spans from a background
+// goroutine, so requests never wait on the collector. Spans are dropped,
+// with a log line, when the buffer is full.
+type Tracer struct {
+	cfg    Config
+	client *http.Client
+	spans  chan *Span
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewTracer(cfg Config, buffer int, interval time.Duration) (*Tracer, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("trace endpoint must be an http or https URL, got %q", cfg.Endpoint)
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+	t := &Tracer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: exportTimeout},
+		spans:  make(chan *Span, buffer),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go t.run(interval)
+	return t, nil
+}
+
+// ParseHeaders parses headers in the OTEL_EXPORTER_OTLP_HEADERS format,
+// key=value pairs separated by commas, with values URL encoded.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("header %q isn't key=value", pair)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", key, err)
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers, nil
+}
+
+// Span is one timed operation in a trace. A nil *Span is valid and records
+// nothing, which is what Start returns when the trace isn't sampled, so
+// callers never need to check.
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+
+	mu     sync.Mutex
+	name   string
+	kind   Kind
+	end    time.Time
+	attrs  []attribute
+	errMsg string
+	ended  bool
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span ctx is in, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// StartRequest starts the root span for a request. traceparent is the
+// request's W3C traceparent header; if it is valid the span joins that
+// trace, otherwise a new trace starts.
+func (t *Tracer) StartRequest(ctx context.Context, name, traceparent string) (context.Context, *Span) {
+	span := &Span{tracer: t, kind: KindServer, name: name, start: time.Now()}
+	if traceID, parentID, sampled, ok := ParseTraceparent(traceparent); ok {
+		if !sampled {
+			return ctx, nil
+		}
+		span.traceID, span.parentID = traceID, parentID
+	} else {
+		span.traceID = newTraceID()
+		if !t.sample(span.traceID) {
+			return ctx, nil
+		}
+	}
+	span.spanID = newSpanID()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a span as a child of the one ctx is in. If ctx isn't in a
+// span, because tracing is off or the trace isn't sampled, it returns ctx
+// and nil.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:   parent.tracer,
+		traceID:  parent.traceID,
+		spanID:   newSpanID(),
+		parentID: parent.spanID,
+		kind:     KindInternal,
+		name:     name,
+		start:    time.Now(),
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// sample keeps traces whose ID falls in the lowest SampleRatio of the ID
+// space, so every service sampling by ratio keeps the same traces.
+func (t *Tracer) sample(traceID [16]byte) bool {
+	if t.cfg.SampleRatio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])) < t.cfg.SampleRatio*math.MaxUint64
+}
+
+// ParseTraceparent reads a W3C traceparent header: version 00, a trace ID,
+// the parent span ID and flags, all in lowercase hex. Other versions are
+// treated as invalid, which starts a new trace.
+func ParseTraceparent(header string) (traceID [16]byte, parentID [8]byte, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceID, parentID, false, false
+	}
+	for _, part := range parts[1:] {
+		if strings.ToLower(part) != part {
+			return traceID, parentID, false, false
+		}
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil {
+		return traceID, parentID, false, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil {
+		return traceID, parentID, false, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return traceID, parentID, false, false
+	}
+	if traceID == [16]byte{} || parentID == [8]byte{} {
+		return traceID, parentID, false, false
+	}
+	return traceID, parentID, flags[0]&1 == 1, true
+}
+
+// TraceID returns the span's trace ID in hex, or "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetName renames the span, for names only known once it has run, such as
+// a request's route.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetKind sets what the span stands for. Spans from Start are internal.
+func (s *Span) SetKind(kind Kind) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kind = kind
+}
+
+// SetAttribute records a string, bool, int, int64 or float64 value on the
+// span. Values of other types are recorded as strings.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// SetError marks the span as failed with err. A nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export without blocking. Only
+// the first call counts, so End can be deferred as well as called early.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+
+	select {
+	case s.tracer.spans <- s:
+	default:
+		log.Printf("trace buffer full, dropping span %s", s.name)
+	}
+}
+
+// Close stops the exporter after sending the spans already queued.
+func (t *Tracer) Close() {
+	close(t.stop)
+	<-t.done
+}
+
+func (t *Tracer) run(interval time.Duration) {
+	defer close(t.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil {
+			log.Printf("Couldn't export %d spans: %v", len(batch), err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.spans:
+					batch = append(batch, span)
+					if len(batch) >= batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) export(spans []*Span) error {
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector responded %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// The types below are the parts of the OTLP JSON encoding the tracer
+// sends. IDs are hex and 64-bit integers are decimal strings.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanJSON `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanJSON struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              Kind       `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// statusError is OTLP's STATUS_CODE_ERROR.
+const statusError = 2
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (t *Tracer) request(spans []*Span) exportRequest {
+	out := make([]spanJSON, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		sj := spanJSON{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != [8]byte{} {
+			sj.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, attr := range s.attrs {
+			sj.Attributes = append(sj.Attributes, keyValue{Key: attr.key, Value: valueOf(attr.value)})
+		}
+		if s.errMsg != "" {
+			sj.Status = &status{Code: statusError, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		out = append(out, sj)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{
+			{Key: "service.name", Value: valueOf(t.cfg.ServiceName)},
+		}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "notely"},
+			Spans: out,
+		}},
+	}}}
+}
+
+func valueOf(v any) anyValue {
+	switch v := v.(type) {
+	case string:
+		return anyValue{StringValue: &v}
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return anyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return anyValue{IntValue: &s}
+	case float64:
+		return anyValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return anyValue{StringValue: &s}
+	}
+}
+
+// newTraceID and newSpanID use math/rand: IDs only need to be unique,
+// not unpredictable, and crypto/rand would cost a syscall per span.
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		// #nosec G404 -- trace IDs aren't secrets
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		// #nosec G404 -- trace IDs aren't secrets
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		// #nosec G404 -- span IDs aren't secrets
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		header      string
+		traceID     string
+		sampled     bool
+		ok          bool
+	}{
+		"sampled": {
+			description: "A version 00 header with the sampled flag is read",
+			header:      "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			sampled:     true,
+			ok:          true,
+		},
+		"not sampled": {
+			description: "The caller's decision not to sample is kept",
+			header:      "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			ok:          true,
+		},
+		"empty": {
+			description: "A missing header is invalid",
+			header:      "",
+		},
+		"uppercase": {
+			description: "IDs must be lowercase hex",
+			header:      "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		},
+		"zero trace id": {
+			description: "An all-zero trace ID is invalid",
+			header:      "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		"other version": {
+			description: "Versions other than 00 are invalid",
+			header:      "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			traceID, _, sampled, ok := ParseTraceparent(tc.header)
+			if diff := cmp.Diff(tc.ok, ok); diff != "" {
+				t.Fatalf("ok mismatch (-want +got):\n%s", diff)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tc.traceID, (&Span{traceID: traceID}).TraceID()); diff != "" {
+				t.Errorf("trace ID mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.sampled, sampled); diff != "" {
+				t.Errorf("sampled mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		expected    map[string]string
+		wantErr     bool
+	}{
+		"pairs": {
+			description: "Pairs are split on commas and values unescaped",
+			input:       "api-key=abc%20123, x-team = notes",
+			expected:    map[string]string{"api-key": "abc 123", "x-team": "notes"},
+		},
+		"empty": {
+			description: "No headers is fine",
+			input:       "",
+			expected:    map[string]string{},
+		},
+		"missing value": {
+			description: "A pair without = is rejected",
+			input:       "api-key",
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := ParseHeaders(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTracerExport(t *testing.T) {
+	received := make(chan exportRequest, 1)
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("couldn't decode export: %v", err)
+		}
+		header = r.Header
+		received <- req
+	}))
+	defer srv.Close()
+
+	tracer, err := NewTracer(Config{
+		Endpoint:    srv.URL + "/v1/traces",
+		Headers:     map[string]string{"Api-Key": "secret"},
+		ServiceName: "notely-test",
+		SampleRatio: 1,
+	}, 16, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, root := tracer.StartRequest(context.Background(), "POST", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	root.SetName("POST /v1/notes")
+	_, child := Start(ctx, "CreateNote")
+	child.SetKind(KindClient)
+	child.SetAttribute("rows", 1)
+	child.SetError(errors.New("disk full"))
+	child.End()
+	root.End()
+	root.End()
+	tracer.Close()
+
+	req := <-received
+	if diff := cmp.Diff("secret", header.Get("Api-Key")); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("notely-test", *req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue); diff != "" {
+		t.Errorf("service name mismatch (-want +got):\n%s", diff)
+	}
+
+	type summary struct {
+		Name    string
+		Kind    Kind
+		TraceID string
+		Parent  string
+		Status  *status
+	}
+	var got []summary
+	for _, s := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+		got = append(got, summary{Name: s.Name, Kind: s.Kind, TraceID: s.TraceID, Parent: s.ParentSpanID, Status: s.Status})
+	}
+	rootID := req.ResourceSpans[0].ScopeSpans[0].Spans[1].SpanID
+	expected := []summary{
+		{Name: "CreateNote", Kind: KindClient, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", Parent: rootID, Status: &status{Code: statusError, Message: "disk full"}},
+		{Name: "POST /v1/notes", Kind: KindServer, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", Parent: "00f067aa0ba902b7"},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTracerSampling(t *testing.T) {
+	tracer, err := NewTracer(Config{Endpoint: "http://localhost:4318/v1/traces"}, 16, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.Close()
+
+	ctx, span := tracer.StartRequest(context.Background(), "GET", "")
+	if span != nil {
+		t.Fatal("expected no span with a sample ratio of 0")
+	}
+	if _, child := Start(ctx, "GetNote"); child != nil {
+		t.Error("expected no child span outside a sampled trace")
+	}
+	if _, span := tracer.StartRequest(context.Background(), "GET", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"); span == nil {
+		t.Error("expected a span when the caller sampled the trace")
+	}
+}
@@ -0,0 +1,262 @@
+// Package transcode re-encodes JSON documents in other formats, so values
+// whose encoding is described by their JSON tags can be sent as XML or
+// MessagePack too.
+package transcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// object is a JSON object with its members in document order.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+// parse reads the JSON document data into nil, bool, json.Number, string,
+// []any and object values.
+func parse(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := parseValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("transcode: data after JSON document")
+	}
+	return v, nil
+}
+
+func parseValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := parseValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := parseValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// XML returns the JSON document data as XML, in the style of RFC 7807's
+// appendix A: root is the document element, object members are elements
+// named by their keys, array elements are i elements, and null members are
+// left out. A member whose key isn't an XML name is an entry element with
+// the key in its key attribute.
+func XML(data []byte, root xml.Name) ([]byte, error) {
+	v, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := writeXML(enc, xml.StartElement{Name: root}, v); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXML(enc *xml.Encoder, start xml.StartElement, v any) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case object:
+		for _, m := range v {
+			if m.value == nil {
+				continue
+			}
+			child := xml.StartElement{Name: xml.Name{Local: m.key}}
+			if !isXMLName(m.key) {
+				child = xml.StartElement{
+					Name: xml.Name{Local: "entry"},
+					Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: m.key}},
+				}
+			}
+			if err := writeXML(enc, child, m.value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if err := writeXML(enc, xml.StartElement{Name: xml.Name{Local: "i"}}, e); err != nil {
+				return err
+			}
+		}
+	case string:
+		if err := enc.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case json.Number:
+		if err := enc.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case bool:
+		if err := enc.EncodeToken(xml.CharData(strconv.FormatBool(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// isXMLName reports whether key can name an element. Names with colons,
+// which XML reserves for namespaces, or starting with "xml", which it
+// reserves for itself, are turned down too.
+func isXMLName(key string) bool {
+	if key == "" || strings.HasPrefix(strings.ToLower(key), "xml") {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Msgpack returns the JSON document data as MessagePack. Numbers without a
+// fraction or exponent that fit in 64 bits become integers, and others
+// become float64s.
+func Msgpack(data []byte) ([]byte, error) {
+	v, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, v)
+}
+
+func appendMsgpack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendNumber(b, v)
+	case string:
+		b = appendLen(b, len(v), 0xa0, 31, [3]byte{0xd9, 0xda, 0xdb})
+		return append(b, v...), nil
+	case []any:
+		b = appendLen(b, len(v), 0x90, 15, [3]byte{0, 0xdc, 0xdd})
+		for _, e := range v {
+			var err error
+			if b, err = appendMsgpack(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case object:
+		b = appendLen(b, len(v), 0x80, 15, [3]byte{0, 0xde, 0xdf})
+		for _, m := range v {
+			b = appendLen(b, len(m.key), 0xa0, 31, [3]byte{0xd9, 0xda, 0xdb})
+			b = append(b, m.key...)
+			var err error
+			if b, err = appendMsgpack(b, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, errors.New("transcode: unexpected JSON value")
+}
+
+func appendNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := n.Int64(); err == nil {
+		return appendInt(b, i), nil
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return appendBE(append(b, 0xcf), u, 8), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return appendBE(append(b, 0xcb), math.Float64bits(f), 8), nil
+}
+
+// appendInt appends n in the smallest signed integer format it fits.
+func appendInt(b []byte, n int64) []byte {
+	u := uint64(n) // #nosec G115 -- the two's complement bits, which appendBE truncates
+	switch {
+	case n >= -32 && n <= math.MaxInt8:
+		return appendBE(b, u, 1)
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		return appendBE(append(b, 0xd0), u, 1)
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		return appendBE(append(b, 0xd1), u, 2)
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return appendBE(append(b, 0xd2), u, 4)
+	}
+	return appendBE(append(b, 0xd3), u, 8)
+}
+
+// appendLen appends the header of a string, array or map of length n:
+// fix plus n if n is at most fixMax, or else the first of sized, the
+// codes for 1, 2 and 4 byte lengths, that n fits, followed by n. Arrays
+// and maps have no 1 byte form, marked by a 0 code.
+func appendLen(b []byte, n int, fix byte, fixMax int, sized [3]byte) []byte {
+	u := uint64(n) // #nosec G115 -- lengths aren't negative
+	switch {
+	case n <= fixMax:
+		return appendBE(b, uint64(fix)|u, 1)
+	case n <= math.MaxUint8 && sized[0] != 0:
+		return appendBE(append(b, sized[0]), u, 1)
+	case n <= math.MaxUint16:
+		return appendBE(append(b, sized[1]), u, 2)
+	}
+	return appendBE(append(b, sized[2]), u, 4)
+}
+
+// appendBE appends the low size bytes of n, most significant first.
+func appendBE(b []byte, n uint64, size int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[8-size:]...)
+}
@@ -0,0 +1,108 @@
+package transcode
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestXML(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		root        xml.Name
+		expected    string
+	}{
+		"object": {
+			description: "Members become elements in document order, nulls left out",
+			input:       `{"name":"a & b","count":3,"ok":true,"remind_at":null,"nested":{"id":"x"}}`,
+			root:        xml.Name{Local: "response"},
+			expected:    `<response><name>a &amp; b</name><count>3</count><ok>true</ok><nested><id>x</id></nested></response>`,
+		},
+		"array": {
+			description: "Array elements become i elements",
+			input:       `{"tags":["a","b"],"empty":[]}`,
+			root:        xml.Name{Local: "response"},
+			expected:    `<response><tags><i>a</i><i>b</i></tags><empty></empty></response>`,
+		},
+		"keys": {
+			description: "Keys that aren't XML names become entry elements",
+			input:       `{"2fa":1,"a b":2,"xmlns":3,"ok-key.v2":4}`,
+			root:        xml.Name{Local: "response"},
+			expected:    `<response><entry key="2fa">1</entry><entry key="a b">2</entry><entry key="xmlns">3</entry><ok-key.v2>4</ok-key.v2></response>`,
+		},
+		"namespace": {
+			description: "The root element can have a namespace",
+			input:       `{"status":404}`,
+			root:        xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"},
+			expected:    `<problem xmlns="urn:ietf:rfc:7807"><status>404</status></problem>`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := XML([]byte(tc.input), tc.root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(xml.Header+tc.expected, string(got)); diff != "" {
+				t.Errorf("XML mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMsgpack(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		input       string
+		expected    []byte
+	}{
+		"scalars": {
+			description: "Scalars use their smallest formats",
+			input:       `[null,true,false,0,-1,127,-33,200,-200,70000,1.5,"hi"]`,
+			expected: []byte{
+				0x9c, 0xc0, 0xc3, 0xc2, 0x00, 0xff, 0x7f, 0xd0, 0xdf, 0xd1, 0x00, 0xc8, 0xd1, 0xff, 0x38,
+				0xd2, 0x00, 0x01, 0x11, 0x70, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 0xa2, 'h', 'i',
+			},
+		},
+		"big numbers": {
+			description: "Integers past int64 are unsigned, and past uint64 floats",
+			input:       `[18446744073709551615,1e3]`,
+			expected: []byte{
+				0x92, 0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+				0xcb, 0x40, 0x8f, 0x40, 0, 0, 0, 0, 0,
+			},
+		},
+		"object": {
+			description: "Objects become maps in document order",
+			input:       `{"b":1,"a":{}}`,
+			expected:    []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x80},
+		},
+		"long string": {
+			description: "Strings past 31 bytes have a length byte",
+			input:       `"` + strings.Repeat("a", 32) + `"`,
+			expected:    append([]byte{0xd9, 32}, strings.Repeat("a", 32)...),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			got, err := Msgpack([]byte(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("MessagePack mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Msgpack([]byte(`{"a":1} {}`)); err == nil {
+		t.Error("expected an error for data after the document")
+	}
+}
@@ -0,0 +1,250 @@
+// Package validate checks decoded request bodies, collecting every invalid
+// field instead of stopping at the first, so a client can fix them all from
+// one response.
+package validate
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError is one invalid field. Field is its name in the JSON body,
+// with nested fields joined by dots, and Message says what is wrong with
+// it, such as "is required".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is every invalid field in a request, in the order checked.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validator collects the invalid fields of one request. Only a field's
+// first violation is kept, so checks on the same field can be chained,
+// from the most basic to the most specific, without piling up messages.
+// The zero value is ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Decode reads the JSON body into dst. Values of the wrong type are
+// recorded as invalid fields rather than returned, every one of them, and
+// the rest of the body is still decoded so it can be checked too. The
+// error returned is for bodies that can't be read or aren't JSON at all.
+func (v *Validator) Decode(body io.Reader, dst any) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return err
+	}
+	err := json.Unmarshal(raw, dst)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return err
+	}
+
+	// encoding/json only reports the first mismatch, so walk the body
+	// again to find the rest.
+	found := len(v.errs)
+	var doc any
+	if json.Unmarshal(raw, &doc) == nil {
+		v.checkKinds("", doc, reflect.TypeOf(dst).Elem())
+	}
+	if len(v.errs) == found {
+		v.Check(false, typeErr.Field, "must be "+jsonKind(typeErr.Type))
+	}
+	return nil
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// checkKinds records every value in doc, a body decoded into generic
+// values, that can't be decoded into t. Types that decode themselves are
+// trusted to accept what they're given.
+func (v *Validator) checkKinds(field string, doc any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if doc == nil || t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return
+	}
+
+	ok := true
+	switch t.Kind() {
+	case reflect.String:
+		_, ok = doc.(string)
+	case reflect.Bool:
+		_, ok = doc.(bool)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, isNum := doc.(float64)
+		ok = isNum && n == math.Trunc(n) && !reflect.Zero(t).OverflowInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, isNum := doc.(float64)
+		ok = isNum && n >= 0 && n == math.Trunc(n) && !reflect.Zero(t).OverflowUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		_, ok = doc.(float64)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			_, ok = doc.(string)
+			break
+		}
+		var items []any
+		if items, ok = doc.([]any); ok {
+			for i, item := range items {
+				v.checkKinds(joinField(field, strconv.Itoa(i)), item, t.Elem())
+			}
+		}
+	case reflect.Map:
+		var obj map[string]any
+		if obj, ok = doc.(map[string]any); ok {
+			keys := make([]string, 0, len(obj))
+			for k := range obj {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				v.checkKinds(joinField(field, k), obj[k], t.Elem())
+			}
+		}
+	case reflect.Struct:
+		var obj map[string]any
+		if obj, ok = doc.(map[string]any); ok {
+			for _, f := range reflect.VisibleFields(t) {
+				name, decoded := jsonName(f)
+				if !decoded {
+					continue
+				}
+				if k, present := lookupKey(obj, name); present {
+					v.checkKinds(joinField(field, name), obj[k], f.Type)
+				}
+			}
+		}
+	}
+	v.Check(ok, field, "must be "+jsonKind(t))
+}
+
+// jsonName is the key encoding/json decodes into f, if any.
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() || f.Anonymous {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
+
+// lookupKey finds name in obj the way encoding/json does, preferring an
+// exact match but otherwise ignoring case.
+func lookupKey(obj map[string]any, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for k := range obj {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func joinField(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// jsonKind names the JSON value expected for a Go type.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// Check records message for field unless ok.
+func (v *Validator) Check(ok bool, field, message string) {
+	if ok || v.failed(field) {
+		return
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Message: message})
+}
+
+// Required checks that value isn't empty or only whitespace.
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// MaxLength checks that value, once trimmed, is at most max characters.
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(strings.TrimSpace(value)) <= max, field, fmt.Sprintf("must be at most %d characters", max))
+}
+
+// UUID checks that value is a UUID in its canonical, hyphenated form.
+func (v *Validator) UUID(field, value string) {
+	_, err := uuid.Parse(value)
+	v.Check(err == nil && len(value) == 36, field, "must be a UUID")
+}
+
+// OneOf checks that value is one of allowed.
+func (v *Validator) OneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Check(false, field, "must be one of "+strings.Join(allowed, ", "))
+}
+
+// Errors returns the invalid fields found so far, or nil if there are
+// none.
+func (v *Validator) Errors() Errors {
+	return v.errs
+}
+
+func (v *Validator) failed(field string) bool {
+	for _, fe := range v.errs {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
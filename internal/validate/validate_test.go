@@ -0,0 +1,141 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidator(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		check       func(v *Validator)
+		expected    Errors
+	}{
+		"valid": {
+			description: "A request passing every check has no errors",
+			check: func(v *Validator) {
+				v.Required("name", "Notes")
+				v.MaxLength("name", "Notes", 5)
+				v.UUID("id", "0b6a3d3e-2b8e-4a53-9a8e-7c3f1a2b4c5d")
+				v.OneOf("permission", "read", "read", "write")
+			},
+		},
+		"every field": {
+			description: "Every invalid field is reported, not just the first",
+			check: func(v *Validator) {
+				v.Required("name", "  ")
+				v.MaxLength("title", "héllo", 4)
+				v.UUID("id", "42")
+				v.OneOf("permission", "admin", "read", "write")
+			},
+			expected: Errors{
+				{Field: "name", Message: "is required"},
+				{Field: "title", Message: "must be at most 4 characters"},
+				{Field: "id", Message: "must be a UUID"},
+				{Field: "permission", Message: "must be one of read, write"},
+			},
+		},
+		"first per field": {
+			description: "Only a field's first violation is kept",
+			check: func(v *Validator) {
+				v.Required("id", "")
+				v.UUID("id", "")
+			},
+			expected: Errors{{Field: "id", Message: "is required"}},
+		},
+		"non-canonical uuid": {
+			description: "UUIDs must be in their hyphenated form",
+			check: func(v *Validator) {
+				v.UUID("id", "urn:uuid:0b6a3d3e-2b8e-4a53-9a8e-7c3f1a2b4c5d")
+			},
+			expected: Errors{{Field: "id", Message: "must be a UUID"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			v := &Validator{}
+			tc.check(v)
+			if diff := cmp.Diff(tc.expected, v.Errors()); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type owner struct {
+		Name string `json:"name"`
+	}
+	type parameters struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+		Owner *owner   `json:"owner"`
+	}
+
+	tests := map[string]struct {
+		description string
+		body        string
+		expected    parameters
+		errs        Errors
+		wantErr     bool
+	}{
+		"valid": {
+			description: "A well-formed body is decoded",
+			body:        `{"name":"a","count":2,"tags":["x"]}`,
+			expected:    parameters{Name: "a", Count: 2, Tags: []string{"x"}},
+		},
+		"wrong type": {
+			description: "A value of the wrong type is an invalid field and the rest is still decoded",
+			body:        `{"name":5,"count":2}`,
+			expected:    parameters{Count: 2},
+			errs:        Errors{{Field: "name", Message: "must be a string"}},
+		},
+		"every wrong type": {
+			description: "Every value of the wrong type is reported, including ones inside arrays",
+			body:        `{"name":5,"count":1.5,"tags":["x",3]}`,
+			expected:    parameters{Tags: []string{"x", ""}},
+			errs: Errors{
+				{Field: "name", Message: "must be a string"},
+				{Field: "count", Message: "must be an integer"},
+				{Field: "tags.1", Message: "must be a string"},
+			},
+		},
+		"nested": {
+			description: "Fields of nested objects are named by their path",
+			body:        `{"name":"a","owner":{"name":true}}`,
+			expected:    parameters{Name: "a", Owner: &owner{}},
+			errs:        Errors{{Field: "owner.name", Message: "must be a string"}},
+		},
+		"malformed": {
+			description: "A body that isn't JSON is an error",
+			body:        `{"name":`,
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			v := &Validator{}
+			got := parameters{}
+			err := v.Decode(strings.NewReader(tc.body), &got)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("parameters mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.errs, v.Errors()); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
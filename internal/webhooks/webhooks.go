@@ -0,0 +1,205 @@
+// Package webhooks sends note events to the endpoints users register,
+// signed by auth.SignWebhook as the Standard Webhooks specification
+// describes, retrying
+// failed deliveries with exponential backoff until they're given up on.
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+)
+
+// Delivery statuses. A pending delivery is waiting for its next attempt;
+// a dead one failed MaxAttempts times and is tried again only if its job
+// is requeued.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it's dead.
+	MaxAttempts = 8
+	// Timeout bounds each attempt, from connecting to reading the
+	// response headers.
+	Timeout = 10 * time.Second
+	// Retention is how long delivered and dead deliveries stay in the log.
+	Retention = 30 * 24 * time.Hour
+
+	// JobKind is the kind of job that makes an attempt at a delivery.
+	JobKind = "webhook_delivery"
+
+	// firstBackoff is the wait after the first failed attempt, doubled
+	// after each one since.
+	firstBackoff = time.Minute
+)
+
+// ErrPrivateAddress is returned for deliveries to addresses on private
+// networks, when those aren't allowed.
+var ErrPrivateAddress = errors.New("webhooks: endpoint is on a private network")
+
+// Policy is how delivery jobs are run. Their timeout leaves room for the
+// client's own, so that a slow endpoint's failure is still recorded.
+var Policy = jobs.Policy{MaxAttempts: MaxAttempts, Backoff: Backoff, Timeout: 2 * Timeout}
+
+type Store interface {
+	GetWebhookDeliveryForSending(ctx context.Context, id string) (database.GetWebhookDeliveryForSendingRow, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error
+}
+
+// JobPayload is the payload of a JobKind job.
+type JobPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Backoff is how long to wait after a delivery's attempts-th failed
+// attempt before trying again.
+func Backoff(attempts int64) time.Duration {
+	return firstBackoff << min(max(attempts-1, 0), MaxAttempts)
+}
+
+// NewClient returns a client for sending deliveries. It doesn't follow
+// redirects, which count as failures, or use a proxy. Unless allowPrivate
+// is set, it refuses to connect to loopback, private and link-local
+// addresses, so webhooks can't reach into the server's own network.
+func NewClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: Timeout}
+	if !allowPrivate {
+		// Checking the address dialed, rather than the URL's host, also
+		// covers names that resolve to private addresses.
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Deliverer makes the attempts at deliveries, as jobs of JobKind.
+type Deliverer struct {
+	store  Store
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewDeliverer(store Store, client *http.Client) *Deliverer {
+	return &Deliverer{store: store, client: client, now: time.Now}
+}
+
+// Deliver is the jobs.Handler for JobKind. It makes one attempt at the
+// delivery in the job's payload and records how it went, failing the job
+// if the attempt failed so the queue retries it. A delivery to a paused
+// webhook is left pending, to be queued again when it's resumed. A job
+// cut short by its server stopping is run again, so endpoints should use
+// the webhook-id header to ignore repeats.
+func (d *Deliverer) Deliver(ctx context.Context, job jobs.Job) error {
+	var payload JobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	delivery, err := d.store.GetWebhookDeliveryForSending(ctx, payload.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Its webhook was deleted.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == StatusDelivered || !delivery.Active {
+		return nil
+	}
+
+	status, err := d.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Shutting down: the job is run again.
+		return ctx.Err()
+	}
+	attempted := d.now().UTC()
+	arg := database.RecordWebhookDeliveryAttemptParams{
+		Status:        StatusDelivered,
+		NextAttemptAt: attempted.Format(time.RFC3339),
+		LastAttemptAt: sql.NullString{String: attempted.Format(time.RFC3339), Valid: true},
+		ID:            delivery.ID,
+	}
+	if status != 0 {
+		arg.ResponseStatus = sql.NullInt64{Int64: int64(status), Valid: true}
+	}
+	if err != nil {
+		arg.LastError = sql.NullString{String: err.Error(), Valid: true}
+		arg.Status = StatusPending
+		arg.NextAttemptAt = attempted.Add(Backoff(job.Attempt)).Format(time.RFC3339)
+		if job.LastAttempt() {
+			arg.Status = StatusDead
+		}
+	}
+	if err := d.store.RecordWebhookDeliveryAttempt(ctx, arg); err != nil {
+		log.Printf("Couldn't record webhook delivery %s: %v", delivery.ID, err)
+	}
+	return err
+}
+
+// send posts a delivery's payload to its endpoint, returning the
+// response's status code, if there was one. Anything but a 2xx status is
+// an error.
+func (d *Deliverer) send(ctx context.Context, delivery database.GetWebhookDeliveryForSendingRow) (int, error) {
+	timestamp := d.now()
+	signature, err := auth.SignWebhook(delivery.Secret, delivery.ID, timestamp, []byte(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Notely-Webhooks")
+	req.Header.Set(auth.WebhookIDHeader, delivery.ID)
+	req.Header.Set(auth.WebhookTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(auth.WebhookSignatureHeader, signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Reading some of the body lets the connection be reused.
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)); err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeStore holds deliveries in memory.
+type fakeStore struct {
+	deliveries []database.GetWebhookDeliveryForSendingRow
+}
+
+func (s *fakeStore) GetWebhookDeliveryForSending(ctx context.Context, id string) (database.GetWebhookDeliveryForSendingRow, error) {
+	for _, d := range s.deliveries {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return database.GetWebhookDeliveryForSendingRow{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error {
+	for i, d := range s.deliveries {
+		if d.ID != arg.ID {
+			continue
+		}
+		s.deliveries[i].Status = arg.Status
+		s.deliveries[i].Attempts++
+		s.deliveries[i].NextAttemptAt = arg.NextAttemptAt
+		s.deliveries[i].LastAttemptAt = arg.LastAttemptAt
+		s.deliveries[i].ResponseStatus = arg.ResponseStatus
+		s.deliveries[i].LastError = arg.LastError
+	}
+	return nil
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		attempts    int64
+		expected    time.Duration
+	}{
+		"first": {
+			description: "The first retry waits a minute",
+			attempts:    1,
+			expected:    time.Minute,
+		},
+		"doubling": {
+			description: "Each failure doubles the wait",
+			attempts:    4,
+			expected:    8 * time.Minute,
+		},
+		"last": {
+			description: "The last retry waits longest",
+			attempts:    MaxAttempts - 1,
+			expected:    64 * time.Minute,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			if got := Backoff(tc.attempts); got != tc.expected {
+				t.Errorf("Backoff(%d) = %s, want %s", tc.attempts, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDelivererDeliver(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	type outcome struct {
+		Status         string
+		Attempts       int64
+		NextAttemptAt  string
+		ResponseStatus sql.NullInt64
+	}
+	tests := map[string]struct {
+		description string
+		status      int
+		attempt     int64
+		deliveryID  string
+		delivered   bool
+		paused      bool
+		expected    outcome
+		expectSent  bool
+		expectErr   bool
+	}{
+		"delivered": {
+			description: "A 2xx response delivers it",
+			status:      http.StatusNoContent,
+			attempt:     1,
+			deliveryID:  "d1",
+			expected:    outcome{Status: StatusDelivered, Attempts: 1, NextAttemptAt: "2024-03-01T12:00:00Z", ResponseStatus: sql.NullInt64{Int64: 204, Valid: true}},
+			expectSent:  true,
+		},
+		"retried": {
+			description: "A failure fails the job, to be retried after the backoff",
+			status:      http.StatusInternalServerError,
+			attempt:     3,
+			deliveryID:  "d1",
+			expected:    outcome{Status: StatusPending, Attempts: 1, NextAttemptAt: "2024-03-01T12:04:00Z", ResponseStatus: sql.NullInt64{Int64: 500, Valid: true}},
+			expectSent:  true,
+			expectErr:   true,
+		},
+		"dead": {
+			description: "The last failed attempt gives up on it",
+			status:      http.StatusFound,
+			attempt:     MaxAttempts,
+			deliveryID:  "d1",
+			expected:    outcome{Status: StatusDead, Attempts: 1, NextAttemptAt: "2024-03-01T14:08:00Z", ResponseStatus: sql.NullInt64{Int64: 302, Valid: true}},
+			expectSent:  true,
+			expectErr:   true,
+		},
+		"already delivered": {
+			description: "Deliveries made by an earlier run of the job aren't sent again",
+			status:      http.StatusOK,
+			attempt:     2,
+			deliveryID:  "d1",
+			delivered:   true,
+			expected:    outcome{Status: StatusDelivered, NextAttemptAt: "2024-03-01T11:00:00Z"},
+		},
+		"paused": {
+			description: "Deliveries to paused webhooks stay pending",
+			status:      http.StatusOK,
+			attempt:     1,
+			deliveryID:  "d1",
+			paused:      true,
+			expected:    outcome{Status: StatusPending, NextAttemptAt: "2024-03-01T11:00:00Z"},
+		},
+		"deleted": {
+			description: "Deliveries deleted with their webhook are skipped",
+			status:      http.StatusOK,
+			attempt:     1,
+			deliveryID:  "d2",
+			expected:    outcome{Status: StatusPending, NextAttemptAt: "2024-03-01T11:00:00Z"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("Test case: %s", tc.description)
+			secret, err := auth.GenerateWebhookSecret()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sent []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id := r.Header.Get(auth.WebhookIDHeader)
+				err := auth.VerifyWebhook(secret, id, r.Header.Get(auth.WebhookTimestampHeader), r.Header.Get(auth.WebhookSignatureHeader),
+					[]byte(`{"type":"note.created"}`), now, time.Minute)
+				if err != nil {
+					t.Errorf("VerifyWebhook() error = %v", err)
+				}
+				sent = append(sent, id)
+				if tc.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			status := StatusPending
+			if tc.delivered {
+				status = StatusDelivered
+			}
+			store := &fakeStore{
+				deliveries: []database.GetWebhookDeliveryForSendingRow{{
+					ID:            "d1",
+					Payload:       `{"type":"note.created"}`,
+					Status:        status,
+					NextAttemptAt: "2024-03-01T11:00:00Z",
+					Url:           srv.URL,
+					Secret:        secret,
+					Active:        !tc.paused,
+				}},
+			}
+			d := NewDeliverer(store, NewClient(true))
+			d.now = func() time.Time { return now }
+			err = d.Deliver(context.Background(), jobs.Job{
+				ID:          "j1",
+				Kind:        JobKind,
+				Payload:     json.RawMessage(`{"delivery_id":"` + tc.deliveryID + `"}`),
+				Attempt:     tc.attempt,
+				MaxAttempts: MaxAttempts,
+			})
+			if tc.expectErr != (err != nil) {
+				t.Errorf("Deliver() error = %v, expected an error: %v", err, tc.expectErr)
+			}
+
+			row := store.deliveries[0]
+			got := outcome{Status: row.Status, Attempts: row.Attempts, NextAttemptAt: row.NextAttemptAt, ResponseStatus: row.ResponseStatus}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("delivery mismatch (-want +got):\n%s", diff)
+			}
+			if tc.expectSent != (len(sent) == 1) {
+				t.Errorf("sent %v, expected a delivery: %v", sent, tc.expectSent)
+			}
+		})
+	}
+}
+
+func TestNewClientPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := NewClient(false).Get(srv.URL)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Get(%s) error = %v, want %v", srv.URL, err, ErrPrivateAddress)
+	}
+}
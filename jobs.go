@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/bootdotdev/learn-cicd-starter/internal/reminders"
+	"github.com/bootdotdev/learn-cicd-starter/internal/webhooks"
+)
+
+// Kinds of background job, besides webhooks.JobKind.
+const (
+	jobKindDataExport   = "data_export"
+	jobKindReminderScan = "reminders.scan"
+	jobKindReminder     = "reminder"
+	jobKindPurge        = "purge"
+)
+
+// purgeInterval is how often expired rows are cleared out.
+const purgeInterval = time.Hour
+
+// newJobQueue returns a queue, not yet started, that runs every kind of
+// background job the server has: exports, webhook deliveries, reminders
+// and clearing out expired rows.
+func (cfg *apiConfig) newJobQueue(c *Config) *jobs.Queue {
+	q := jobs.NewQueue(cfg.DB, c.JobsWorkers, c.JobsPollInterval)
+
+	q.Handle(jobKindDataExport, jobs.Policy{
+		MaxAttempts: 3,
+		Backoff:     jobs.Exponential(30*time.Second, 5*time.Minute),
+		Timeout:     10 * time.Minute,
+	}, cfg.runDataExportJob)
+
+	deliverer := webhooks.NewDeliverer(cfg.DB, webhooks.NewClient(c.WebhookAllowPrivateNetworks))
+	q.Handle(webhooks.JobKind, webhooks.Policy, deliverer.Deliver)
+
+	// The scan isn't retried: the next one picks up whatever it missed.
+	scheduler := reminders.NewScheduler(cfg.DB, reminderJobNotifier{queue: q})
+	q.Handle(jobKindReminderScan, jobs.Policy{
+		MaxAttempts: 1,
+		Backoff:     jobs.Exponential(time.Minute, time.Minute),
+		Timeout:     5 * time.Minute,
+	}, func(ctx context.Context, job jobs.Job) error {
+		scheduler.RunOnce(ctx)
+		return nil
+	})
+	q.Every(jobKindReminderScan, c.ReminderInterval)
+	q.Handle(jobKindReminder, jobs.Policy{
+		MaxAttempts: 5,
+		Backoff:     jobs.Exponential(time.Minute, 30*time.Minute),
+		Timeout:     time.Minute,
+	}, cfg.runReminderJob)
+
+	q.Handle(jobKindPurge, jobs.Policy{
+		MaxAttempts: 1,
+		Backoff:     jobs.Exponential(time.Minute, time.Minute),
+		Timeout:     5 * time.Minute,
+	}, cfg.runPurgeJob)
+	q.Every(jobKindPurge, purgeInterval)
+
+	return q
+}
+
+// reminderJob is the payload of jobKindReminder jobs.
+type reminderJob struct {
+	NoteID   string `json:"note_id"`
+	RemindAt string `json:"remind_at"`
+}
+
+// reminderJobNotifier queues a job for each reminder the scan finds due,
+// so that one that can't be sent is retried. Each reminder is queued under
+// a unique key, so one handed over again, by another server's scan or
+// after failing to be marked sent, isn't sent twice.
+type reminderJobNotifier struct {
+	queue *jobs.Queue
+}
+
+func (n reminderJobNotifier) NotifyReminder(ctx context.Context, note database.Note) error {
+	payload := reminderJob{NoteID: note.ID, RemindAt: note.RemindAt.String}
+	return n.queue.EnqueueUnique(ctx, jobKindReminder, payload.NoteID+"@"+payload.RemindAt, payload)
+}
+
+// runReminderJob sends a reminder, unless the note was deleted, archived
+// or given another reminder since it was queued.
+func (cfg *apiConfig) runReminderJob(ctx context.Context, job jobs.Job) error {
+	var payload reminderJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+	note, err := cfg.DB.GetNote(ctx, payload.NoteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if note.Archived || note.RemindAt.String != payload.RemindAt {
+		return nil
+	}
+	return reminderEmailNotifier{cfg: cfg}.NotifyReminder(ctx, note)
+}
+
+// runPurgeJob deletes the rows that are only kept for a while: expired
+// exports and idempotency keys, and old webhook deliveries and jobs.
+func (cfg *apiConfig) runPurgeJob(ctx context.Context, job jobs.Job) error {
+	now := time.Now().UTC()
+	before := func(d time.Duration) string {
+		return now.Add(-d).Format(time.RFC3339)
+	}
+	return errors.Join(
+		cfg.DB.DeleteExpiredDataExports(ctx, now.Format(time.RFC3339)),
+		cfg.DB.DeleteExpiredIdempotencyKeys(ctx, before(idempotencyKeyTTL)),
+		cfg.DB.DeleteFinishedWebhookDeliveries(ctx, before(webhooks.Retention)),
+		cfg.DB.DeleteFinishedJobs(ctx, database.DeleteFinishedJobsParams{
+			Status:     jobs.StatusSucceeded,
+			FinishedAt: sql.NullString{String: before(jobs.SucceededRetention), Valid: true},
+		}),
+		cfg.DB.DeleteFinishedJobs(ctx, database.DeleteFinishedJobsParams{
+			Status:     jobs.StatusFailed,
+			FinishedAt: sql.NullString{String: before(jobs.FailedRetention), Valid: true},
+		}),
+	)
+}
@@ -2,35 +2,181 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
 )
 
+// errCodeQueryTimeout is the error code sent when a database query runs
+// past DB_QUERY_TIMEOUT.
+const errCodeQueryTimeout = "query_timeout"
+
+// errCodeValidationFailed is the error code sent with a 422 when fields of
+// a request body are invalid.
+const errCodeValidationFailed = "validation_failed"
+
+// problemTitles are the titles sent with each error code. They describe
+// the kind of problem, and the detail describes the occurrence.
+var problemTitles = map[string]string{
+	errCodeAccountSuspended: "Account suspended",
+	errCodeBodyTooLarge:     "Request body too large",
+	errCodeInternal:         "Internal server error",
+	errCodeNoteTooLarge:     "Note too large",
+	errCodeQueryTimeout:     "Database query timed out",
+	errCodeQuotaExceeded:    "Quota exceeded",
+	errCodeValidationFailed: "Invalid request fields",
+}
+
+// problem is an RFC 7807 problem details document, the body of every error
+// response. Errors with an error code have a type of their own, a URN
+// naming the code that identifies it but isn't meant to be fetched. Other
+// errors are about:blank, titled by their status, and their code is the
+// status text in snake case, such as "not_found", so clients can always
+// switch on code.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// problemTypePrefix starts the type of every problem with an error code.
+const problemTypePrefix = "urn:notely:problem:"
+
+func newProblem(status int, errCode, detail, requestID string) problem {
+	p := problem{
+		Type:      problemTypePrefix + errCode,
+		Title:     problemTitles[errCode],
+		Status:    status,
+		Detail:    detail,
+		Code:      errCode,
+		RequestID: requestID,
+	}
+	if errCode == "" {
+		p.Type = "about:blank"
+		p.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(status)
+	}
+	return p
+}
+
 func respondWithError(w http.ResponseWriter, code int, msg string, logErr error) {
+	respondWithErrorCode(w, code, "", msg, logErr)
+}
+
+// respondWithErrorCode is respondWithError with a machine-readable code next
+// to the message, for errors clients are expected to handle. The error is
+// sent as application/problem+json, with msg as the problem's detail.
+//
+// Server errors caused by a database query timing out are sent as a 504
+// with errCodeQueryTimeout instead, and errors from reading a body past its
+// limit as a 413 with errCodeBodyTooLarge. The request's ID is logged with the
+// error and included in the response, so a client's report can be matched
+// to the log.
+func respondWithErrorCode(w http.ResponseWriter, code int, errCode, msg string, logErr error) {
+	id := w.Header().Get(requestIDHeader)
+	prefix := requestLogPrefix(id)
 	if logErr != nil {
-		log.Println(logErr)
+		log.Print(prefix, logErr)
 	}
-	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
+	if code > 499 && database.IsTimeout(logErr) {
+		code, errCode = http.StatusGatewayTimeout, errCodeQueryTimeout
+		msg = "The database took too long to respond"
+	}
+	var maxBytesErr *http.MaxBytesError
+	if code != http.StatusRequestEntityTooLarge && errors.As(logErr, &maxBytesErr) {
+		code, errCode = http.StatusRequestEntityTooLarge, errCodeBodyTooLarge
+		msg = fmt.Sprintf("Request body is larger than %d bytes", maxBytesErr.Limit)
 	}
-	type errorResponse struct {
-		Error string `json:"error"`
+	if code > 499 {
+		log.Printf("%sResponding with 5XX error: %s", prefix, msg)
 	}
-	respondWithJSON(w, code, errorResponse{
-		Error: msg,
+	respondWithProblem(w, code, newProblem(code, errCode, msg, id))
+}
+
+// validationProblem is the problem sent for invalid request fields, listing
+// every one of them.
+type validationProblem struct {
+	problem
+	Errors validate.Errors `json:"errors"`
+}
+
+// respondWithInvalidFields answers a request whose body has the invalid
+// fields errs with a 422.
+func respondWithInvalidFields(w http.ResponseWriter, errs validate.Errors) {
+	id := w.Header().Get(requestIDHeader)
+	respondWithProblem(w, http.StatusUnprocessableEntity, validationProblem{
+		problem: newProblem(http.StatusUnprocessableEntity, errCodeValidationFailed, invalidFieldsMessage("Request", errs), id),
+		Errors:  errs,
 	})
 }
 
+// invalidFieldsMessage summarizes errs, found in what.
+func invalidFieldsMessage(what string, errs validate.Errors) string {
+	if len(errs) > 1 {
+		return fmt.Sprintf("%s has %d invalid fields", what, len(errs))
+	}
+	return what + " has an invalid field"
+}
+
+// respondWithProblem sends p, a problem or a type embedding one, as
+// problem details, such as application/problem+json, unless the API
+// version maps it to an error body of its own.
+func respondWithProblem(w http.ResponseWriter, code int, p any) {
+	payload := versionedPayload(w, p)
+	writePayload(w, code, reflect.TypeOf(payload) == reflect.TypeOf(p), payload)
+}
+
+// respondWithJSON sends payload in the shape of the API version serving
+// the request, in the format the client asked for in its Accept header.
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	writePayload(w, code, false, versionedPayload(w, payload))
+}
+
+func versionedPayload(w http.ResponseWriter, payload any) any {
+	return apiversion.Find(apiVersions, w.Header().Get(apiversion.Header)).MapPayload(payload)
+}
+
+// writePayload sends payload, as problem details if problem is set, in the
+// format negotiated for the request.
+func writePayload(w http.ResponseWriter, code int, problem bool, payload any) {
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		log.Printf("%sError marshalling JSON: %s", requestLogPrefix(w.Header().Get(requestIDHeader)), err)
+		w.WriteHeader(500)
+		return
+	}
+	writeEncoded(w, code, problem, dat)
+}
+
+// writeEncoded sends data, a JSON document, in the format negotiated for
+// the request.
+func writeEncoded(w http.ResponseWriter, code int, problem bool, data []byte) {
+	enc := responseEncoderFor(w)
+	contentType := enc.mediaType
+	if problem && enc.problemType != "" {
+		contentType = enc.problemType
+	}
+	w.Header().Set("Content-Type", contentType)
+	body, err := enc.encode(data, problem)
+	if err != nil {
+		log.Printf("%sError encoding %s: %s", requestLogPrefix(w.Header().Get(requestIDHeader)), contentType, err)
 		w.WriteHeader(500)
 		return
 	}
 	w.WriteHeader(code)
-	if _, err := w.Write(dat); err != nil {
-		log.Printf("Error writing response: %s", err)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("%sError writing response: %s", requestLogPrefix(w.Header().Get(requestIDHeader)), err)
 	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+)
+
+// link is an entry in a response's _links, in the style of HAL: generic
+// clients follow href instead of building URLs from templates.
+type link struct {
+	Href string `json:"href"`
+}
+
+// noteLinks are the _links of a note. Owner is the owner's account, which
+// only the owner can read, so other readers don't get it.
+type noteLinks struct {
+	Self  link  `json:"self"`
+	Owner *link `json:"owner,omitempty"`
+}
+
+// pageLinks are the _links of a listing. Next is the following page, when
+// there is one.
+type pageLinks struct {
+	Self link  `json:"self"`
+	Next *link `json:"next,omitempty"`
+}
+
+// linksRequested reports whether the request opted into _links with
+// ?links=true. They're opt-in to keep responses small for clients that
+// already know the URLs.
+func linksRequested(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("links")
+	if s == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// apiLink links to path under the API version w answers with.
+func apiLink(w http.ResponseWriter, path string) link {
+	return link{Href: "/" + w.Header().Get(apiversion.Header) + path}
+}
+
+// addNoteLinks sets the _links of notes read by the user with viewerID,
+// which is empty for guests.
+func addNoteLinks(w http.ResponseWriter, notes []Note, viewerID string) {
+	for i := range notes {
+		links := &noteLinks{Self: apiLink(w, "/notes/"+url.PathEscape(notes[i].ID))}
+		if viewerID != "" && notes[i].UserID == viewerID {
+			owner := apiLink(w, "/users")
+			links.Owner = &owner
+		}
+		notes[i].Links = links
+	}
+}
+
+// addPageLinks sets the _links of a listing answering r: the request
+// itself, and the same request continuing from the page's next_cursor.
+func addPageLinks(page *notesPage, r *http.Request) {
+	page.Links = &pageLinks{Self: link{Href: r.URL.RequestURI()}}
+	if page.NextCursor != "" {
+		query := r.URL.Query()
+		query.Del("offset")
+		query.Set("cursor", page.NextCursor)
+		page.Links.Next = &link{Href: r.URL.Path + "?" + query.Encode()}
+	}
+}
@@ -1,102 +1,568 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"embed"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
-	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/acmecert"
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/audit"
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/backup"
+	"github.com/bootdotdev/learn-cicd-starter/internal/cache"
+	"github.com/bootdotdev/learn-cicd-starter/internal/contenthash"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/bootdotdev/learn-cicd-starter/internal/keyusage"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mailer"
+	"github.com/bootdotdev/learn-cicd-starter/internal/memstore"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mysql"
+	"github.com/bootdotdev/learn-cicd-starter/internal/oauth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/postgres"
+	"github.com/bootdotdev/learn-cicd-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-cicd-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-cicd-starter/internal/sqlite"
+	"github.com/bootdotdev/learn-cicd-starter/internal/storage"
+	"github.com/bootdotdev/learn-cicd-starter/internal/tracing"
 
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
 )
 
 type apiConfig struct {
-	DB *database.Queries
+	DB                   database.Store
+	Authenticator        auth.Authenticator
+	APIKeyAuth           auth.APIKeyAuthenticator
+	Users                *auth.UserCache
+	Cache                cache.Cache
+	KeyRotationGrace     time.Duration
+	JWTSecret            string
+	RateLimiter          *ratelimit.Limiter
+	PasswordResetLimiter *ratelimit.Limiter
+	AuthFailures         *ratelimit.FailureTracker
+	AuthAudit            *audit.Logger
+	KeyUsage             *keyusage.Tracker
+	Jobs                 *jobs.Queue
+	Backups              *backup.Scheduler
+	Events               *pubsub.Hub[event]
+	Metrics              *apiMetrics
+	MetricsToken         string
+	Tracer               *tracing.Tracer
+	CORS                 *corsPolicy
+	OAuthProviders       map[string]*oauth.Provider
+	TrustProxy           bool
+	GuestReadAccess      bool
+	NotesMaxPageSize     int
+	NotesMaxPinned       int
+	NotesMaxBatch        int
+	NoteMaxBytes         int
+	QuotaMaxNotes        int
+	QuotaMaxBytes        int
+	Attachments          storage.Storage
+	Mailer               mailer.Mailer
+	PublicBaseURL        string
+
+	RequireVerifiedEmail bool
 }
 
+// keyDenylistTTL is how long revoked keys are also held in memory, covering
+// any lookup path that could still see them as active.
+const keyDenylistTTL = 24 * time.Hour
+
+// userCacheTTL is how long authentication can use a cached key or user.
+// Handlers forget them as they change, so this only bounds what a missed
+// invalidation can cost.
+const userCacheTTL = 5 * time.Minute
+
+// Keys and users are also held in each server's memory, where changes made
+// through other servers can't reach them, for userCacheLocalTTL.
+const (
+	userCacheLocalTTL   = 30 * time.Second
+	userCacheLocalBytes = 4 << 20
+)
+
 //go:embed static/*
 var staticFiles embed.FS
 
 func main() {
+	migrateCommand := flag.String("migrate", "", "run database migrations and exit: up, down or status")
+	seedCommand := flag.Bool("seed", false, "fill the database with sample data and exit")
+	seedUsers := flag.Int("seed-users", 5, "number of users to create with -seed")
+	seedNotes := flag.Int("seed-notes", 3000, "number of notes to create with -seed, shared between the users")
+	configFile := flag.String("config", "", "YAML file of settings the environment doesn't set (default config.yaml, if it exists)")
+	flag.Parse()
+
 	err := godotenv.Load(".env")
 	if err != nil {
 		log.Printf("warning: assuming default configuration. .env unreadable: %v", err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		log.Fatal("PORT environment variable is not set")
+	required := "PORT"
+	if *migrateCommand != "" || *seedCommand {
+		required = "DATABASE_URL"
+	}
+	cfg, err := loadConfig(*configFile, required)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	if *migrateCommand != "" {
+		runMigrateCommand(cfg.Database, *migrateCommand)
+		return
+	}
+	if *seedCommand {
+		runSeedCommand(cfg.Database, *seedUsers, *seedNotes)
+		return
+	}
+
+	srv := newHTTPServer(":"+cfg.Port, cfg.HTTP)
+
+	apiCfg := apiConfig{
+		KeyRotationGrace: cfg.APIKeyRotationGrace,
+		JWTSecret:        cfg.JWTSecret,
+		TrustProxy:       cfg.TrustProxy,
+		GuestReadAccess:  cfg.GuestReadAccess,
+		NotesMaxPageSize: cfg.NotesMaxPageSize,
+		NotesMaxPinned:   cfg.NotesMaxPinned,
+		NotesMaxBatch:    cfg.NotesMaxBatch,
+		NoteMaxBytes:     cfg.NoteMaxBytes,
+		PublicBaseURL:    cfg.PublicBaseURL,
+		Metrics:          newAPIMetrics(),
+		MetricsToken:     cfg.MetricsToken,
+		CORS:             newCORSPolicy(cfg.CORS),
+
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+		QuotaMaxNotes:        cfg.QuotaMaxNotes,
+		QuotaMaxBytes:        cfg.QuotaMaxBytes,
+	}
+
+	if cfg.RateLimitRPS > 0 {
+		apiCfg.RateLimiter = ratelimit.New(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	} else {
+		log.Println("Running without rate limiting")
+	}
+
+	if cfg.PasswordResetsPerHour > 0 {
+		apiCfg.PasswordResetLimiter = ratelimit.New(float64(cfg.PasswordResetsPerHour)/3600, cfg.PasswordResetsPerHour)
+	} else {
+		log.Println("Running without password reset rate limiting")
+	}
+
+	if cfg.AuthFailureThreshold > 0 {
+		apiCfg.AuthFailures = ratelimit.NewFailureTracker(cfg.AuthFailureThreshold, cfg.AuthFailureWindow, cfg.AuthBlockDuration)
+	} else {
+		log.Println("Running without brute-force protection")
+	}
+
+	apiCfg.OAuthProviders = map[string]*oauth.Provider{}
+	if baseURL := cfg.OAuthRedirectBaseURL; baseURL != "" {
+		callback := func(provider string) string {
+			return baseURL + "/v1/oauth/" + provider + "/callback"
+		}
+		if github := cfg.OAuthGitHub; github.ID != "" {
+			apiCfg.OAuthProviders["github"] = oauth.GitHub(github.ID, github.Secret, callback("github"))
+		}
+		if google := cfg.OAuthGoogle; google.ID != "" {
+			apiCfg.OAuthProviders["google"] = oauth.Google(google.ID, google.Secret, callback("google"))
+		}
+	}
+
+	if cfg.S3.Bucket != "" {
+		apiCfg.Attachments, err = storage.NewS3(cfg.S3)
+		if err != nil {
+			log.Fatalf("Couldn't configure attachment storage: %v", err)
+		}
+	}
+
+	apiCfg.Tracer, err = openTracer(cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Couldn't configure tracing: %v", err)
 	}
 
-	apiCfg := apiConfig{}
+	if apiCfg.PublicBaseURL == "" {
+		apiCfg.PublicBaseURL = "http://localhost:" + cfg.Port
+	}
+	apiCfg.Mailer = mailer.LogMailer{}
+	if cfg.SMTP.Host != "" {
+		apiCfg.Mailer, err = mailer.NewSMTP(cfg.SMTP)
+		if err != nil {
+			log.Fatalf("Couldn't configure mailer: %v", err)
+		}
+	} else {
+		log.Println("SMTP_HOST is not set, logging emails instead of sending them")
+	}
 
 	// https://github.com/libsql/libsql-client-go/#open-a-connection-to-sqld
 	// libsql://[your-database].turso.io?authToken=[your-auth-token]
-	dbURL := os.Getenv("DATABASE_URL")
+	// or postgres://[user]:[password]@[host]:[port]/[database]
+	// or mysql://[user]:[password]@[host]:[port]/[database]
+	// or memory: for an empty in-memory database
+	dbURL := cfg.Database.URL
 	if dbURL == "" {
 		log.Println("DATABASE_URL environment variable is not set")
 		log.Println("Running without CRUD endpoints")
 	} else {
-		db, err := sql.Open("libsql", dbURL)
+		dbQueries, err := openStore(context.Background(), cfg.Database)
 		if err != nil {
 			log.Fatal(err)
 		}
-		dbQueries := database.New(db)
+		apiCfg.Cache, err = openCache(context.Background(), cfg.Cache)
+		if err != nil {
+			log.Fatalf("Couldn't connect to the cache: %v", err)
+		}
+		apiCfg.Users = newUserCache(dbQueries, apiCfg.Cache, cfg.Cache)
+		hashed, err := auth.HashLegacyAPIKeys(context.Background(), dbQueries)
+		if err != nil {
+			log.Fatalf("Couldn't hash legacy api keys: %v", err)
+		}
+		if hashed > 0 {
+			log.Printf("Hashed %d legacy api keys", hashed)
+		}
+		backfilled, err := contenthash.Backfill(context.Background(), dbQueries)
+		if err != nil {
+			log.Fatalf("Couldn't backfill note content hashes: %v", err)
+		}
+		if backfilled > 0 {
+			log.Printf("Backfilled content hashes for %d notes", backfilled)
+		}
+		counted, err := backfillWordCounts(context.Background(), dbQueries)
+		if err != nil {
+			log.Fatalf("Couldn't backfill note word counts: %v", err)
+		}
+		if counted > 0 {
+			log.Printf("Backfilled word counts for %d notes", counted)
+		}
+		if adminID := cfg.BootstrapAdminUserID; adminID != "" {
+			promoted, err := bootstrapAdmin(context.Background(), dbQueries, adminID)
+			if errors.Is(err, errBootstrapUserNotFound) {
+				log.Printf("BOOTSTRAP_ADMIN_USER_ID %s does not match a user yet", adminID)
+			} else if err != nil {
+				log.Fatalf("Couldn't bootstrap admin: %v", err)
+			}
+			if promoted {
+				apiCfg.Users.ForgetUser(context.Background(), adminID)
+				log.Printf("Promoted user %s to admin", adminID)
+			}
+		}
+		if sqlStore, ok := dbQueries.(*database.SQLStore); ok {
+			sqlStore.SetQueryObserver(apiCfg.Metrics.observeQuery)
+			apiCfg.Metrics.watchDB(sqlStore.DB())
+			sqlStore.SetTracing(apiCfg.Tracer != nil)
+		}
 		apiCfg.DB = dbQueries
+		apiCfg.AuthAudit = audit.NewLogger(dbQueries, 1024)
+		apiCfg.KeyUsage = keyusage.NewTracker(dbQueries, cfg.APIKeyUsageFlushInterval)
+		apiCfg.Jobs = apiCfg.newJobQueue(cfg)
+		apiCfg.Jobs.Start()
+		apiCfg.Events = pubsub.NewHub[event](eventBuffer, eventHistory)
+		// Shutdown doesn't wait for hijacked WebSocket connections, and
+		// would wait out its timeout for event streams, so end them both
+		// as it starts.
+		srv.RegisterOnShutdown(apiCfg.Events.Close)
+		if cfg.Backup.Dir != "" {
+			apiCfg.Backups, err = openBackups(dbQueries, cfg.Backup, apiCfg.Attachments)
+			if err != nil {
+				log.Fatalf("Couldn't configure backups: %v", err)
+			}
+		}
+		apiCfg.APIKeyAuth = auth.APIKeyAuthenticator{
+			Store:    apiCfg.Users,
+			Sources:  cfg.APIKeySources,
+			Denylist: auth.NewDenylist(keyDenylistTTL),
+		}
+		authenticators := auth.SchemeAuthenticator{
+			"ApiKey": apiCfg.APIKeyAuth,
+		}
+		if apiCfg.JWTSecret != "" {
+			authenticators["Bearer"] = auth.JWTAuthenticator{Store: apiCfg.Users, Secret: apiCfg.JWTSecret}
+		} else {
+			log.Println("JWT_SECRET environment variable is not set")
+			log.Println("Running without token endpoints")
+		}
+		chain := auth.ChainAuthenticator{auth.CertAuthenticator{Store: dbQueries}, authenticators}
+		if cfg.APIKeySources != (auth.APIKeySources{}) {
+			// Picks up X-API-Key or ?api_key= when there is no Authorization header.
+			chain = append(chain, apiCfg.APIKeyAuth)
+		}
+		apiCfg.Authenticator = append(chain, auth.SessionAuthenticator{Store: dbQueries})
 		log.Println("Connected to database!")
 	}
 
 	router := chi.NewRouter()
 
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
-
-	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		f, err := staticFiles.Open("static/index.html")
+	router.Use(middlewareRequestID)
+	if apiCfg.Tracer != nil {
+		router.Use(middlewareTracing(apiCfg.Tracer))
+	}
+	router.Use(apiCfg.Metrics.middleware)
+	router.Use(apiCfg.CORS.middleware)
+	router.Use(middlewareNegotiate)
+	router.Use(middlewareRecover)
+	// Note, import and avatar uploads have limits of their own.
+	router.Use(middlewareMaxBody(int64(cfg.HTTP.MaxBodyBytes)))
+
+	if cfg.LenientAuthScheme {
+		router.Use(middlewareLenientAuthorization)
+	}
+	if cfg.Database.ReplicaURL != "" {
+		router.Use(middlewareTrackWrites)
+	}
+
+	router.Get("/", serveStaticFile("static/index.html"))
+	router.Get("/docs", serveStaticFile("static/docs.html"))
+
+	router.Get("/metrics", apiCfg.handlerMetrics)
+
+	if apiCfg.DB != nil {
+		router.Get("/share/{token}", apiCfg.middlewareOptionalAuth(apiCfg.handlerShareLinkGet))
+	}
+	apiversion.Mount(router, apiVersions, apiCfg.apiRoutes, respondWithBadVersionedBody)
+	srv.Handler = router
+
+	srv.TLSConfig, err = serverTLSConfig(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Couldn't configure TLS: %v", err)
+	}
+
+	log.Printf("Serving on port: %s\n", cfg.Port)
+	apiCfg.serve(srv, cfg.ShutdownTimeout, func() { apiCfg.reload(*configFile) })
+}
+
+// newHTTPServer bounds how long a client can hold a connection, so slow or
+// idle clients can't tie up connections and goroutines indefinitely.
+// HTTP_READ_HEADER_TIMEOUT (5s) and HTTP_READ_TIMEOUT (10s) limit reading
+// the headers and the whole request, HTTP_WRITE_TIMEOUT (10s) limits the
+// request from the end of its headers to the end of the response, and
+// HTTP_IDLE_TIMEOUT (60s) limits a kept-alive connection between requests.
+// Exports lift the write timeout themselves, since they can run long.
+func newHTTPServer(addr string, cfg HTTPConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serverTLSConfig configures HTTPS, or returns nil to serve plain HTTP.
+// TLS_CERT_FILE and TLS_KEY_FILE serve a certificate from files. Instead,
+// TLS_AUTOCERT_DOMAINS, a comma-separated list of names, gets certificates
+// for them from Let's Encrypt, or the ACME CA at TLS_AUTOCERT_DIRECTORY_URL,
+// and renews them. They are cached in TLS_AUTOCERT_CACHE_DIR ("certs" by
+// default), and TLS_AUTOCERT_EMAIL is given to the CA for expiry notices.
+// The CA checks each name by connecting to it on port 443, so that has to
+// reach PORT. TLS_CLIENT_CA_FILE adds client certificates to either.
+func serverTLSConfig(settings TLSConfig) (*tls.Config, error) {
+	if settings.CertFile == "" && len(settings.Autocert.Domains) == 0 {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if settings.ClientCAFile != "" {
+		var err error
+		cfg, err = clientCertTLSConfig(settings.ClientCAFile, settings.RequireClientCert)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("couldn't load client CAs: %w", err)
 		}
-		defer f.Close()
-		if _, err := io.Copy(w, f); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	if settings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, err
 		}
-	})
+		cfg.Certificates = []tls.Certificate{cert}
+		return cfg, nil
+	}
 
-	v1Router := chi.NewRouter()
+	manager, err := acmecert.NewManager(settings.Autocert)
+	if err != nil {
+		return nil, err
+	}
+	manager.Configure(cfg)
+	log.Printf("Getting TLS certificates for %s", strings.Join(settings.Autocert.Domains, ", "))
+	return cfg, nil
+}
 
-	if apiCfg.DB != nil {
-		v1Router.Post("/users", apiCfg.handlerUsersCreate)
-		v1Router.Get("/users", apiCfg.middlewareAuth(apiCfg.handlerUsersGet))
-		v1Router.Get("/notes", apiCfg.middlewareAuth(apiCfg.handlerNotesGet))
-		v1Router.Post("/notes", apiCfg.middlewareAuth(apiCfg.handlerNotesCreate))
+// clientCertTLSConfig verifies client certificates against the CAs in
+// caFile. Unless require is set, clients may still connect without a
+// certificate and authenticate another way.
+func clientCertTLSConfig(caFile string, require bool) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile) // #nosec G304 -- path comes from operator config
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + caFile)
 	}
 
-	v1Router.Get("/healthz", handlerReadiness)
+	clientAuth := tls.VerifyClientCertIfGiven
+	if require {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// memoryDatabaseURL is the DATABASE_URL for an in-memory database that
+// starts empty on every run.
+const memoryDatabaseURL = "memory:"
 
-	router.Mount("/v1", v1Router)
-	srv := &http.Server{
-		Addr:              ":" + port,
-		Handler:           router,
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      10 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
+// openStore connects to the database behind dbURL and brings its schema up
+// to date, unless SKIP_MIGRATIONS is set because they're run separately
+// with -migrate up. Either way, it fails unless the schema then matches
+// the binary's migrations exactly.
+//
+// Each query is limited to DB_QUERY_TIMEOUT, 0 for no limit. SQLite
+// doesn't interrupt a query waiting on a lock, so that wait is bounded by
+// SQLITE_BUSY_TIMEOUT instead, which should be the shorter of the two.
+//
+// With DATABASE_REPLICA_URL set, reads are served from that replica and
+// writes go to DATABASE_URL. Point it at a libSQL server replicating the
+// primary, such as a sqld replica running next to the API, which keeps
+// itself in sync.
+//
+// DB_PREPARED_STATEMENTS keeps queries prepared for reuse across requests.
+func openStore(ctx context.Context, cfg DatabaseConfig) (database.Store, error) {
+	dbURL := cfg.URL
+	if dbURL == memoryDatabaseURL {
+		log.Println("Using an in-memory database, data will be lost on exit")
+		return memstore.New(), nil
+	}
+	db, err := openDB(dbURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.SkipMigrations {
+		if err := migrateUp(ctx, db, dbURL); err != nil {
+			return nil, fmt.Errorf("couldn't migrate: %w", err)
+		}
+	}
+	if err := checkSchema(ctx, db, dbURL); err != nil {
+		return nil, err
+	}
+	store := database.NewStore(db)
+	if sqlite.IsFileURL(dbURL) {
+		store = database.NewSQLiteStore(db)
 	}
+	if cfg.ReplicaURL != "" {
+		replica, err := openDB(cfg.ReplicaURL, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't open replica: %w", err)
+		}
+		store.SetReplica(replica)
+		log.Println("Reading from the database replica")
+	}
+	if cfg.PreparedStatements {
+		if err := store.PrepareStatements(ctx); err != nil {
+			return nil, fmt.Errorf("couldn't prepare statements: %w", err)
+		}
+	}
+	store.SetQueryTimeout(cfg.QueryTimeout)
+	return store, nil
+}
 
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+// openBackups backs up the local SQLite database into dir every
+// BACKUP_INTERVAL, keeping the newest BACKUP_KEEP. With BACKUP_UPLOAD set,
+// each backup is also put in the attachment storage bucket.
+func openBackups(store database.Store, cfg BackupConfig, attachments storage.Storage) (*backup.Scheduler, error) {
+	sqlStore, ok := store.(*database.SQLStore)
+	if !ok {
+		return nil, errors.New("BACKUP_DIR needs a local SQLite DATABASE_URL")
+	}
+	backupCfg := backup.Config{Dir: cfg.Dir, Keep: cfg.Keep}
+	if cfg.Upload {
+		backupCfg.Upload = attachments
+	}
+	return backup.NewScheduler(sqlStore.DB(), backupCfg, cfg.Interval)
+}
+
+// openCache connects to Redis when REDIS_URL is set, checking it answers so
+// a typo fails at startup rather than on every request. Otherwise it keeps
+// up to CACHE_MAX_BYTES in memory, which only this server sees.
+func openCache(ctx context.Context, cfg CacheConfig) (cache.Cache, error) {
+	if cfg.RedisURL == "" {
+		return cache.NewLRU(cfg.MaxBytes), nil
+	}
+	redis, err := cache.NewRedis(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := redis.Ping(ctx); err != nil {
+		redis.Close()
+		return nil, err
+	}
+	log.Println("Caching in Redis")
+	return redis, nil
+}
+
+// newUserCache caches the keys and users that authenticate requests in
+// memory for a short while, in front of Redis when there is one. Without
+// Redis the memory is all there is, so that short while is all they get.
+func newUserCache(store auth.UserStore, shared cache.Cache, cfg CacheConfig) *auth.UserCache {
+	if cfg.RedisURL == "" {
+		return auth.NewUserCache(store, shared, userCacheLocalTTL)
+	}
+	local := cache.NewTiered(cache.NewLRU(userCacheLocalBytes), shared, userCacheLocalTTL)
+	return auth.NewUserCache(store, local, userCacheTTL)
+}
+
+// openDB connects to PostgreSQL for postgres:// URLs, to MySQL for mysql://
+// URLs and to SQLite or Turso through libsql for anything else. Local SQLite
+// files get WAL and a busy timeout of SQLITE_BUSY_TIMEOUT. DB_MAX_OPEN_CONNS
+// (0 for no limit), DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME (0 to keep
+// connections forever) size the pool.
+func openDB(dbURL string, cfg DatabaseConfig) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+	switch {
+	case postgres.IsURL(dbURL):
+		db, err = postgres.Open(dbURL)
+	case mysql.IsURL(dbURL):
+		db, err = mysql.Open(dbURL)
+	case sqlite.IsFileURL(dbURL):
+		db, err = sqlite.Open("libsql", dbURL, cfg.SQLiteBusyTimeout)
+	default:
+		db, err = sql.Open("libsql", dbURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return db, nil
+}
+
+// serveStaticFile serves the embedded file name.
+func serveStaticFile(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := staticFiles.Open(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		if _, err := io.Copy(w, f); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/metrics"
+	"github.com/go-chi/chi"
+)
+
+// apiMetrics are the metrics served on /metrics.
+type apiMetrics struct {
+	registry *metrics.Registry
+
+	inFlight        atomic.Int64
+	requests        *metrics.CounterVec
+	requestDuration *metrics.HistogramVec
+	queryDuration   *metrics.HistogramVec
+	queryErrors     *metrics.CounterVec
+	authFailures    *metrics.CounterVec
+}
+
+func newAPIMetrics() *apiMetrics {
+	r := metrics.NewRegistry()
+	m := &apiMetrics{
+		registry:        r,
+		requests:        r.NewCounterVec("http_requests_total", "HTTP requests served, by route and status.", "method", "route", "status"),
+		requestDuration: r.NewHistogramVec("http_request_duration_seconds", "HTTP request latency, by route and status.", metrics.DefaultBuckets, "method", "route", "status"),
+		queryDuration:   r.NewHistogramVec("db_query_duration_seconds", "Database query latency, by sqlc query name.", metrics.DefaultBuckets, "query"),
+		queryErrors:     r.NewCounterVec("db_query_errors_total", "Database queries that failed, by sqlc query name.", "query"),
+		authFailures:    r.NewCounterVec("auth_failures_total", "Rejected authentication attempts, by reason.", "reason"),
+	}
+	r.NewGaugeFunc("http_requests_in_flight", "HTTP requests being served.", func() float64 {
+		return float64(m.inFlight.Load())
+	})
+	return m
+}
+
+// handlerMetrics serves the metrics for Prometheus to scrape. With
+// METRICS_TOKEN set, scrapes must send it as a bearer token.
+func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
+	if cfg.MetricsToken != "" {
+		want := []byte("Bearer " + cfg.MetricsToken)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "Invalid metrics token", nil)
+			return
+		}
+	}
+	cfg.Metrics.registry.ServeHTTP(w, r)
+}
+
+// watchDB adds gauges for db's connection pool.
+func (m *apiMetrics) watchDB(db *sql.DB) {
+	m.registry.NewGaugeFunc("db_open_connections", "Open database connections, in use or idle.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	m.registry.NewGaugeFunc("db_in_use_connections", "Database connections in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	m.registry.NewGaugeFunc("db_idle_connections", "Idle database connections.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	m.registry.NewGaugeFunc("db_wait_count", "Times a query waited for a free database connection.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+}
+
+// observeQuery is the database.QueryObserver. A missing row isn't counted
+// as an error; handlers use it to answer 404.
+func (m *apiMetrics) observeQuery(name string, took time.Duration, err error) {
+	m.queryDuration.Observe(took.Seconds(), name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.queryErrors.Inc(name)
+	}
+}
+
+func (m *apiMetrics) authFailure(reason string) {
+	m.authFailures.Inc(reason)
+}
+
+// middleware counts and times each request by its route pattern, such as
+// /v1/notes/{noteID}, so IDs in paths don't create a series each.
+// Requests that match no route are counted as "unmatched".
+func (m *apiMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := routePattern(r)
+		status := strconv.Itoa(rec.status)
+		m.requests.Inc(r.Method, route, status)
+		m.requestDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
+
+// routePattern returns the pattern of the route r matched, or "unmatched".
+// It is only set once the router has handled r.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return "unmatched"
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = code, true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, for
+// flushing and write deadlines on streamed responses.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Hijack hands the connection over to WebSocket handlers, which find it by
+// type assertion rather than through http.ResponseController.
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rec.ResponseWriter).Hijack()
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+func (cfg *apiConfig) middlewareAdmin(handler authedHandler) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if user.Role != roleAdmin {
+			respondWithError(w, http.StatusForbidden, "Admin access required", nil)
+			return
+		}
+		handler(w, r, user)
+	})
+}
@@ -1,28 +1,208 @@
 package main
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/audit"
 	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/tracing"
 )
 
 type authedHandler func(http.ResponseWriter, *http.Request, database.User)
 
+// optionalAuthedHandler receives a nil user for guest requests that carried
+// no credentials.
+type optionalAuthedHandler func(http.ResponseWriter, *http.Request, *database.User)
+
 func (cfg *apiConfig) middlewareAuth(handler authedHandler) http.HandlerFunc {
+	return cfg.middlewareAuthWith(cfg.Authenticator, handler)
+}
+
+func (cfg *apiConfig) middlewareAuthWith(authenticator auth.Authenticator, handler authedHandler) http.HandlerFunc {
+	return cfg.authenticate(authenticator, false, func(w http.ResponseWriter, r *http.Request, user *database.User) {
+		handler(w, r, *user)
+	})
+}
+
+// middlewareOptionalAuth lets requests without any credentials through as
+// guests. Credentials that are present are still checked, so a bad key is
+// rejected rather than silently downgraded to guest access.
+func (cfg *apiConfig) middlewareOptionalAuth(handler optionalAuthedHandler) http.HandlerFunc {
+	return cfg.authenticate(cfg.Authenticator, true, handler)
+}
+
+// middlewareReadAuth guards read-only routes for shared content: guests are
+// allowed when GUEST_READ_ACCESS is on, otherwise authentication is required.
+func (cfg *apiConfig) middlewareReadAuth(handler optionalAuthedHandler) http.HandlerFunc {
+	if cfg.GuestReadAccess {
+		return cfg.middlewareOptionalAuth(handler)
+	}
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		handler(w, r, &user)
+	})
+}
+
+func (cfg *apiConfig) authenticate(authenticator auth.Authenticator, optional bool, handler optionalAuthedHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		apiKey, err := auth.GetAPIKey(r.Header)
+		// A malformed or conflicting credential is reported by the
+		// authenticator below; the partial context is still good for the
+		// client IP and audit fields.
+		ac, _ := auth.NewAuthContext(r, cfg.APIKeyAuth.Sources, cfg.TrustProxy)
+		r = r.WithContext(auth.WithAuthContext(r.Context(), ac))
+
+		// Authentication and the handler get a span each, side by side
+		// under the request's, so a slow request shows which was slow.
+		parent := r.Context()
+		ctx, span := tracing.Start(parent, "auth")
+		defer span.End()
+		r = r.WithContext(ctx)
+		serve := func(user *database.User) {
+			span.End()
+			ctx, handlerSpan := tracing.Start(parent, "handler")
+			defer handlerSpan.End()
+			if user != nil {
+				handlerSpan.SetAttribute("enduser.id", user.ID)
+			}
+			handler(w, r.WithContext(ctx), user)
+		}
+
+		if cfg.AuthFailures != nil {
+			if blocked, wait := cfg.AuthFailures.Blocked(ac.ClientIP); blocked {
+				msg := "Too many failed authentication attempts"
+				cfg.recordAuthAttempt(ac, database.User{}, msg)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				respondWithError(w, http.StatusTooManyRequests, msg, nil)
+				return
+			}
+		}
+
+		user, err := authenticator.Authenticate(r)
+		if optional && errors.Is(err, auth.ErrNoAuthHeaderIncluded) {
+			if !cfg.allowRequest(w, ac, database.User{}) {
+				return
+			}
+			serve(nil)
+			return
+		}
+		if cfg.AuthFailures != nil && (errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, auth.ErrInvalidToken)) {
+			cfg.AuthFailures.RecordFailure(ac.ClientIP)
+		}
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Couldn't find api key", err)
+			span.SetError(err)
+			code, msg := authErrorResponse(err)
+			cfg.recordAuthAttempt(ac, database.User{}, msg)
+			respondWithError(w, code, msg, err)
+			return
+		}
+		if user.SuspendedAt.Valid {
+			cfg.recordAuthAttempt(ac, user, accountSuspendedMessage)
+			rejectSuspended(w)
 			return
 		}
+		cfg.recordAuthAttempt(ac, user, "")
+		cfg.recordKeyUsage(ac)
 
-		user, err := cfg.DB.GetUser(r.Context(), apiKey)
-		if err != nil {
-			respondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		if !cfg.allowRequest(w, ac, user) {
 			return
 		}
 
-		handler(w, r, user)
+		serve(&user)
+	}
+}
+
+// middlewareLenientAuthorization accepts scheme case variations and stray
+// whitespace in the Authorization header by normalizing it before any
+// handler parses it.
+func middlewareLenientAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.NormalizeAuthorization(r.Header, "ApiKey", "Bearer")
+		next.ServeHTTP(w, r)
+	})
+}
+
+const (
+	errCodeAccountSuspended = "account_suspended"
+	accountSuspendedMessage = "Account is suspended"
+)
+
+// rejectSuspended answers a request from a suspended account. The account
+// and its data are kept, so the error has its own code for clients to tell
+// it apart from other permission errors.
+func rejectSuspended(w http.ResponseWriter) {
+	respondWithErrorCode(w, http.StatusForbidden, errCodeAccountSuspended, accountSuspendedMessage, nil)
+}
+
+func authErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, auth.ErrMalformedAuthHeader):
+		return http.StatusBadRequest, "Malformed authorization header"
+	case errors.Is(err, auth.ErrConflictingAPIKeys):
+		return http.StatusBadRequest, "Conflicting api keys"
+	case errors.Is(err, auth.ErrAPIKeyExpired):
+		return http.StatusUnauthorized, "API key expired"
+	case errors.Is(err, auth.ErrIPNotAllowed):
+		return http.StatusForbidden, "IP address not allowed for this api key"
+	case errors.Is(err, auth.ErrSessionExpired):
+		return http.StatusUnauthorized, "Session expired"
+	case errors.Is(err, auth.ErrInvalidToken):
+		return http.StatusUnauthorized, "Invalid token"
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return http.StatusNotFound, "Couldn't get user"
+	default:
+		return http.StatusUnauthorized, "Couldn't find api key"
+	}
+}
+
+// recordAuthAttempt writes an attempt to the audit log and counts failures
+// in the metrics. An empty reason means the attempt succeeded.
+func (cfg *apiConfig) recordAuthAttempt(ac auth.AuthContext, user database.User, reason string) {
+	if reason != "" && cfg.Metrics != nil {
+		cfg.Metrics.authFailure(reason)
+	}
+	if cfg.AuthAudit == nil {
+		return
+	}
+	cfg.AuthAudit.Record(audit.Attempt{
+		At:               time.Now(),
+		UserID:           user.ID,
+		CredentialPrefix: credentialPrefix(ac),
+		IP:               ac.ClientIP,
+		UserAgent:        ac.UserAgent,
+		Success:          reason == "",
+		Reason:           reason,
+	})
+}
+
+// recordKeyUsage notes the API key on a successfully authenticated request
+// as used. The write happens in the next batch flush.
+func (cfg *apiConfig) recordKeyUsage(ac auth.AuthContext) {
+	if cfg.KeyUsage == nil || ac.Scheme != auth.SchemeAPIKey {
+		return
+	}
+	cfg.KeyUsage.Touch(auth.HashAPIKey(ac.Credential), ac.ClientIP, time.Now())
+}
+
+// credentialPrefix identifies the credential on a request without logging
+// the secret: the display prefix of an API key, or the kind of credential
+// for tokens, sessions and passwords.
+func credentialPrefix(ac auth.AuthContext) string {
+	switch ac.Scheme {
+	case auth.SchemeAPIKey:
+		return auth.DisplayPrefix(ac.Credential)
+	case auth.SchemeBearer:
+		return "bearer"
+	case auth.SchemeSession:
+		return "session"
+	case auth.SchemeClientCert:
+		return "cert:" + ac.Credential
+	case auth.SchemePassword:
+		return "password"
+	default:
+		return ""
 	}
 }
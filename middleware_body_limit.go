@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// errCodeBodyTooLarge is the error code sent when a request body runs past
+// MAX_BODY_BYTES.
+const errCodeBodyTooLarge = "body_too_large"
+
+type rawBodyKey struct{}
+
+// middlewareMaxBody caps every request body at limit bytes, so no handler
+// can be made to read an oversized body into memory. Reads past the limit
+// fail with an *http.MaxBytesError, which respondWithErrorCode turns into a
+// 413. Routes that take bodies of their own size use limitBody instead.
+func middlewareMaxBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(context.WithValue(r.Context(), rawBodyKey{}, r.Body))
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitBody caps r's body at limit bytes in place of MAX_BODY_BYTES, which
+// would otherwise still apply to a larger limit. Call it before anything
+// reads the body.
+func limitBody(w http.ResponseWriter, r *http.Request, limit int64) {
+	body := r.Body
+	if raw, ok := r.Context().Value(rawBodyKey{}).(io.ReadCloser); ok {
+		body = raw
+	}
+	r.Body = http.MaxBytesReader(w, body, limit)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+const (
+	idempotencyKeyHeader   = "Idempotency-Key"
+	idempotencyReplayed    = "Idempotent-Replayed"
+	idempotencyKeyTTL      = 24 * time.Hour
+	idempotencyKeyMaxBytes = 255
+)
+
+// middlewareIdempotency lets clients safely retry a request by sending an
+// Idempotency-Key header. The first response for a key is stored for 24
+// hours and replayed for retries with the same key and body, so a retry
+// after a dropped connection doesn't repeat the side effect. Server errors
+// aren't stored, so those can be retried for real. It runs inside
+// middlewareAuth; keys are scoped to the user.
+func (cfg *apiConfig) middlewareIdempotency(handler authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			handler(w, r, user)
+			return
+		}
+		if len(key) > idempotencyKeyMaxBytes {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long", nil)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't read request", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		now := time.Now().UTC()
+		err = cfg.DB.DeleteExpiredIdempotencyKeys(r.Context(), now.Add(-idempotencyKeyTTL).Format(time.RFC3339))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't expire idempotency keys", err)
+			return
+		}
+
+		created, err := cfg.DB.CreateIdempotencyKey(r.Context(), database.CreateIdempotencyKeyParams{
+			UserID:      user.ID,
+			Key:         key,
+			CreatedAt:   now.Format(time.RFC3339),
+			RequestHash: requestHash,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't store idempotency key", err)
+			return
+		}
+		if created == 0 {
+			cfg.replayIdempotentResponse(w, r, user, key, requestHash)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		saved := false
+		// The response is already on its way to the client, so storing it
+		// must not be cut short by the client going away.
+		ctx := context.WithoutCancel(r.Context())
+		defer func() {
+			if saved {
+				return
+			}
+			err := cfg.DB.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{
+				UserID: user.ID,
+				Key:    key,
+			})
+			if err != nil {
+				logf(ctx, "Couldn't release idempotency key: %v", err)
+			}
+		}()
+
+		handler(rec, r, user)
+		rec.send(w)
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		err = cfg.DB.SaveIdempotencyKeyResponse(ctx, database.SaveIdempotencyKeyResponseParams{
+			StatusCode:   sql.NullInt64{Int64: int64(rec.status), Valid: true},
+			ResponseBody: sql.NullString{String: rec.body.String(), Valid: true},
+			UserID:       user.ID,
+			Key:          key,
+		})
+		if err != nil {
+			logf(ctx, "Couldn't save idempotent response: %v", err)
+			return
+		}
+		saved = true
+	}
+}
+
+func (cfg *apiConfig) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, user database.User, key, requestHash string) {
+	stored, err := cfg.DB.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+		UserID: user.ID,
+		Key:    key,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get idempotency key", err)
+		return
+	}
+	if stored.RequestHash != requestHash {
+		respondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", nil)
+		return
+	}
+	if !stored.StatusCode.Valid {
+		respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress", nil)
+		return
+	}
+
+	w.Header().Set(idempotencyReplayed, "true")
+	writeEncoded(w, int(stored.StatusCode.Int64), false, []byte(stored.ResponseBody.String))
+}
+
+// responseRecorder holds on to a response for the middleware to store and
+// then send. It doesn't unwrap, so the handler writes JSON, and the stored
+// response can be sent again in whatever format a retry asks for.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+// send writes the recorded response to w in the format negotiated for the
+// request.
+func (rec *responseRecorder) send(w http.ResponseWriter) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	problem := w.Header().Get("Content-Type") == "application/problem+json"
+	writeEncoded(w, rec.status, problem, rec.body.Bytes())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// passwordLoginRequest is the body of middlewarePasswordAuth requests.
+type passwordLoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// middlewarePasswordAuth authenticates a JSON body of email and password
+// instead of a credential header, for people signing in by hand. Failures
+// count towards the same per-IP block and audit log as other credentials.
+func (cfg *apiConfig) middlewarePasswordAuth(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ac, _ := auth.NewAuthContext(r, cfg.APIKeyAuth.Sources, cfg.TrustProxy)
+		ac.Scheme, ac.Credential = auth.SchemePassword, ""
+		r = r.WithContext(auth.WithAuthContext(r.Context(), ac))
+
+		if cfg.AuthFailures != nil {
+			if blocked, wait := cfg.AuthFailures.Blocked(ac.ClientIP); blocked {
+				msg := "Too many failed authentication attempts"
+				cfg.recordAuthAttempt(ac, database.User{}, msg)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				respondWithError(w, http.StatusTooManyRequests, msg, nil)
+				return
+			}
+		}
+
+		v := &validate.Validator{}
+		params := passwordLoginRequest{}
+		err := v.Decode(r.Body, &params)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+			return
+		}
+		if errs := v.Errors(); errs != nil {
+			respondWithInvalidFields(w, errs)
+			return
+		}
+
+		// Unknown emails still pay for a hash comparison so response times
+		// don't reveal which accounts exist.
+		var user database.User
+		if email, err := normalizeEmail(params.Email); err == nil {
+			user, _ = cfg.DB.GetUserByEmail(r.Context(), sql.NullString{String: email, Valid: true})
+		}
+		if !auth.CheckPassword(params.Password, user.PasswordHash.String) {
+			msg := "Invalid email or password"
+			if cfg.AuthFailures != nil {
+				cfg.AuthFailures.RecordFailure(ac.ClientIP)
+			}
+			cfg.recordAuthAttempt(ac, database.User{}, msg)
+			respondWithError(w, http.StatusUnauthorized, msg, nil)
+			return
+		}
+		if user.SuspendedAt.Valid {
+			cfg.recordAuthAttempt(ac, user, accountSuspendedMessage)
+			rejectSuspended(w)
+			return
+		}
+		cfg.recordAuthAttempt(ac, user, "")
+
+		if !cfg.allowRequest(w, ac, user) {
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
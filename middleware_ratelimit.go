@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// allowRequest applies the per-credential rate limit to a request, writing a 429 response and returning false when it is exceeded.
+func (cfg *apiConfig) allowRequest(w http.ResponseWriter, ac auth.AuthContext, user database.User) bool {
+	if cfg.RateLimiter == nil {
+		return true
+	}
+
+	ok, wait := cfg.RateLimiter.Allow(rateLimitKey(ac, user))
+	if ok {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded", nil)
+	return false
+}
+
+// rateLimitKey buckets requests by the API key they carry, falling back to
+// the user for JWT and session authentication and to the client IP for
+// guests.
+func rateLimitKey(ac auth.AuthContext, user database.User) string {
+	if ac.Scheme == auth.SchemeAPIKey {
+		return "key:" + auth.HashAPIKey(ac.Credential)
+	}
+	if user.ID == "" {
+		return "ip:" + ac.ClientIP
+	}
+	return "user:" + user.ID
+}
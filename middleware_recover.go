@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+)
+
+// errCodeInternal is the error code sent when a handler panics.
+const errCodeInternal = "internal_error"
+
+// middlewareRecover catches a panicking handler, logs the panic and its
+// stack with the request's ID, and answers with a JSON 500 carrying the
+// same ID. Without it net/http drops the connection and the panic is
+// logged with nothing tying it to the request.
+//
+// If the handler had already started its response, a clean error can't be
+// sent, so the response is aborted instead of being left to look complete.
+func middlewareRecover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			// Handlers panic with ErrAbortHandler on purpose to cut off a
+			// response; net/http handles it quietly.
+			if err, ok := rvr.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rvr)
+			}
+			logf(r.Context(), "Recovered from panic: %v\n%s", rvr, debug.Stack())
+			if rec.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			respondWithErrorCode(w, http.StatusInternalServerError, errCodeInternal, "Internal server error", nil)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+// middlewareTrackWrites sends a request's reads to the primary once it has
+// written, so a handler that creates a row and reads it back doesn't miss
+// it on a replica that hasn't caught up yet.
+func middlewareTrackWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(database.TrackWrites(r.Context())))
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// requestIDMaxBytes bounds IDs taken from clients, which end up in logs.
+	requestIDMaxBytes = 128
+)
+
+type requestIDKey struct{}
+
+// middlewareRequestID gives every request an ID, echoed back in the
+// X-Request-ID response header and written into its log lines and error
+// responses. A well-formed X-Request-ID sent by the client or a proxy in
+// front is kept, so the same ID can be followed through every hop.
+func middlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// validRequestID accepts IDs made of letters, digits and a little
+// punctuation, so a client can't inject anything into a log line.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > requestIDMaxBytes {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the ID middlewareRequestID gave the request ctx
+// belongs to, or "" outside a request.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// logf is log.Printf with the ID of the request ctx belongs to in front.
+func logf(ctx context.Context, format string, v ...any) {
+	log.Print(requestLogPrefix(requestID(ctx)) + fmt.Sprintf(format, v...))
+}
+
+func requestLogPrefix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "[" + id + "] "
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/totp"
+)
+
+const totpHeader = "X-TOTP-Code"
+
+// middlewareTOTP guards destructive actions and password sign-in: users who
+// have enabled two-factor authentication must send a current code in the
+// X-TOTP-Code header. It runs inside middlewareAuth or
+// middlewarePasswordAuth.
+func (cfg *apiConfig) middlewareTOTP(handler authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if !user.TotpEnabledAt.Valid {
+			handler(w, r, user)
+			return
+		}
+
+		code := r.Header.Get(totpHeader)
+		if code == "" {
+			respondWithError(w, http.StatusForbidden, "TOTP code required", nil)
+			return
+		}
+		ok, err := cfg.useTOTPCode(r.Context(), user, code)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't verify TOTP code", err)
+			return
+		}
+		if !ok {
+			if ac, found := auth.AuthContextFrom(r.Context()); found && cfg.AuthFailures != nil {
+				cfg.AuthFailures.RecordFailure(ac.ClientIP)
+			}
+			respondWithError(w, http.StatusUnauthorized, "Invalid TOTP code", nil)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// useTOTPCode checks code against the user's enabled secret and consumes its
+// time step, so the same code can't be replayed. The secret is loaded from
+// the database, since cached users don't carry it.
+func (cfg *apiConfig) useTOTPCode(ctx context.Context, user database.User, code string) (bool, error) {
+	user, err := cfg.DB.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	step, ok := totp.Validate(user.TotpSecret.String, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	updated, err := cfg.DB.UseUserTOTPStep(ctx, database.UseUserTOTPStepParams{
+		Step: step,
+		ID:   user.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/migrate"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mysql"
+	"github.com/bootdotdev/learn-cicd-starter/internal/postgres"
+)
+
+//go:embed sql/schema/*.sql sql/postgres/schema/*.sql sql/mysql/schema/*.sql
+var migrationFiles embed.FS
+
+// newMigrator picks the embedded migrations for the database behind dbURL.
+func newMigrator(db *sql.DB, dbURL string) (*migrate.Migrator, error) {
+	dir, dialect := "sql/schema", migrate.SQLite
+	switch {
+	case postgres.IsURL(dbURL):
+		dir, dialect = "sql/postgres/schema", migrate.Postgres
+	case mysql.IsURL(dbURL):
+		dir, dialect = "sql/mysql/schema", migrate.MySQL
+	}
+	fsys, err := fs.Sub(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, dialect, fsys)
+}
+
+// migrateUp applies pending migrations before the server starts.
+func migrateUp(ctx context.Context, db *sql.DB, dbURL string) error {
+	migrator, err := newMigrator(db, dbURL)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		log.Printf("Applied migration %s", m.Name)
+	}
+	return err
+}
+
+// checkSchema fails unless the database's schema is at exactly the version
+// the embedded migrations produce, so a mismatch stops the server at
+// startup instead of failing requests with SQL errors.
+func checkSchema(ctx context.Context, db *sql.DB, dbURL string) error {
+	migrator, err := newMigrator(db, dbURL)
+	if err != nil {
+		return err
+	}
+	return migrator.Check(ctx)
+}
+
+// runMigrateCommand handles the -migrate flag: "up" applies pending
+// migrations, "down" rolls back the latest one and "status" lists them all.
+// It exits instead of starting the server.
+func runMigrateCommand(cfg DatabaseConfig, command string) {
+	dbURL := cfg.URL
+	if dbURL == memoryDatabaseURL {
+		log.Fatal("An in-memory database has no migrations to run")
+	}
+	db, err := openDB(dbURL, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		if err := migrateUp(ctx, db, dbURL); err != nil {
+			log.Fatalf("Couldn't migrate: %v", err)
+		}
+	case "down":
+		migrator, err := newMigrator(db, dbURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m, err := migrator.Down(ctx)
+		if errors.Is(err, migrate.ErrNoMigrations) {
+			log.Println("No migrations to roll back")
+			return
+		}
+		if err != nil {
+			log.Fatalf("Couldn't roll back: %v", err)
+		}
+		log.Printf("Rolled back migration %s", m.Name)
+	case "status":
+		migrator, err := newMigrator(db, dbURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Couldn't get migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%-8s %s\n", state, s.Name)
+		}
+	default:
+		log.Fatalf("Unknown -migrate command %q: use up, down or status", command)
+	}
+}
@@ -1,17 +1,29 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
 	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/jobs"
+	"github.com/bootdotdev/learn-cicd-starter/internal/webhooks"
 )
 
 type User struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	ApiKey    string    `json:"api_key"`
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	ApiKey        string    `json:"api_key,omitempty"`
+	ApiKeyPrefix  string    `json:"api_key_prefix"`
+	Role          string    `json:"role"`
+	TOTPEnabled   bool      `json:"totp_enabled"`
+	HasPassword   bool      `json:"has_password"`
+	HasAvatar     bool      `json:"has_avatar"`
 }
 
 func databaseUserToUser(user database.User) (User, error) {
@@ -25,20 +37,54 @@ func databaseUserToUser(user database.User) (User, error) {
 		return User{}, err
 	}
 	return User{
-		ID:        user.ID,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		Name:      user.Name,
-		ApiKey:    user.ApiKey,
+		ID:            user.ID,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		Name:          user.Name,
+		Email:         user.Email.String,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		ApiKeyPrefix:  user.ApiKeyPrefix,
+		Role:          user.Role,
+		TOTPEnabled:   user.TotpEnabledAt.Valid,
+		HasPassword:   user.PasswordHash.Valid,
+		HasAvatar:     user.AvatarUpdatedAt.Valid,
 	}, nil
 }
 
 type Note struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Note      string    `json:"note"`
-	UserID    string    `json:"user_id"`
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Title      *string         `json:"title"`
+	Metadata   json.RawMessage `json:"metadata"`
+	Note       string          `json:"note"`
+	Encrypted  bool            `json:"encrypted"`
+	Nonce      string          `json:"nonce,omitempty"`
+	Ciphertext string          `json:"ciphertext,omitempty"`
+	UserID     string          `json:"user_id"`
+	Public     bool            `json:"public"`
+	NotebookID *string         `json:"notebook_id"`
+	Archived   bool            `json:"archived"`
+	Pinned     bool            `json:"pinned"`
+	Version    int64           `json:"version"`
+	RemindAt   *time.Time      `json:"remind_at"`
+	Tags       []string        `json:"tags"`
+	Items      []NoteItem      `json:"items"`
+	Links      *noteLinks      `json:"_links,omitempty"`
+}
+
+type NoteItem struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+func databaseNoteItemToNoteItem(item database.NoteItem) NoteItem {
+	return NoteItem{
+		ID:   item.ID,
+		Text: item.Text,
+		Done: item.Done,
+	}
 }
 
 func databaseNoteToNote(post database.Note) (Note, error) {
@@ -51,12 +97,45 @@ func databaseNoteToNote(post database.Note) (Note, error) {
 	if err != nil {
 		return Note{}, err
 	}
+	var notebookID *string
+	if post.NotebookID.Valid {
+		notebookID = &post.NotebookID.String
+	}
+	var title *string
+	if post.Title.Valid {
+		title = &post.Title.String
+	}
+	var metadata json.RawMessage
+	if post.Metadata.Valid {
+		metadata = json.RawMessage(post.Metadata.String)
+	}
+	var remindAt *time.Time
+	if post.RemindAt.Valid {
+		t, err := time.Parse(time.RFC3339, post.RemindAt.String)
+		if err != nil {
+			return Note{}, err
+		}
+		remindAt = &t
+	}
 	return Note{
-		ID:        post.ID,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		Note:      post.Note,
-		UserID:    post.UserID,
+		ID:         post.ID,
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+		Title:      title,
+		Metadata:   metadata,
+		Note:       post.Note,
+		Encrypted:  post.Encrypted,
+		Nonce:      post.Nonce.String,
+		Ciphertext: post.Ciphertext.String,
+		UserID:     post.UserID,
+		Public:     post.Public,
+		NotebookID: notebookID,
+		Archived:   post.Archived,
+		Pinned:     post.Pinned,
+		Version:    post.Version,
+		RemindAt:   remindAt,
+		Tags:       []string{},
+		Items:      []NoteItem{},
 	}, nil
 }
 
@@ -72,3 +151,447 @@ func databasePostsToPosts(notes []database.Note) ([]Note, error) {
 	}
 	return result, nil
 }
+
+type APIKey struct {
+	ID           string     `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Label        string     `json:"label"`
+	Prefix       string     `json:"prefix"`
+	Key          string     `json:"key,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	LastUsedIP   *string    `json:"last_used_ip"`
+	AllowedCIDRs []string   `json:"allowed_cidrs"`
+}
+
+func databaseAPIKeyToAPIKey(key database.ApiKey) (APIKey, error) {
+	createdAt, err := time.Parse(time.RFC3339, key.CreatedAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339, key.UpdatedAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	revokedAt, err := parseNullTime(key.RevokedAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	expiresAt, err := parseNullTime(key.ExpiresAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	lastUsedAt, err := parseNullTime(key.LastUsedAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	var lastUsedIP *string
+	if key.LastUsedIp.Valid {
+		lastUsedIP = &key.LastUsedIp.String
+	}
+
+	return APIKey{
+		ID:           key.ID,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+		Label:        key.Label,
+		Prefix:       key.KeyPrefix,
+		RevokedAt:    revokedAt,
+		ExpiresAt:    expiresAt,
+		LastUsedAt:   lastUsedAt,
+		LastUsedIP:   lastUsedIP,
+		AllowedCIDRs: auth.SplitCIDRs(key.AllowedCidrs),
+	}, nil
+}
+
+func databaseAPIKeysToAPIKeys(keys []database.ApiKey) ([]APIKey, error) {
+	result := make([]APIKey, len(keys))
+	for i, key := range keys {
+		var err error
+		result[i], err = databaseAPIKeyToAPIKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type AuthAuditEntry struct {
+	ID               string    `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	UserID           *string   `json:"user_id"`
+	CredentialPrefix string    `json:"credential_prefix"`
+	IP               string    `json:"ip"`
+	UserAgent        string    `json:"user_agent"`
+	Success          bool      `json:"success"`
+	Reason           string    `json:"reason,omitempty"`
+}
+
+func databaseAuthAuditEntryToAuthAuditEntry(entry database.AuthAudit) (AuthAuditEntry, error) {
+	createdAt, err := time.Parse(time.RFC3339, entry.CreatedAt)
+	if err != nil {
+		return AuthAuditEntry{}, err
+	}
+
+	var userID *string
+	if entry.UserID.Valid {
+		userID = &entry.UserID.String
+	}
+
+	return AuthAuditEntry{
+		ID:               entry.ID,
+		CreatedAt:        createdAt,
+		UserID:           userID,
+		CredentialPrefix: entry.CredentialPrefix,
+		IP:               entry.Ip,
+		UserAgent:        entry.UserAgent,
+		Success:          entry.Success,
+		Reason:           entry.Reason,
+	}, nil
+}
+
+func databaseAuthAuditToAuthAudit(entries []database.AuthAudit) ([]AuthAuditEntry, error) {
+	result := make([]AuthAuditEntry, len(entries))
+	for i, entry := range entries {
+		var err error
+		result[i], err = databaseAuthAuditEntryToAuthAuditEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type ClientCert struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"user_id"`
+}
+
+func databaseClientCertToClientCert(cert database.ClientCert) (ClientCert, error) {
+	createdAt, err := time.Parse(time.RFC3339, cert.CreatedAt)
+	if err != nil {
+		return ClientCert{}, err
+	}
+	return ClientCert{
+		ID:        cert.ID,
+		CreatedAt: createdAt,
+		Subject:   cert.Subject,
+		UserID:    cert.UserID,
+	}, nil
+}
+
+func databaseClientCertsToClientCerts(certs []database.ClientCert) ([]ClientCert, error) {
+	result := make([]ClientCert, len(certs))
+	for i, cert := range certs {
+		var err error
+		result[i], err = databaseClientCertToClientCert(cert)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Job is a background job, for admins looking into failures. RunAt is
+// only set while it's pending.
+type Job struct {
+	ID          string          `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int64           `json:"attempts"`
+	MaxAttempts int64           `json:"max_attempts"`
+	RunAt       *time.Time      `json:"run_at"`
+	LastError   *string         `json:"last_error"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+func databaseJobToJob(job database.Job) (Job, error) {
+	createdAt, err := time.Parse(time.RFC3339, job.CreatedAt)
+	if err != nil {
+		return Job{}, err
+	}
+	updatedAt, err := time.Parse(time.RFC3339, job.UpdatedAt)
+	if err != nil {
+		return Job{}, err
+	}
+	finishedAt, err := parseNullTime(job.FinishedAt)
+	if err != nil {
+		return Job{}, err
+	}
+	var runAt *time.Time
+	if job.Status == jobs.StatusPending {
+		runAt, err = parseNullTime(sql.NullString{String: job.RunAt, Valid: true})
+		if err != nil {
+			return Job{}, err
+		}
+	}
+
+	result := Job{
+		ID:          job.ID,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		Kind:        job.Kind,
+		Payload:     json.RawMessage(job.Payload),
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       runAt,
+		FinishedAt:  finishedAt,
+	}
+	if job.LastError.Valid {
+		result.LastError = &job.LastError.String
+	}
+	return result, nil
+}
+
+func databaseJobsToJobs(list []database.Job) ([]Job, error) {
+	result := make([]Job, len(list))
+	for i, job := range list {
+		var err error
+		result[i], err = databaseJobToJob(job)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type NoteShare struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func databaseNoteShareToNoteShare(share database.NoteShare, email string) (NoteShare, error) {
+	createdAt, err := time.Parse(time.RFC3339, share.CreatedAt)
+	if err != nil {
+		return NoteShare{}, err
+	}
+	return NoteShare{
+		UserID:     share.UserID,
+		Email:      email,
+		Permission: share.Permission,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+func databaseNoteSharesToNoteShares(shares []database.ListNoteSharesRow) ([]NoteShare, error) {
+	result := make([]NoteShare, len(shares))
+	for i, share := range shares {
+		var err error
+		result[i], err = databaseNoteShareToNoteShare(database.NoteShare{
+			NoteID:     share.NoteID,
+			UserID:     share.UserID,
+			CreatedAt:  share.CreatedAt,
+			Permission: share.Permission,
+		}, share.Email.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ShareLink is a freshly minted public link. The token is only returned when
+// the link is created; afterwards just its hash is stored.
+type ShareLink struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+}
+
+type Notebook struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func databaseNotebookToNotebook(notebook database.Notebook) (Notebook, error) {
+	createdAt, err := time.Parse(time.RFC3339, notebook.CreatedAt)
+	if err != nil {
+		return Notebook{}, err
+	}
+	updatedAt, err := time.Parse(time.RFC3339, notebook.UpdatedAt)
+	if err != nil {
+		return Notebook{}, err
+	}
+	return Notebook{
+		ID:        notebook.ID,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Name:      notebook.Name,
+	}, nil
+}
+
+func databaseNotebooksToNotebooks(notebooks []database.Notebook) ([]Notebook, error) {
+	result := make([]Notebook, len(notebooks))
+	for i, notebook := range notebooks {
+		var err error
+		result[i], err = databaseNotebookToNotebook(notebook)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type Tag struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	NoteCount int64     `json:"note_count"`
+}
+
+func databaseTagsToTags(tags []database.ListTagsForUserRow) ([]Tag, error) {
+	result := make([]Tag, len(tags))
+	for i, tag := range tags {
+		createdAt, err := time.Parse(time.RFC3339, tag.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		updatedAt, err := time.Parse(time.RFC3339, tag.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = Tag{
+			ID:        tag.ID,
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+			Name:      tag.Name,
+			NoteCount: tag.NoteCount,
+		}
+	}
+	return result, nil
+}
+
+// Webhook is an endpoint note events are sent to. Events lists the event
+// types it receives, every one when it's empty. Secret, for checking
+// signatures, is only included when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+}
+
+func databaseWebhookToWebhook(hook database.Webhook) (Webhook, error) {
+	createdAt, err := time.Parse(time.RFC3339, hook.CreatedAt)
+	if err != nil {
+		return Webhook{}, err
+	}
+	updatedAt, err := time.Parse(time.RFC3339, hook.UpdatedAt)
+	if err != nil {
+		return Webhook{}, err
+	}
+	return Webhook{
+		ID:        hook.ID,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		URL:       hook.Url,
+		Events:    webhookEvents(hook.Events),
+		Active:    hook.Active,
+	}, nil
+}
+
+func databaseWebhooksToWebhooks(hooks []database.Webhook) ([]Webhook, error) {
+	result := make([]Webhook, len(hooks))
+	for i, hook := range hooks {
+		var err error
+		result[i], err = databaseWebhookToWebhook(hook)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// WebhookDelivery is one event sent, or being sent, to a webhook.
+// NextAttemptAt is only set while it's pending.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int64           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus *int64          `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+}
+
+func databaseWebhookDeliveriesToWebhookDeliveries(deliveries []database.WebhookDelivery) ([]WebhookDelivery, error) {
+	result := make([]WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		createdAt, err := time.Parse(time.RFC3339, delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lastAttemptAt, err := parseNullTime(delivery.LastAttemptAt)
+		if err != nil {
+			return nil, err
+		}
+		var nextAttemptAt *time.Time
+		if delivery.Status == webhooks.StatusPending {
+			nextAttemptAt, err = parseNullTime(sql.NullString{String: delivery.NextAttemptAt, Valid: true})
+			if err != nil {
+				return nil, err
+			}
+		}
+		result[i] = WebhookDelivery{
+			ID:            delivery.ID,
+			CreatedAt:     createdAt,
+			EventType:     delivery.EventType,
+			Payload:       json.RawMessage(delivery.Payload),
+			Status:        delivery.Status,
+			Attempts:      delivery.Attempts,
+			NextAttemptAt: nextAttemptAt,
+			LastAttemptAt: lastAttemptAt,
+		}
+		if delivery.ResponseStatus.Valid {
+			result[i].ResponseStatus = &delivery.ResponseStatus.Int64
+		}
+		if delivery.LastError.Valid {
+			result[i].LastError = &delivery.LastError.String
+		}
+	}
+	return result, nil
+}
+
+func parseNullTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/negotiate"
+	"github.com/bootdotdev/learn-cicd-starter/internal/transcode"
+)
+
+// responseEncoder sends payloads in a format clients can ask for in their
+// Accept header. encode converts the payload's JSON encoding, so the JSON
+// tags and version mappers shape every format alike.
+type responseEncoder struct {
+	mediaType string
+	// problemType is the media type of problem details, if the format has
+	// one of its own.
+	problemType string
+	encode      func(data []byte, problem bool) ([]byte, error)
+}
+
+// responseEncoders are the formats responses can be sent in, the first
+// being the default. Adding a format only takes an entry here.
+var responseEncoders = []*responseEncoder{
+	{
+		mediaType:   "application/json",
+		problemType: "application/problem+json",
+		encode:      func(data []byte, _ bool) ([]byte, error) { return data, nil },
+	},
+	{
+		mediaType:   "application/xml",
+		problemType: "application/problem+xml",
+		encode:      encodeXML,
+	},
+	{
+		mediaType: "application/msgpack",
+		encode:    func(data []byte, _ bool) ([]byte, error) { return transcode.Msgpack(data) },
+	},
+}
+
+var responseMediaTypes = func() []string {
+	types := make([]string, len(responseEncoders))
+	for i, enc := range responseEncoders {
+		types[i] = enc.mediaType
+	}
+	return types
+}()
+
+// encodeXML sends problems as RFC 7807 describes, in a problem element,
+// and other payloads in a response element.
+func encodeXML(data []byte, problem bool) ([]byte, error) {
+	if problem {
+		return transcode.XML(data, xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"})
+	}
+	return transcode.XML(data, xml.Name{Local: "response"})
+}
+
+// middlewareNegotiate picks the format of the response from the Accept
+// header, falling back to JSON when none of the formats is acceptable.
+// Browsers list application/xml in the Accept header of every page load,
+// so requests accepting text/html, such as a followed verification link,
+// keep getting JSON.
+func middlewareNegotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		accept := r.Header.Get("Accept")
+		if !strings.Contains(strings.ToLower(accept), "text/html") {
+			mediaType := negotiate.MediaType(accept, responseMediaTypes)
+			for _, enc := range responseEncoders[1:] {
+				if enc.mediaType == mediaType {
+					w = negotiatedWriter{ResponseWriter: w, encoder: enc}
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiatedWriter carries the encoder picked for a request to the
+// respond helpers, which only have its ResponseWriter.
+type negotiatedWriter struct {
+	http.ResponseWriter
+	encoder *responseEncoder
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack hands the connection over to WebSocket handlers.
+func (w negotiatedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// responseEncoderFor returns the encoder picked for the request w answers.
+// Writers that don't unwrap, such as the idempotency recorder, get JSON.
+func responseEncoderFor(w http.ResponseWriter) *responseEncoder {
+	for {
+		switch rw := w.(type) {
+		case negotiatedWriter:
+			return rw.encoder
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return responseEncoders[0]
+		}
+	}
+}
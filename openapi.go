@@ -0,0 +1,687 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/openapi"
+	"github.com/bootdotdev/learn-cicd-starter/internal/settings"
+	"github.com/go-chi/chi"
+)
+
+// authed are the security schemes middlewareAuth accepts.
+var authed = []string{"apiKey", "session", "bearer"}
+
+// jsonObject documents JSON responses built from types local to their
+// handler, which can't be named here.
+var jsonObject = map[string]any{}
+
+func binary(contentType string) openapi.Body {
+	return openapi.Body{ContentType: contentType}
+}
+
+// apiDocs describes the routes added by apiRoutes, by method and pattern,
+// for the OpenAPI description. Bodies are values of the types the handlers
+// decode and encode, so their schemas follow the handlers. Routes missing
+// here are still listed, just without a description.
+var apiDocs = map[string]openapi.Route{
+	"POST /users": {
+		Summary:   "Sign up",
+		Tags:      []string{"users"},
+		Request:   userCreateRequest{},
+		Responses: map[int]any{http.StatusCreated: User{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /users": {
+		Summary:   "Get the current user",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: User{}},
+	},
+	"PUT /users": {
+		Summary:   "Update the current user",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Request:   userUpdateRequest{},
+		Responses: map[int]any{http.StatusOK: User{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /users": {
+		Summary:   "Delete the current user and all their data",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Request:   userDeleteRequest{},
+		Responses: map[int]any{http.StatusNoContent: nil, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /users/deletion-token": {
+		Summary:   "Get a token confirming account deletion",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusCreated: jsonObject},
+	},
+	"PUT /users/password": {
+		Summary:   "Set or change the password",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Request:   userPasswordRequest{},
+		Responses: map[int]any{http.StatusOK: User{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"PUT /users/avatar": {
+		Summary:   "Upload an avatar",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Request:   binary("image/*"),
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"DELETE /users/avatar": {
+		Summary:   "Remove the avatar",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /users/me/export": {
+		Summary:   "Start an export of all the user's data",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusAccepted: jsonObject},
+	},
+	"GET /users/{userID}/avatar": {
+		Summary:   "Get a user's avatar",
+		Tags:      []string{"users"},
+		Responses: map[int]any{http.StatusOK: binary("image/png"), http.StatusNotModified: nil},
+	},
+	"GET /exports/{token}": {
+		Summary:   "Download a data export",
+		Tags:      []string{"users"},
+		Responses: map[int]any{http.StatusOK: dataExport{}, http.StatusAccepted: jsonObject},
+	},
+	"POST /users/verification": {
+		Summary:   "Resend the verification email",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusAccepted: nil},
+	},
+	"GET /verify": {
+		Summary:   "Verify an email address",
+		Tags:      []string{"users"},
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"POST /password/forgot": {
+		Summary:   "Email a password reset token",
+		Tags:      []string{"users"},
+		Request:   passwordForgotRequest{},
+		Responses: map[int]any{http.StatusAccepted: nil, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /password/reset": {
+		Summary:   "Reset the password with a token",
+		Tags:      []string{"users"},
+		Request:   passwordResetRequest{},
+		Responses: map[int]any{http.StatusNoContent: nil, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /settings": {
+		Summary:   "Get the user's settings",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: settings.Settings{}},
+	},
+	"PUT /settings": {
+		Summary:   "Replace the user's settings",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Request:   settings.Settings{},
+		Responses: map[int]any{http.StatusOK: settings.Settings{}},
+	},
+	"GET /notes": {
+		Summary:   "List notes",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: notesPage{}, http.StatusNotModified: nil},
+	},
+	"POST /notes": {
+		Summary:   "Create a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   noteInput{},
+		Responses: map[int]any{http.StatusCreated: Note{}, http.StatusOK: Note{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /notes/batch": {
+		Summary:   "Create several notes",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   notesBatchRequest{},
+		Responses: map[int]any{http.StatusOK: jsonObject, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /notes/bulk-delete": {
+		Summary:   "Delete several notes",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   notesBulkDeleteRequest{},
+		Responses: map[int]any{http.StatusOK: jsonObject, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /notes/search": {
+		Summary:   "Search notes",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: notesPage{}},
+	},
+	"GET /notes/shared-with-me": {
+		Summary:   "List notes shared with the user",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: notesPage{}},
+	},
+	"GET /notes/upcoming": {
+		Summary:   "List notes with upcoming reminders",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: notesPage{}},
+	},
+	"GET /notes/duplicates": {
+		Summary:   "List groups of duplicate notes",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"GET /notes/{noteID}": {
+		Summary:   "Get a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Note{}, http.StatusNotModified: nil},
+	},
+	"GET /notes/{noteID}/html": {
+		Summary:   "Get a note rendered as HTML",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: binary("text/html")},
+	},
+	"PUT /notes/{noteID}": {
+		Summary:   "Update a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   noteUpdateRequest{},
+		Responses: map[int]any{http.StatusOK: Note{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"PATCH /notes/{noteID}": {
+		Summary:   "Update a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   noteUpdateRequest{},
+		Responses: map[int]any{http.StatusOK: Note{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /notes/{noteID}": {
+		Summary:   "Delete a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"PATCH /notes/{noteID}/items": {
+		Summary:   "Edit a note's checklist",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   noteItemsRequest{},
+		Responses: map[int]any{http.StatusOK: Note{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /notes/{noteID}/archive": {
+		Summary:   "Archive a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Note{}},
+	},
+	"POST /notes/{noteID}/unarchive": {
+		Summary:   "Unarchive a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Note{}},
+	},
+	"POST /notes/{noteID}/pin": {
+		Summary:   "Pin a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Note{}},
+	},
+	"POST /notes/{noteID}/unpin": {
+		Summary:   "Unpin a note",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Note{}},
+	},
+	"POST /notes/{noteID}/shares": {
+		Summary:   "Share a note with another user",
+		Tags:      []string{"sharing"},
+		Security:  authed,
+		Request:   noteShareRequest{},
+		Responses: map[int]any{http.StatusCreated: NoteShare{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /notes/{noteID}/shares": {
+		Summary:   "List a note's shares",
+		Tags:      []string{"sharing"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []NoteShare{}},
+	},
+	"DELETE /notes/{noteID}/shares/{userID}": {
+		Summary:   "Stop sharing a note with a user",
+		Tags:      []string{"sharing"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /notes/{noteID}/share-link": {
+		Summary:   "Create a public link to a note",
+		Tags:      []string{"sharing"},
+		Security:  authed,
+		Request:   shareLinkRequest{},
+		Responses: map[int]any{http.StatusCreated: ShareLink{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /notes/{noteID}/share-link": {
+		Summary:   "Remove a note's public link",
+		Tags:      []string{"sharing"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /ws": {
+		Summary: "Receive note changes over a WebSocket",
+		Description: "Upgrades to a WebSocket that sends a JSON message, {\"type\": ..., \"data\": ...}, " +
+			"whenever one of the user's notes changes. note.created and note.updated carry the note, " +
+			"note.deleted its id. Events aren't replayed, so refetch notes after connecting.",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusSwitchingProtocols: nil},
+	},
+	"GET /events": {
+		Summary: "Receive note changes as server-sent events",
+		Description: "Streams the events GET /ws sends, named by their type. Reconnecting with a " +
+			"Last-Event-ID header resumes with the events missed since; otherwise the stream starts " +
+			"with a reset event, after which notes should be refetched.",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: binary("text/event-stream")},
+	},
+	"GET /stats": {
+		Summary:   "Get note statistics",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: noteStats{}},
+	},
+	"GET /usage": {
+		Summary:   "Get usage against quotas",
+		Tags:      []string{"users"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"GET /export": {
+		Summary:   "Export notes as a zip archive",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: binary("application/zip")},
+	},
+	"POST /import": {
+		Summary:   "Import notes from a zip archive",
+		Tags:      []string{"notes"},
+		Security:  authed,
+		Request:   binary("application/zip"),
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"POST /notebooks": {
+		Summary:   "Create a notebook",
+		Tags:      []string{"notebooks"},
+		Security:  authed,
+		Request:   notebookRequest{},
+		Responses: map[int]any{http.StatusCreated: Notebook{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /notebooks": {
+		Summary:   "List notebooks",
+		Tags:      []string{"notebooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []Notebook{}},
+	},
+	"GET /notebooks/{notebookID}": {
+		Summary:   "Get a notebook",
+		Tags:      []string{"notebooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Notebook{}},
+	},
+	"PATCH /notebooks/{notebookID}": {
+		Summary:   "Rename a notebook",
+		Tags:      []string{"notebooks"},
+		Security:  authed,
+		Request:   notebookRequest{},
+		Responses: map[int]any{http.StatusOK: Notebook{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /notebooks/{notebookID}": {
+		Summary:   "Delete a notebook",
+		Tags:      []string{"notebooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /webhooks": {
+		Summary:   "Register a webhook for note events",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Request:   webhookCreateRequest{},
+		Responses: map[int]any{http.StatusCreated: Webhook{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /webhooks": {
+		Summary:   "List webhooks",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []Webhook{}},
+	},
+	"GET /webhooks/{webhookID}": {
+		Summary:   "Get a webhook",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Webhook{}},
+	},
+	"PATCH /webhooks/{webhookID}": {
+		Summary:   "Update, pause or resume a webhook",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Request:   webhookUpdateRequest{},
+		Responses: map[int]any{http.StatusOK: Webhook{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /webhooks/{webhookID}": {
+		Summary:   "Delete a webhook and its delivery log",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /webhooks/{webhookID}/deliveries": {
+		Summary:   "List a webhook's recent deliveries",
+		Tags:      []string{"webhooks"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []WebhookDelivery{}},
+	},
+	"GET /tags": {
+		Summary:   "List tags",
+		Tags:      []string{"tags"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []Tag{}},
+	},
+	"PATCH /tags/{tagID}": {
+		Summary:   "Rename a tag",
+		Tags:      []string{"tags"},
+		Security:  authed,
+		Request:   tagRenameRequest{},
+		Responses: map[int]any{http.StatusOK: Tag{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /keys": {
+		Summary:   "Create an API key",
+		Tags:      []string{"keys"},
+		Security:  authed,
+		Request:   keyCreateRequest{},
+		Responses: map[int]any{http.StatusCreated: APIKey{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"GET /keys": {
+		Summary:   "List API keys",
+		Tags:      []string{"keys"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []APIKey{}},
+	},
+	"POST /keys/rotate": {
+		Summary:   "Replace an API key",
+		Tags:      []string{"keys"},
+		Security:  authed,
+		Request:   keyRotateRequest{},
+		Responses: map[int]any{http.StatusCreated: APIKey{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"PUT /keys/{keyID}/allowed-cidrs": {
+		Summary:   "Limit where an API key can be used from",
+		Tags:      []string{"keys"},
+		Security:  authed,
+		Request:   allowedCIDRsRequest{},
+		Responses: map[int]any{http.StatusOK: APIKey{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /keys/{keyID}": {
+		Summary:   "Revoke an API key",
+		Tags:      []string{"keys"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /totp": {
+		Summary:   "Start TOTP enrollment",
+		Tags:      []string{"totp"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusCreated: jsonObject},
+	},
+	"POST /totp/verify": {
+		Summary:   "Finish TOTP enrollment",
+		Tags:      []string{"totp"},
+		Security:  authed,
+		Request:   totpVerifyRequest{},
+		Responses: map[int]any{http.StatusOK: User{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /totp": {
+		Summary:   "Turn TOTP off",
+		Tags:      []string{"totp"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /sessions": {
+		Summary:   "Start a session with an API key",
+		Tags:      []string{"auth"},
+		Security:  []string{"apiKey"},
+		Responses: map[int]any{http.StatusCreated: User{}},
+	},
+	"POST /sessions/password": {
+		Summary:   "Start a session with a password",
+		Tags:      []string{"auth"},
+		Request:   passwordLoginRequest{},
+		Responses: map[int]any{http.StatusCreated: User{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /sessions": {
+		Summary:   "End the session",
+		Tags:      []string{"auth"},
+		Security:  []string{"session"},
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /login": {
+		Summary:   "Get tokens with an API key",
+		Tags:      []string{"auth"},
+		Security:  []string{"apiKey"},
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"POST /login/password": {
+		Summary:   "Get tokens with a password",
+		Tags:      []string{"auth"},
+		Request:   passwordLoginRequest{},
+		Responses: map[int]any{http.StatusOK: jsonObject, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"POST /refresh": {
+		Summary:   "Get a new access token",
+		Tags:      []string{"auth"},
+		Security:  []string{"bearer"},
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"POST /revoke": {
+		Summary:   "Revoke a refresh token",
+		Tags:      []string{"auth"},
+		Security:  []string{"bearer"},
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /oauth/providers": {
+		Summary:   "List OAuth providers",
+		Tags:      []string{"auth"},
+		Responses: map[int]any{http.StatusOK: []string{}},
+	},
+	"GET /oauth/{provider}/authorize": {
+		Summary:   "Sign in with an OAuth provider",
+		Tags:      []string{"auth"},
+		Responses: map[int]any{http.StatusFound: nil},
+	},
+	"GET /oauth/{provider}/callback": {
+		Summary:   "Finish signing in with an OAuth provider",
+		Tags:      []string{"auth"},
+		Responses: map[int]any{http.StatusFound: nil},
+	},
+	"GET /admin/blocks": {
+		Summary:   "List blocked client IPs",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []map[string]any{}},
+	},
+	"DELETE /admin/blocks/{ip}": {
+		Summary:   "Unblock a client IP",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /admin/audit": {
+		Summary:   "List authentication attempts",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []AuthAuditEntry{}},
+	},
+	"GET /admin/users": {
+		Summary:   "List users",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: adminUsersPage{}},
+	},
+	"POST /admin/users/{userID}/suspend": {
+		Summary:   "Suspend a user",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /admin/users/{userID}/unsuspend": {
+		Summary:   "Lift a user's suspension",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"POST /admin/users/{userID}/revoke-keys": {
+		Summary:   "Revoke all of a user's API keys, sessions and refresh tokens",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"POST /admin/backups": {
+		Summary:   "Back up the database",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusCreated: jsonObject},
+	},
+	"GET /admin/jobs": {
+		Summary:   "List background jobs",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []Job{}},
+	},
+	"GET /admin/jobs/{jobID}": {
+		Summary:   "Get a background job",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Job{}},
+	},
+	"POST /admin/jobs/{jobID}/requeue": {
+		Summary:   "Retry a failed background job",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: Job{}},
+	},
+	"GET /admin/client-certs": {
+		Summary:   "List client certificate mappings",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusOK: []ClientCert{}},
+	},
+	"POST /admin/client-certs": {
+		Summary:   "Map a client certificate to a user",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Request:   clientCertRequest{},
+		Responses: map[int]any{http.StatusCreated: ClientCert{}, http.StatusUnprocessableEntity: validationProblem{}},
+	},
+	"DELETE /admin/client-certs/{certID}": {
+		Summary:   "Remove a client certificate mapping",
+		Tags:      []string{"admin"},
+		Security:  authed,
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+	"GET /openapi.json": {
+		Summary:   "Get this OpenAPI description",
+		Tags:      []string{"meta"},
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"GET /healthz": {
+		Summary:   "Check the server is up",
+		Tags:      []string{"meta"},
+		Responses: map[int]any{http.StatusOK: jsonObject},
+	},
+	"GET /readyz": {
+		Summary:   "Check the server can handle requests",
+		Tags:      []string{"meta"},
+		Responses: map[int]any{http.StatusOK: jsonObject, http.StatusServiceUnavailable: jsonObject},
+	},
+}
+
+// handlerOpenAPI serves the OpenAPI description of the API version serving
+// the request. Routes are read from the router, so ones turned off by
+// configuration are left out and new ones can't be missed.
+func handlerOpenAPI(w http.ResponseWriter, r *http.Request) {
+	version := apiversion.Find(apiVersions, w.Header().Get(apiversion.Header))
+	if version == nil {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+	doc, err := openAPIDocument(chi.RouteContext(r.Context()).Routes, version)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't describe the API", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// openAPIDocument describes the routes router serves under version, with
+// bodies in the version's shape.
+func openAPIDocument(router chi.Routes, version *apiversion.Version) (*openapi.Document, error) {
+	prefix := "/" + version.Name
+	b := openapi.New(openapi.Info{Title: "Notely API", Version: version.Name}, prefix)
+	b.SecurityScheme("apiKey", openapi.SecurityScheme{
+		Type: "apiKey", In: "header", Name: "Authorization",
+		Description: "An API key, sent as `ApiKey <key>`",
+	})
+	b.SecurityScheme("session", openapi.SecurityScheme{
+		Type: "apiKey", In: "header", Name: "Authorization",
+		Description: "A session token from /sessions, sent as `Session <token>`",
+	})
+	b.SecurityScheme("bearer", openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	b.ErrorBody(versionedBody(version, http.StatusInternalServerError, problem{}))
+
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pattern, ok := strings.CutPrefix(route, prefix+"/")
+		if !ok {
+			return nil
+		}
+		pattern = "/" + pattern
+		doc := apiDocs[method+" "+pattern]
+		if doc.Request != nil {
+			doc.Request = versionedBody(version, 0, doc.Request)
+		}
+		responses := make(map[int]any, len(doc.Responses))
+		for code, body := range doc.Responses {
+			if body != nil {
+				body = versionedBody(version, code, body)
+			}
+			responses[code] = body
+		}
+		doc.Responses = responses
+		doc.Deprecated = !version.DeprecatedAt.IsZero()
+		b.Add(method, pattern, doc)
+		return nil
+	})
+	return b.Document(), err
+}
+
+// versionedBody maps body into version's shape. Errors keep the
+// application/problem+json type respondWithProblem gives them unless the
+// version maps them to a shape of its own.
+func versionedBody(version *apiversion.Version, code int, body any) any {
+	if _, ok := body.(openapi.Body); ok {
+		return body
+	}
+	mapped := version.MapPayload(body)
+	if code > 399 && reflect.TypeOf(mapped) == reflect.TypeOf(body) {
+		return openapi.Body{ContentType: "application/problem+json", Value: mapped}
+	}
+	return mapped
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/ratelimit"
+)
+
+// reload re-reads the configuration on SIGHUP and applies the settings
+// that can change without dropping connections: RATE_LIMIT_RPS,
+// RATE_LIMIT_BURST, PASSWORD_RESETS_PER_HOUR and the CORS_ settings.
+// Everything else still needs a restart. The environment of a running
+// process can't change, so only settings in the config file, and not set
+// in the environment or .env, can be reloaded. An invalid configuration is
+// logged and the current one kept.
+func (cfg *apiConfig) reload(path string) {
+	next, err := loadConfig(path, "PORT")
+	if err != nil {
+		log.Printf("Not reloading, invalid configuration:\n%v", err)
+		return
+	}
+
+	reloadLimiter("RATE_LIMIT_RPS", cfg.RateLimiter, next.RateLimitRPS, next.RateLimitBurst)
+	resets := next.PasswordResetsPerHour
+	reloadLimiter("PASSWORD_RESETS_PER_HOUR", cfg.PasswordResetLimiter, float64(resets)/3600, resets)
+	cfg.CORS.Set(next.CORS)
+	log.Println("Reloaded configuration")
+}
+
+// reloadLimiter applies a new rate to limiter, which is nil while the
+// limit named by name is off. Turning it on or off needs a restart, since
+// handlers check for nil without locking.
+func reloadLimiter(name string, limiter *ratelimit.Limiter, rate float64, burst int) {
+	if (limiter != nil) != (rate > 0) {
+		log.Printf("Restart to turn %s on or off", name)
+		return
+	}
+	if limiter != nil {
+		limiter.SetRate(rate, burst)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/mailer"
+	"github.com/bootdotdev/learn-cicd-starter/internal/reminders"
+)
+
+// reminderEmailNotifier emails reminders to owners who opted into reminder
+// emails in their settings and have an email, and logs the rest.
+type reminderEmailNotifier struct {
+	cfg *apiConfig
+}
+
+func (n reminderEmailNotifier) NotifyReminder(ctx context.Context, note database.Note) error {
+	user, err := n.cfg.DB.GetUserByID(ctx, note.UserID)
+	if err != nil {
+		return err
+	}
+	prefs, err := n.cfg.userSettings(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !prefs.Notifications.ReminderEmails || !user.Email.Valid {
+		return reminders.LogNotifier{}.NotifyReminder(ctx, note)
+	}
+
+	when := note.RemindAt.String
+	if t, err := time.Parse(time.RFC3339, when); err == nil {
+		if loc, err := prefs.Location(); err == nil {
+			when = t.In(loc).Format("Mon, 2 Jan 2006 15:04 MST")
+		}
+	}
+	// Encrypted notes only have a ciphertext, so their reminders leave out
+	// the title.
+	title := "Untitled note"
+	if note.Title.Valid && note.Title.String != "" && !note.Encrypted {
+		title = note.Title.String
+	}
+
+	return n.cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email.String,
+		Subject: "Reminder: " + title,
+		Body: fmt.Sprintf("Hi %s,\n\nYou asked to be reminded about \"%s\" at %s.\n\nOpen it at %s/v1/notes/%s.\n",
+			user.Name, title, when, n.cfg.PublicBaseURL, note.ID),
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+)
+
+const (
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+var errBootstrapUserNotFound = errors.New("bootstrap admin user not found")
+
+// bootstrapAdmin promotes userID to admin when no admin exists yet. Once
+// there is an admin the call does nothing, so leaving the variable set can't
+// be used to regain admin access after a demotion.
+func bootstrapAdmin(ctx context.Context, db database.Querier, userID string) (bool, error) {
+	admins, err := db.CountUsersWithRole(ctx, roleAdmin)
+	if err != nil {
+		return false, err
+	}
+	if admins > 0 {
+		return false, nil
+	}
+
+	updated, err := db.SetUserRole(ctx, database.SetUserRoleParams{
+		Role:      roleAdmin,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:        userID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if updated == 0 {
+		return false, errBootstrapUserNotFound
+	}
+	return true, nil
+}
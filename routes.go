@@ -0,0 +1,115 @@
+package main
+
+import "github.com/go-chi/chi"
+
+// apiRoutes adds the API's routes to r. Every version in apiVersions
+// serves the same routes, translating requests and responses with its
+// mappers.
+func (cfg *apiConfig) apiRoutes(r chi.Router) {
+	if cfg.DB != nil {
+		r.Post("/users", cfg.handlerUsersCreate)
+		r.Get("/users", cfg.middlewareAuth(cfg.handlerUsersGet))
+		r.Put("/users", cfg.middlewareAuth(cfg.handlerUsersUpdate))
+		r.Delete("/users", cfg.middlewareAuth(cfg.middlewareTOTP(cfg.handlerUsersDelete)))
+		r.Post("/users/deletion-token", cfg.middlewareAuth(cfg.handlerUsersDeletionToken))
+		r.Put("/users/password", cfg.middlewareAuth(cfg.handlerUsersPassword))
+		r.Put("/users/avatar", cfg.middlewareAuth(cfg.handlerUsersAvatarPut))
+		r.Delete("/users/avatar", cfg.middlewareAuth(cfg.handlerUsersAvatarDelete))
+		r.Get("/users/me/export", cfg.middlewareAuth(cfg.handlerUsersExport))
+		r.Get("/users/{userID}/avatar", cfg.handlerUserAvatarGet)
+		r.Get("/exports/{token}", cfg.handlerExportDownload)
+		r.Post("/users/verification", cfg.middlewareAuth(cfg.handlerUsersVerificationResend))
+		r.Get("/verify", cfg.handlerVerifyEmail)
+		r.Post("/password/forgot", cfg.handlerPasswordForgot)
+		r.Post("/password/reset", cfg.handlerPasswordReset)
+		r.Get("/settings", cfg.middlewareAuth(cfg.handlerSettingsGet))
+		r.Put("/settings", cfg.middlewareAuth(cfg.handlerSettingsUpdate))
+		r.Get("/notes", cfg.middlewareAuth(cfg.handlerNotesGet))
+		r.Post("/notes", cfg.middlewareAuth(cfg.middlewareNoteBodyLimit(1, cfg.middlewareIdempotency(cfg.handlerNotesCreate))))
+		r.Post("/notes/batch", cfg.middlewareAuth(cfg.middlewareNoteBodyLimit(cfg.NotesMaxBatch, cfg.handlerNotesBatchCreate)))
+		r.Post("/notes/bulk-delete", cfg.middlewareAuth(cfg.handlerNotesBulkDelete))
+		r.Get("/notes/search", cfg.middlewareAuth(cfg.handlerNotesSearch))
+		r.Get("/notes/shared-with-me", cfg.middlewareAuth(cfg.handlerNotesSharedWithMe))
+		r.Get("/notes/upcoming", cfg.middlewareAuth(cfg.handlerNotesUpcoming))
+		r.Get("/notes/duplicates", cfg.middlewareAuth(cfg.handlerNotesDuplicates))
+		r.Get("/notes/{noteID}", cfg.middlewareReadAuth(cfg.handlerNoteGet))
+		r.Get("/notes/{noteID}/html", cfg.middlewareReadAuth(cfg.handlerNoteHTML))
+		r.Put("/notes/{noteID}", cfg.middlewareAuth(cfg.middlewareNoteBodyLimit(1, cfg.handlerNotesUpdate)))
+		r.Patch("/notes/{noteID}", cfg.middlewareAuth(cfg.middlewareNoteBodyLimit(1, cfg.handlerNotesUpdate)))
+		r.Delete("/notes/{noteID}", cfg.middlewareAuth(cfg.handlerNotesDelete))
+		r.Patch("/notes/{noteID}/items", cfg.middlewareAuth(cfg.handlerNoteItemsPatch))
+		r.Post("/notes/{noteID}/archive", cfg.middlewareAuth(cfg.handlerNotesArchive))
+		r.Post("/notes/{noteID}/unarchive", cfg.middlewareAuth(cfg.handlerNotesUnarchive))
+		r.Post("/notes/{noteID}/pin", cfg.middlewareAuth(cfg.handlerNotesPin))
+		r.Post("/notes/{noteID}/unpin", cfg.middlewareAuth(cfg.handlerNotesUnpin))
+		r.Post("/notes/{noteID}/shares", cfg.middlewareAuth(cfg.middlewareVerifiedEmail(cfg.handlerNoteSharesCreate)))
+		r.Get("/notes/{noteID}/shares", cfg.middlewareAuth(cfg.handlerNoteSharesGet))
+		r.Delete("/notes/{noteID}/shares/{userID}", cfg.middlewareAuth(cfg.handlerNoteSharesDelete))
+		r.Post("/notes/{noteID}/share-link", cfg.middlewareAuth(cfg.middlewareVerifiedEmail(cfg.handlerShareLinkCreate)))
+		r.Delete("/notes/{noteID}/share-link", cfg.middlewareAuth(cfg.handlerShareLinkDelete))
+		r.Get("/ws", cfg.middlewareAuth(cfg.handlerWebSocket))
+		r.Get("/events", cfg.middlewareAuth(cfg.handlerEvents))
+		r.Get("/stats", cfg.middlewareAuth(cfg.handlerStats))
+		r.Get("/usage", cfg.middlewareAuth(cfg.handlerUsage))
+		r.Get("/export", cfg.middlewareAuth(cfg.handlerExport))
+		r.Post("/import", cfg.middlewareAuth(cfg.handlerImport))
+		r.Post("/notebooks", cfg.middlewareAuth(cfg.handlerNotebooksCreate))
+		r.Get("/notebooks", cfg.middlewareAuth(cfg.handlerNotebooksGet))
+		r.Get("/notebooks/{notebookID}", cfg.middlewareAuth(cfg.handlerNotebookGet))
+		r.Patch("/notebooks/{notebookID}", cfg.middlewareAuth(cfg.handlerNotebooksUpdate))
+		r.Delete("/notebooks/{notebookID}", cfg.middlewareAuth(cfg.handlerNotebooksDelete))
+		r.Post("/webhooks", cfg.middlewareAuth(cfg.handlerWebhooksCreate))
+		r.Get("/webhooks", cfg.middlewareAuth(cfg.handlerWebhooksGet))
+		r.Get("/webhooks/{webhookID}", cfg.middlewareAuth(cfg.handlerWebhookGet))
+		r.Patch("/webhooks/{webhookID}", cfg.middlewareAuth(cfg.handlerWebhooksUpdate))
+		r.Delete("/webhooks/{webhookID}", cfg.middlewareAuth(cfg.handlerWebhooksDelete))
+		r.Get("/webhooks/{webhookID}/deliveries", cfg.middlewareAuth(cfg.handlerWebhookDeliveriesGet))
+		r.Get("/tags", cfg.middlewareAuth(cfg.handlerTagsGet))
+		r.Patch("/tags/{tagID}", cfg.middlewareAuth(cfg.handlerTagsRename))
+		r.Post("/keys", cfg.middlewareAuth(cfg.handlerKeysCreate))
+		r.Get("/keys", cfg.middlewareAuth(cfg.handlerKeysGet))
+		r.Post("/keys/rotate", cfg.middlewareAuth(cfg.handlerKeysRotate))
+		r.Put("/keys/{keyID}/allowed-cidrs", cfg.middlewareAuth(cfg.handlerKeysAllowedCIDRsUpdate))
+		r.Delete("/keys/{keyID}", cfg.middlewareAuth(cfg.middlewareTOTP(cfg.handlerKeysDelete)))
+
+		r.Post("/totp", cfg.middlewareAuth(cfg.handlerTOTPEnroll))
+		r.Post("/totp/verify", cfg.middlewareAuth(cfg.handlerTOTPVerify))
+		r.Delete("/totp", cfg.middlewareAuth(cfg.middlewareTOTP(cfg.handlerTOTPDelete)))
+
+		r.Post("/sessions", cfg.middlewareAuthWith(cfg.APIKeyAuth, cfg.handlerSessionsCreate))
+		r.Post("/sessions/password", cfg.middlewarePasswordAuth(cfg.middlewareTOTP(cfg.handlerSessionsCreate)))
+		r.Delete("/sessions", cfg.handlerSessionsDelete)
+
+		if cfg.AuthFailures != nil {
+			r.Get("/admin/blocks", cfg.middlewareAdmin(cfg.handlerAdminBlocksGet))
+			r.Delete("/admin/blocks/{ip}", cfg.middlewareAdmin(cfg.handlerAdminBlocksDelete))
+		}
+		r.Get("/admin/audit", cfg.middlewareAdmin(cfg.handlerAdminAuditGet))
+		r.Get("/admin/users", cfg.middlewareAdmin(cfg.handlerAdminUsersGet))
+		r.Post("/admin/users/{userID}/suspend", cfg.middlewareAdmin(cfg.handlerAdminUserSuspend))
+		r.Post("/admin/users/{userID}/unsuspend", cfg.middlewareAdmin(cfg.handlerAdminUserUnsuspend))
+		r.Post("/admin/users/{userID}/revoke-keys", cfg.middlewareAdmin(cfg.middlewareTOTP(cfg.handlerAdminUserRevokeKeys)))
+		r.Post("/admin/backups", cfg.middlewareAdmin(cfg.handlerAdminBackupsCreate))
+		r.Get("/admin/jobs", cfg.middlewareAdmin(cfg.handlerAdminJobsGet))
+		r.Get("/admin/jobs/{jobID}", cfg.middlewareAdmin(cfg.handlerAdminJobGet))
+		r.Post("/admin/jobs/{jobID}/requeue", cfg.middlewareAdmin(cfg.handlerAdminJobRequeue))
+		r.Get("/admin/client-certs", cfg.middlewareAdmin(cfg.handlerClientCertsGet))
+		r.Post("/admin/client-certs", cfg.middlewareAdmin(cfg.handlerClientCertsCreate))
+		r.Delete("/admin/client-certs/{certID}", cfg.middlewareAdmin(cfg.handlerClientCertsDelete))
+
+		r.Get("/oauth/providers", cfg.handlerOAuthProviders)
+		r.Get("/oauth/{provider}/authorize", cfg.handlerOAuthAuthorize)
+		r.Get("/oauth/{provider}/callback", cfg.handlerOAuthCallback)
+
+		if cfg.JWTSecret != "" {
+			r.Post("/login", cfg.middlewareAuthWith(cfg.APIKeyAuth, cfg.handlerLogin))
+			r.Post("/login/password", cfg.middlewarePasswordAuth(cfg.middlewareTOTP(cfg.handlerLogin)))
+			r.Post("/refresh", cfg.handlerRefresh)
+			r.Post("/revoke", cfg.handlerRevoke)
+		}
+	}
+
+	r.Get("/openapi.json", handlerOpenAPI)
+	r.Get("/healthz", handlerLiveness)
+	r.Get("/readyz", cfg.handlerReadiness)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/auth"
+	"github.com/bootdotdev/learn-cicd-starter/internal/database"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+	"github.com/google/uuid"
+)
+
+var (
+	seedNames     = []string{"Ada Lovelace", "Grace Hopper", "Alan Turing", "Katherine Johnson", "Linus Torvalds", "Margaret Hamilton", "Dennis Ritchie", "Barbara Liskov"}
+	seedNotebooks = []string{"Work", "Personal", "Reading list", "Recipes"}
+	seedTags      = []string{"todo", "idea", "meeting", "golang", "travel", "finance", "health", "books", "urgent", "later", "draft", "reference"}
+	seedWords     = strings.Fields(`the a of to and in for on with about after before
+		meeting notes project plan review deploy database query server client bug
+		fix release draft idea budget trip flight hotel recipe garlic onion tomato
+		book chapter author quote remember call email follow up weekly monthly
+		quickly carefully maybe tomorrow today later soon important small large
+		team design api key token cache index migration backup schema latency`)
+)
+
+// runSeedCommand handles the -seed flag: it fills DATABASE_URL with users,
+// API keys, notebooks and notes spread over the past year, for frontend and
+// performance work, then exits. Each user's API key is logged so the data
+// can be used straight away. The text is the same for the same counts, but
+// IDs and emails are new each run, so seeding again adds another set.
+func runSeedCommand(dbCfg DatabaseConfig, users, notes int) {
+	if dbCfg.URL == memoryDatabaseURL {
+		log.Fatal("An in-memory database would be lost when seeding exits")
+	}
+	if users < 1 || notes < 0 {
+		log.Fatal("Seeding needs at least one user and no fewer than zero notes")
+	}
+	ctx := context.Background()
+	store, err := openStore(ctx, dbCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// #nosec G404 -- seed data should be repeatable, not unpredictable
+	rng := rand.New(rand.NewPCG(uint64(users), uint64(notes)))
+	cfg := &apiConfig{DB: store, NoteMaxBytes: 1 << 20}
+	now := time.Now()
+
+	for i := 0; i < users; i++ {
+		name := seedNames[i%len(seedNames)]
+		if i >= len(seedNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(seedNames)+1)
+		}
+		user, apiKey, err := seedUser(ctx, store, name, i)
+		if err != nil {
+			log.Fatalf("Couldn't seed user: %v", err)
+		}
+		// Notes are split evenly, with the remainder going to the first users.
+		count := notes / users
+		if i < notes%users {
+			count++
+		}
+		if err := cfg.seedNotes(ctx, rng, user, count, now); err != nil {
+			log.Fatalf("Couldn't seed notes for %s: %v", name, err)
+		}
+		log.Printf("Seeded %s with %d notes, api key %s", name, count, apiKey)
+	}
+}
+
+// seedUser creates a user with an email and a second, labelled API key
+// alongside the default one. It returns the default key.
+func seedUser(ctx context.Context, db database.Querier, name string, i int) (database.User, string, error) {
+	email := sql.NullString{String: fmt.Sprintf("seed%d-%s@example.com", i+1, uuid.NewString()[:8]), Valid: true}
+	user, apiKey, err := createUser(ctx, db, name, email)
+	if err != nil {
+		return database.User{}, "", err
+	}
+	extra, err := auth.GenerateAPIKey()
+	if err != nil {
+		return database.User{}, "", err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	err = db.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Label:     "CI",
+		KeyHash:   auth.HashAPIKey(extra),
+		KeyPrefix: auth.DisplayPrefix(extra),
+	})
+	if err != nil {
+		return database.User{}, "", err
+	}
+	return user, apiKey, nil
+}
+
+// seedNotes creates count notes for user, backdated up to a year before
+// now, in one transaction. Some are tagged, filed in notebooks, public,
+// checklists, pinned or archived.
+func (cfg *apiConfig) seedNotes(ctx context.Context, rng *rand.Rand, user database.User, count int, now time.Time) error {
+	var notebookIDs []string
+	for _, name := range seedNotebooks {
+		id := uuid.New().String()
+		err := cfg.DB.CreateNotebook(ctx, database.CreateNotebookParams{
+			ID:        id,
+			CreatedAt: now.UTC().Format(time.RFC3339),
+			UpdatedAt: now.UTC().Format(time.RFC3339),
+			Name:      name,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		notebookIDs = append(notebookIDs, id)
+	}
+
+	return database.WithTx(ctx, cfg.DB, func(tx database.Querier) error {
+		for i := 0; i < count; i++ {
+			in := noteInput{
+				Title:  seedSentence(rng, 2, 6),
+				Note:   seedParagraphs(rng),
+				Public: rng.IntN(10) == 0,
+			}
+			for _, j := range rng.Perm(len(seedTags))[:rng.IntN(4)] {
+				in.Tags = append(in.Tags, seedTags[j])
+			}
+			if n := rng.IntN(len(notebookIDs) + 1); n < len(notebookIDs) {
+				in.NotebookID = notebookIDs[n]
+			}
+			if rng.IntN(8) == 0 {
+				for range 1 + rng.IntN(5) {
+					in.Items = append(in.Items, noteItemInput{Text: seedSentence(rng, 2, 5), Done: rng.IntN(2) == 0})
+				}
+			}
+
+			createdAt := now.Add(-time.Duration(rng.Int64N(int64(365 * 24 * time.Hour))))
+			note, err := cfg.prepareNote(ctx, &validate.Validator{}, user, in, createdAt)
+			if err != nil {
+				return err
+			}
+			if err := insertNote(ctx, tx, note); err != nil {
+				return err
+			}
+
+			if i < 3 {
+				_, err = tx.SetNotePinned(ctx, database.SetNotePinnedParams{Pinned: true, ID: note.params.ID, UserID: user.ID})
+			} else if rng.IntN(20) == 0 {
+				_, err = tx.SetNoteArchived(ctx, database.SetNoteArchivedParams{Archived: true, ID: note.params.ID, UserID: user.ID})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return touchNoteList(ctx, tx, user.ID)
+	})
+}
+
+// seedSentence returns between lo and hi random words.
+func seedSentence(rng *rand.Rand, lo, hi int) string {
+	words := make([]string, lo+rng.IntN(hi-lo+1))
+	for i := range words {
+		words[i] = seedWords[rng.IntN(len(seedWords))]
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// seedParagraphs returns one to four paragraphs of random sentences.
+func seedParagraphs(rng *rand.Rand) string {
+	paragraphs := make([]string, 1+rng.IntN(4))
+	for i := range paragraphs {
+		sentences := make([]string, 1+rng.IntN(5))
+		for j := range sentences {
+			sentences[j] = seedSentence(rng, 4, 14) + "."
+		}
+		paragraphs[i] = strings.Join(sentences, " ")
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
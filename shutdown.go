@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs srv until SIGINT or SIGTERM, then shuts down gracefully: the
+// listener closes straight away, in-flight requests get up to timeout to
+// finish, and then the background jobs are flushed and the database is
+// closed. A second signal while draining stops the process at once.
+// SIGHUP calls reload instead.
+func (cfg *apiConfig) serve(srv *http.Server, timeout time.Duration, reload func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			serveErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			log.Fatal(err)
+		case <-hangup:
+			reload()
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Couldn't finish in-flight requests: %v", err)
+		if err := srv.Close(); err != nil {
+			log.Printf("Couldn't close connections: %v", err)
+		}
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server stopped with an error: %v", err)
+	}
+
+	cfg.close()
+	log.Println("Shut down cleanly")
+}
+
+// close stops the background jobs, writing out the API key usage, audit
+// entries and spans they still hold, then closes the database. Call it only
+// once the server has stopped taking requests.
+func (cfg *apiConfig) close() {
+	if cfg.Jobs != nil {
+		cfg.Jobs.Close()
+	}
+	if cfg.Backups != nil {
+		cfg.Backups.Close()
+	}
+	if cfg.KeyUsage != nil {
+		cfg.KeyUsage.Close()
+	}
+	if cfg.AuthAudit != nil {
+		cfg.AuthAudit.Close()
+	}
+	if cfg.Tracer != nil {
+		cfg.Tracer.Close()
+	}
+	if cfg.Cache != nil {
+		if err := cfg.Cache.Close(); err != nil {
+			log.Printf("Couldn't close cache: %v", err)
+		}
+	}
+	if cfg.DB != nil {
+		if err := cfg.DB.Close(); err != nil {
+			log.Printf("Couldn't close database: %v", err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+-- +goose Up
+-- The MySQL schema matches sql/schema as of 040_data_exports.sql, as
+-- sql/postgres/schema does. Timestamps stay RFC 3339 strings so the same
+-- queries work on every database. Strings that are keyed or indexed are
+-- VARCHAR, since MySQL can only index a prefix of TEXT, and every table
+-- compares them byte for byte with utf8mb4_bin, as SQLite does.
+CREATE TABLE users (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    name TEXT NOT NULL,
+    api_key VARCHAR(255) UNIQUE NOT NULL,
+    api_key_hashed BIGINT NOT NULL DEFAULT 0,
+    api_key_prefix VARCHAR(255) NOT NULL DEFAULT '',
+    role VARCHAR(32) NOT NULL DEFAULT 'member',
+    totp_secret TEXT,
+    totp_enabled_at VARCHAR(64),
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    email VARCHAR(255),
+    deletion_token_hash VARCHAR(255),
+    deletion_token_expires_at VARCHAR(64),
+    password_hash TEXT,
+    email_verified_at VARCHAR(64),
+    suspended_at VARCHAR(64),
+    avatar_updated_at VARCHAR(64),
+    UNIQUE INDEX users_email_idx (email)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE notebooks (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    name TEXT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE notes (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    note MEDIUMTEXT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    notebook_id VARCHAR(255),
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    version BIGINT NOT NULL DEFAULT 1,
+    remind_at VARCHAR(64),
+    reminded_at VARCHAR(64),
+    encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    nonce TEXT,
+    ciphertext MEDIUMTEXT,
+    content_hash VARCHAR(255),
+    word_count BIGINT,
+    title TEXT,
+    metadata TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (notebook_id) REFERENCES notebooks(id) ON DELETE SET NULL,
+    INDEX notes_user_id_created_at_idx (user_id, created_at, id),
+    INDEX notes_remind_at_idx (remind_at),
+    INDEX notes_user_content_hash_idx (user_id, content_hash),
+    -- Stands in for the notes_fts FTS5 table.
+    FULLTEXT INDEX notes_search_idx (note)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE api_keys (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    label TEXT NOT NULL,
+    key_hash VARCHAR(255) UNIQUE NOT NULL,
+    key_prefix VARCHAR(255) NOT NULL,
+    revoked_at VARCHAR(64),
+    expires_at VARCHAR(64),
+    last_used_at VARCHAR(64),
+    last_used_ip VARCHAR(255),
+    allowed_cidrs TEXT NOT NULL DEFAULT (''),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE refresh_tokens (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at VARCHAR(64) NOT NULL,
+    revoked_at VARCHAR(64),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE sessions (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE auth_audit (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255),
+    credential_prefix VARCHAR(255) NOT NULL,
+    ip VARCHAR(255) NOT NULL,
+    user_agent TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    reason TEXT NOT NULL,
+    INDEX auth_audit_created_at_idx (created_at)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE client_certs (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) UNIQUE NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE oauth_identities (
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (provider, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE tags (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE note_tags (
+    note_id VARCHAR(255) NOT NULL,
+    tag_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (note_id, tag_id),
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+    INDEX note_tags_tag_id_idx (tag_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE note_shares (
+    note_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at VARCHAR(64) NOT NULL,
+    permission VARCHAR(16) NOT NULL CHECK (permission IN ('read', 'write')),
+    PRIMARY KEY (note_id, user_id),
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX note_shares_user_id_idx (user_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE note_share_links (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    note_id VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    expires_at VARCHAR(64),
+    revoked_at VARCHAR(64),
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    INDEX note_share_links_note_id_idx (note_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- key is a reserved word in MySQL, so the queries on this table are
+-- overridden in internal/mysql to quote it.
+CREATE TABLE idempotency_keys (
+    user_id VARCHAR(255) NOT NULL,
+    `key` VARCHAR(255) NOT NULL,
+    created_at VARCHAR(64) NOT NULL,
+    request_hash VARCHAR(255) NOT NULL,
+    status_code BIGINT,
+    response_body MEDIUMTEXT,
+    PRIMARY KEY (user_id, `key`),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idempotency_keys_created_at_idx (created_at)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE note_items (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    note_id VARCHAR(255) NOT NULL,
+    position BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    INDEX note_items_note_id_idx (note_id, position)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE email_verification_tokens (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    expires_at VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX email_verification_tokens_user_id_idx (user_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE password_reset_tokens (
+    token_hash VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    expires_at VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX password_reset_tokens_user_id_idx (user_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE user_settings (
+    user_id VARCHAR(255) PRIMARY KEY,
+    settings TEXT NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE data_exports (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    expires_at VARCHAR(64) NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    data LONGTEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX data_exports_user_id_idx (user_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- +goose Down
+DROP TABLE data_exports;
+DROP TABLE user_settings;
+DROP TABLE password_reset_tokens;
+DROP TABLE email_verification_tokens;
+DROP TABLE note_items;
+DROP TABLE idempotency_keys;
+DROP TABLE note_share_links;
+DROP TABLE note_shares;
+DROP TABLE note_tags;
+DROP TABLE tags;
+DROP TABLE oauth_identities;
+DROP TABLE client_certs;
+DROP TABLE auth_audit;
+DROP TABLE sessions;
+DROP TABLE refresh_tokens;
+DROP TABLE api_keys;
+DROP TABLE notes;
+DROP TABLE notebooks;
+DROP TABLE users;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE note_list_changes (
+    user_id VARCHAR(255) PRIMARY KEY,
+    changed_at VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- +goose Down
+DROP TABLE note_list_changes;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE webhooks (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT (''),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX webhooks_user_id_idx (user_id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE webhook_deliveries (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    webhook_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at VARCHAR(64) NOT NULL,
+    last_attempt_at VARCHAR(64),
+    response_status BIGINT,
+    last_error TEXT,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    INDEX webhook_deliveries_due_idx (status, next_attempt_at),
+    INDEX webhook_deliveries_webhook_id_idx (webhook_id, created_at)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
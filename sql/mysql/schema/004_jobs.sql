@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id VARCHAR(255) PRIMARY KEY,
+    created_at VARCHAR(64) NOT NULL,
+    updated_at VARCHAR(64) NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    unique_key VARCHAR(255) UNIQUE,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts BIGINT NOT NULL DEFAULT 0,
+    max_attempts BIGINT NOT NULL,
+    run_at VARCHAR(64) NOT NULL,
+    last_error TEXT,
+    finished_at VARCHAR(64),
+    INDEX jobs_due_idx (status, run_at)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- Webhook deliveries are sent by jobs now, rather than found by due time.
+DROP INDEX webhook_deliveries_due_idx ON webhook_deliveries;
+
+-- +goose Down
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries(status, next_attempt_at);
+DROP TABLE jobs;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE note_list_changes ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE note_list_changes DROP COLUMN version;
@@ -0,0 +1,241 @@
+-- +goose Up
+-- The PostgreSQL schema matches sql/schema as of 040_data_exports.sql.
+-- Timestamps stay TEXT in RFC 3339 so the same queries work on both.
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    name TEXT NOT NULL,
+    api_key TEXT UNIQUE NOT NULL,
+    api_key_hashed BIGINT NOT NULL DEFAULT 0,
+    api_key_prefix TEXT NOT NULL DEFAULT '',
+    role TEXT NOT NULL DEFAULT 'member',
+    totp_secret TEXT,
+    totp_enabled_at TEXT,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    email TEXT,
+    deletion_token_hash TEXT,
+    deletion_token_expires_at TEXT,
+    password_hash TEXT,
+    email_verified_at TEXT,
+    suspended_at TEXT,
+    avatar_updated_at TEXT
+);
+
+CREATE UNIQUE INDEX users_email_idx ON users(email);
+
+CREATE TABLE notebooks (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    name TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notes (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    note TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    notebook_id TEXT REFERENCES notebooks(id) ON DELETE SET NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    version BIGINT NOT NULL DEFAULT 1,
+    remind_at TEXT,
+    reminded_at TEXT,
+    encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    nonce TEXT,
+    ciphertext TEXT,
+    content_hash TEXT,
+    word_count BIGINT,
+    title TEXT,
+    metadata TEXT
+);
+
+CREATE INDEX notes_user_id_created_at_idx ON notes(user_id, created_at, id);
+CREATE INDEX notes_notebook_id_idx ON notes(notebook_id);
+CREATE INDEX notes_remind_at_idx ON notes(remind_at) WHERE remind_at IS NOT NULL;
+CREATE INDEX notes_user_content_hash_idx ON notes(user_id, content_hash) WHERE content_hash IS NOT NULL;
+-- Stands in for the notes_fts FTS5 table.
+CREATE INDEX notes_search_idx ON notes USING GIN (to_tsvector('simple', note));
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    key_prefix TEXT NOT NULL,
+    revoked_at TEXT,
+    expires_at TEXT,
+    last_used_at TEXT,
+    last_used_ip TEXT,
+    allowed_cidrs TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL,
+    revoked_at TEXT
+);
+
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL
+);
+
+CREATE TABLE auth_audit (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT,
+    credential_prefix TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX auth_audit_created_at_idx ON auth_audit(created_at);
+
+CREATE TABLE client_certs (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    subject TEXT UNIQUE NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE TABLE tags (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    name TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE note_tags (
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX note_tags_tag_id_idx ON note_tags(tag_id);
+
+CREATE TABLE note_shares (
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL,
+    permission TEXT NOT NULL CHECK (permission IN ('read', 'write')),
+    PRIMARY KEY (note_id, user_id)
+);
+
+CREATE INDEX note_shares_user_id_idx ON note_shares(user_id);
+
+CREATE TABLE note_share_links (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TEXT,
+    revoked_at TEXT
+);
+
+CREATE INDEX note_share_links_note_id_idx ON note_share_links(note_id);
+
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code BIGINT,
+    response_body TEXT,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys(created_at);
+
+CREATE TABLE note_items (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    position BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX note_items_note_id_idx ON note_items(note_id, position);
+
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens(user_id);
+
+CREATE TABLE password_reset_tokens (
+    token_hash TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX password_reset_tokens_user_id_idx ON password_reset_tokens(user_id);
+
+CREATE TABLE user_settings (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    settings TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE data_exports (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    data TEXT
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports(user_id);
+
+-- +goose Down
+DROP TABLE data_exports;
+DROP TABLE user_settings;
+DROP TABLE password_reset_tokens;
+DROP TABLE email_verification_tokens;
+DROP TABLE note_items;
+DROP TABLE idempotency_keys;
+DROP TABLE note_share_links;
+DROP TABLE note_shares;
+DROP TABLE note_tags;
+DROP TABLE tags;
+DROP TABLE oauth_identities;
+DROP TABLE client_certs;
+DROP TABLE auth_audit;
+DROP TABLE sessions;
+DROP TABLE refresh_tokens;
+DROP TABLE api_keys;
+DROP TABLE notes;
+DROP TABLE notebooks;
+DROP TABLE users;
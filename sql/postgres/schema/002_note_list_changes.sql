@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE note_list_changes (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    changed_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE note_list_changes;
@@ -0,0 +1,56 @@
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (id, created_at, updated_at, user_id, label, key_hash, key_prefix, expires_at, allowed_cidrs)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+--
+
+-- name: CreateAPIKeyIfMissing :exec
+INSERT INTO api_keys (id, created_at, updated_at, user_id, label, key_hash, key_prefix)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (key_hash) DO NOTHING;
+--
+
+-- name: GetAPIKey :one
+SELECT * FROM api_keys WHERE id = ? AND user_id = ?;
+--
+
+-- name: GetActiveAPIKeyByHash :one
+SELECT * FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL;
+--
+
+-- name: ListAPIKeysForUser :many
+SELECT * FROM api_keys WHERE user_id = ? ORDER BY created_at;
+--
+
+-- name: CountActiveAPIKeysForUser :one
+SELECT COUNT(*) FROM api_keys
+WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?);
+--
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = ?, updated_at = ?
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+--
+
+-- name: SetAPIKeyExpiry :execrows
+UPDATE api_keys SET expires_at = ?, updated_at = ?
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+--
+
+-- name: TouchAPIKeyUsage :exec
+UPDATE api_keys SET last_used_at = ?, last_used_ip = ?
+WHERE key_hash = ?;
+--
+
+-- name: RevokeAllAPIKeysForUser :execrows
+UPDATE api_keys SET revoked_at = ?, updated_at = ?
+WHERE user_id = ? AND revoked_at IS NULL;
+--
+
+-- name: SetAPIKeyAllowedCIDRs :execrows
+UPDATE api_keys SET allowed_cidrs = ?, updated_at = ?
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+--
+
+-- name: DeleteAPIKeysForUser :exec
+DELETE FROM api_keys WHERE user_id = ?;
+--
@@ -0,0 +1,15 @@
+-- name: CreateAuthAuditEntry :exec
+INSERT INTO auth_audit (id, created_at, user_id, credential_prefix, ip, user_agent, success, reason)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+--
+
+-- name: ListAuthAuditEntries :many
+SELECT * FROM auth_audit
+WHERE (sqlc.narg('user_id') IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('ip') IS NULL OR ip = sqlc.narg('ip'))
+  AND (sqlc.narg('success') IS NULL OR success = sqlc.narg('success'))
+  AND (sqlc.narg('since') IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until') IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit');
+--
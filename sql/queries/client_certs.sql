@@ -0,0 +1,20 @@
+-- name: CreateClientCert :exec
+INSERT INTO client_certs (id, created_at, subject, user_id)
+VALUES (?, ?, ?, ?);
+--
+
+-- name: GetClientCertBySubject :one
+SELECT * FROM client_certs WHERE subject = ?;
+--
+
+-- name: ListClientCerts :many
+SELECT * FROM client_certs ORDER BY created_at;
+--
+
+-- name: DeleteClientCert :execrows
+DELETE FROM client_certs WHERE id = ?;
+--
+
+-- name: DeleteClientCertsForUser :exec
+DELETE FROM client_certs WHERE user_id = ?;
+--
@@ -0,0 +1,24 @@
+-- name: CreateDataExport :exec
+INSERT INTO data_exports (id, created_at, user_id, token_hash, expires_at)
+VALUES (?, ?, ?, ?, ?);
+--
+
+-- name: GetDataExportByTokenHash :one
+SELECT * FROM data_exports WHERE token_hash = ?;
+--
+
+-- name: CountPendingDataExportsForUser :one
+SELECT COUNT(*) FROM data_exports WHERE user_id = ? AND status = 'pending';
+--
+
+-- name: FinishDataExport :exec
+UPDATE data_exports SET status = ?, data = ? WHERE id = ?;
+--
+
+-- name: DeleteExpiredDataExports :exec
+DELETE FROM data_exports WHERE expires_at <= ?;
+--
+
+-- name: DeleteDataExportsForUser :exec
+DELETE FROM data_exports WHERE user_id = ?;
+--
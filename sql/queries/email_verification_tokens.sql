@@ -0,0 +1,12 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, created_at, user_id, email, expires_at)
+VALUES (?, ?, ?, ?, ?);
+--
+
+-- name: GetEmailVerificationToken :one
+SELECT * FROM email_verification_tokens WHERE token_hash = ?;
+--
+
+-- name: DeleteEmailVerificationTokensForUser :exec
+DELETE FROM email_verification_tokens WHERE user_id = ?;
+--
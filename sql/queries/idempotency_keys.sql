@@ -0,0 +1,26 @@
+-- name: CreateIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, key, created_at, request_hash)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, key) DO NOTHING;
+--
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE user_id = ? AND key = ?;
+--
+
+-- name: SaveIdempotencyKeyResponse :exec
+UPDATE idempotency_keys SET status_code = ?, response_body = ?
+WHERE user_id = ? AND key = ?;
+--
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = ? AND key = ?;
+--
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE created_at < ?;
+--
+
+-- name: DeleteIdempotencyKeysForUser :exec
+DELETE FROM idempotency_keys WHERE user_id = ?;
+--
@@ -0,0 +1,43 @@
+-- name: CreateJob :execrows
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, unique_key, max_attempts, run_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+--
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = ?;
+--
+
+-- name: ListJobs :many
+SELECT * FROM jobs
+WHERE (sqlc.arg(status) = '' OR status = sqlc.arg(status))
+  AND (sqlc.arg(kind) = '' OR kind = sqlc.arg(kind))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+--
+
+-- name: ListDueJobs :many
+SELECT * FROM jobs
+WHERE status = 'pending' AND run_at <= ?
+ORDER BY run_at, id
+LIMIT ?;
+--
+
+-- name: ClaimJob :execrows
+UPDATE jobs SET run_at = sqlc.arg(lease_until), attempts = attempts + 1, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = 'pending' AND run_at = sqlc.arg(run_at);
+--
+
+-- name: FinishJob :exec
+UPDATE jobs SET status = ?, run_at = ?, last_error = ?, finished_at = ?, updated_at = ?
+WHERE id = ?;
+--
+
+-- name: RequeueJob :execrows
+UPDATE jobs SET status = 'pending', attempts = 0, run_at = sqlc.arg(updated_at), finished_at = NULL, updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND status = 'failed';
+--
+
+-- name: DeleteFinishedJobs :exec
+DELETE FROM jobs WHERE status = ? AND finished_at < ?;
+--
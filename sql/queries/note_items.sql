@@ -0,0 +1,39 @@
+-- name: CreateNoteItem :exec
+INSERT INTO note_items (id, created_at, updated_at, note_id, position, text, done)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+--
+
+-- name: ListNoteItems :many
+SELECT * FROM note_items WHERE note_id = ? ORDER BY position;
+--
+
+-- name: UpdateNoteItem :execrows
+UPDATE note_items
+SET text = COALESCE(sqlc.narg(text), text),
+    done = COALESCE(sqlc.narg(done), done),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id) AND note_id = sqlc.arg(note_id);
+--
+
+-- name: DeleteNoteItem :execrows
+DELETE FROM note_items WHERE id = ? AND note_id = ?;
+--
+
+-- name: DeleteNoteItems :exec
+DELETE FROM note_items WHERE note_id = ?;
+--
+
+-- name: DeleteNoteItemsInNotebook :exec
+DELETE FROM note_items
+WHERE note_id IN (SELECT id FROM notes WHERE notebook_id = ? AND user_id = ?);
+--
+
+-- name: ListItemsForNotes :many
+SELECT * FROM note_items
+WHERE note_id IN (sqlc.slice(note_ids))
+ORDER BY note_id, position;
+--
+
+-- name: DeleteNoteItemsForUser :exec
+DELETE FROM note_items WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
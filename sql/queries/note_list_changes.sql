@@ -0,0 +1,20 @@
+-- name: TouchNoteList :exec
+INSERT INTO note_list_changes (user_id, changed_at, version)
+VALUES (?, ?, 1)
+ON CONFLICT (user_id) DO UPDATE SET changed_at = excluded.changed_at, version = note_list_changes.version + 1;
+--
+
+-- name: GetNoteListState :one
+SELECT
+    CAST(COALESCE((SELECT note_list_changes.version FROM note_list_changes WHERE note_list_changes.user_id = sqlc.arg(user_id)), 0) AS BIGINT) AS version,
+    CAST(COALESCE(MAX(notes.updated_at), '') AS TEXT) AS notes_updated_at,
+    CAST(COALESCE((SELECT MAX(tags.updated_at) FROM tags WHERE tags.user_id = sqlc.arg(user_id)), '') AS TEXT) AS tags_updated_at,
+    CAST(COALESCE((SELECT user_settings.updated_at FROM user_settings WHERE user_settings.user_id = sqlc.arg(user_id)), '') AS TEXT) AS settings_updated_at,
+    CAST(COALESCE((SELECT note_list_changes.changed_at FROM note_list_changes WHERE note_list_changes.user_id = sqlc.arg(user_id)), '') AS TEXT) AS changed_at
+FROM notes
+WHERE notes.user_id = sqlc.arg(user_id);
+--
+
+-- name: DeleteNoteListChangesForUser :exec
+DELETE FROM note_list_changes WHERE user_id = ?;
+--
@@ -0,0 +1,33 @@
+-- name: CreateNoteShareLink :exec
+INSERT INTO note_share_links (id, created_at, note_id, token_hash, expires_at)
+VALUES (?, ?, ?, ?, ?);
+--
+
+-- name: GetNoteShareLinkByTokenHash :one
+SELECT * FROM note_share_links WHERE token_hash = ?;
+--
+
+-- name: RevokeNoteShareLinks :execrows
+UPDATE note_share_links SET revoked_at = ?
+WHERE note_id = ? AND revoked_at IS NULL;
+--
+
+-- name: DeleteNoteShareLinks :exec
+DELETE FROM note_share_links WHERE note_id = ?;
+--
+
+-- name: DeleteNoteShareLinksInNotebook :exec
+DELETE FROM note_share_links
+WHERE note_id IN (SELECT id FROM notes WHERE notebook_id = ? AND user_id = ?);
+--
+
+-- name: DeleteNoteShareLinksForUser :exec
+DELETE FROM note_share_links WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
+
+-- name: ListNoteShareLinksForUser :many
+SELECT note_share_links.* FROM note_share_links
+JOIN notes ON notes.id = note_share_links.note_id
+WHERE notes.user_id = ?
+ORDER BY note_share_links.created_at, note_share_links.id;
+--
@@ -0,0 +1,52 @@
+-- name: UpsertNoteShare :exec
+INSERT INTO note_shares (note_id, user_id, created_at, permission)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (note_id, user_id) DO UPDATE SET permission = excluded.permission;
+--
+
+-- name: GetNoteShare :one
+SELECT * FROM note_shares WHERE note_id = ? AND user_id = ?;
+--
+
+-- name: ListNoteShares :many
+SELECT note_shares.*, users.email
+FROM note_shares
+JOIN users ON users.id = note_shares.user_id
+WHERE note_shares.note_id = ?
+ORDER BY note_shares.created_at;
+--
+
+-- name: ListNotesSharedWithUser :many
+SELECT notes.* FROM notes
+JOIN note_shares ON note_shares.note_id = notes.id
+WHERE note_shares.user_id = ?
+ORDER BY note_shares.created_at DESC, notes.id;
+--
+
+-- name: DeleteNoteShare :execrows
+DELETE FROM note_shares WHERE note_id = ? AND user_id = ?;
+--
+
+-- name: DeleteNoteShares :exec
+DELETE FROM note_shares WHERE note_id = ?;
+--
+
+-- name: DeleteNoteSharesInNotebook :exec
+DELETE FROM note_shares
+WHERE note_id IN (SELECT id FROM notes WHERE notebook_id = ? AND user_id = ?);
+--
+
+-- name: DeleteNoteSharesForUser :exec
+DELETE FROM note_shares WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
+
+-- name: DeleteNoteSharesWithUser :exec
+DELETE FROM note_shares WHERE user_id = ?;
+--
+
+-- name: ListNoteSharesForOwner :many
+SELECT note_shares.* FROM note_shares
+JOIN notes ON notes.id = note_shares.note_id
+WHERE notes.user_id = ?
+ORDER BY note_shares.created_at, note_shares.note_id, note_shares.user_id;
+--
@@ -0,0 +1,25 @@
+-- name: CreateNotebook :exec
+INSERT INTO notebooks (id, created_at, updated_at, name, user_id)
+VALUES (?, ?, ?, ?, ?);
+--
+
+-- name: GetNotebook :one
+SELECT * FROM notebooks WHERE id = ? AND user_id = ?;
+--
+
+-- name: ListNotebooksForUser :many
+SELECT * FROM notebooks WHERE user_id = ? ORDER BY name;
+--
+
+-- name: RenameNotebook :execrows
+UPDATE notebooks SET name = ?, updated_at = ?
+WHERE id = ? AND user_id = ?;
+--
+
+-- name: DeleteNotebook :execrows
+DELETE FROM notebooks WHERE id = ? AND user_id = ?;
+--
+
+-- name: DeleteNotebooksForUser :exec
+DELETE FROM notebooks WHERE user_id = ?;
+--
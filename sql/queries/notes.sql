@@ -1,6 +1,10 @@
 -- name: CreateNote :exec
-INSERT INTO notes (id, created_at, updated_at, note, user_id)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO notes (id, created_at, updated_at, note, user_id, public, notebook_id, remind_at, encrypted, nonce, ciphertext, content_hash, word_count, title, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+--
+
+-- name: DeleteNote :execrows
+DELETE FROM notes WHERE id = ? AND user_id = ?;
 --
 
 -- name: GetNote :one
@@ -10,3 +14,146 @@ SELECT * FROM notes WHERE id = ?;
 -- name: GetNotesForUser :many
 SELECT * FROM notes WHERE user_id = ?;
 --
+
+-- name: UpdateNote :execrows
+UPDATE notes
+SET note = COALESCE(sqlc.narg(note), note),
+    content_hash = COALESCE(sqlc.narg(content_hash), content_hash),
+    word_count = COALESCE(sqlc.narg(word_count), word_count),
+    title = CASE WHEN CAST(sqlc.arg(set_title) AS BOOLEAN) THEN sqlc.narg(title) ELSE title END,
+    metadata = CASE WHEN CAST(sqlc.arg(set_metadata) AS BOOLEAN) THEN sqlc.narg(metadata) ELSE metadata END,
+    public = COALESCE(sqlc.narg(public), public),
+    nonce = COALESCE(sqlc.narg(nonce), nonce),
+    ciphertext = COALESCE(sqlc.narg(ciphertext), ciphertext),
+    notebook_id = CASE WHEN CAST(sqlc.arg(set_notebook) AS BOOLEAN) THEN sqlc.narg(notebook_id) ELSE notebook_id END,
+    remind_at = CASE WHEN CAST(sqlc.arg(set_remind_at) AS BOOLEAN) THEN sqlc.narg(remind_at) ELSE remind_at END,
+    reminded_at = CASE WHEN CAST(sqlc.arg(set_remind_at) AS BOOLEAN) THEN NULL ELSE reminded_at END,
+    updated_at = sqlc.arg(updated_at),
+    version = version + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND version = sqlc.arg(version);
+--
+
+-- name: ListNotesForUser :many
+SELECT * FROM notes
+WHERE user_id = sqlc.arg(user_id)
+  AND (CAST(sqlc.arg(include_archived) AS BOOLEAN) OR NOT archived)
+  AND (sqlc.narg(created_after) IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before) IS NULL OR created_at < sqlc.narg(created_before))
+  AND (sqlc.narg(notebook_id) IS NULL OR notebook_id = sqlc.narg(notebook_id))
+  AND (sqlc.narg(tag) IS NULL OR id IN (
+    SELECT note_tags.note_id FROM note_tags
+    JOIN tags ON tags.id = note_tags.tag_id
+    WHERE tags.user_id = sqlc.arg(user_id) AND tags.name = sqlc.narg(tag)
+  ))
+  AND (
+    sqlc.narg(after_value) IS NULL
+    OR pinned < CAST(sqlc.arg(after_pinned) AS BOOLEAN)
+    OR (pinned = CAST(sqlc.arg(after_pinned) AS BOOLEAN) AND NOT CAST(sqlc.arg(descending) AS BOOLEAN) AND (
+      CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END > sqlc.narg(after_value)
+      OR (CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END = sqlc.narg(after_value) AND id > sqlc.narg(after_id))
+    ))
+    OR (pinned = CAST(sqlc.arg(after_pinned) AS BOOLEAN) AND CAST(sqlc.arg(descending) AS BOOLEAN) AND (
+      CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END < sqlc.narg(after_value)
+      OR (CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END = sqlc.narg(after_value) AND id < sqlc.narg(after_id))
+    ))
+  )
+ORDER BY
+  pinned DESC,
+  CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN NULL ELSE CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END END ASC,
+  CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN CASE CAST(sqlc.arg(sort_by) AS TEXT) WHEN 'updated_at' THEN updated_at ELSE created_at END END DESC,
+  CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN NULL ELSE id END ASC,
+  CASE WHEN CAST(sqlc.arg(descending) AS BOOLEAN) THEN id END DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+--
+
+-- name: SearchNotesForUser :many
+SELECT notes.* FROM notes
+JOIN notes_fts ON notes_fts.rowid = notes.rowid
+WHERE notes.user_id = sqlc.arg(user_id) AND notes_fts MATCH sqlc.arg(query) AND NOT notes.encrypted
+ORDER BY notes_fts.rank
+LIMIT sqlc.arg(limit);
+--
+
+-- name: MoveNotesToDefaultNotebook :exec
+UPDATE notes SET notebook_id = NULL WHERE notebook_id = ? AND user_id = ?;
+--
+
+-- name: DeleteNotesInNotebook :exec
+DELETE FROM notes WHERE notebook_id = ? AND user_id = ?;
+--
+
+-- name: SetNoteArchived :execrows
+UPDATE notes SET archived = ?, version = version + 1 WHERE id = ? AND user_id = ?;
+--
+
+-- name: SetNotePinned :execrows
+UPDATE notes SET pinned = ?, version = version + 1 WHERE id = ? AND user_id = ?;
+--
+
+-- name: CountPinnedNotesForUser :one
+SELECT COUNT(*) FROM notes WHERE user_id = ? AND pinned;
+--
+
+-- name: ListUpcomingNotesForUser :many
+SELECT * FROM notes
+WHERE user_id = ? AND remind_at > ? AND NOT archived
+ORDER BY remind_at, id
+LIMIT ?;
+--
+
+-- name: ListDueReminders :many
+SELECT * FROM notes
+WHERE remind_at <= ? AND reminded_at IS NULL AND NOT archived
+ORDER BY remind_at, id
+LIMIT ?;
+--
+
+-- name: MarkNoteReminded :execrows
+UPDATE notes SET reminded_at = ? WHERE id = ? AND remind_at = ? AND reminded_at IS NULL;
+--
+
+-- name: ListDuplicateNotesForUser :many
+SELECT * FROM notes
+WHERE user_id = sqlc.arg(user_id) AND content_hash IN (
+  SELECT content_hash FROM notes
+  WHERE user_id = sqlc.arg(user_id) AND content_hash IS NOT NULL
+  GROUP BY content_hash
+  HAVING COUNT(*) > 1
+)
+ORDER BY content_hash, created_at, id;
+--
+
+-- name: GetNoteByContentHash :one
+SELECT * FROM notes
+WHERE user_id = ? AND content_hash = ?
+ORDER BY created_at, id
+LIMIT 1;
+--
+
+-- name: ListNotesWithoutContentHash :many
+SELECT * FROM notes
+WHERE content_hash IS NULL AND NOT encrypted
+LIMIT ?;
+--
+
+-- name: SetNoteContentHash :exec
+UPDATE notes SET content_hash = ? WHERE id = ?;
+--
+
+-- name: ListNotesWithoutWordCount :many
+SELECT * FROM notes
+WHERE word_count IS NULL AND NOT encrypted
+LIMIT ?;
+--
+
+-- name: SetNoteWordCount :exec
+UPDATE notes SET word_count = ? WHERE id = ?;
+--
+
+-- name: TouchNote :execrows
+UPDATE notes SET updated_at = ?, version = version + 1 WHERE id = ? AND version = ?;
+--
+
+-- name: DeleteNotesForUser :exec
+DELETE FROM notes WHERE user_id = ?;
+--
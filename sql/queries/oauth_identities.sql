@@ -0,0 +1,16 @@
+-- name: CreateOAuthIdentity :exec
+INSERT INTO oauth_identities (provider, subject, created_at, user_id)
+VALUES (?, ?, ?, ?);
+--
+
+-- name: GetOAuthIdentity :one
+SELECT * FROM oauth_identities WHERE provider = ? AND subject = ?;
+--
+
+-- name: DeleteOAuthIdentitiesForUser :exec
+DELETE FROM oauth_identities WHERE user_id = ?;
+--
+
+-- name: ListOAuthIdentitiesForUser :many
+SELECT * FROM oauth_identities WHERE user_id = ? ORDER BY created_at;
+--
@@ -0,0 +1,12 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, created_at, user_id, expires_at)
+VALUES (?, ?, ?, ?);
+--
+
+-- name: GetPasswordResetToken :one
+SELECT * FROM password_reset_tokens WHERE token_hash = ?;
+--
+
+-- name: DeletePasswordResetTokensForUser :exec
+DELETE FROM password_reset_tokens WHERE user_id = ?;
+--
@@ -0,0 +1,17 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at)
+VALUES (?, ?, ?, ?, ?);
+--
+
+-- name: GetRefreshToken :one
+SELECT * FROM refresh_tokens WHERE token_hash = ?;
+--
+
+-- name: RevokeRefreshToken :execrows
+UPDATE refresh_tokens SET revoked_at = ?, updated_at = ?
+WHERE token_hash = ? AND revoked_at IS NULL;
+--
+
+-- name: DeleteRefreshTokensForUser :exec
+DELETE FROM refresh_tokens WHERE user_id = ?;
+--
@@ -0,0 +1,16 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (token_hash, created_at, user_id, expires_at)
+VALUES (?, ?, ?, ?);
+--
+
+-- name: GetSession :one
+SELECT * FROM sessions WHERE token_hash = ?;
+--
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE token_hash = ?;
+--
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions WHERE user_id = ?;
+--
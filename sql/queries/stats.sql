@@ -0,0 +1,23 @@
+-- name: GetNoteTotalsForUser :one
+SELECT COUNT(*) AS note_count, CAST(COALESCE(SUM(word_count), 0) AS INTEGER) AS word_count
+FROM notes
+WHERE user_id = ?;
+--
+
+-- name: CountNotesPerDayForUser :many
+SELECT CAST(substr(created_at, 1, 10) AS TEXT) AS day, COUNT(*) AS note_count
+FROM notes
+WHERE user_id = ? AND created_at >= ?
+GROUP BY day
+ORDER BY day;
+--
+
+-- name: ListTopTagsForUser :many
+SELECT tags.name, COUNT(*) AS note_count
+FROM tags
+JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE tags.user_id = ?
+GROUP BY tags.id
+ORDER BY note_count DESC, tags.name
+LIMIT ?;
+--
@@ -0,0 +1,54 @@
+-- name: CreateTag :exec
+INSERT INTO tags (id, created_at, updated_at, name, user_id)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, name) DO NOTHING;
+--
+
+-- name: GetTagByName :one
+SELECT * FROM tags WHERE user_id = ? AND name = ?;
+--
+
+-- name: ListTagsForUser :many
+SELECT tags.*, COUNT(note_tags.note_id) AS note_count
+FROM tags
+LEFT JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE tags.user_id = ?
+GROUP BY tags.id
+ORDER BY tags.name;
+--
+
+-- name: RenameTag :execrows
+UPDATE tags SET name = ?, updated_at = ?
+WHERE id = ? AND user_id = ?;
+--
+
+-- name: AddNoteTag :exec
+INSERT INTO note_tags (note_id, tag_id)
+VALUES (?, ?)
+ON CONFLICT DO NOTHING;
+--
+
+-- name: DeleteNoteTags :exec
+DELETE FROM note_tags WHERE note_id = ?;
+--
+
+-- name: ListTagsForNotes :many
+SELECT note_tags.note_id, tags.name
+FROM note_tags
+JOIN tags ON tags.id = note_tags.tag_id
+WHERE note_tags.note_id IN (sqlc.slice(note_ids))
+ORDER BY tags.name;
+--
+
+-- name: DeleteNoteTagsInNotebook :exec
+DELETE FROM note_tags
+WHERE note_id IN (SELECT id FROM notes WHERE notebook_id = ? AND user_id = ?);
+--
+
+-- name: DeleteNoteTagsForUser :exec
+DELETE FROM note_tags WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?);
+--
+
+-- name: DeleteTagsForUser :exec
+DELETE FROM tags WHERE user_id = ?;
+--
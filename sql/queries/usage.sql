@@ -0,0 +1,7 @@
+-- name: GetUsageForUser :one
+SELECT
+    COUNT(*) AS note_count,
+    CAST(COALESCE(SUM(LENGTH(CAST(note AS BLOB)) + COALESCE(LENGTH(CAST(ciphertext AS BLOB)), 0)), 0) AS INTEGER) AS note_bytes
+FROM notes
+WHERE user_id = ?;
+--
@@ -0,0 +1,13 @@
+-- name: GetUserSettings :one
+SELECT * FROM user_settings WHERE user_id = ?;
+--
+
+-- name: UpsertUserSettings :exec
+INSERT INTO user_settings (user_id, settings, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET settings = excluded.settings, updated_at = excluded.updated_at;
+--
+
+-- name: DeleteUserSettingsForUser :exec
+DELETE FROM user_settings WHERE user_id = ?;
+--
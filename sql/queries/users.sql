@@ -1,14 +1,117 @@
 -- name: CreateUser :exec
-INSERT INTO users (id, created_at, updated_at, name, api_key)
+INSERT INTO users (id, created_at, updated_at, name, api_key, api_key_hashed, api_key_prefix, email)
 VALUES (
     ?,
     ?,
     ?,
     ?,
+    ?,
+    1,
+    ?,
     ?
 );
 --
 
 -- name: GetUser :one
-SELECT * FROM users WHERE api_key = ?;
+SELECT * FROM users WHERE api_key = ? AND api_key_hashed = 1;
+--
+
+-- name: GetUserByLegacyAPIKey :one
+SELECT * FROM users WHERE api_key = ? AND api_key_hashed = 0;
+--
+
+-- name: ListLegacyAPIKeys :many
+SELECT id, api_key FROM users WHERE api_key_hashed = 0;
+--
+
+-- name: SetUserAPIKeyHash :exec
+UPDATE users SET api_key = ?, api_key_hashed = 1, api_key_prefix = ? WHERE id = ?;
+--
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = ?;
+--
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = ?;
+--
+
+-- name: CountUsersWithRole :one
+SELECT COUNT(*) FROM users WHERE role = ?;
+--
+
+-- name: SetUserRole :execrows
+UPDATE users SET role = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SetUserTOTPSecret :exec
+UPDATE users SET totp_secret = ?, totp_enabled_at = NULL, updated_at = ? WHERE id = ?;
+--
+
+-- name: EnableUserTOTP :execrows
+UPDATE users SET totp_enabled_at = ?, totp_last_step = ?, updated_at = ?
+WHERE id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+--
+
+-- name: DisableUserTOTP :exec
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = ?
+WHERE id = ?;
+--
+
+-- name: UseUserTOTPStep :execrows
+UPDATE users SET totp_last_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step);
+--
+
+-- name: UpdateUserProfile :exec
+UPDATE users SET name = ?, email = ?, email_verified_at = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SetUserDeletionToken :exec
+UPDATE users SET deletion_token_hash = ?, deletion_token_expires_at = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = ?;
+--
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SetUserEmailVerified :execrows
+UPDATE users SET email_verified_at = ?, updated_at = ? WHERE id = ? AND email = ?;
+--
+
+-- name: ListUsersForAdmin :many
+SELECT
+    users.id,
+    users.created_at,
+    users.name,
+    users.email,
+    users.role,
+    users.suspended_at,
+    (SELECT COUNT(*) FROM notes WHERE notes.user_id = users.id) AS note_count,
+    CAST(MAX(
+        users.updated_at,
+        COALESCE((SELECT MAX(notes.updated_at) FROM notes WHERE notes.user_id = users.id), ''),
+        COALESCE((SELECT MAX(api_keys.last_used_at) FROM api_keys WHERE api_keys.user_id = users.id), '')
+    ) AS TEXT) AS last_active_at
+FROM users
+WHERE (sqlc.narg('pattern') IS NULL
+       OR users.name LIKE sqlc.narg('pattern') ESCAPE '\'
+       OR users.email LIKE sqlc.narg('pattern') ESCAPE '\')
+  AND (sqlc.narg('before_created_at') IS NULL
+       OR users.created_at < sqlc.narg('before_created_at')
+       OR (users.created_at = sqlc.narg('before_created_at') AND users.id < sqlc.narg('before_id')))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT sqlc.arg('limit');
+--
+
+-- name: SetUserSuspended :execrows
+UPDATE users SET suspended_at = ?, updated_at = ? WHERE id = ?;
+--
+
+-- name: SetUserAvatar :exec
+UPDATE users SET avatar_updated_at = ?, updated_at = ? WHERE id = ?;
 --
@@ -0,0 +1,69 @@
+-- name: CreateWebhook :exec
+INSERT INTO webhooks (id, created_at, updated_at, user_id, url, secret, events)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+--
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks WHERE id = ? AND user_id = ?;
+--
+
+-- name: ListWebhooksForUser :many
+SELECT * FROM webhooks WHERE user_id = ? ORDER BY created_at, id;
+--
+
+-- name: CountWebhooksForUser :one
+SELECT COUNT(*) FROM webhooks WHERE user_id = ?;
+--
+
+-- name: UpdateWebhook :execrows
+UPDATE webhooks SET url = ?, events = ?, active = ?, updated_at = ?
+WHERE id = ? AND user_id = ?;
+--
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = ? AND user_id = ?;
+--
+
+-- name: DeleteWebhooksForUser :exec
+DELETE FROM webhooks WHERE user_id = ?;
+--
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, webhook_id, event_type, payload, next_attempt_at)
+VALUES (?, ?, ?, ?, ?, ?);
+--
+
+-- name: GetWebhookDeliveryForSending :one
+SELECT webhook_deliveries.*, webhooks.url, webhooks.secret, webhooks.active FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.id = ?;
+--
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = attempts + 1, next_attempt_at = ?, last_attempt_at = ?, response_status = ?, last_error = ?
+WHERE id = ?;
+--
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries WHERE webhook_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+--
+
+-- name: ListPendingWebhookDeliveryIDs :many
+SELECT id FROM webhook_deliveries WHERE webhook_id = ? AND status = 'pending'
+ORDER BY created_at, id;
+--
+
+-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?;
+--
+
+-- name: DeleteWebhookDeliveriesForUser :exec
+DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?);
+--
+
+-- name: DeleteFinishedWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE status != 'pending' AND created_at < ?;
+--
@@ -0,0 +1,10 @@
+-- +goose Up
+-- api_key holds the SHA-256 hex digest of the key once api_key_hashed is set.
+-- Rows written before this migration keep their plaintext key until the
+-- server hashes them on startup or on first use.
+ALTER TABLE users ADD COLUMN api_key_hashed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN api_key_prefix TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN api_key_prefix;
+ALTER TABLE users DROP COLUMN api_key_hashed;
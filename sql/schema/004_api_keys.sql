@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    key_prefix TEXT NOT NULL,
+    revoked_at TEXT
+);
+
+INSERT INTO api_keys (id, created_at, updated_at, user_id, label, key_hash, key_prefix)
+SELECT lower(hex(randomblob(16))), created_at, updated_at, id, 'default', api_key, api_key_prefix
+FROM users WHERE api_key_hashed = 1;
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE api_keys ADD COLUMN expires_at TEXT;
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN expires_at;
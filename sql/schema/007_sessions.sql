@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE sessions;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE auth_audit (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT,
+    credential_prefix TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX auth_audit_created_at_idx ON auth_audit(created_at);
+
+-- +goose Down
+DROP TABLE auth_audit;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE api_keys ADD COLUMN last_used_at TEXT;
+ALTER TABLE api_keys ADD COLUMN last_used_ip TEXT;
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN last_used_ip;
+ALTER TABLE api_keys DROP COLUMN last_used_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE client_certs (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    subject TEXT UNIQUE NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE client_certs;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE oauth_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, subject)
+);
+
+-- +goose Down
+DROP TABLE oauth_identities;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE api_keys ADD COLUMN allowed_cidrs TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN allowed_cidrs;
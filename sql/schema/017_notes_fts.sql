@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE VIRTUAL TABLE notes_fts USING fts5(note, content='notes', content_rowid='rowid');
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_insert AFTER INSERT ON notes BEGIN
+    INSERT INTO notes_fts(rowid, note) VALUES (new.rowid, new.note);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_delete AFTER DELETE ON notes BEGIN
+    INSERT INTO notes_fts(notes_fts, rowid, note) VALUES ('delete', old.rowid, old.note);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER notes_fts_update AFTER UPDATE OF note ON notes BEGIN
+    INSERT INTO notes_fts(notes_fts, rowid, note) VALUES ('delete', old.rowid, old.note);
+    INSERT INTO notes_fts(rowid, note) VALUES (new.rowid, new.note);
+END;
+-- +goose StatementEnd
+
+INSERT INTO notes_fts(notes_fts) VALUES ('rebuild');
+
+-- +goose Down
+DROP TRIGGER notes_fts_update;
+DROP TRIGGER notes_fts_delete;
+DROP TRIGGER notes_fts_insert;
+DROP TABLE notes_fts;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE notebooks (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    name TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE notes ADD COLUMN notebook_id TEXT REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX notes_notebook_id_idx ON notes(notebook_id);
+
+-- +goose Down
+DROP INDEX notes_notebook_id_idx;
+ALTER TABLE notes DROP COLUMN notebook_id;
+DROP TABLE notebooks;
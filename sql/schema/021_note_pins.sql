@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE notes DROP COLUMN pinned;
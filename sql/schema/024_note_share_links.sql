@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE note_share_links (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TEXT,
+    revoked_at TEXT
+);
+
+CREATE INDEX note_share_links_note_id_idx ON note_share_links(note_id);
+
+-- +goose Down
+DROP TABLE note_share_links;
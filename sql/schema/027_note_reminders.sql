@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN remind_at TEXT;
+ALTER TABLE notes ADD COLUMN reminded_at TEXT;
+CREATE INDEX notes_remind_at_idx ON notes(remind_at) WHERE remind_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX notes_remind_at_idx;
+ALTER TABLE notes DROP COLUMN reminded_at;
+ALTER TABLE notes DROP COLUMN remind_at;
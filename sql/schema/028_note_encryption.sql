@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE notes ADD COLUMN nonce TEXT;
+ALTER TABLE notes ADD COLUMN ciphertext TEXT;
+
+-- +goose Down
+ALTER TABLE notes DROP COLUMN ciphertext;
+ALTER TABLE notes DROP COLUMN nonce;
+ALTER TABLE notes DROP COLUMN encrypted;
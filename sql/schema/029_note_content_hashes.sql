@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN content_hash TEXT;
+CREATE INDEX notes_user_content_hash_idx ON notes(user_id, content_hash) WHERE content_hash IS NOT NULL;
+
+-- +goose Down
+DROP INDEX notes_user_content_hash_idx;
+ALTER TABLE notes DROP COLUMN content_hash;
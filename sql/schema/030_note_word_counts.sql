@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN word_count INTEGER;
+
+-- +goose Down
+ALTER TABLE notes DROP COLUMN word_count;
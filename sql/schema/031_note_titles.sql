@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE notes ADD COLUMN title TEXT;
+ALTER TABLE notes ADD COLUMN metadata TEXT;
+
+-- +goose Down
+ALTER TABLE notes DROP COLUMN metadata;
+ALTER TABLE notes DROP COLUMN title;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE note_items (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    note_id TEXT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE INDEX note_items_note_id_idx ON note_items(note_id, position);
+
+-- +goose Down
+DROP TABLE note_items;
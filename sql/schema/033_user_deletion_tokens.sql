@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN deletion_token_hash TEXT;
+ALTER TABLE users ADD COLUMN deletion_token_expires_at TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN deletion_token_expires_at;
+ALTER TABLE users DROP COLUMN deletion_token_hash;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN suspended_at TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN suspended_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN avatar_updated_at TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN avatar_updated_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE data_exports (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    data TEXT
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports(user_id);
+
+-- +goose Down
+DROP TABLE data_exports;
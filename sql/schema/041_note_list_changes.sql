@@ -0,0 +1,10 @@
+-- +goose Up
+-- Records when a user's note listing last changed in a way the notes'
+-- own timestamps don't show, such as a deletion.
+CREATE TABLE note_list_changes (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    changed_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE note_list_changes;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    unique_key TEXT UNIQUE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TEXT NOT NULL,
+    last_error TEXT,
+    finished_at TEXT
+);
+
+CREATE INDEX jobs_due_idx ON jobs(status, run_at);
+
+-- Webhook deliveries are sent by jobs now, rather than found by due time.
+DROP INDEX webhook_deliveries_due_idx;
+
+-- +goose Down
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries(status, next_attempt_at);
+DROP TABLE jobs;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Counts the changes to each user's note listing, so no two states of it
+-- share an ETag, however close together they are.
+ALTER TABLE note_list_changes ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE note_list_changes DROP COLUMN version;
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/config"
+	"github.com/bootdotdev/learn-cicd-starter/internal/tracing"
+)
+
+// tracingConfig reads where to export spans to an OpenTelemetry collector:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or else /v1/traces under
+// OTEL_EXPORTER_OTLP_ENDPOINT. OTEL_EXPORTER_OTLP_HEADERS are sent with
+// each export and OTEL_SERVICE_NAME names the service, notely by default.
+// OTEL_TRACES_SAMPLER_ARG is the share of new traces kept, 1 by default;
+// requests carrying a traceparent header keep the caller's decision. With
+// neither endpoint set, the endpoint is empty and tracing is off.
+func tracingConfig(l *config.Loader) tracing.Config {
+	cfg := tracing.Config{
+		Endpoint:    l.String("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""),
+		ServiceName: l.String("OTEL_SERVICE_NAME", "notely"),
+		SampleRatio: l.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+	}
+	if base := l.String("OTEL_EXPORTER_OTLP_ENDPOINT", ""); cfg.Endpoint == "" && base != "" {
+		cfg.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	headers, err := tracing.ParseHeaders(l.String("OTEL_EXPORTER_OTLP_HEADERS", ""))
+	if err != nil {
+		l.Errorf("OTEL_EXPORTER_OTLP_HEADERS is not valid: %v", err)
+	}
+	cfg.Headers = headers
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		l.Errorf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+	return cfg
+}
+
+// openTracer starts exporting spans as cfg describes, or returns nil when
+// tracing is off.
+func openTracer(cfg tracing.Config) (*tracing.Tracer, error) {
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+	return tracing.NewTracer(cfg, 2048, 5*time.Second)
+}
+
+// middlewareTracing runs each request in a root span, named by its method
+// and route pattern once the router has matched it. Spans started from the
+// request's context, for authentication, the handler and each query, nest
+// under it.
+func middlewareTracing(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracer.StartRequest(r.Context(), r.Method, r.Header.Get("traceparent"))
+			defer span.End()
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			route := routePattern(r)
+			span.SetName(r.Method + " " + route)
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("http.route", route)
+			span.SetAttribute("url.path", r.URL.Path)
+			span.SetAttribute("http.response.status_code", rec.status)
+			span.SetAttribute("request.id", w.Header().Get(requestIDHeader))
+			if rec.status > 499 {
+				span.SetError(errors.New(http.StatusText(rec.status)))
+			}
+		})
+	}
+}
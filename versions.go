@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bootdotdev/learn-cicd-starter/internal/apiversion"
+	"github.com/bootdotdev/learn-cicd-starter/internal/validate"
+)
+
+// The API versions served, oldest first. Handlers work in v2's shape; v1
+// is kept for existing clients, which are told it is deprecated. When a
+// response's shape changes in v2, v1 gets a mapper keeping the old one, as
+// for errors, which v2 sends as problem details.
+var (
+	apiV1 = &apiversion.Version{
+		Name:         "v1",
+		DeprecatedAt: time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+		Successor:    "v2",
+	}
+	apiV2 = &apiversion.Version{Name: "v2"}
+
+	apiVersions = []*apiversion.Version{apiV1, apiV2}
+)
+
+func init() {
+	apiversion.MapResponse(apiV1, v1Error)
+	apiversion.MapResponse(apiV1, v1QuotaError)
+	apiversion.MapResponse(apiV1, v1ValidationError)
+}
+
+// v1ErrorResponse is the body of v1 errors, which predate problem details.
+// Only errors with an error code of their own carry a code.
+type v1ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func v1Error(p problem) v1ErrorResponse {
+	resp := v1ErrorResponse{Error: p.Detail, RequestID: p.RequestID}
+	if p.Type != "about:blank" {
+		resp.Code = p.Code
+	}
+	return resp
+}
+
+type v1QuotaErrorResponse struct {
+	Error string     `json:"error"`
+	Code  string     `json:"code"`
+	Quota quotaUsage `json:"quota"`
+}
+
+func v1QuotaError(p quotaProblem) v1QuotaErrorResponse {
+	return v1QuotaErrorResponse{Error: p.Detail, Code: p.Code, Quota: p.Quota}
+}
+
+type v1ValidationErrorResponse struct {
+	Error     string          `json:"error"`
+	Code      string          `json:"code"`
+	Errors    validate.Errors `json:"errors"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+func v1ValidationError(p validationProblem) v1ValidationErrorResponse {
+	return v1ValidationErrorResponse{Error: p.Detail, Code: p.Code, Errors: p.Errors, RequestID: p.RequestID}
+}
+
+// respondWithBadVersionedBody answers a request whose body its version's
+// request mapper couldn't translate.
+func respondWithBadVersionedBody(w http.ResponseWriter, err error) {
+	respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+}